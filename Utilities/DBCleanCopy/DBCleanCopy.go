@@ -12,45 +12,39 @@ import (
 
 const level string = "level"
 const bolt string = "bolt"
+const lsm string = "lsm"
 
 func main() {
 	fmt.Println("Usage:")
-	fmt.Println("DBCleanCopy level/bolt DBFileLocation")
+	fmt.Println("DBCleanCopy level/bolt/lsm DBFileLocation [level/bolt/lsm]")
 	fmt.Println("Database will be copied over block by block to remove some DB inconsistencies")
+	fmt.Println("The copy is of the same type, unless the type of the copy is given (to migrate a level database to lsm, say)")
 
 	if len(os.Args) < 3 {
 		fmt.Println("\nNot enough arguments passed")
 		os.Exit(1)
 	}
-	if len(os.Args) > 3 {
+	if len(os.Args) > 4 {
 		fmt.Println("\nToo many arguments passed")
 		os.Exit(1)
 	}
 
-	levelBolt := os.Args[1]
+	from := os.Args[1]
+	path := os.Args[2]
+	to := from
+	if len(os.Args) == 4 {
+		to = os.Args[3]
+	}
 
-	if levelBolt != level && levelBolt != bolt {
-		fmt.Println("\nFirst argument should be `level` or `bolt`")
+	dbase1, err := openDB(from, path, false)
+	if err != nil {
+		fmt.Printf("\nCould not open %s: %v\n", path, err)
 		os.Exit(1)
 	}
-	path := os.Args[2]
-
-	var dbase1 *hybridDB.HybridDB
-	var dbase2 *hybridDB.HybridDB
-
-	var err error
-	if levelBolt == bolt {
-		dbase1 = hybridDB.NewBoltMapHybridDB(nil, path)
-		dbase2 = hybridDB.NewBoltMapHybridDB(nil, "copied.db")
-	} else {
-		dbase1, err = hybridDB.NewLevelMapHybridDB(path, false)
-		if err != nil {
-			panic(err)
-		}
-		dbase2, err = hybridDB.NewLevelMapHybridDB("copied.db", true)
-		if err != nil {
-			panic(err)
-		}
+	dbase2, err := openDB(to, "copied.db", true)
+	if err != nil {
+		fmt.Printf("\nCould not create copied.db: %v\n", err)
+		os.Exit(1)
 	}
 
 	dbo1 := databaseOverlay.NewOverlay(dbase1)
@@ -62,6 +56,18 @@ func main() {
 	dbo2.Close()
 }
 
+func openDB(dbType, path string, create bool) (*hybridDB.HybridDB, error) {
+	switch dbType {
+	case bolt:
+		return hybridDB.NewBoltMapHybridDB(nil, path), nil
+	case level:
+		return hybridDB.NewLevelMapHybridDB(path, create)
+	case lsm:
+		return hybridDB.NewLSMMapHybridDB(path, create)
+	}
+	return nil, fmt.Errorf("database type should be `level`, `bolt` or `lsm`, not %q", dbType)
+}
+
 func CopyDB(dbase1, dbase2 interfaces.DBOverlay) {
 	processing := ""
 	defer func() {
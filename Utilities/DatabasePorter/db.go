@@ -38,6 +38,17 @@ func InitLevelDB(cfg *util.FactomdConfig) interfaces.DBOverlay {
 	return databaseOverlay.NewOverlay(dbase)
 }
 
+func InitLSMDB(cfg *util.FactomdConfig) interfaces.DBOverlay {
+	path := cfg.App.LdbPath + "/" + "FactoidLSM-Import.db"
+
+	dbase, err := hybridDB.NewLSMMapHybridDB(path, true)
+	if err != nil {
+		panic(err)
+	}
+
+	return databaseOverlay.NewOverlay(dbase)
+}
+
 func InitMapDB(cfg *util.FactomdConfig) interfaces.DBOverlay {
 	//fmt.Println("InitMapDB")
 	dbase := new(mapdb.MapDB)
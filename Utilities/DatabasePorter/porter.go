@@ -70,6 +70,9 @@ func main() {
 	case "LDB":
 		dbo = InitLevelDB(cfg)
 		break
+	case "LSM":
+		dbo = InitLSMDB(cfg)
+		break
 	default:
 		dbo = InitMapDB(cfg)
 		break
@@ -168,6 +171,9 @@ func main() {
 	case "LDB":
 		dbo = InitLevelDB(cfg)
 		break
+	case "LSM":
+		dbo = InitLSMDB(cfg)
+		break
 	default:
 		dbo = InitMapDB(cfg)
 		break
@@ -185,6 +191,9 @@ func main() {
 	case "LDB":
 		dbo = InitLevelDB(cfg)
 		break
+	case "LSM":
+		dbo = InitLSMDB(cfg)
+		break
 	default:
 		dbo = InitMapDB(cfg)
 		break
@@ -317,6 +326,9 @@ func SaveToDBLoop(input chan []BlockSet, done chan int) {
 		case "LDB":
 			dbo = InitLevelDB(cfg)
 			break
+		case "LSM":
+			dbo = InitLSMDB(cfg)
+			break
 		default:
 			dbo = InitMapDB(cfg)
 			break
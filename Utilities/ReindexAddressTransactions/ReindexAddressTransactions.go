@@ -0,0 +1,59 @@
+// Copyright 2017 Factom Foundation
+// Use of this source code is governed by the MIT license
+// that can be found in the LICENSE file.
+
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/FactomProject/factomd/database/databaseOverlay"
+	"github.com/FactomProject/factomd/database/hybridDB"
+)
+
+const level string = "level"
+const bolt string = "bolt"
+
+func main() {
+	fmt.Println("Usage:")
+	fmt.Println("ReindexAddressTransactions level/bolt DBFileLocation")
+	fmt.Println("Program will index the transactions and balance changes of every saved factoid and entry credit block by address")
+
+	if len(os.Args) < 3 {
+		fmt.Println("\nNot enough arguments passed")
+		os.Exit(1)
+	}
+	if len(os.Args) > 3 {
+		fmt.Println("\nToo many arguments passed")
+		os.Exit(1)
+	}
+
+	levelBolt := os.Args[1]
+	if levelBolt != level && levelBolt != bolt {
+		fmt.Println("\nFirst argument should be `level` or `bolt`")
+		os.Exit(1)
+	}
+	path := os.Args[2]
+
+	var dbase *hybridDB.HybridDB
+	var err error
+	if levelBolt == bolt {
+		dbase = hybridDB.NewBoltMapHybridDB(nil, path)
+	} else {
+		dbase, err = hybridDB.NewLevelMapHybridDB(path, false)
+		if err != nil {
+			panic(err)
+		}
+	}
+
+	dbo := databaseOverlay.NewOverlay(dbase)
+	defer dbo.Close()
+
+	err = dbo.RebuildAddressTransactions()
+	if err != nil {
+		fmt.Printf("ERROR: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Println("Address transactions reindexed")
+}
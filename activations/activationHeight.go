@@ -16,6 +16,7 @@ const (
 	_                       ActivationType = iota // 0 Don't use ZERO
 	ELECTION_NO_SORT                       = iota // 1 -- this is a passing activation and this ID may be reused once that height is passes and the references are removed
 	TESTNET_COINBASE_PERIOD                = iota // 2 -- this is a passing activation and this ID may be reused once that height is passes and the references are removed
+	RCD2_MULTISIG                          = iota // 3 -- allow m of n multisig (RCD_2) factoid inputs
 	//
 	ACTIVATION_TYPE_COUNT = iota - 1 // Always Last
 )
@@ -53,6 +54,15 @@ func init() {
 				"CUSTOM:fct_community_test": 45335, //  Monday morning September 17
 			},
 		},
+		Activation{"RCD2Multisig", RCD2_MULTISIG,
+			"Accept m of n multisig (RCD_2) factoid transaction inputs",
+			math.MaxInt32, // inactive unless overridden below
+			map[string]int{
+				"MAIN":                      math.MaxInt32,
+				"LOCAL":                     10,
+				"CUSTOM:fct_community_test": math.MaxInt32,
+			},
+		},
 	}
 
 	if ACTIVATION_TYPE_COUNT != len(activations) {
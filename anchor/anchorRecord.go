@@ -17,11 +17,37 @@ import (
 	"github.com/FactomProject/factomd/common/primitives"
 )
 
+// AnchorChainID is the chain the records of the mainnet anchors are in
+const AnchorChainID string = "df3ade9eec4b08d5379cc64270c30ea7315d8a8a1a69efe2b98a60ecdd69e604"
+
+// AnchorSigKeys are the public keys that sign the records of the mainnet anchors
+var AnchorSigKeys []string = []string{
+	"0426a802617848d4d16d87830fc521f4d136bb2d0c352850919c2679f189613a", //m1 key
+	"d569419348ed7056ec2ba54f0ecd9eea02648b260b26e0474f8c07fe9ac6bf83", //m2 key
+}
+
+// ParseAnchorSigKeys turns hex public keys into Verifiers for validating records
+func ParseAnchorSigKeys(keys []string) ([]interfaces.Verifier, error) {
+	answer := []interfaces.Verifier{}
+	for _, v := range keys {
+		pubKey := new(primitives.PublicKey)
+		err := pubKey.UnmarshalText([]byte(v))
+		if err != nil {
+			return nil, err
+		}
+		answer = append(answer, pubKey)
+	}
+	return answer, nil
+}
+
 //AnchorRecord is used to construct anchor chain
 type AnchorRecord struct {
 	AnchorRecordVer int
 	DBHeight        uint32
 	KeyMR           string
+	DBHeightMax     uint32 `json:",omitempty"` //the highest directory block in the window of an Ethereum anchor
+	DBHeightMin     uint32 `json:",omitempty"` //the lowest directory block in the window of an Ethereum anchor
+	WindowMR        string `json:",omitempty"` //the Merkle root of the KeyMRs of the directory blocks in the window
 	RecordHeight    uint32 //the block height we intended to put the anchorrecod into
 
 	Bitcoin  *BitcoinStruct  `json:",omitempty"`
@@ -37,7 +63,7 @@ type BitcoinStruct struct {
 }
 
 type EthereumStruct struct {
-	Address     string //0x30aa981f6d2fce81083e584c8ee2f822b548752f
+	Address     string //0x30aa981f6d2fce81083e584c8ee2f822b548752f, the anchor contract
 	TXID        string //0x50ea0effc383542811a58704a6d6842ed6d76439a2d942d941896ad097c06a78
 	BlockHeight int64  //293003
 	BlockHash   string //0x3b504616495fc9cf7be9b5b776692a9abbfb95491fa62abf62dcdf4d53ff5979
@@ -202,6 +228,12 @@ func UnmarshalAndValidateAnchorEntryAnyVersion(entry interfaces.IEBEntry, public
 	return ar, valid, err
 }
 
+// IsWindow tells if the record anchors a window of directory blocks, from
+// DBHeightMin to DBHeightMax, by their WindowMR rather than a single KeyMR
+func (ar *AnchorRecord) IsWindow() bool {
+	return ar.WindowMR != ""
+}
+
 func CreateAnchorRecordFromDBlock(dBlock interfaces.IDirectoryBlock) *AnchorRecord {
 	ar := new(AnchorRecord)
 	ar.AnchorRecordVer = 1
@@ -0,0 +1,262 @@
+// Copyright 2017 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+// Package compactFilter implements the Golomb-coded sets of BIP158, which the node
+// builds for every directory block over the chain IDs and addresses it touches.
+// A light client fetches the filters (a few hundred bytes per block), tests its
+// chain IDs and addresses against them, and only downloads the blocks that match.
+//
+// The parameters and the encoding are those of the BIP158 basic filter: items are
+// hashed with SipHash-2-4, keyed by the first 16 bytes of the directory block KeyMR,
+// into the range [0, N*M), and the sorted differences are Golomb-Rice coded with P
+// bits of remainder.  A serialized filter is N as a CompactSize followed by the bit
+// stream, so existing BIP158 decoders can read it.
+package compactFilter
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"sort"
+)
+
+const (
+	P = 19     // bits of remainder in the Golomb-Rice coding
+	M = 784931 // inverse of the false positive rate
+)
+
+// KeySize is the size of the SipHash key a filter is built with
+const KeySize = 16
+
+// Filter is the Golomb-coded set of the items of one block
+type Filter struct {
+	N    uint32 // number of items in the set
+	Data []byte // the Golomb-Rice coded bit stream
+}
+
+// Key returns the SipHash key for the block with the given hash (its KeyMR)
+func Key(hash []byte) [KeySize]byte {
+	var key [KeySize]byte
+	copy(key[:], hash)
+	return key
+}
+
+// New builds the filter over the items.  Duplicates are only counted once.
+func New(key [KeySize]byte, items [][]byte) *Filter {
+	unique := make(map[string]bool, len(items))
+	for _, item := range items {
+		unique[string(item)] = true
+	}
+
+	f := new(Filter)
+	f.N = uint32(len(unique))
+	values := make([]uint64, 0, len(unique))
+	for item := range unique {
+		values = append(values, hashToRange(key, []byte(item), f.N))
+	}
+	sort.Slice(values, func(i, j int) bool { return values[i] < values[j] })
+
+	w := new(bitWriter)
+	var last uint64
+	for _, v := range values {
+		delta := v - last
+		last = v
+		for q := delta >> P; q > 0; q-- {
+			w.writeBit(1)
+		}
+		w.writeBit(0)
+		w.writeBits(delta, P)
+	}
+	f.Data = w.bytes()
+	return f
+}
+
+// Match is true if the item may be in the set.  False positives happen about once
+// in M tests; false negatives never.
+func (f *Filter) Match(key [KeySize]byte, item []byte) bool {
+	return f.MatchAny(key, [][]byte{item})
+}
+
+// MatchAny is true if any of the items may be in the set
+func (f *Filter) MatchAny(key [KeySize]byte, items [][]byte) bool {
+	if f.N == 0 || len(items) == 0 {
+		return false
+	}
+	queries := make([]uint64, len(items))
+	for i, item := range items {
+		queries[i] = hashToRange(key, item, f.N)
+	}
+	sort.Slice(queries, func(i, j int) bool { return queries[i] < queries[j] })
+
+	r := &bitReader{data: f.Data}
+	var value uint64
+	q := 0
+	for i := uint32(0); i < f.N; i++ {
+		delta, err := r.readGolombRice()
+		if err != nil {
+			return false
+		}
+		value += delta
+		for q < len(queries) && queries[q] < value {
+			q++
+		}
+		if q == len(queries) {
+			return false
+		}
+		if queries[q] == value {
+			return true
+		}
+	}
+	return false
+}
+
+func (f *Filter) MarshalBinary() ([]byte, error) {
+	var buf bytes.Buffer
+	writeCompactSize(&buf, uint64(f.N))
+	buf.Write(f.Data)
+	return buf.Bytes(), nil
+}
+
+func (f *Filter) UnmarshalBinaryData(data []byte) ([]byte, error) {
+	n, rest, err := readCompactSize(data)
+	if err != nil {
+		return nil, err
+	}
+	if n > uint64(^uint32(0)) {
+		return nil, fmt.Errorf("filter has too many items: %d", n)
+	}
+	f.N = uint32(n)
+	f.Data = append([]byte{}, rest...)
+	return nil, nil
+}
+
+func (f *Filter) UnmarshalBinary(data []byte) error {
+	_, err := f.UnmarshalBinaryData(data)
+	return err
+}
+
+// hashToRange maps the item to [0, n*M), as in BIP158
+func hashToRange(key [KeySize]byte, item []byte, n uint32) uint64 {
+	k0 := binary.LittleEndian.Uint64(key[:8])
+	k1 := binary.LittleEndian.Uint64(key[8:])
+	return mulHigh64(sipHash24(k0, k1, item), uint64(n)*M)
+}
+
+// mulHigh64 returns the high 64 bits of the 128 bit product of a and b
+func mulHigh64(a, b uint64) uint64 {
+	const mask32 = 1<<32 - 1
+	aLo, aHi := a&mask32, a>>32
+	bLo, bHi := b&mask32, b>>32
+	lo := aLo * bLo
+	mid1 := aHi * bLo
+	mid2 := aLo * bHi
+	carry := (lo>>32 + mid1&mask32 + mid2&mask32) >> 32
+	return aHi*bHi + mid1>>32 + mid2>>32 + carry
+}
+
+func writeCompactSize(buf *bytes.Buffer, n uint64) {
+	var b [8]byte
+	switch {
+	case n < 0xfd:
+		buf.WriteByte(byte(n))
+	case n <= 0xffff:
+		buf.WriteByte(0xfd)
+		binary.LittleEndian.PutUint16(b[:], uint16(n))
+		buf.Write(b[:2])
+	case n <= 0xffffffff:
+		buf.WriteByte(0xfe)
+		binary.LittleEndian.PutUint32(b[:], uint32(n))
+		buf.Write(b[:4])
+	default:
+		buf.WriteByte(0xff)
+		binary.LittleEndian.PutUint64(b[:], n)
+		buf.Write(b[:])
+	}
+}
+
+func readCompactSize(data []byte) (uint64, []byte, error) {
+	if len(data) < 1 {
+		return 0, nil, fmt.Errorf("Not enough data to unmarshal")
+	}
+	size := 0
+	switch data[0] {
+	case 0xfd:
+		size = 2
+	case 0xfe:
+		size = 4
+	case 0xff:
+		size = 8
+	default:
+		return uint64(data[0]), data[1:], nil
+	}
+	if len(data) < 1+size {
+		return 0, nil, fmt.Errorf("Not enough data to unmarshal")
+	}
+	var b [8]byte
+	copy(b[:], data[1:1+size])
+	return binary.LittleEndian.Uint64(b[:]), data[1+size:], nil
+}
+
+type bitWriter struct {
+	data  []byte
+	nbits uint
+}
+
+func (w *bitWriter) writeBit(bit byte) {
+	if w.nbits%8 == 0 {
+		w.data = append(w.data, 0)
+	}
+	if bit != 0 {
+		w.data[len(w.data)-1] |= 1 << (7 - w.nbits%8)
+	}
+	w.nbits++
+}
+
+// writeBits writes the low n bits of v, most significant first
+func (w *bitWriter) writeBits(v uint64, n uint) {
+	for i := n; i > 0; i-- {
+		w.writeBit(byte(v >> (i - 1) & 1))
+	}
+}
+
+func (w *bitWriter) bytes() []byte {
+	return w.data
+}
+
+type bitReader struct {
+	data []byte
+	pos  uint
+}
+
+func (r *bitReader) readBit() (uint64, error) {
+	if r.pos/8 >= uint(len(r.data)) {
+		return 0, fmt.Errorf("filter ended early")
+	}
+	bit := r.data[r.pos/8] >> (7 - r.pos%8) & 1
+	r.pos++
+	return uint64(bit), nil
+}
+
+func (r *bitReader) readGolombRice() (uint64, error) {
+	var q uint64
+	for {
+		bit, err := r.readBit()
+		if err != nil {
+			return 0, err
+		}
+		if bit == 0 {
+			break
+		}
+		q++
+	}
+	v := q << P
+	for i := P - 1; i >= 0; i-- {
+		bit, err := r.readBit()
+		if err != nil {
+			return 0, err
+		}
+		v |= bit << uint(i)
+	}
+	return v, nil
+}
@@ -0,0 +1,79 @@
+package compactFilter_test
+
+import (
+	"testing"
+
+	. "github.com/FactomProject/factomd/common/compactFilter"
+	"github.com/FactomProject/factomd/common/primitives"
+)
+
+func TestFilterMatch(t *testing.T) {
+	key := Key(primitives.RandomHash().Bytes())
+	items := [][]byte{}
+	for i := 0; i < 500; i++ {
+		items = append(items, primitives.RandomHash().Bytes())
+	}
+	items = append(items, items[0]) // Duplicates count once
+
+	f := New(key, items)
+	if f.N != 500 {
+		t.Errorf("Filter has %d items, expected 500", f.N)
+	}
+	for _, item := range items {
+		if !f.Match(key, item) {
+			t.Fatalf("Item %x not matched", item)
+		}
+	}
+
+	falsePositives := 0
+	for i := 0; i < 10000; i++ {
+		if f.Match(key, primitives.RandomHash().Bytes()) {
+			falsePositives++
+		}
+	}
+	if falsePositives > 5 {
+		t.Errorf("%d false positives in 10000 tests", falsePositives)
+	}
+
+	if !f.MatchAny(key, [][]byte{primitives.RandomHash().Bytes(), items[42]}) {
+		t.Error("MatchAny missed an item")
+	}
+	other := Key(primitives.RandomHash().Bytes())
+	if f.Match(other, items[0]) && f.Match(other, items[1]) && f.Match(other, items[2]) {
+		t.Error("Filter matches regardless of the key")
+	}
+}
+
+func TestFilterMarshal(t *testing.T) {
+	key := Key(primitives.RandomHash().Bytes())
+	for _, n := range []int{0, 1, 300} {
+		items := [][]byte{}
+		for i := 0; i < n; i++ {
+			items = append(items, primitives.RandomHash().Bytes())
+		}
+		f := New(key, items)
+		data, err := f.MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+		f2 := new(Filter)
+		if err = f2.UnmarshalBinary(data); err != nil {
+			t.Fatal(err)
+		}
+		if f2.N != f.N || string(f2.Data) != string(f.Data) {
+			t.Errorf("Filter of %d items changed in a round trip", n)
+		}
+		for _, item := range items {
+			if !f2.Match(key, item) {
+				t.Errorf("Unmarshaled filter does not match %x", item)
+			}
+		}
+	}
+
+	if new(Filter).UnmarshalBinary([]byte{0xfd, 0x01}) == nil {
+		t.Error("Short filter unmarshaled")
+	}
+	if New(key, nil).Match(key, []byte{1}) {
+		t.Error("Empty filter matched")
+	}
+}
@@ -0,0 +1,36 @@
+package compactFilter
+
+import (
+	"encoding/binary"
+	"testing"
+)
+
+func TestSipHash24(t *testing.T) {
+	// Test vector from the SipHash paper, appendix A
+	var key [16]byte
+	msg := make([]byte, 15)
+	for i := range key {
+		key[i] = byte(i)
+	}
+	for i := range msg {
+		msg[i] = byte(i)
+	}
+	k0 := binary.LittleEndian.Uint64(key[:8])
+	k1 := binary.LittleEndian.Uint64(key[8:])
+	if h := sipHash24(k0, k1, msg); h != 0xa129ca6149be45e5 {
+		t.Errorf("Got %x", h)
+	}
+}
+
+func TestMulHigh64(t *testing.T) {
+	max := ^uint64(0)
+	if mulHigh64(max, max) != max-1 {
+		t.Error("Wrong high bits of max*max")
+	}
+	if mulHigh64(1<<32, 1<<32) != 1 {
+		t.Error("Wrong high bits of 2^32*2^32")
+	}
+	if mulHigh64(12345, 67890) != 0 {
+		t.Error("Small product has high bits")
+	}
+}
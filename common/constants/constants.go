@@ -238,6 +238,7 @@ const (
 	// length of a Private Key
 	SIGNATURE_LENGTH     = 64    // Length of a signature
 	MAX_TRANSACTION_SIZE = 10240 // 10K like everything else?
+	MAX_RCD2_ADDRESSES   = 16    // Most addresses an m of n multisig (RCD_2) can name
 	// Not sure if we need a minimum amount.  Set at 1 Factoshi
 
 	// Database
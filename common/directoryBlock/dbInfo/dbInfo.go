@@ -27,6 +27,24 @@ type DirBlockInfo struct {
 	DBMerkleRoot interfaces.IHash
 	// A flag to to show BTC anchor confirmation
 	BTCConfirmed bool
+	// EthTxHash is the hash of the Ethereum TX anchoring the window this
+	// directory block is in
+	EthTxHash interfaces.IHash
+	// EthTxOffset is the index of the TX in this Ethereum block
+	EthTxOffset int64
+	// EthBlockHeight is the height of the block where this TX is stored in Ethereum
+	EthBlockHeight int64
+	// EthBlockHash is the hash of the block where this TX is stored in Ethereum
+	EthBlockHash interfaces.IHash
+	// EthContractAddress is the anchor contract the TX writes to
+	EthContractAddress string
+	// EthWindowMR is the merkle root of the KeyMRs of the Directory Blocks
+	// from EthDBHeightMin to EthDBHeightMax, and is written into the contract
+	EthWindowMR    interfaces.IHash
+	EthDBHeightMin uint32
+	EthDBHeightMax uint32
+	// A flag to to show Ethereum anchor confirmation
+	EthConfirmed bool
 }
 
 var _ interfaces.Printable = (*DirBlockInfo)(nil)
@@ -47,6 +65,15 @@ func (e *DirBlockInfo) Init() {
 	if e.DBMerkleRoot == nil {
 		e.DBMerkleRoot = primitives.NewZeroHash()
 	}
+	if e.EthTxHash == nil {
+		e.EthTxHash = primitives.NewZeroHash()
+	}
+	if e.EthBlockHash == nil {
+		e.EthBlockHash = primitives.NewZeroHash()
+	}
+	if e.EthWindowMR == nil {
+		e.EthWindowMR = primitives.NewZeroHash()
+	}
 }
 
 func NewDirBlockInfo() *DirBlockInfo {
@@ -55,6 +82,9 @@ func NewDirBlockInfo() *DirBlockInfo {
 	dbi.BTCTxHash = primitives.NewZeroHash()
 	dbi.BTCBlockHash = primitives.NewZeroHash()
 	dbi.DBMerkleRoot = primitives.NewZeroHash()
+	dbi.EthTxHash = primitives.NewZeroHash()
+	dbi.EthBlockHash = primitives.NewZeroHash()
+	dbi.EthWindowMR = primitives.NewZeroHash()
 	return dbi
 }
 
@@ -121,6 +151,15 @@ func (e *DirBlockInfo) GetBTCBlockHeight() int32 {
 	return e.BTCBlockHeight
 }
 
+func (c *DirBlockInfo) GetEthConfirmed() bool {
+	return c.EthConfirmed
+}
+
+func (e *DirBlockInfo) GetEthTxHash() interfaces.IHash {
+	e.Init()
+	return e.EthTxHash
+}
+
 func (e *DirBlockInfo) MarshalBinary() (rval []byte, err error) {
 	defer func(pe *error) {
 		if *pe != nil {
@@ -177,6 +216,24 @@ type dirBlockInfoCopy struct {
 	DBMerkleRoot interfaces.IHash
 	// A flag to to show BTC anchor confirmation
 	BTCConfirmed bool
+	// EthTxHash is the hash of the Ethereum TX anchoring the window this
+	// directory block is in
+	EthTxHash interfaces.IHash
+	// EthTxOffset is the index of the TX in this Ethereum block
+	EthTxOffset int64
+	// EthBlockHeight is the height of the block where this TX is stored in Ethereum
+	EthBlockHeight int64
+	// EthBlockHash is the hash of the block where this TX is stored in Ethereum
+	EthBlockHash interfaces.IHash
+	// EthContractAddress is the anchor contract the TX writes to
+	EthContractAddress string
+	// EthWindowMR is the merkle root of the KeyMRs of the Directory Blocks
+	// from EthDBHeightMin to EthDBHeightMax, and is written into the contract
+	EthWindowMR    interfaces.IHash
+	EthDBHeightMin uint32
+	EthDBHeightMax uint32
+	// A flag to to show Ethereum anchor confirmation
+	EthConfirmed bool
 }
 
 func newDirBlockInfoCopyFromDBI(dbi *DirBlockInfo) *dirBlockInfoCopy {
@@ -190,6 +247,15 @@ func newDirBlockInfoCopyFromDBI(dbi *DirBlockInfo) *dirBlockInfoCopy {
 	dbic.BTCBlockHash = dbi.BTCBlockHash
 	dbic.DBMerkleRoot = dbi.DBMerkleRoot
 	dbic.BTCConfirmed = dbi.BTCConfirmed
+	dbic.EthTxHash = dbi.EthTxHash
+	dbic.EthTxOffset = dbi.EthTxOffset
+	dbic.EthBlockHeight = dbi.EthBlockHeight
+	dbic.EthBlockHash = dbi.EthBlockHash
+	dbic.EthContractAddress = dbi.EthContractAddress
+	dbic.EthWindowMR = dbi.EthWindowMR
+	dbic.EthDBHeightMin = dbi.EthDBHeightMin
+	dbic.EthDBHeightMax = dbi.EthDBHeightMax
+	dbic.EthConfirmed = dbi.EthConfirmed
 	return dbic
 }
 
@@ -199,6 +265,9 @@ func newDirBlockInfoCopy() *dirBlockInfoCopy {
 	dbi.BTCTxHash = primitives.NewZeroHash()
 	dbi.BTCBlockHash = primitives.NewZeroHash()
 	dbi.DBMerkleRoot = primitives.NewZeroHash()
+	dbi.EthTxHash = primitives.NewZeroHash()
+	dbi.EthBlockHash = primitives.NewZeroHash()
+	dbi.EthWindowMR = primitives.NewZeroHash()
 	return dbi
 }
 
@@ -212,6 +281,15 @@ func (dbic *DirBlockInfo) parseDirBlockInfoCopy(dbi *dirBlockInfoCopy) {
 	dbic.BTCBlockHash = dbi.BTCBlockHash
 	dbic.DBMerkleRoot = dbi.DBMerkleRoot
 	dbic.BTCConfirmed = dbi.BTCConfirmed
+	dbic.EthTxHash = dbi.EthTxHash
+	dbic.EthTxOffset = dbi.EthTxOffset
+	dbic.EthBlockHeight = dbi.EthBlockHeight
+	dbic.EthBlockHash = dbi.EthBlockHash
+	dbic.EthContractAddress = dbi.EthContractAddress
+	dbic.EthWindowMR = dbi.EthWindowMR
+	dbic.EthDBHeightMin = dbi.EthDBHeightMin
+	dbic.EthDBHeightMax = dbi.EthDBHeightMax
+	dbic.EthConfirmed = dbi.EthConfirmed
 }
 
 // NewDirBlockInfoFromDirBlock creates a DirDirBlockInfo from DirectoryBlock
@@ -224,5 +302,8 @@ func NewDirBlockInfoFromDirBlock(dirBlock interfaces.IDirectoryBlock) *DirBlockI
 	dbi.BTCTxHash = primitives.NewZeroHash()
 	dbi.BTCBlockHash = primitives.NewZeroHash()
 	dbi.BTCConfirmed = false
+	dbi.EthTxHash = primitives.NewZeroHash()
+	dbi.EthBlockHash = primitives.NewZeroHash()
+	dbi.EthWindowMR = primitives.NewZeroHash()
 	return dbi
 }
@@ -442,6 +442,14 @@ func (b *FBlock) GetLedgerMR() interfaces.IHash {
 }
 
 func (b *FBlock) GetBodyMR() interfaces.IHash {
+	b.BodyMR = primitives.ComputeMerkleRoot(b.GetBodyHashes())
+
+	return b.BodyMR
+}
+
+// Returns the leaves of the BodyMR: the hashes of the transactions, with a marker
+// at the end of every minute.
+func (b *FBlock) GetBodyHashes() []interfaces.IHash {
 	hashes := make([]interfaces.IHash, 0, len(b.Transactions))
 	marker := 0
 	for i, trans := range b.Transactions {
@@ -456,10 +464,7 @@ func (b *FBlock) GetBodyMR() interfaces.IHash {
 		marker++
 		hashes = append(hashes, primitives.Sha(constants.ZERO))
 	}
-
-	b.BodyMR = primitives.ComputeMerkleRoot(hashes)
-
-	return b.BodyMR
+	return hashes
 }
 
 func (b *FBlock) GetPrevKeyMR() interfaces.IHash {
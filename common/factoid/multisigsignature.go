@@ -0,0 +1,135 @@
+// Copyright 2017 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package factoid
+
+import (
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/FactomProject/ed25519"
+	"github.com/FactomProject/factomd/common/constants"
+	"github.com/FactomProject/factomd/common/interfaces"
+	"github.com/FactomProject/factomd/common/primitives"
+)
+
+// MultisigSignature is one of the m signatures satisfying an RCD_2.  The RCD_2
+// only holds addresses, so the signature carries the index of the address it
+// signs for and the public key that hashes (as an RCD_1) to that address.
+// It marshals as the index (uint16, big endian), the 32 byte public key, and
+// the 64 byte signature.
+type MultisigSignature struct {
+	Index     uint16                           `json:"index"`
+	PublicKey [constants.ADDRESS_LENGTH]byte   `json:"publickey"`
+	Signature [constants.SIGNATURE_LENGTH]byte `json:"signature"`
+}
+
+var _ interfaces.ISignature = (*MultisigSignature)(nil)
+
+const multisigSignatureLength = 2 + constants.ADDRESS_LENGTH + constants.SIGNATURE_LENGTH
+
+func NewMultisigSignature(index int, priv, data []byte) *MultisigSignature {
+	ms := new(MultisigSignature)
+	ms.Index = uint16(index)
+	sig := primitives.Sign(priv, data)
+	copy(ms.Signature[:], sig[:constants.SIGNATURE_LENGTH])
+	var sec [ed25519.PrivateKeySize]byte
+	copy(sec[:], priv)
+	copy(ms.PublicKey[:], ed25519.GetPublicKey(&sec)[:])
+	return ms
+}
+
+// GetAddress returns the RCD_1 address of the signing key
+func (s *MultisigSignature) GetAddress() interfaces.IAddress {
+	address, _ := NewRCD_1(s.PublicKey[:]).GetAddress()
+	return address
+}
+
+// Verify checks that the public key belongs to the given address, and that
+// the signature over data is valid for that key.
+func (s *MultisigSignature) Verify(address interfaces.IAddress, data []byte) bool {
+	if address == nil || !s.GetAddress().IsSameAs(address) {
+		return false
+	}
+	return ed25519.VerifyCanonical(&s.PublicKey, data, &s.Signature)
+}
+
+func (s *MultisigSignature) IsSameAs(sig interfaces.ISignature) bool {
+	other, ok := sig.(*MultisigSignature)
+	if !ok {
+		return false
+	}
+	return s.Index == other.Index && s.PublicKey == other.PublicKey && s.Signature == other.Signature
+}
+
+func (s *MultisigSignature) Bytes() []byte {
+	return s.Signature[:]
+}
+
+func (s *MultisigSignature) SetSignature(sig []byte) error {
+	if len(sig) != constants.SIGNATURE_LENGTH {
+		return fmt.Errorf("Bad MultisigSignature.  Should not happen")
+	}
+	copy(s.Signature[:], sig)
+	return nil
+}
+
+func (s *MultisigSignature) GetSignature() *[constants.SIGNATURE_LENGTH]byte {
+	return &s.Signature
+}
+
+func (s *MultisigSignature) JSONByte() ([]byte, error) {
+	return primitives.EncodeJSON(s)
+}
+
+func (s *MultisigSignature) JSONString() (string, error) {
+	return primitives.EncodeJSONString(s)
+}
+
+func (s MultisigSignature) String() string {
+	txt, err := s.CustomMarshalText()
+	if err != nil {
+		return "<error>"
+	}
+	return string(txt)
+}
+
+func (s MultisigSignature) MarshalBinary() ([]byte, error) {
+	var out primitives.Buffer
+	binary.Write(&out, binary.BigEndian, s.Index)
+	out.Write(s.PublicKey[:])
+	out.Write(s.Signature[:])
+	return out.DeepCopyBytes(), nil
+}
+
+func (s *MultisigSignature) UnmarshalBinaryData(data []byte) ([]byte, error) {
+	if data == nil || len(data) < multisigSignatureLength {
+		return nil, fmt.Errorf("Not enough data to unmarshal")
+	}
+	s.Index, data = binary.BigEndian.Uint16(data[:2]), data[2:]
+	copy(s.PublicKey[:], data[:constants.ADDRESS_LENGTH])
+	data = data[constants.ADDRESS_LENGTH:]
+	copy(s.Signature[:], data[:constants.SIGNATURE_LENGTH])
+	return data[constants.SIGNATURE_LENGTH:], nil
+}
+
+func (s *MultisigSignature) UnmarshalBinary(data []byte) error {
+	_, err := s.UnmarshalBinaryData(data)
+	return err
+}
+
+func (s MultisigSignature) CustomMarshalText() ([]byte, error) {
+	var out primitives.Buffer
+
+	out.WriteString(" MultisigSignature: ")
+	primitives.WriteNumber16(&out, s.Index)
+	out.WriteString(" ")
+	out.WriteString(hex.EncodeToString(s.PublicKey[:]))
+	out.WriteString(" ")
+	out.WriteString(hex.EncodeToString(s.Signature[:]))
+	out.WriteString("\n")
+
+	return out.DeepCopyBytes(), nil
+}
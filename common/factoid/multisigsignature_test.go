@@ -0,0 +1,68 @@
+// Copyright 2017 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package factoid_test
+
+import (
+	"testing"
+
+	. "github.com/FactomProject/factomd/common/factoid"
+	"github.com/FactomProject/factomd/testHelper"
+)
+
+func TestUnmarshalNilMultisigSignature(t *testing.T) {
+	defer func() {
+		if r := recover(); r != nil {
+			t.Errorf("Panic caught during the test - %v", r)
+		}
+	}()
+
+	a := new(MultisigSignature)
+	err := a.UnmarshalBinary(nil)
+	if err == nil {
+		t.Errorf("Error is nil when it shouldn't be")
+	}
+
+	err = a.UnmarshalBinary([]byte{})
+	if err == nil {
+		t.Errorf("Error is nil when it shouldn't be")
+	}
+}
+
+func TestMultisigSignatureMarshalUnmarshal(t *testing.T) {
+	data := []byte("some data to sign")
+	sig := NewMultisigSignature(3, testHelper.NewPrivKey(1), data)
+
+	hex, err := sig.MarshalBinary()
+	if err != nil {
+		t.Error(err)
+	}
+
+	sig2 := new(MultisigSignature)
+	rest, err := sig2.UnmarshalBinaryData(hex)
+	if err != nil {
+		t.Error(err)
+	}
+	if len(rest) > 0 {
+		t.Error("Returned spare data when it shouldn't")
+	}
+	if sig.IsSameAs(sig2) == false {
+		t.Error("Signatures are not equal")
+	}
+	if sig2.Index != 3 {
+		t.Errorf("Wrong index %d", sig2.Index)
+	}
+
+	address, _ := testHelper.NewFactoidRCDAddress(1).GetAddress()
+	if sig2.GetAddress().IsSameAs(address) == false {
+		t.Error("Signature does not reveal the signer's address")
+	}
+	if sig2.Verify(address, data) == false {
+		t.Error("Valid signature did not verify")
+	}
+	other, _ := testHelper.NewFactoidRCDAddress(2).GetAddress()
+	if sig2.Verify(other, data) {
+		t.Error("Signature verified against the wrong address")
+	}
+}
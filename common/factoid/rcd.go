@@ -49,17 +49,21 @@ func NewRCD_1(publicKey []byte) interfaces.IRCD {
 	return a
 }
 
-func NewRCD_2(n int, m int, addresses []interfaces.IAddress) (interfaces.IRCD, error) {
-	if len(addresses) != m {
-		return nil, fmt.Errorf("Improper number of addresses.  m = %d n = %d #addresses = %d", m, n, len(addresses))
+// NewRCD_2 creates a multisig RCD over the addresses, required of which must sign
+func NewRCD_2(required int, total int, addresses []interfaces.IAddress) (interfaces.IRCD, error) {
+	if len(addresses) != total {
+		return nil, fmt.Errorf("Improper number of addresses.  required = %d total = %d #addresses = %d", required, total, len(addresses))
 	}
 
 	au := new(RCD_2)
-	au.N = n
-	au.M = m
+	au.M = required
+	au.N = total
 	au.N_Addresses = make([]interfaces.IAddress, len(addresses), len(addresses))
 	copy(au.N_Addresses, addresses)
 
+	if err := au.validateStructure(); err != nil {
+		return nil, err
+	}
 	return au, nil
 }
 
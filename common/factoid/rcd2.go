@@ -9,6 +9,7 @@ import (
 	"encoding/hex"
 	"fmt"
 
+	"github.com/FactomProject/factomd/common/constants"
 	"github.com/FactomProject/factomd/common/interfaces"
 	"github.com/FactomProject/factomd/common/primitives"
 )
@@ -19,10 +20,13 @@ import (
 
 // Type 2 RCD implement multisig
 // m of n
-// Must have m addresses from which to choose, no fewer, no more
-// Must have n RCD, no fewer no more.
-// NOTE: This does mean you can have a multisig nested in a
-// multisig.  It just works.
+// Must have n addresses from which to choose, no fewer, no more
+// Must have m signatures, no fewer no more.
+// Each of the n addresses is an RCD_1 address.  A signature names the
+// index of the address it signs for, and reveals the public key behind it,
+// see MultisigSignature.
+// It marshals as the type byte (2), n and m (uint16, big endian), then the n
+// addresses.
 
 type RCD_2 struct {
 	M           int                   // Number signatures required
@@ -32,21 +36,53 @@ type RCD_2 struct {
 
 var _ interfaces.IRCD = (*RCD_2)(nil)
 
-/*************************************
- *       Stubs
- *************************************/
+/***************************************
+ *       Methods
+ ***************************************/
 
+// GetAddress returns the hash of the marshalled RCD, just like an RCD_1.
+// An RCD_2 that is not well formed has no address.
 func (b RCD_2) GetAddress() (interfaces.IAddress, error) {
-	return nil, nil
+	if err := b.validateStructure(); err != nil {
+		return nil, err
+	}
+	data, err := b.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+	return CreateAddress(primitives.Shad(data)), nil
 }
 
+// NumberOfSignatures is the number of signatures that must be provided (m),
+// which is also what is charged for in the transaction fee.
 func (b RCD_2) NumberOfSignatures() int {
-	return 1
+	return b.M
 }
 
-/***************************************
- *       Methods
- ***************************************/
+// validateStructure checks that m and n are sane, and that the addresses are
+// unique.
+func (b RCD_2) validateStructure() error {
+	if b.N < 1 || b.N > constants.MAX_RCD2_ADDRESSES {
+		return fmt.Errorf("RCD_2 must have between 1 and %d addresses, found n = %d", constants.MAX_RCD2_ADDRESSES, b.N)
+	}
+	if b.M < 1 || b.M > b.N {
+		return fmt.Errorf("RCD_2 requires 1 <= m <= n, found m = %d n = %d", b.M, b.N)
+	}
+	if len(b.N_Addresses) != b.N {
+		return fmt.Errorf("RCD_2 has %d addresses, expected n = %d", len(b.N_Addresses), b.N)
+	}
+	seen := make(map[[32]byte]bool, b.N)
+	for i, a := range b.N_Addresses {
+		if a == nil {
+			return fmt.Errorf("RCD_2 address %d is nil", i)
+		}
+		if seen[a.Fixed()] {
+			return fmt.Errorf("RCD_2 address %d is a duplicate", i)
+		}
+		seen[a.Fixed()] = true
+	}
+	return nil
+}
 
 func (b RCD_2) IsSameAs(rcd interfaces.IRCD) bool {
 	return b.String() == rcd.String()
@@ -57,16 +93,47 @@ func (b RCD_2) UnmarshalBinary(data []byte) error {
 	return err
 }
 
+// CheckSig requires exactly m MultisigSignatures, in ascending order of the
+// address index they sign for, each valid for a different one of the n
+// addresses.
 func (b RCD_2) CheckSig(trans interfaces.ITransaction, sigblk interfaces.ISignatureBlock) bool {
-	return false
+	if sigblk == nil {
+		return false
+	}
+	if b.validateStructure() != nil {
+		return false
+	}
+	data, err := trans.MarshalBinarySig()
+	if err != nil {
+		return false
+	}
+
+	sigs := sigblk.GetSignatures()
+	if len(sigs) != b.M {
+		return false
+	}
+	last := -1
+	for _, sig := range sigs {
+		msig, ok := sig.(*MultisigSignature)
+		if !ok {
+			return false
+		}
+		index := int(msig.Index)
+		if index <= last || index >= b.N { // Ascending means no address signs twice
+			return false
+		}
+		last = index
+		if !msig.Verify(b.N_Addresses[index], data) {
+			return false
+		}
+	}
+	return true
 }
 
 func (e *RCD_2) JSONByte() ([]byte, error) {
 	return primitives.EncodeJSON(e)
 }
 
-// TODO: Fix Json marshaling of RCD_2. Right now the RCD type
-// is not included in the json marshal.
 func (e *RCD_2) JSONString() (string, error) {
 	return primitives.EncodeJSONString(e)
 }
@@ -103,7 +170,14 @@ func (t *RCD_2) UnmarshalBinaryData(data []byte) (newData []byte, err error) {
 	t.N, data = int(binary.BigEndian.Uint16(data[0:2])), data[2:]
 	t.M, data = int(binary.BigEndian.Uint16(data[0:2])), data[2:]
 
-	t.N_Addresses = make([]interfaces.IAddress, t.M, t.M)
+	if t.N > constants.MAX_RCD2_ADDRESSES {
+		return nil, fmt.Errorf("RCD_2 has too many addresses: %d", t.N)
+	}
+	if len(data) < t.N*constants.ADDRESS_LENGTH {
+		return nil, fmt.Errorf("Data source too short to unmarshal %d addresses: %d", t.N, len(data))
+	}
+
+	t.N_Addresses = make([]interfaces.IAddress, t.N, t.N)
 
 	for i, _ := range t.N_Addresses {
 		t.N_Addresses[i] = new(Address)
@@ -122,7 +196,10 @@ func (a RCD_2) MarshalBinary() ([]byte, error) {
 	binary.Write(&out, binary.BigEndian, uint8(2))
 	binary.Write(&out, binary.BigEndian, uint16(a.N))
 	binary.Write(&out, binary.BigEndian, uint16(a.M))
-	for i := 0; i < a.M; i++ {
+	if len(a.N_Addresses) != a.N {
+		return nil, fmt.Errorf("RCD_2 has %d addresses, expected n = %d", len(a.N_Addresses), a.N)
+	}
+	for i := 0; i < a.N; i++ {
 		data, err := a.N_Addresses[i].MarshalBinary()
 		if err != nil {
 			return nil, err
@@ -142,8 +219,8 @@ func (a RCD_2) CustomMarshalText() ([]byte, error) {
 	out.WriteString(" m: ")
 	primitives.WriteNumber16(&out, uint16(a.M))
 	out.WriteString("\n")
-	for i := 0; i < a.M; i++ {
-		out.WriteString("  m: ")
+	for i := 0; i < len(a.N_Addresses); i++ {
+		out.WriteString("  n: ")
 		out.WriteString(hex.EncodeToString(a.N_Addresses[i].Bytes()))
 		out.WriteString("\n")
 	}
@@ -10,6 +10,8 @@ import (
 
 	. "github.com/FactomProject/factomd/common/factoid"
 	"github.com/FactomProject/factomd/common/interfaces"
+	"github.com/FactomProject/factomd/common/primitives"
+	"github.com/FactomProject/factomd/testHelper"
 )
 
 func TestUnmarshalNilRCD_2(t *testing.T) {
@@ -98,13 +100,141 @@ func nextAuth2_rcd2() *RCD_2 {
 	if r == nil {
 		r = rand.New(rand.NewSource(1))
 	}
-	n := r.Int()%4 + 1
-	m := r.Int()%4 + n
-	addresses := make([]interfaces.IAddress, m, m)
-	for j := 0; j < m; j++ {
+	required := r.Int()%4 + 1
+	total := r.Int()%4 + required
+	addresses := make([]interfaces.IAddress, total, total)
+	for j := 0; j < total; j++ {
 		addresses[j] = nextAddress()
 	}
 
-	rcd, _ := NewRCD_2(n, m, addresses)
+	rcd, _ := NewRCD_2(required, total, addresses)
 	return rcd.(*RCD_2)
 }
+
+func TestRCD2Validation(t *testing.T) {
+	addresses := make([]interfaces.IAddress, 3)
+	for i := range addresses {
+		addresses[i], _ = testHelper.NewFactoidRCDAddress(uint64(i)).GetAddress()
+	}
+
+	if _, err := NewRCD_2(2, 3, addresses); err != nil {
+		t.Errorf("Valid 2 of 3 rejected - %v", err)
+	}
+	if _, err := NewRCD_2(0, 3, addresses); err == nil {
+		t.Error("0 of 3 accepted")
+	}
+	if _, err := NewRCD_2(4, 3, addresses); err == nil {
+		t.Error("4 of 3 accepted")
+	}
+	if _, err := NewRCD_2(2, 2, addresses); err == nil {
+		t.Error("Wrong number of addresses accepted")
+	}
+	if _, err := NewRCD_2(1, 2, []interfaces.IAddress{addresses[0], addresses[0]}); err == nil {
+		t.Error("Duplicate addresses accepted")
+	}
+}
+
+func TestRCD2GetAddress(t *testing.T) {
+	rcd := nextAuth2_rcd2()
+	address, err := rcd.GetAddress()
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := rcd.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if address.IsSameAs(primitives.Shad(data)) == false {
+		t.Error("RCD_2 address is not the hash of the RCD")
+	}
+
+	other := rcd.Clone().(*RCD_2)
+	other.M = other.N + 1
+	if _, err := other.GetAddress(); err == nil {
+		t.Error("Malformed RCD_2 returned an address")
+	}
+}
+
+func TestRCD2CheckSig(t *testing.T) {
+	addresses := make([]interfaces.IAddress, 3)
+	for i := range addresses {
+		addresses[i], _ = testHelper.NewFactoidRCDAddress(uint64(i)).GetAddress()
+	}
+	irc, err := NewRCD_2(2, 3, addresses)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rcd := irc.(*RCD_2)
+	if rcd.NumberOfSignatures() != 2 {
+		t.Errorf("Expected 2 signatures, found %d", rcd.NumberOfSignatures())
+	}
+	multisigAddress, _ := rcd.GetAddress()
+
+	tx := new(Transaction)
+	tx.AddInput(multisigAddress, 1000)
+	tx.AddOutput(testHelper.NewFactoidAddress(5), 900)
+	tx.AddAuthorization(rcd)
+	data, err := tx.MarshalBinarySig()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Signed by keys 2 and 0, which the block puts in index order
+	sigblk, err := NewMultisigSignatureBlock(rcd, [][]byte{testHelper.NewPrivKey(2), testHelper.NewPrivKey(0)}, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx.SetSignatureBlock(0, sigblk)
+	if err := tx.Validate(1); err != nil {
+		t.Errorf("%v", err)
+	}
+	if err := tx.ValidateSignatures(); err != nil {
+		t.Errorf("%v", err)
+	}
+
+	// The signatures must survive a binary round trip
+	raw, err := tx.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx2 := new(Transaction)
+	if err := tx2.UnmarshalBinary(raw); err != nil {
+		t.Fatal(err)
+	}
+	if err := tx2.ValidateSignatures(); err != nil {
+		t.Errorf("Unmarshalled transaction - %v", err)
+	}
+	if tx.IsSameAs(tx2) == false {
+		t.Error("Transactions are not the same after a round trip")
+	}
+
+	// One signature is not enough
+	short, _ := NewMultisigSignatureBlock(rcd, [][]byte{testHelper.NewPrivKey(1)}, data)
+	if rcd.CheckSig(tx, short) {
+		t.Error("1 of 3 signatures accepted for a 2 of 3")
+	}
+
+	// The same key twice is not two signatures
+	twice, _ := NewMultisigSignatureBlock(rcd, [][]byte{testHelper.NewPrivKey(1), testHelper.NewPrivKey(1)}, data)
+	if rcd.CheckSig(tx, twice) {
+		t.Error("A repeated signature was accepted")
+	}
+
+	// Keys outside the RCD cannot sign
+	if _, err := NewMultisigSignatureBlock(rcd, [][]byte{testHelper.NewPrivKey(7)}, data); err == nil {
+		t.Error("Signed with a key that is not part of the RCD")
+	}
+
+	// A signature that claims the wrong index fails
+	bad, _ := NewMultisigSignatureBlock(rcd, [][]byte{testHelper.NewPrivKey(0), testHelper.NewPrivKey(1)}, data)
+	bad.Signatures[1].(*MultisigSignature).Index = 2
+	if rcd.CheckSig(tx, bad) {
+		t.Error("Signature for the wrong address index accepted")
+	}
+
+	// Signatures over other data fail
+	wrong, _ := NewMultisigSignatureBlock(rcd, [][]byte{testHelper.NewPrivKey(0), testHelper.NewPrivKey(1)}, []byte("other data"))
+	if rcd.CheckSig(tx, wrong) {
+		t.Error("Signatures over the wrong data accepted")
+	}
+}
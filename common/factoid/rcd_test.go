@@ -55,13 +55,13 @@ func nextAuth2_rcd() interfaces.IRCD {
 	if r == nil {
 		r = rand.New(rand.NewSource(1))
 	}
-	n := r.Int()%4 + 1
-	m := r.Int()%4 + n
-	addresses := make([]interfaces.IAddress, m, m)
-	for j := 0; j < m; j++ {
+	required := r.Int()%4 + 1
+	total := r.Int()%4 + required
+	addresses := make([]interfaces.IAddress, total, total)
+	for j := 0; j < total; j++ {
 		addresses[j] = nextAddress()
 	}
 
-	rcd, _ := NewRCD_2(n, m, addresses)
+	rcd, _ := NewRCD_2(required, total, addresses)
 	return rcd
 }
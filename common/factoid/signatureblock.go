@@ -5,6 +5,10 @@
 package factoid
 
 import (
+	"fmt"
+	"sort"
+
+	"github.com/FactomProject/factomd/common/constants"
 	"github.com/FactomProject/factomd/common/interfaces"
 	"github.com/FactomProject/factomd/common/primitives"
 )
@@ -127,8 +131,73 @@ func (s *SignatureBlock) UnmarshalBinaryData(data []byte) ([]byte, error) {
 	return buf.DeepCopyBytes(), nil
 }
 
+// UnmarshalBinaryDataForRCD reads the signatures the given RCD requires.  An
+// RCD_1 has a single FactoidSignature, while an RCD_2 has m MultisigSignatures.
+func (s *SignatureBlock) UnmarshalBinaryDataForRCD(rcd interfaces.IRCD, data []byte) ([]byte, error) {
+	rcd2, ok := rcd.(*RCD_2)
+	if !ok {
+		return s.UnmarshalBinaryData(data)
+	}
+
+	if rcd2.M < 1 || rcd2.M > constants.MAX_RCD2_ADDRESSES {
+		return nil, fmt.Errorf("Bad number of signatures for an RCD_2: %d", rcd2.M)
+	}
+	buf := primitives.NewBuffer(data)
+	s.Signatures = make([]interfaces.ISignature, rcd2.M)
+	for i := range s.Signatures {
+		s.Signatures[i] = new(MultisigSignature)
+		err := buf.PopBinaryMarshallable(s.Signatures[i])
+		if err != nil {
+			return nil, err
+		}
+	}
+	return buf.DeepCopyBytes(), nil
+}
+
 func NewSingleSignatureBlock(priv, data []byte) *SignatureBlock {
 	s := new(SignatureBlock)
 	s.AddSignature(NewED25519Signature(priv, data))
 	return s
 }
+
+// NewUnsignedSignatureBlock returns a signature block shaped the way the RCD
+// expects, with empty signatures: none for an RCD_1, and the m an RCD_2 needs,
+// for the addresses in order.  It is a placeholder until the transaction is
+// signed, so it can be marshaled and sized.
+func NewUnsignedSignatureBlock(rcd interfaces.IRCD) *SignatureBlock {
+	s := new(SignatureBlock)
+	if rcd2, ok := rcd.(*RCD_2); ok {
+		for i := 0; i < rcd2.M; i++ {
+			sig := new(MultisigSignature)
+			sig.Index = uint16(i)
+			s.Signatures = append(s.Signatures, sig)
+		}
+	}
+	return s
+}
+
+// NewMultisigSignatureBlock signs data with each of the private keys, which
+// must belong to distinct addresses of the RCD_2.  The signatures are ordered
+// by address index, as RCD_2.CheckSig expects.
+func NewMultisigSignatureBlock(rcd *RCD_2, privs [][]byte, data []byte) (*SignatureBlock, error) {
+	s := new(SignatureBlock)
+	for _, priv := range privs {
+		sig := NewMultisigSignature(0, priv, data)
+		index := -1
+		for i, address := range rcd.N_Addresses {
+			if address.IsSameAs(sig.GetAddress()) {
+				index = i
+				break
+			}
+		}
+		if index < 0 {
+			return nil, fmt.Errorf("Private key does not belong to any address of the RCD_2")
+		}
+		sig.Index = uint16(index)
+		s.Signatures = append(s.Signatures, sig)
+	}
+	sort.Slice(s.Signatures, func(i, j int) bool {
+		return s.Signatures[i].(*MultisigSignature).Index < s.Signatures[j].(*MultisigSignature).Index
+	})
+	return s, nil
+}
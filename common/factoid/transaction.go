@@ -430,35 +430,109 @@ func (t *Transaction) GetRCD(i int) (interfaces.IRCD, error) {
 func (t *Transaction) UnmarshalBinaryData(data []byte) ([]byte, error) {
 	buf := primitives.NewBuffer(data)
 
-	v, err := buf.PopVarInt()
+	err := t.unmarshalLedger(buf)
 	if err != nil {
 		return nil, err
 	}
+
+	t.RCDs = make([]interfaces.IRCD, len(t.Inputs))
+	t.SigBlocks = make([]interfaces.ISignatureBlock, len(t.Inputs))
+
+	for i := 0; i < len(t.Inputs); i++ {
+		b, err := buf.PeekByte()
+		if err != nil {
+			return nil, err
+		}
+		t.RCDs[i] = CreateRCD([]byte{b})
+		err = buf.PopBinaryMarshallable(t.RCDs[i])
+		if err != nil {
+			return nil, err
+		}
+		sigBlock := new(SignatureBlock)
+		rest, err := sigBlock.UnmarshalBinaryDataForRCD(t.RCDs[i], buf.DeepCopyBytes())
+		if err != nil {
+			return nil, err
+		}
+		buf = primitives.NewBuffer(rest)
+		t.SigBlocks[i] = sigBlock
+	}
+
+	t.Txid = t.GetSigHash()
+	return buf.DeepCopyBytes(), nil
+}
+
+// UnmarshalUnsignedBinaryData reads a transaction that is not signed yet, which is
+// the ledger (as MarshalBinarySig writes it) optionally followed by the RCD of each
+// input.  The signature blocks are left empty but sized for their RCD, so the
+// transaction marshals to the size it will have once signed.  Inputs without an
+// RCD are given an RCD_1; assumed is how many were.
+func (t *Transaction) UnmarshalUnsignedBinaryData(data []byte) (newData []byte, assumed int, err error) {
+	buf := primitives.NewBuffer(data)
+
+	err = t.unmarshalLedger(buf)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	t.RCDs = make([]interfaces.IRCD, len(t.Inputs))
+	t.SigBlocks = make([]interfaces.ISignatureBlock, len(t.Inputs))
+
+	for i := 0; i < len(t.Inputs); i++ {
+		if buf.Len() == 0 {
+			t.RCDs[i] = NewRCD_1(make([]byte, constants.ADDRESS_LENGTH))
+			assumed++
+		} else {
+			b, err := buf.PeekByte()
+			if err != nil {
+				return nil, 0, err
+			}
+			if b != 1 && b != 2 {
+				return nil, 0, fmt.Errorf("RCD %d has an unknown type %d", i, b)
+			}
+			t.RCDs[i] = CreateRCD([]byte{b})
+			err = buf.PopBinaryMarshallable(t.RCDs[i])
+			if err != nil {
+				return nil, 0, err
+			}
+		}
+		t.SigBlocks[i] = NewUnsignedSignatureBlock(t.RCDs[i])
+	}
+
+	t.Txid = t.GetSigHash()
+	return buf.DeepCopyBytes(), assumed, nil
+}
+
+// unmarshalLedger reads the part of the transaction that is signed
+func (t *Transaction) unmarshalLedger(buf *primitives.Buffer) error {
+	v, err := buf.PopVarInt()
+	if err != nil {
+		return err
+	}
 	if v != t.GetVersion() {
-		return nil, fmt.Errorf("Wrong Transaction Version encountered. Expected %v and found %v", t.GetVersion(), v)
+		return fmt.Errorf("Wrong Transaction Version encountered. Expected %v and found %v", t.GetVersion(), v)
 	}
 
 	hd, err := buf.PopUInt32()
 	if err != nil {
-		return nil, err
+		return err
 	}
 	ld, err := buf.PopUInt16()
 	if err != nil {
-		return nil, err
+		return err
 	}
 	t.MilliTimestamp = (uint64(hd) << 16) + uint64(ld)
 
 	numInputs, err := buf.PopUInt8()
 	if err != nil {
-		return nil, err
+		return err
 	}
 	numOutputs, err := buf.PopUInt8()
 	if err != nil {
-		return nil, err
+		return err
 	}
 	numOutECs, err := buf.PopUInt8()
 	if err != nil {
-		return nil, err
+		return err
 	}
 
 	t.Inputs = make([]interfaces.ITransAddress, int(numInputs), int(numInputs))
@@ -469,7 +543,7 @@ func (t *Transaction) UnmarshalBinaryData(data []byte) ([]byte, error) {
 		t.Inputs[i] = new(TransAddress)
 		err = buf.PopBinaryMarshallable(t.Inputs[i])
 		if err != nil {
-			return nil, err
+			return err
 		}
 		t.Inputs[i].(*TransAddress).UserAddress = primitives.ConvertFctAddressToUserStr(t.Inputs[i].(*TransAddress).Address)
 	}
@@ -477,7 +551,7 @@ func (t *Transaction) UnmarshalBinaryData(data []byte) ([]byte, error) {
 		t.Outputs[i] = new(TransAddress)
 		err = buf.PopBinaryMarshallable(t.Outputs[i])
 		if err != nil {
-			return nil, err
+			return err
 		}
 		t.Outputs[i].(*TransAddress).UserAddress = primitives.ConvertFctAddressToUserStr(t.Outputs[i].(*TransAddress).Address)
 	}
@@ -485,33 +559,11 @@ func (t *Transaction) UnmarshalBinaryData(data []byte) ([]byte, error) {
 		t.OutECs[i] = new(TransAddress)
 		err = buf.PopBinaryMarshallable(t.OutECs[i])
 		if err != nil {
-			return nil, err
+			return err
 		}
 		t.OutECs[i].(*TransAddress).UserAddress = primitives.ConvertECAddressToUserStr(t.OutECs[i].(*TransAddress).Address)
 	}
-
-	t.RCDs = make([]interfaces.IRCD, len(t.Inputs))
-	t.SigBlocks = make([]interfaces.ISignatureBlock, len(t.Inputs))
-
-	for i := 0; i < len(t.Inputs); i++ {
-		b, err := buf.PeekByte()
-		if err != nil {
-			return nil, err
-		}
-		t.RCDs[i] = CreateRCD([]byte{b})
-		err = buf.PopBinaryMarshallable(t.RCDs[i])
-		if err != nil {
-			return nil, err
-		}
-		t.SigBlocks[i] = new(SignatureBlock)
-		err = buf.PopBinaryMarshallable(t.SigBlocks[i])
-		if err != nil {
-			return nil, err
-		}
-	}
-
-	t.Txid = t.GetSigHash()
-	return buf.DeepCopyBytes(), nil
+	return nil
 }
 
 func (t *Transaction) UnmarshalBinary(data []byte) (err error) {
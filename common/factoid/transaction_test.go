@@ -78,14 +78,14 @@ func nextAuth2() interfaces.IRCD {
 	if r == nil {
 		r = rand.New(rand.NewSource(1))
 	}
-	n := r.Int()%4 + 1
-	m := r.Int()%4 + n
-	addresses := make([]interfaces.IAddress, m, m)
-	for j := 0; j < m; j++ {
+	required := r.Int()%4 + 1
+	total := r.Int()%4 + required
+	addresses := make([]interfaces.IAddress, total, total)
+	for j := 0; j < total; j++ {
 		addresses[j] = nextAddress()
 	}
 
-	rcd, _ := NewRCD_2(n, m, addresses)
+	rcd, _ := NewRCD_2(required, total, addresses)
 	return rcd
 }
 
@@ -148,7 +148,9 @@ func getSignedTrans() interfaces.ITransaction {
 	}
 
 	for i := 0; i < 2; i++ {
-		t.AddAuthorization(nextAuth2())
+		rcd := nextAuth2()
+		t.AddAuthorization(rcd)
+		t.SetSignatureBlock(3+i, NewUnsignedSignatureBlock(rcd))
 	}
 
 	return nb
@@ -307,3 +309,73 @@ func TestHasUserAddress(t *testing.T) {
 		}
 	}
 }
+
+func TestUnmarshalUnsignedTransaction(t *testing.T) {
+	addresses := make([]interfaces.IAddress, 3)
+	for i := range addresses {
+		addresses[i], _ = testHelper.NewFactoidRCDAddress(uint64(i)).GetAddress()
+	}
+	multisig, err := NewRCD_2(2, 3, addresses)
+	if err != nil {
+		t.Fatal(err)
+	}
+	multisigAddress, _ := multisig.GetAddress()
+
+	tx := new(Transaction)
+	tx.AddInput(testHelper.NewFactoidAddress(0), 1000)
+	tx.AddInput(multisigAddress, 1000)
+	tx.AddOutput(testHelper.NewFactoidAddress(5), 1900)
+	ledger, err := tx.MarshalBinarySig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	rcd1, _ := testHelper.NewFactoidRCDAddress(0).MarshalBinary()
+	rcd2, _ := multisig.MarshalBinary()
+
+	// Sign it, to compare the size
+	tx.AddAuthorization(testHelper.NewFactoidRCDAddress(0))
+	tx.AddAuthorization(multisig)
+	tx.SetSignatureBlock(0, NewSingleSignatureBlock(testHelper.NewPrivKey(0), ledger))
+	sigblk, err := NewMultisigSignatureBlock(multisig.(*RCD_2), [][]byte{testHelper.NewPrivKey(0), testHelper.NewPrivKey(1)}, ledger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tx.SetSignatureBlock(1, sigblk)
+	signed, err := tx.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	unsigned := new(Transaction)
+	rest, assumed, err := unsigned.UnmarshalUnsignedBinaryData(append(append(ledger, rcd1...), rcd2...))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rest) != 0 || assumed != 0 {
+		t.Errorf("%d bytes left and %d RCDs assumed", len(rest), assumed)
+	}
+	data, err := unsigned.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(data) != len(signed) {
+		t.Errorf("Unsigned transaction marshals to %d bytes, signed to %d", len(data), len(signed))
+	}
+	if !unsigned.GetSigHash().IsSameAs(tx.GetSigHash()) {
+		t.Error("Unsigned transaction has another TxID")
+	}
+
+	// Without the RCDs both inputs are taken to be single signature
+	unsigned = new(Transaction)
+	_, assumed, err = unsigned.UnmarshalUnsignedBinaryData(ledger)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if assumed != 2 || unsigned.GetRCDs()[1].NumberOfSignatures() != 1 {
+		t.Errorf("%d RCDs assumed", assumed)
+	}
+
+	if _, _, err = new(Transaction).UnmarshalUnsignedBinaryData(append(ledger, 9)); err == nil {
+		t.Error("RCD of an unknown type was read")
+	}
+}
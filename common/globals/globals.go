@@ -18,6 +18,9 @@ type FactomParams struct {
 	DropRate                 int
 	Journal                  string
 	Journaling               bool
+	JournalMaxSize           int64   // Bytes before the journal is rotated
+	JournalMaxFiles          int     // Journal files kept
+	JournalSpeed             float64 // Speed a journal is replayed at
 	Follower                 bool
 	Leader                   bool
 	Db                       string
@@ -33,6 +36,8 @@ type FactomParams struct {
 	RuntimeLog               bool
 	Exclusive                bool
 	ExclusiveIn              bool
+	EncryptP2P               bool
+	RejectPlaintextPeers     bool
 	Prefix                   string
 	Rotate                   bool
 	TimeOffset               int
@@ -54,6 +59,7 @@ type FactomParams struct {
 	PluginPath               string
 	TorManage                bool
 	TorUpload                bool
+	Indexer                  string
 	Sim_Stdin                bool
 	ExposeProfiling          bool
 	UseLogstash              bool
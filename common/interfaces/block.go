@@ -41,6 +41,8 @@ type IFBlock interface {
 	GetKeyMR() IHash
 	// Get the MR for the list of transactions
 	GetBodyMR() IHash
+	// Get the hashes the body MR is built from
+	GetBodyHashes() []IHash
 	// Get the KeyMR of the previous block.
 	GetPrevKeyMR() IHash
 	SetPrevKeyMR(IHash)
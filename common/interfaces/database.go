@@ -16,6 +16,51 @@ type IDatabase interface {
 	ListAllBuckets() ([][]byte, error)
 	Trim()
 	DoesKeyExist(bucket, key []byte) (bool, error)
+	// NewIterator walks over the keys of the bucket without loading the bucket
+	NewIterator(bucket []byte, options *IterateOptions) IIterator
+}
+
+// IterateOptions select the keys an iterator walks over.  A nil or zero value walks
+// over the whole bucket in ascending order.
+type IterateOptions struct {
+	Prefix  []byte // Only the keys starting with Prefix
+	Seek    []byte // Start at the first key at or after Seek (at or before, if Reverse)
+	Reverse bool   // Walk in descending order
+	Limit   int    // Stop after Limit keys, if positive
+}
+
+// IIterator is a streaming iterator over the keys of a bucket, in the manner of
+// the goleveldb iterators:
+//
+//	iter := db.NewIterator(bucket, nil)
+//	for iter.Next() {
+//		use(iter.Key(), iter.Value())
+//	}
+//	iter.Release()
+//	err := iter.Error()
+//
+// The database can be used while iterating, but writes may or may not be seen.
+// Key and Value are only valid until the next call to Next.  Release must be called
+// once done.
+type IIterator interface {
+	Next() bool
+	Key() []byte
+	Value() []byte
+	Release()
+	Error() error
+}
+
+// ISnapshotDatabase is a database that can take snapshots of itself
+type ISnapshotDatabase interface {
+	Snapshot() (IDatabaseSnapshot, error)
+}
+
+// IDatabaseSnapshot is a read only view of a database as it was when the snapshot
+// was taken.  It must be released once done.
+type IDatabaseSnapshot interface {
+	Get(bucket, key []byte, destination BinaryMarshallable) (BinaryMarshallable, error)
+	NewIterator(bucket []byte, options *IterateOptions) IIterator
+	Release()
 }
 
 type Record struct {
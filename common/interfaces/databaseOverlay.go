@@ -31,8 +31,14 @@ type DBOverlaySimple interface {
 	FetchHeadIndexByChainID(chainID IHash) (IHash, error)
 	FetchIncludedIn(hash IHash) (IHash, error)
 	FetchPaidFor(hash IHash) (IHash, error)
+	FetchFactoidAddressTransactions(address IHash, startHeight, endHeight uint32, limit int) ([]*AddressTransaction, error)
+	FetchECAddressTransactions(address IHash, startHeight, endHeight uint32, limit int) ([]*AddressTransaction, error)
+	FetchFactoidBalanceAtHeight(address IHash, height uint32) (int64, error)
+	FetchECBalanceAtHeight(address IHash, height uint32) (int64, error)
+	FetchDBlockFilter(keyMR IHash) ([]byte, error)
 	FetchAllEBlocksByChain(IHash) ([]IEntryBlock, error)
 	InsertEntryMultiBatch(entry IEBEntry) error
+	PruneEntry(hash IHash) error
 	ProcessABlockMultiBatch(block DatabaseBatchable) error
 	ProcessDBlockMultiBatch(block DatabaseBlockWithEntries) error
 	ProcessEBlockBatch(eblock DatabaseBlockWithEntries, checkForDuplicateEntries bool) error
@@ -49,6 +55,16 @@ type DBOverlaySimple interface {
 	FetchKeyValueStore(key []byte, dst BinaryMarshallable) (BinaryMarshallable, error)
 	SaveDatabaseEntryHeight(height uint32) error
 	FetchDatabaseEntryHeight() (uint32, error)
+	SaveDatabasePrunedHeight(height uint32) error
+	FetchDatabasePrunedHeight() (uint32, error)
+	Snapshot() (DBOverlaySimple, error)
+}
+
+// AddressTransaction locates one transaction in the history of an address
+type AddressTransaction struct {
+	Height     uint32 `json:"height"`
+	TxID       IHash  `json:"txid"`
+	BlockKeyMR IHash  `json:"blockkeymr"`
 }
 
 // Db defines a generic interface that is used to request and insert data into db
@@ -61,6 +77,10 @@ type DBOverlay interface {
 
 	StartMultiBatch()
 	PutInMultiBatch(records []Record)
+
+	// Snapshot returns a read only overlay over the database as it is now, which
+	// must be closed once done.  Closing it does not close the database.
+	Snapshot() (DBOverlaySimple, error)
 	ExecuteMultiBatch() error
 	GetEntryType(hash IHash) (IHash, error)
 
@@ -70,10 +90,14 @@ type DBOverlay interface {
 	InsertEntry(entry IEBEntry) (err error)
 	InsertEntryMultiBatch(entry IEBEntry) error
 
+	// PruneEntry deletes the content of an entry but not where it is included
+	PruneEntry(hash IHash) error
+
 	// FetchEntry gets an entry by hash from the database.
 	FetchEntry(IHash) (IEBEntry, error)
 
 	FetchAllEntriesByChainID(chainID IHash) ([]IEBEntry, error)
+	IterateEntriesByChainID(chainID IHash, f func(IEBEntry) error) error
 
 	FetchAllEntryIDsByChainID(chainID IHash) ([]IHash, error)
 
@@ -100,6 +124,7 @@ type DBOverlay interface {
 
 	// FetchAllEBlocksByChain gets all of the blocks by chain id
 	FetchAllEBlocksByChain(IHash) ([]IEntryBlock, error)
+	IterateEBlocksByChain(chainID IHash, f func(IEntryBlock) error) error
 
 	SaveEBlockHead(block DatabaseBlockWithEntries, checkForDuplicateEntries bool) error
 
@@ -145,6 +170,7 @@ type DBOverlay interface {
 
 	// FetchAllFBInfo gets all of the fbInfo
 	FetchAllDBlocks() ([]IDirectoryBlock, error)
+	IterateDBlocks(f func(IDirectoryBlock) error) error
 	FetchAllDBlockKeys() ([]IHash, error)
 
 	SaveDirectoryBlockHead(DatabaseBlockWithEntries) error
@@ -169,6 +195,7 @@ type DBOverlay interface {
 
 	// FetchAllECBlocks gets all of the entry credit blocks
 	FetchAllECBlocks() ([]IEntryCreditBlock, error)
+	IterateECBlocks(f func(IEntryCreditBlock) error) error
 	FetchAllECBlockKeys() ([]IHash, error)
 
 	SaveECBlockHead(IEntryCreditBlock, bool) error
@@ -193,6 +220,7 @@ type DBOverlay interface {
 
 	// FetchAllABlocks gets all of the admin blocks
 	FetchAllABlocks() ([]IAdminBlock, error)
+	IterateABlocks(f func(IAdminBlock) error) error
 	FetchAllABlockKeys() ([]IHash, error)
 
 	SaveABlockHead(DatabaseBatchable) error
@@ -215,6 +243,7 @@ type DBOverlay interface {
 
 	// FetchAllFBlocks gets all of the factoid blocks
 	FetchAllFBlocks() ([]IFBlock, error)
+	IterateFBlocks(f func(IFBlock) error) error
 	FetchAllFBlockKeys() ([]IHash, error)
 
 	SaveFactoidBlockHead(fblock DatabaseBlockWithEntries) error
@@ -241,6 +270,7 @@ type DBOverlay interface {
 
 	// FetchAllDirBlockInfos gets all of the dirblock info blocks
 	FetchAllDirBlockInfos() ([]IDirBlockInfo, error)
+	IterateDirBlockInfos(f func(IDirBlockInfo) error) error
 
 	SaveDirBlockInfo(block IDirBlockInfo) error
 
@@ -251,9 +281,27 @@ type DBOverlay interface {
 	SaveIncludedInMulti(entries []IHash, block IHash, checkForDuplicateEntries bool) error
 	FetchIncludedIn(hash IHash) (IHash, error)
 	RebuildDirBlockInfo() error
+	ReindexAnchorInfo() error
 
 	FetchPaidFor(hash IHash) (IHash, error)
 
+	//******************************AddressTransactions**********************************//
+
+	FetchFactoidAddressTransactions(address IHash, startHeight, endHeight uint32, limit int) ([]*AddressTransaction, error)
+	FetchECAddressTransactions(address IHash, startHeight, endHeight uint32, limit int) ([]*AddressTransaction, error)
+	RebuildAddressTransactions() error
+
+	//******************************BalanceHistory**********************************//
+
+	FetchFactoidBalanceAtHeight(address IHash, height uint32) (int64, error)
+	FetchECBalanceAtHeight(address IHash, height uint32) (int64, error)
+
+	//******************************DBlockFilter**********************************//
+
+	FetchDBlockFilter(keyMR IHash) ([]byte, error)
+	FetchDBlockFilterByHeight(height uint32) ([]byte, error)
+	ReindexDBlockFilters() error
+
 	FetchFactoidTransaction(hash IHash) (ITransaction, error)
 	FetchECTransaction(hash IHash) (IECBlockEntry, error)
 
@@ -262,6 +310,8 @@ type DBOverlay interface {
 	FetchKeyValueStore(key []byte, dst BinaryMarshallable) (BinaryMarshallable, error)
 	SaveDatabaseEntryHeight(height uint32) error
 	FetchDatabaseEntryHeight() (uint32, error)
+	SaveDatabasePrunedHeight(height uint32) error
+	FetchDatabasePrunedHeight() (uint32, error)
 }
 
 type ISCDatabaseOverlay interface {
@@ -9,6 +9,7 @@ type IDirBlockInfo interface {
 	DatabaseBatchable
 	GetDBHeight() uint32
 	GetBTCConfirmed() bool
+	GetEthConfirmed() bool
 	GetDBMerkleRoot() IHash
 	GetBTCTxHash() IHash
 	GetTimestamp() Timestamp
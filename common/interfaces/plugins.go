@@ -18,3 +18,20 @@ type IManagerController interface {
 	// Plugin Control
 	Alive() error
 }
+
+// IIndexerController is the interface an external block indexer exposes as a
+// plugin. Factomd pushes every saved DBState to it in height order, and asks it
+// where to resume from when it is (re)started.
+type IIndexerController interface {
+	// IndexDBState hands the plugin a marshalled WholeBlock (all blocks and
+	// entries) at the given height. Once it returns nil the height is
+	// acknowledged, and is not sent again. A pruned node leaves out the entries
+	// it pruned; their entry blocks still list them.
+	IndexDBState(height uint32, data []byte) error
+	// AckedHeight returns the highest height the plugin has indexed, or -1 if
+	// it has not indexed anything yet
+	AckedHeight() (int64, error)
+
+	// Plugin Control
+	Alive() error
+}
@@ -75,9 +75,18 @@ type IState interface {
 	GetRpcPass() string
 	SetRpcAuthHash(authHash []byte)
 	GetRpcAuthHash() []byte
+	GetRpcTokensFile() string
+	GetApiLimits() (perIP int, perToken int, expensive int, maxBatch int)
 	GetTlsInfo() (bool, string, string)
+	GetSubscriptions() ISubscriptionHub
 	GetFactomdLocations() string
 
+	// Peer bans of the p2p network.  The bans are returned as interface{}, as this
+	// package can not import p2p
+	GetPeerBans() (interface{}, error)
+	AddPeerBan(address string, reason string, seconds int64) (interface{}, error)
+	RemovePeerBan(address string) (bool, error)
+
 	// Routine for handling the syncroniztion of the leader and follower processes
 	// and how they process messages.
 	Process() (progress bool)
@@ -131,6 +140,7 @@ type IState interface {
 	GetPendingEntries(interface{}) []IPendingEntry
 	NextCommit(hash IHash) IMsg
 	PutCommit(hash IHash, msg IMsg)
+	PendingCommit(hash IHash) (commit IMsg, expires Timestamp, revealed bool)
 
 	IncEntryChains()
 	IncEntries()
@@ -302,6 +312,7 @@ type IState interface {
 	GetLLeaderHeight() uint32
 	GetEntryDBHeightComplete() uint32
 	GetMissingEntryCount() uint32
+	IsEntryPruned(hash IHash) bool // the entry is in a block but this node pruned its content
 	GetEntryBlockDBHeightProcessing() uint32
 	GetEntryBlockDBHeightComplete() uint32
 	GetCurrentBlockStartTime() int64
@@ -317,6 +328,7 @@ type IState interface {
 
 	// Access to Holding Queue
 	LoadHoldingMap() map[[32]byte]IMsg
+	ExplainHolding() interface{} // Why each message in holding is waiting
 	LoadAcksMap() map[[32]byte]IMsg
 
 	// Plugins
@@ -0,0 +1,12 @@
+// Copyright 2017 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package interfaces
+
+// ISubscriptionHub is where the state publishes what happens to subscribers.  The
+// hub itself is in the subscriptions package, which the API asserts it to.
+type ISubscriptionHub interface {
+	Subscribers() int                 // How many subscribers are connected
+	HasSubscribers(topic string) bool // Whether anyone subscribed to the topic
+}
@@ -150,7 +150,7 @@ func (m *FedVoteProposalMsg) LeaderExecute(state interfaces.IState) {
 func (m *FedVoteProposalMsg) FollowerExecute(is interfaces.IState) {
 	s := is.(*state.State)
 	if s.Elections.(*elections.Elections).Adapter == nil {
-		s.Holding[m.GetMsgHash().Fixed()] = m
+		s.AddToHolding(m.GetMsgHash().Fixed(), m)
 		return
 	}
 	is.ElectionsQueue().Enqueue(m)
@@ -102,7 +102,7 @@ func (m *FedVoteVolunteerMsg) FollowerExecute(is interfaces.IState) {
 	s := is.(*state.State)
 	e := s.Elections.(*elections.Elections)
 	if e.Adapter == nil {
-		s.Holding[m.GetMsgHash().Fixed()] = m
+		s.AddToHolding(m.GetMsgHash().Fixed(), m)
 		return
 	}
 
@@ -97,7 +97,9 @@ func newFactoidTransaction() *FactoidTransaction {
 	}
 
 	for i := 0; i < 2; i++ {
-		t.AddAuthorization(nextAuth2())
+		rcd := nextAuth2()
+		t.AddAuthorization(rcd)
+		t.SetSignatureBlock(3+i, factoid.NewUnsignedSignatureBlock(rcd))
 	}
 
 	msg.Transaction = t
@@ -134,14 +136,14 @@ func nextAuth2() interfaces.IRCD {
 	if r == nil {
 		r = rand.New(rand.NewSource(1))
 	}
-	n := r.Int()%4 + 1
-	m := r.Int()%4 + n
-	addresses := make([]interfaces.IAddress, m, m)
-	for j := 0; j < m; j++ {
+	required := r.Int()%4 + 1
+	total := r.Int()%4 + required
+	addresses := make([]interfaces.IAddress, total, total)
+	for j := 0; j < total; j++ {
 		addresses[j] = nextAddress()
 	}
 
-	rcd, _ := factoid.NewRCD_2(n, m, addresses)
+	rcd, _ := factoid.NewRCD_2(required, total, addresses)
 	return rcd
 }
 
@@ -61,6 +61,8 @@ type JSON2Response struct {
 	ID      interface{} `json:"id"`
 	Error   *JSONError  `json:"error,omitempty"`
 	Result  interface{} `json:"result,omitempty"`
+	// The saved directory block height the request was served at, if known
+	Height *uint32 `json:"height,omitempty"`
 }
 
 func (e *JSON2Response) JSONByte() ([]byte, error) {
@@ -10,11 +10,9 @@ import (
 	"fmt"
 	"io/ioutil"
 	"os"
-	"sort"
 
 	"github.com/FactomProject/factomd/common/interfaces"
 	"github.com/FactomProject/factomd/common/primitives"
-	"github.com/FactomProject/factomd/util"
 )
 
 type BlockExtractor struct {
@@ -139,14 +137,9 @@ func (be *BlockExtractor) ExportEChain(chainID string, db interfaces.DBOverlay)
 	if err != nil {
 		return err
 	}
-	eBlocks, err := db.FetchAllEBlocksByChain(id)
-	if err != nil {
-		return err
-	}
-	fmt.Printf("Fetched %v blocks\n", len(eBlocks))
-	sort.Sort(util.ByEBlockIDAscending(eBlocks))
-
-	for _, block := range eBlocks {
+	count := 0
+	err = db.IterateEBlocksByChain(id, func(block interfaces.IEntryBlock) error {
+		count++
 		be.SaveBinary(block.(interfaces.DatabaseBatchable))
 		be.SaveJSON(block.(interfaces.DatabaseBatchable))
 		height := block.GetDatabaseHeight()
@@ -164,101 +157,57 @@ func (be *BlockExtractor) ExportEChain(chainID string, db interfaces.DBOverlay)
 				return err
 			}
 		}
+		return nil
+	})
+	if err != nil {
+		return err
 	}
+	fmt.Printf("Exported %v blocks\n", count)
 	return nil
 }
 
 func (be *BlockExtractor) ExportDChain(db interfaces.DBOverlay) error {
 	fmt.Printf("ExportDChain\n")
-	// get all ecBlocks from db
-	dBlocks, err := db.FetchAllDBlocks()
-	if err != nil {
-		return err
-	}
-	sort.Sort(util.ByDBlockIDAscending(dBlocks))
-
-	for _, block := range dBlocks {
+	return db.IterateDBlocks(func(block interfaces.IDirectoryBlock) error {
 		//Making sure Hash and KeyMR are set for the JSON export
 		block.GetFullHash()
 		block.GetKeyMR()
-		err = be.ExportBlock(block.(interfaces.DatabaseBatchable))
-		if err != nil {
-			return err
-		}
-	}
-	return nil
+		return be.ExportBlock(block.(interfaces.DatabaseBatchable))
+	})
 }
 
 func (be *BlockExtractor) ExportECChain(db interfaces.DBOverlay) error {
 	fmt.Printf("ExportECChain\n")
-	// get all ecBlocks from db
-	ecBlocks, err := db.FetchAllECBlocks()
-	if err != nil {
-		return err
-	}
-	sort.Sort(util.ByECBlockIDAscending(ecBlocks))
-
-	for _, block := range ecBlocks {
-		err = be.ExportBlock(block.(interfaces.DatabaseBatchable))
-		if err != nil {
-			return err
-		}
-	}
-	return nil
+	return db.IterateECBlocks(func(block interfaces.IEntryCreditBlock) error {
+		return be.ExportBlock(block.(interfaces.DatabaseBatchable))
+	})
 }
 
 func (be *BlockExtractor) ExportAChain(db interfaces.DBOverlay) error {
 	fmt.Printf("ExportAChain\n")
-	// get all aBlocks from db
-	aBlocks, err := db.FetchAllABlocks()
-	if err != nil {
-		return err
-	}
-	sort.Sort(util.ByABlockIDAscending(aBlocks))
-
-	for _, block := range aBlocks {
-		err = be.ExportBlock(block.(interfaces.DatabaseBatchable))
-		if err != nil {
-			return err
-		}
-	}
-	return nil
+	return db.IterateABlocks(func(block interfaces.IAdminBlock) error {
+		return be.ExportBlock(block.(interfaces.DatabaseBatchable))
+	})
 }
 
 func (be *BlockExtractor) ExportFctChain(db interfaces.DBOverlay) error {
 	fmt.Printf("ExportFctChain\n")
-	// get all aBlocks from db
-	fBlocks, err := db.FetchAllFBlocks()
-	if err != nil {
-		return err
-	}
-	sort.Sort(util.ByFBlockIDAscending(fBlocks))
-
-	for _, block := range fBlocks {
-		err = be.ExportBlock(block.(interfaces.DatabaseBatchable))
-		if err != nil {
-			return err
-		}
-	}
-	return nil
+	return db.IterateFBlocks(func(block interfaces.IFBlock) error {
+		return be.ExportBlock(block.(interfaces.DatabaseBatchable))
+	})
 }
 
 func (be *BlockExtractor) ExportDirBlockInfo(db interfaces.DBOverlay) error {
 	fmt.Printf("ExportDirBlockInfo\n")
-	// get all aBlocks from db
-	dbi, err := db.FetchAllDirBlockInfos()
+	count := 0
+	err := db.IterateDirBlockInfos(func(block interfaces.IDirBlockInfo) error {
+		count++
+		return be.ExportBlock(block)
+	})
 	if err != nil {
 		return err
 	}
-	fmt.Printf("Fetched %v blocks\n", len(dbi))
-	sort.Sort(util.ByDirBlockInfoIDAscending(dbi))
-
-	for _, block := range dbi {
-		err = be.ExportBlock(block)
-		if err != nil {
-			return err
-		}
-	}
+	fmt.Printf("Exported %v blocks\n", count)
 	return nil
 }
 
@@ -34,9 +34,14 @@ import (
 type BoltDB struct {
 	Sem sync.RWMutex
 	db  *bolt.DB // Pointer to the bolt db
+
+	// Held for reading by every open snapshot, and for writing by Close, which
+	// must not unmap the database under them.  Writes do not wait for it.
+	snapshots sync.RWMutex
 }
 
 var _ interfaces.IDatabase = (*BoltDB)(nil)
+var _ interfaces.ISnapshotDatabase = (*BoltDB)(nil)
 
 func NewBoltDB(bucketList [][]byte, filename string) *BoltDB {
 	db := new(BoltDB)
@@ -101,6 +106,8 @@ func (db *BoltDB) Trim() {
 }
 
 func (db *BoltDB) Close() error {
+	db.snapshots.Lock()
+	defer db.snapshots.Unlock()
 	db.Sem.Lock()
 	defer db.Sem.Unlock()
 
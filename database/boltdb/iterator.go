@@ -0,0 +1,200 @@
+// Copyright 2017 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package boltdb
+
+import (
+	"bytes"
+
+	"github.com/FactomProject/bolt"
+	"github.com/FactomProject/factomd/common/interfaces"
+)
+
+// IteratorPageSize is the number of keys an iterator reads per transaction.  An
+// open read transaction holds off growing the database file, so an iterator does
+// not keep one open between pages.
+var IteratorPageSize = 256
+
+type Iterator struct {
+	view    func(func(*bolt.Tx) error) error // runs a read transaction
+	bucket  []byte
+	options interfaces.IterateOptions
+
+	keys   [][]byte
+	values [][]byte
+	pos    int
+	count  int
+	last   []byte // the last key read, the next page starts after it
+	done   bool   // the last page was read
+	err    error
+}
+
+var _ interfaces.IIterator = (*Iterator)(nil)
+
+func (db *BoltDB) NewIterator(bucket []byte, options *interfaces.IterateOptions) interfaces.IIterator {
+	return newIterator(db.view, bucket, options)
+}
+
+// view runs a read transaction on the database
+func (db *BoltDB) view(fn func(*bolt.Tx) error) error {
+	db.Sem.RLock()
+	defer db.Sem.RUnlock()
+
+	return db.db.View(fn)
+}
+
+func newIterator(view func(func(*bolt.Tx) error) error, bucket []byte, options *interfaces.IterateOptions) *Iterator {
+	it := new(Iterator)
+	it.view = view
+	it.bucket = append([]byte{}, bucket...)
+	if options != nil {
+		it.options = *options
+	}
+	return it
+}
+
+func (it *Iterator) Next() bool {
+	if it.options.Limit > 0 && it.count >= it.options.Limit {
+		return false
+	}
+	it.pos++
+	if it.pos >= len(it.keys) {
+		if it.done {
+			it.keys, it.values = nil, nil
+			return false
+		}
+		it.readPage()
+		if len(it.keys) == 0 {
+			return false
+		}
+	}
+	it.count++
+	return true
+}
+
+func (it *Iterator) readPage() {
+	started := it.last != nil
+	it.keys, it.values, it.pos = [][]byte{}, [][]byte{}, 0
+
+	it.err = it.view(func(tx *bolt.Tx) error {
+		b := tx.Bucket(it.bucket)
+		if b == nil {
+			return nil
+		}
+		c := b.Cursor()
+		var k, v []byte
+		if started {
+			k, v = it.resume(c)
+		} else {
+			k, v = it.first(c)
+		}
+		for ; k != nil && bytes.HasPrefix(k, it.options.Prefix) && len(it.keys) < IteratorPageSize; k, v = it.step(c) {
+			it.keys = append(it.keys, append([]byte{}, k...))
+			it.values = append(it.values, append([]byte{}, v...))
+		}
+		return nil
+	})
+	if it.err != nil || len(it.keys) < IteratorPageSize {
+		it.done = true
+	}
+	if len(it.keys) > 0 {
+		it.last = it.keys[len(it.keys)-1]
+	}
+}
+
+func (it *Iterator) step(c *bolt.Cursor) ([]byte, []byte) {
+	if it.options.Reverse {
+		return c.Prev()
+	}
+	return c.Next()
+}
+
+// first puts the cursor on the first key to return
+func (it *Iterator) first(c *bolt.Cursor) ([]byte, []byte) {
+	prefix := it.options.Prefix
+	seek := it.options.Seek
+	if !it.options.Reverse {
+		if bytes.Compare(seek, prefix) > 0 {
+			return c.Seek(seek)
+		}
+		if len(prefix) == 0 {
+			return c.First()
+		}
+		return c.Seek(prefix)
+	}
+
+	limit := prefixLimit(prefix)
+	if seek != nil && (limit == nil || bytes.Compare(seek, limit) < 0) {
+		k, v := c.Seek(seek)
+		if k == nil {
+			return c.Last()
+		}
+		if !bytes.Equal(k, seek) {
+			return c.Prev()
+		}
+		return k, v
+	}
+	if limit == nil {
+		return c.Last()
+	}
+	return before(c, limit)
+}
+
+// resume puts the cursor on the key after the last one read
+func (it *Iterator) resume(c *bolt.Cursor) ([]byte, []byte) {
+	if it.options.Reverse {
+		return before(c, it.last)
+	}
+	k, v := c.Seek(it.last)
+	if bytes.Equal(k, it.last) {
+		return c.Next()
+	}
+	return k, v
+}
+
+// before puts the cursor on the last key before the given one
+func before(c *bolt.Cursor, key []byte) ([]byte, []byte) {
+	k, _ := c.Seek(key)
+	if k == nil {
+		return c.Last()
+	}
+	return c.Prev()
+}
+
+// prefixLimit is the first key after all the keys starting with the prefix, or nil
+// if there is none
+func prefixLimit(prefix []byte) []byte {
+	for i := len(prefix) - 1; i >= 0; i-- {
+		if prefix[i] < 0xff {
+			limit := make([]byte, i+1)
+			copy(limit, prefix)
+			limit[i]++
+			return limit
+		}
+	}
+	return nil
+}
+
+func (it *Iterator) Key() []byte {
+	if it.pos >= len(it.keys) {
+		return nil
+	}
+	return it.keys[it.pos]
+}
+
+func (it *Iterator) Value() []byte {
+	if it.pos >= len(it.values) {
+		return nil
+	}
+	return it.values[it.pos]
+}
+
+func (it *Iterator) Release() {
+	it.keys, it.values = nil, nil
+	it.done = true
+}
+
+func (it *Iterator) Error() error {
+	return it.err
+}
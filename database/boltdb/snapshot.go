@@ -0,0 +1,76 @@
+// Copyright 2017 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package boltdb
+
+import (
+	"github.com/FactomProject/bolt"
+	"github.com/FactomProject/factomd/common/interfaces"
+)
+
+// Snapshot is a read transaction kept open, so it reads the database as it was
+// when it was taken.  Writes go on while it is open.
+//
+// Bolt cannot grow its memory map while a read transaction is open, so the rare
+// write that has to grow it waits for the open snapshots to be released, and
+// blocks new readers meanwhile.  Snapshots must be released quickly, and whoever
+// holds one must read through it rather than the database itself.  Close waits
+// for the open snapshots.
+type Snapshot struct {
+	db       *BoltDB
+	tx       *bolt.Tx
+	released bool
+}
+
+var _ interfaces.IDatabaseSnapshot = (*Snapshot)(nil)
+
+// Snapshot takes a snapshot of the database
+func (db *BoltDB) Snapshot() (interfaces.IDatabaseSnapshot, error) {
+	db.snapshots.RLock()
+	db.Sem.RLock()
+	defer db.Sem.RUnlock()
+
+	tx, err := db.db.Begin(false)
+	if err != nil {
+		db.snapshots.RUnlock()
+		return nil, err
+	}
+	return &Snapshot{db: db, tx: tx}, nil
+}
+
+func (s *Snapshot) Get(bucket []byte, key []byte, destination interfaces.BinaryMarshallable) (interfaces.BinaryMarshallable, error) {
+	b := s.tx.Bucket(bucket)
+	if b == nil {
+		return nil, nil
+	}
+	v := b.Get(key)
+	if v == nil {
+		return nil, nil
+	}
+
+	// The value lives in the memory map, which the transaction only holds till released
+	_, err := destination.UnmarshalBinaryData(append([]byte{}, v...))
+	if err != nil {
+		return nil, err
+	}
+	return destination, nil
+}
+
+// NewIterator is BoltDB.NewIterator as of the snapshot
+func (s *Snapshot) NewIterator(bucket []byte, options *interfaces.IterateOptions) interfaces.IIterator {
+	return newIterator(s.view, bucket, options)
+}
+
+func (s *Snapshot) view(fn func(*bolt.Tx) error) error {
+	return fn(s.tx)
+}
+
+func (s *Snapshot) Release() {
+	if s.released {
+		return
+	}
+	s.released = true
+	s.tx.Rollback()
+	s.db.snapshots.RUnlock()
+}
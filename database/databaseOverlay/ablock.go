@@ -78,6 +78,13 @@ func (db *Overlay) FetchAllABlocks() ([]interfaces.IAdminBlock, error) {
 	return toABlocksList(list), nil
 }
 
+// IterateABlocks calls f with the admin blocks in height order, one at a time
+func (db *Overlay) IterateABlocks(f func(interfaces.IAdminBlock) error) error {
+	return db.iterateBlocksByHeight(ADMINBLOCK_NUMBER, ADMINBLOCK, new(adminBlock.AdminBlock), func(block interfaces.DatabaseBatchable) error {
+		return f(block.(interfaces.IAdminBlock))
+	})
+}
+
 func (db *Overlay) FetchAllABlockKeys() ([]interfaces.IHash, error) {
 	return db.FetchAllBlockKeysFromBucket(ADMINBLOCK)
 }
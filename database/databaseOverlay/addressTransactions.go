@@ -0,0 +1,231 @@
+// Copyright 2017 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package databaseOverlay
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"github.com/FactomProject/factomd/common/constants"
+	"github.com/FactomProject/factomd/common/entryCreditBlock"
+	"github.com/FactomProject/factomd/common/interfaces"
+	"github.com/FactomProject/factomd/common/primitives"
+)
+
+// Every address gets its own bucket (the table prefix followed by the 32 byte
+// address).  Keys in that bucket are the 4 byte big endian block height
+// followed by the transaction hash, so listing the bucket returns the history
+// of the address in height order.  The value is the KeyMR of the FBlock or
+// ECBlock holding the transaction.
+//
+// Factoid transactions are indexed by their TxID (sig hash) under every input
+// and output address.  ECBlock entries (commits and balance increases) are
+// indexed by their hash under the entry credit public key they touch.
+
+func addressTransactionsBucket(table []byte, address []byte) []byte {
+	bucket := make([]byte, 0, len(table)+len(address))
+	bucket = append(bucket, table...)
+	return append(bucket, address...)
+}
+
+func addressTransactionsKey(height uint32, txid interfaces.IHash) []byte {
+	key := make([]byte, 4, 4+constants.HASH_LENGTH)
+	binary.BigEndian.PutUint32(key, height)
+	return append(key, txid.Bytes()...)
+}
+
+// AddressTransactionRecordsFromFBlock returns the index records for every factoid
+// address touched by a transaction in the given block
+func AddressTransactionRecordsFromFBlock(block interfaces.IFBlock) []interfaces.Record {
+	if block == nil {
+		return nil
+	}
+	batch := []interfaces.Record{}
+	height := block.GetDatabaseHeight()
+	keyMR := block.DatabasePrimaryIndex()
+
+	for _, tx := range block.GetTransactions() {
+		key := addressTransactionsKey(height, tx.GetSigHash())
+		// ECOutputs are indexed through the balance increase in the ECBlock
+		for _, adrs := range [][]interfaces.ITransAddress{tx.GetInputs(), tx.GetOutputs()} {
+			for _, adr := range adrs {
+				bucket := addressTransactionsBucket(FACTOID_ADDRESS_TRANSACTIONS, adr.GetAddress().Bytes())
+				batch = append(batch, interfaces.Record{bucket, key, keyMR})
+			}
+		}
+	}
+	return batch
+}
+
+// AddressTransactionRecordsFromECBlock returns the index records for every entry
+// credit address touched by a commit or balance increase in the given block
+func AddressTransactionRecordsFromECBlock(block interfaces.IEntryCreditBlock) []interfaces.Record {
+	if block == nil {
+		return nil
+	}
+	batch := []interfaces.Record{}
+	height := block.GetDatabaseHeight()
+	keyMR := block.DatabasePrimaryIndex()
+
+	for _, entry := range block.GetBody().GetEntries() {
+		pubKey := ecEntryPubKey(entry)
+		if pubKey == nil {
+			continue
+		}
+		bucket := addressTransactionsBucket(EC_ADDRESS_TRANSACTIONS, pubKey[:])
+		batch = append(batch, interfaces.Record{bucket, addressTransactionsKey(height, entry.Hash()), keyMR})
+	}
+	return batch
+}
+
+// ecEntryPubKey returns the entry credit public key of a commit or balance
+// increase, or nil for other ECBlock entries
+func ecEntryPubKey(entry interfaces.IECBlockEntry) *primitives.ByteSlice32 {
+	switch entry.ECID() {
+	case constants.ECIDChainCommit:
+		return entry.(*entryCreditBlock.CommitChain).ECPubKey
+	case constants.ECIDEntryCommit:
+		return entry.(*entryCreditBlock.CommitEntry).ECPubKey
+	case constants.ECIDBalanceIncrease:
+		return entry.(*entryCreditBlock.IncreaseBalance).ECPubKey
+	}
+	return nil
+}
+
+func (db *Overlay) SaveAddressTransactionsFromFBlock(block interfaces.IFBlock) error {
+	batch := AddressTransactionRecordsFromFBlock(block)
+	if len(batch) == 0 {
+		return nil
+	}
+	return db.DB.PutInBatch(batch)
+}
+
+func (db *Overlay) SaveAddressTransactionsFromFBlockMultiBatch(block interfaces.IFBlock) error {
+	batch := AddressTransactionRecordsFromFBlock(block)
+	if len(batch) == 0 {
+		return nil
+	}
+	db.PutInMultiBatch(batch)
+	return nil
+}
+
+func (db *Overlay) SaveAddressTransactionsFromECBlock(block interfaces.IEntryCreditBlock) error {
+	batch := AddressTransactionRecordsFromECBlock(block)
+	if len(batch) == 0 {
+		return nil
+	}
+	return db.DB.PutInBatch(batch)
+}
+
+func (db *Overlay) SaveAddressTransactionsFromECBlockMultiBatch(block interfaces.IEntryCreditBlock) error {
+	batch := AddressTransactionRecordsFromECBlock(block)
+	if len(batch) == 0 {
+		return nil
+	}
+	db.PutInMultiBatch(batch)
+	return nil
+}
+
+// FetchFactoidAddressTransactions returns the factoid transactions touching the
+// address (an RCD hash) between the start and end heights, inclusive.  See
+// fetchAddressTransactions for the limit.
+func (db *Overlay) FetchFactoidAddressTransactions(address interfaces.IHash, startHeight, endHeight uint32, limit int) ([]*interfaces.AddressTransaction, error) {
+	return db.fetchAddressTransactions(FACTOID_ADDRESS_TRANSACTIONS, address, startHeight, endHeight, limit)
+}
+
+// FetchECAddressTransactions returns the ECBlock entries touching the entry
+// credit public key between the start and end heights, inclusive.  See
+// fetchAddressTransactions for the limit.
+func (db *Overlay) FetchECAddressTransactions(address interfaces.IHash, startHeight, endHeight uint32, limit int) ([]*interfaces.AddressTransaction, error) {
+	return db.fetchAddressTransactions(EC_ADDRESS_TRANSACTIONS, address, startHeight, endHeight, limit)
+}
+
+// fetchAddressTransactions walks the address's transactions from the start height.
+// Once it has limit of them (if positive), it finishes the height of the last and
+// stops after the first transaction of a later height, which it still returns so
+// callers can tell there are more.
+func (db *Overlay) fetchAddressTransactions(table []byte, address interfaces.IHash, startHeight, endHeight uint32, limit int) ([]*interfaces.AddressTransaction, error) {
+	if address == nil {
+		return nil, nil
+	}
+	if startHeight > endHeight {
+		return nil, fmt.Errorf("start height %d is above end height %d", startHeight, endHeight)
+	}
+	seek := make([]byte, 4)
+	binary.BigEndian.PutUint32(seek, startHeight)
+	iter := db.DB.NewIterator(addressTransactionsBucket(table, address.Bytes()), &interfaces.IterateOptions{Seek: seek})
+	defer iter.Release()
+
+	answer := []*interfaces.AddressTransaction{}
+	for iter.Next() {
+		key := iter.Key()
+		if len(key) != 4+constants.HASH_LENGTH {
+			return nil, fmt.Errorf("malformed address transaction key %x", key)
+		}
+		height := binary.BigEndian.Uint32(key[:4])
+		if height > endHeight {
+			break
+		}
+		keyMR := new(primitives.Hash)
+		err := keyMR.UnmarshalBinary(iter.Value())
+		if err != nil {
+			return nil, err
+		}
+		at := new(interfaces.AddressTransaction)
+		at.Height = height
+		at.TxID = primitives.NewHash(key[4:])
+		at.BlockKeyMR = keyMR
+		answer = append(answer, at)
+		if limit > 0 && len(answer) > limit && height != answer[limit-1].Height {
+			break
+		}
+	}
+	err := iter.Error()
+	if err != nil {
+		return nil, err
+	}
+	return answer, nil
+}
+
+// RebuildAddressTransactions indexes the transactions and balance changes of every
+// saved FBlock and ECBlock.  It is used to build the indexes for databases written
+// before they existed; indexing a block twice is harmless.
+func (db *Overlay) RebuildAddressTransactions() error {
+	head, err := db.FetchDBlockHead()
+	if err != nil {
+		return err
+	}
+	if head == nil {
+		return nil
+	}
+	top := head.GetDatabaseHeight()
+	for height := uint32(0); height <= top; height++ {
+		fBlock, err := db.FetchFBlockByHeight(height)
+		if err != nil {
+			return err
+		}
+		err = db.SaveAddressTransactionsFromFBlock(fBlock)
+		if err != nil {
+			return err
+		}
+		err = db.SaveBalanceDeltasFromFBlock(fBlock)
+		if err != nil {
+			return err
+		}
+		ecBlock, err := db.FetchECBlockByHeight(height)
+		if err != nil {
+			return err
+		}
+		err = db.SaveAddressTransactionsFromECBlock(ecBlock)
+		if err != nil {
+			return err
+		}
+		err = db.SaveBalanceDeltasFromECBlock(ecBlock)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,213 @@
+package databaseOverlay_test
+
+import (
+	"encoding/binary"
+	"testing"
+
+	"github.com/FactomProject/factomd/common/constants"
+	"github.com/FactomProject/factomd/common/entryCreditBlock"
+	"github.com/FactomProject/factomd/common/interfaces"
+	"github.com/FactomProject/factomd/common/primitives"
+	"github.com/FactomProject/factomd/database/databaseOverlay"
+	"github.com/FactomProject/factomd/database/mapdb"
+	. "github.com/FactomProject/factomd/testHelper"
+)
+
+func containsTx(txs []*interfaces.AddressTransaction, txid interfaces.IHash, height uint32) bool {
+	for _, tx := range txs {
+		if tx.TxID.IsSameAs(txid) && tx.Height == height {
+			return true
+		}
+	}
+	return false
+}
+
+func txAddresses(tx interfaces.ITransaction) []interfaces.ITransAddress {
+	adrs := []interfaces.ITransAddress{}
+	adrs = append(adrs, tx.GetInputs()...)
+	return append(adrs, tx.GetOutputs()...)
+}
+
+func TestFetchFactoidAddressTransactions(t *testing.T) {
+	blocks := CreateFullTestBlockSet()
+	dbo := CreateAndPopulateTestDatabaseOverlay()
+
+	for _, block := range blocks {
+		height := block.FBlock.GetDatabaseHeight()
+		for _, tx := range block.FBlock.GetTransactions() {
+			for _, adr := range txAddresses(tx) {
+				txs, err := dbo.FetchFactoidAddressTransactions(adr.GetAddress(), height, height, 0)
+				if err != nil {
+					t.Errorf("%v", err)
+				}
+				if containsTx(txs, tx.GetSigHash(), height) == false {
+					t.Errorf("Tx %v not indexed under %v", tx.GetSigHash(), adr.GetAddress())
+				}
+				for _, at := range txs {
+					if at.Height != height {
+						t.Errorf("Got height %v outside of the requested range", at.Height)
+					}
+					if at.BlockKeyMR.IsSameAs(block.FBlock.DatabasePrimaryIndex()) == false {
+						t.Errorf("Wrong block KeyMR %v", at.BlockKeyMR)
+					}
+				}
+			}
+		}
+	}
+
+	_, err := dbo.FetchFactoidAddressTransactions(primitives.NewZeroHash(), 2, 1, 0)
+	if err == nil {
+		t.Errorf("Expected an error for an inverted height range")
+	}
+}
+
+func TestFetchAddressTransactionsLimit(t *testing.T) {
+	dbo := databaseOverlay.NewOverlay(new(mapdb.MapDB))
+	address := primitives.RandomHash()
+	bucket := append(append([]byte{}, databaseOverlay.FACTOID_ADDRESS_TRANSACTIONS...), address.Bytes()...)
+	records := []interfaces.Record{}
+	for _, height := range []uint32{1, 2, 2, 2, 3, 5} {
+		key := make([]byte, 4)
+		binary.BigEndian.PutUint32(key, height)
+		key = append(key, primitives.RandomHash().Bytes()...)
+		records = append(records, interfaces.Record{bucket, key, primitives.RandomHash()})
+	}
+	if err := dbo.PutInBatch(records); err != nil {
+		t.Fatal(err)
+	}
+
+	heights := func(start, end uint32, limit int) []uint32 {
+		txs, err := dbo.FetchFactoidAddressTransactions(address, start, end, limit)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got := []uint32{}
+		for _, tx := range txs {
+			got = append(got, tx.Height)
+		}
+		return got
+	}
+	for _, c := range []struct {
+		start, end uint32
+		limit      int
+		want       []uint32
+	}{
+		{0, 10, 0, []uint32{1, 2, 2, 2, 3, 5}},
+		{2, 4, 0, []uint32{2, 2, 2, 3}},
+		{0, 10, 2, []uint32{1, 2, 2, 2, 3}}, // finishes height 2, and shows there is more
+		{3, 10, 1, []uint32{3, 5}},
+		{4, 10, 1, []uint32{5}},
+		{6, 10, 1, []uint32{}},
+	} {
+		got := heights(c.start, c.end, c.limit)
+		if len(got) != len(c.want) {
+			t.Errorf("Heights %d to %d, limit %d, got %v, expected %v", c.start, c.end, c.limit, got, c.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != c.want[i] {
+				t.Errorf("Heights %d to %d, limit %d, got %v, expected %v", c.start, c.end, c.limit, got, c.want)
+				break
+			}
+		}
+	}
+}
+
+func TestFetchECAddressTransactions(t *testing.T) {
+	blocks := CreateFullTestBlockSet()
+	dbo := CreateAndPopulateTestDatabaseOverlay()
+
+	found := 0
+	for _, block := range blocks {
+		height := block.ECBlock.GetDatabaseHeight()
+		for _, entry := range block.ECBlock.GetEntries() {
+			var pubKey *primitives.ByteSlice32
+			switch entry.ECID() {
+			case constants.ECIDChainCommit:
+				pubKey = entry.(*entryCreditBlock.CommitChain).ECPubKey
+			case constants.ECIDEntryCommit:
+				pubKey = entry.(*entryCreditBlock.CommitEntry).ECPubKey
+			case constants.ECIDBalanceIncrease:
+				pubKey = entry.(*entryCreditBlock.IncreaseBalance).ECPubKey
+			default:
+				continue
+			}
+			txs, err := dbo.FetchECAddressTransactions(primitives.NewHash(pubKey[:]), 0, height, 0)
+			if err != nil {
+				t.Errorf("%v", err)
+			}
+			if containsTx(txs, entry.Hash(), height) == false {
+				t.Errorf("EC entry %v not indexed", entry.Hash())
+			}
+			found++
+		}
+	}
+	if found == 0 {
+		t.Errorf("Test block set has no EC entries to check")
+	}
+}
+
+func TestRebuildAddressTransactions(t *testing.T) {
+	blocks := CreateFullTestBlockSet()
+	dbo := CreateAndPopulateTestDatabaseOverlay()
+
+	// Copy only the blocks, leaving the address index empty
+	rebuilt := databaseOverlay.NewOverlay(new(mapdb.MapDB))
+	for _, block := range blocks {
+		err := rebuilt.ProcessDBlockBatch(block.DBlock)
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+		err = rebuilt.ProcessFBlockBatch(block.FBlock)
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+		err = rebuilt.ProcessECBlockBatch(block.ECBlock, false)
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+	}
+	for _, bucket := range [][]byte{databaseOverlay.FACTOID_ADDRESS_TRANSACTIONS, databaseOverlay.EC_ADDRESS_TRANSACTIONS} {
+		buckets, err := rebuilt.ListAllBuckets()
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+		for _, b := range buckets {
+			if len(b) > len(bucket) && string(b[:len(bucket)]) == string(bucket) {
+				err = rebuilt.Clear(b)
+				if err != nil {
+					t.Fatalf("%v", err)
+				}
+			}
+		}
+	}
+
+	err := rebuilt.RebuildAddressTransactions()
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+
+	top := blocks[len(blocks)-1].DBlock.GetDatabaseHeight()
+	for _, block := range blocks {
+		for _, tx := range block.FBlock.GetTransactions() {
+			for _, adr := range txAddresses(tx) {
+				want, err := dbo.FetchFactoidAddressTransactions(adr.GetAddress(), 0, top, 0)
+				if err != nil {
+					t.Fatalf("%v", err)
+				}
+				got, err := rebuilt.FetchFactoidAddressTransactions(adr.GetAddress(), 0, top, 0)
+				if err != nil {
+					t.Fatalf("%v", err)
+				}
+				if len(want) != len(got) {
+					t.Fatalf("Rebuilt index has %v transactions for %v, expected %v", len(got), adr.GetAddress(), len(want))
+				}
+				for i := range want {
+					if want[i].Height != got[i].Height || want[i].TxID.IsSameAs(got[i].TxID) == false {
+						t.Errorf("Rebuilt index differs at %v", i)
+					}
+				}
+			}
+		}
+	}
+}
@@ -5,8 +5,10 @@
 package databaseOverlay
 
 import (
-	//"fmt"
+	"bytes"
+	"fmt"
 	"sort"
+	"strings"
 
 	"github.com/FactomProject/factomd/anchor"
 	"github.com/FactomProject/factomd/common/directoryBlock/dbInfo"
@@ -14,21 +16,15 @@ import (
 	"github.com/FactomProject/factomd/common/primitives"
 )
 
-var AnchorBlockID string = "df3ade9eec4b08d5379cc64270c30ea7315d8a8a1a69efe2b98a60ecdd69e604"
-var AnchorSigKeys []string = []string{
-	"0426a802617848d4d16d87830fc521f4d136bb2d0c352850919c2679f189613a", //m1 key
-	"d569419348ed7056ec2ba54f0ecd9eea02648b260b26e0474f8c07fe9ac6bf83", //m2 key
-}
+var AnchorBlockID string = anchor.AnchorChainID
+var AnchorSigKeys []string = anchor.AnchorSigKeys
 var AnchorSigPublicKeys []interfaces.Verifier
 
 func init() {
-	for _, v := range AnchorSigKeys {
-		pubKey := new(primitives.PublicKey)
-		err := pubKey.UnmarshalText([]byte(v))
-		if err != nil {
-			panic(err)
-		}
-		AnchorSigPublicKeys = append(AnchorSigPublicKeys, pubKey)
+	var err error
+	AnchorSigPublicKeys, err = anchor.ParseAnchorSigKeys(AnchorSigKeys)
+	if err != nil {
+		panic(err)
 	}
 }
 
@@ -46,45 +42,83 @@ func (dbo *Overlay) RebuildDirBlockInfo() error {
 }
 
 func (dbo *Overlay) SaveAnchorInfoFromEntry(entry interfaces.IEBEntry) error {
-	if entry.DatabasePrimaryIndex().String() == "24674e6bc3094eb773297de955ee095a05830e431da13a37382dcdc89d73c7d7" {
-		return nil
+	ar, err := validAnchorRecordFromEntry(entry)
+	if err != nil || ar == nil {
+		return err
 	}
-	ar, ok, err := anchor.UnmarshalAndValidateAnchorEntryAnyVersion(entry, AnchorSigPublicKeys)
+	dbis, err := dbo.anchorRecordToDirBlockInfos(ar, false)
 	if err != nil {
 		return err
 	}
-	if ok == false {
-		return nil
+	for _, dbi := range dbis {
+		err = dbo.ProcessDirBlockInfoBatch(dbi)
+		if err != nil {
+			return err
+		}
 	}
-	if ar == nil {
-		return nil
+	return nil
+}
+
+func (dbo *Overlay) SaveAnchorInfoFromEntryMultiBatch(entry interfaces.IEBEntry) error {
+	ar, err := validAnchorRecordFromEntry(entry)
+	if err != nil || ar == nil {
+		return err
 	}
-	dbi, err := AnchorRecordToDirBlockInfo(ar)
+	dbis, err := dbo.anchorRecordToDirBlockInfos(ar, true)
 	if err != nil {
 		return err
 	}
-	return dbo.ProcessDirBlockInfoBatch(dbi)
+	for _, dbi := range dbis {
+		err = dbo.ProcessDirBlockInfoMultiBatch(dbi)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
-func (dbo *Overlay) SaveAnchorInfoFromEntryMultiBatch(entry interfaces.IEBEntry) error {
+// validAnchorRecordFromEntry returns the anchor record in the entry, or nil if the
+// entry is not a record signed by one of the anchor keys
+func validAnchorRecordFromEntry(entry interfaces.IEBEntry) (*anchor.AnchorRecord, error) {
 	if entry.DatabasePrimaryIndex().String() == "24674e6bc3094eb773297de955ee095a05830e431da13a37382dcdc89d73c7d7" {
-		return nil
+		return nil, nil
 	}
 	ar, ok, err := anchor.UnmarshalAndValidateAnchorEntryAnyVersion(entry, AnchorSigPublicKeys)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	if ok == false {
-		return nil
-	}
-	if ar == nil {
-		return nil
+		return nil, nil
 	}
-	dbi, err := AnchorRecordToDirBlockInfo(ar)
+	return ar, nil
+}
+
+// ReindexAnchorInfo saves the anchors of every valid record in the anchor chain
+// into the DirBlockInfos again, adding what older versions did not index, such as
+// the Ethereum anchors
+func (dbo *Overlay) ReindexAnchorInfo() error {
+	chainID, err := primitives.NewShaHashFromStr(AnchorBlockID)
 	if err != nil {
 		return err
 	}
-	return dbo.ProcessDirBlockInfoMultiBatch(dbi)
+	return dbo.IterateEntriesByChainID(chainID, func(entry interfaces.IEBEntry) error {
+		// Entries that are not anchor records are skipped, as they are when saved
+		ar, err := validAnchorRecordFromEntry(entry)
+		if err != nil || ar == nil {
+			return nil
+		}
+		dbis, err := dbo.anchorRecordToDirBlockInfos(ar, false)
+		if err != nil {
+			return err
+		}
+		for _, dbi := range dbis {
+			err = dbo.SaveDirBlockInfo(dbi)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
 }
 
 func (dbo *Overlay) FetchAllAnchorInfo() ([]*anchor.AnchorRecord, error) {
@@ -119,21 +153,106 @@ func (dbo *Overlay) SaveAnchorInfoAsDirBlockInfo(ars []*anchor.AnchorRecord) err
 	sort.Sort(ByAnchorDBHeightAscending(ars))
 
 	for _, v := range ars {
-		dbi, err := AnchorRecordToDirBlockInfo(v)
+		dbis, err := dbo.anchorRecordToDirBlockInfos(v, false)
 		if err != nil {
 			return err
 		}
-		err = dbo.SaveDirBlockInfo(dbi)
-		if err != nil {
-			return err
+		for _, dbi := range dbis {
+			err = dbo.SaveDirBlockInfo(dbi)
+			if err != nil {
+				return err
+			}
 		}
 	}
 
 	return nil
 }
 
+// anchorRecordToDirBlockInfos returns the DirBlockInfos of every directory block
+// the record anchors, with the anchor added to what is already saved for them.  A
+// Bitcoin record anchors a single block; an Ethereum record may anchor a window of
+// blocks, which have to be saved already.
+func (dbo *Overlay) anchorRecordToDirBlockInfos(ar *anchor.AnchorRecord, multiBatch bool) ([]*dbInfo.DirBlockInfo, error) {
+	if ar.IsWindow() == false {
+		keyMR, err := primitives.NewShaHashFromStr(ar.KeyMR)
+		if err != nil {
+			return nil, err
+		}
+		dbi, err := dbo.fetchDirBlockInfoToUpdate(keyMR, multiBatch)
+		if err != nil {
+			return nil, err
+		}
+		if dbi.DBHash.IsZero() {
+			dbi, err = AnchorRecordToDirBlockInfo(ar)
+		} else {
+			err = addAnchorToDirBlockInfo(dbi, ar)
+		}
+		if err != nil {
+			return nil, err
+		}
+		return []*dbInfo.DirBlockInfo{dbi}, nil
+	}
+
+	if ar.DBHeightMin > ar.DBHeightMax {
+		return nil, fmt.Errorf("Anchor window %v-%v is empty", ar.DBHeightMin, ar.DBHeightMax)
+	}
+	answer := []*dbInfo.DirBlockInfo{}
+	for height := ar.DBHeightMin; ; height++ {
+		keyMR, err := dbo.FetchDBKeyMRByHeight(height)
+		if err != nil {
+			return nil, err
+		}
+		if keyMR == nil {
+			// The rest of the window is not saved yet
+			break
+		}
+		dbi, err := dbo.fetchDirBlockInfoToUpdate(keyMR, multiBatch)
+		if err != nil {
+			return nil, err
+		}
+		if dbi.DBHash.IsZero() {
+			dbi.DBHash = keyMR
+			dbi.DBHeight = height
+			dbi.DBMerkleRoot = keyMR
+		}
+		err = addAnchorToDirBlockInfo(dbi, ar)
+		if err != nil {
+			return nil, err
+		}
+		answer = append(answer, dbi)
+		if height == ar.DBHeightMax {
+			break
+		}
+	}
+	return answer, nil
+}
+
+// fetchDirBlockInfoToUpdate returns the DirBlockInfo saved for the directory
+// block, or an empty one.  When saving in a multi batch, a DirBlockInfo waiting
+// in the batch is the latest one.
+func (dbo *Overlay) fetchDirBlockInfoToUpdate(keyMR interfaces.IHash, multiBatch bool) (*dbInfo.DirBlockInfo, error) {
+	if multiBatch {
+		for i := len(dbo.MultiBatch) - 1; i >= 0; i-- {
+			r := dbo.MultiBatch[i]
+			if (bytes.Equal(r.Bucket, DIRBLOCKINFO) || bytes.Equal(r.Bucket, DIRBLOCKINFO_UNCONFIRMED)) && bytes.Equal(r.Key, keyMR.Bytes()) {
+				if dbi, ok := r.Data.(*dbInfo.DirBlockInfo); ok {
+					return dbi, nil
+				}
+			}
+		}
+	}
+	dbi, err := dbo.FetchDirBlockInfoByKeyMR(keyMR)
+	if err != nil {
+		return nil, err
+	}
+	if dbi == nil {
+		return dbInfo.NewDirBlockInfo(), nil
+	}
+	return dbi.(*dbInfo.DirBlockInfo), nil
+}
+
 func AnchorRecordToDirBlockInfo(ar *anchor.AnchorRecord) (*dbInfo.DirBlockInfo, error) {
-	dbi := new(dbInfo.DirBlockInfo)
+	dbi := dbInfo.NewDirBlockInfo()
 	var err error
 
 	//TODO: fetch proper data
@@ -144,23 +263,68 @@ func AnchorRecordToDirBlockInfo(ar *anchor.AnchorRecord) (*dbInfo.DirBlockInfo,
 	}
 	dbi.DBHeight = ar.DBHeight
 	//dbi.Timestamp =
-	dbi.BTCTxHash, err = primitives.NewShaHashFromStr(ar.Bitcoin.TXID)
+	dbi.DBMerkleRoot, err = primitives.NewShaHashFromStr(ar.KeyMR)
 	if err != nil {
 		return nil, err
 	}
-	dbi.BTCTxOffset = ar.Bitcoin.Offset
-	dbi.BTCBlockHeight = ar.Bitcoin.BlockHeight
-	dbi.BTCBlockHash, err = primitives.NewShaHashFromStr(ar.Bitcoin.BlockHash)
+
+	err = addAnchorToDirBlockInfo(dbi, ar)
 	if err != nil {
 		return nil, err
 	}
-	dbi.DBMerkleRoot, err = primitives.NewShaHashFromStr(ar.KeyMR)
-	if err != nil {
-		return nil, err
+	return dbi, nil
+}
+
+// addAnchorToDirBlockInfo sets the Bitcoin and Ethereum anchors the record carries
+// in the DirBlockInfo, leaving the others as they are
+func addAnchorToDirBlockInfo(dbi *dbInfo.DirBlockInfo, ar *anchor.AnchorRecord) error {
+	var err error
+	if ar.Bitcoin != nil {
+		dbi.BTCTxHash, err = primitives.NewShaHashFromStr(ar.Bitcoin.TXID)
+		if err != nil {
+			return err
+		}
+		dbi.BTCTxOffset = ar.Bitcoin.Offset
+		dbi.BTCBlockHeight = ar.Bitcoin.BlockHeight
+		dbi.BTCBlockHash, err = primitives.NewShaHashFromStr(ar.Bitcoin.BlockHash)
+		if err != nil {
+			return err
+		}
+		dbi.BTCConfirmed = true
 	}
-	dbi.BTCConfirmed = true
 
-	return dbi, nil
+	if ar.Ethereum != nil {
+		dbi.EthTxHash, err = primitives.NewShaHashFromStr(strings.TrimPrefix(ar.Ethereum.TXID, "0x"))
+		if err != nil {
+			return err
+		}
+		dbi.EthTxOffset = ar.Ethereum.Offset
+		dbi.EthBlockHeight = ar.Ethereum.BlockHeight
+		dbi.EthBlockHash, err = primitives.NewShaHashFromStr(strings.TrimPrefix(ar.Ethereum.BlockHash, "0x"))
+		if err != nil {
+			return err
+		}
+		dbi.EthContractAddress = ar.Ethereum.Address
+		if ar.IsWindow() {
+			dbi.EthWindowMR, err = primitives.NewShaHashFromStr(ar.WindowMR)
+			if err != nil {
+				return err
+			}
+			dbi.EthDBHeightMin = ar.DBHeightMin
+			dbi.EthDBHeightMax = ar.DBHeightMax
+		} else {
+			// A window of one block, whose Merkle root is its KeyMR
+			dbi.EthWindowMR, err = primitives.NewShaHashFromStr(ar.KeyMR)
+			if err != nil {
+				return err
+			}
+			dbi.EthDBHeightMin = ar.DBHeight
+			dbi.EthDBHeightMax = ar.DBHeight
+		}
+		dbi.EthConfirmed = true
+	}
+
+	return nil
 }
 
 // AnchorRecord array sorting implementation - ascending
@@ -8,6 +8,7 @@ import (
 	"testing"
 
 	"github.com/FactomProject/factomd/anchor"
+	"github.com/FactomProject/factomd/common/directoryBlock/dbInfo"
 	"github.com/FactomProject/factomd/testHelper"
 )
 
@@ -120,3 +121,63 @@ func CreateAnchors() []*anchor.AnchorRecord {
 
 	return answer
 }
+
+func TestEthereumAnchorWindow(t *testing.T) {
+	dbo := testHelper.CreateAndPopulateTestDatabaseOverlay()
+	ar := testHelper.CreateTestEthereumAnchorRecord(dbo, 1, 5)
+
+	before := map[uint32]*dbInfo.DirBlockInfo{}
+	for h := uint32(1); h <= 5; h++ {
+		keyMR, err := dbo.FetchDBKeyMRByHeight(h)
+		if err != nil {
+			t.Fatal(err)
+		}
+		dbi, err := dbo.FetchDirBlockInfoByKeyMR(keyMR)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if dbi != nil {
+			before[h] = dbi.(*dbInfo.DirBlockInfo)
+		}
+	}
+
+	err := dbo.SaveAnchorInfoAsDirBlockInfo([]*anchor.AnchorRecord{ar})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for h := uint32(0); h <= 6; h++ {
+		keyMR, err := dbo.FetchDBKeyMRByHeight(h)
+		if err != nil {
+			t.Fatal(err)
+		}
+		i, err := dbo.FetchDirBlockInfoByKeyMR(keyMR)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if h == 0 || h == 6 {
+			if i != nil && i.(*dbInfo.DirBlockInfo).EthConfirmed {
+				t.Errorf("DBlock %v outside the window is anchored in Ethereum", h)
+			}
+			continue
+		}
+		if i == nil {
+			t.Fatalf("No DirBlockInfo for DBlock %v", h)
+		}
+		dbi := i.(*dbInfo.DirBlockInfo)
+		if dbi.EthConfirmed == false || dbi.EthDBHeightMin != 1 || dbi.EthDBHeightMax != 5 {
+			t.Errorf("DBlock %v is not anchored in the window - %v", h, dbi)
+		}
+		if dbi.EthWindowMR.String() != ar.WindowMR {
+			t.Errorf("DBlock %v has WindowMR %v, not %v", h, dbi.EthWindowMR, ar.WindowMR)
+		}
+		if dbi.EthContractAddress != ar.Ethereum.Address {
+			t.Errorf("DBlock %v has contract %v", h, dbi.EthContractAddress)
+		}
+		if prev, ok := before[h]; ok {
+			if dbi.BTCConfirmed != prev.BTCConfirmed || dbi.BTCTxHash.IsSameAs(prev.BTCTxHash) == false {
+				t.Errorf("Bitcoin anchor of DBlock %v changed", h)
+			}
+		}
+	}
+}
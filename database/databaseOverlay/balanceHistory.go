@@ -0,0 +1,278 @@
+// Copyright 2017 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package databaseOverlay
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"github.com/FactomProject/factomd/common/constants"
+	"github.com/FactomProject/factomd/common/entryCreditBlock"
+	"github.com/FactomProject/factomd/common/interfaces"
+	"github.com/FactomProject/factomd/common/primitives"
+)
+
+// The balance of an address at any saved height is the sum of the changes the
+// blocks up to that height made to it.  Like the address transactions, every
+// address gets its own bucket (the table prefix followed by the 32 byte address),
+// keyed by the 4 byte big endian height of the block, with the net change that
+// block made to the balance as an 8 byte big endian signed value.
+//
+// Factoid balances change with the inputs and outputs of the FBlock transactions.
+// Entry credit balances change with the balance increases and commits of the
+// ECBlock; the EC outputs of factoid transactions show up there as balance
+// increases.  Saving a block twice writes the same changes again.
+//
+// So a lookup does not add up the whole history of a busy address, every
+// BalanceCheckpointInterval changes the balance as of that block is saved as a
+// checkpoint, in a bucket per address keyed the same way.  A lookup starts from
+// the last checkpoint at or below the height and adds the changes after it.
+
+// BalanceCheckpointInterval is how many changes to the balance of an address are
+// saved between two of its checkpoints
+var BalanceCheckpointInterval = 100
+
+// balanceCheckpointTable is the table of the checkpoints of the deltas table
+func balanceCheckpointTable(table []byte) []byte {
+	if string(table) == string(EC_BALANCE_DELTAS) {
+		return EC_BALANCE_CHECKPOINTS
+	}
+	return FACTOID_BALANCE_CHECKPOINTS
+}
+
+func balanceValue(balance int64) *primitives.ByteSlice {
+	value := new(primitives.ByteSlice)
+	value.Bytes = make([]byte, 8)
+	binary.BigEndian.PutUint64(value.Bytes, uint64(balance))
+	return value
+}
+
+func balanceDeltaKey(height uint32) []byte {
+	key := make([]byte, 4)
+	binary.BigEndian.PutUint32(key, height)
+	return key
+}
+
+func balanceDeltaRecords(table []byte, height uint32, deltas map[[32]byte]int64) []interfaces.Record {
+	batch := []interfaces.Record{}
+	key := balanceDeltaKey(height)
+	for adr, delta := range deltas {
+		batch = append(batch, interfaces.Record{addressTransactionsBucket(table, adr[:]), key, balanceValue(delta)})
+	}
+	return batch
+}
+
+// balanceCheckpointRecords returns the checkpoints due now that the block at the
+// height makes the changes to the balances
+func (db *Overlay) balanceCheckpointRecords(table []byte, height uint32, deltas map[[32]byte]int64) ([]interfaces.Record, error) {
+	batch := []interfaces.Record{}
+	if height == 0 {
+		return batch, nil
+	}
+	key := balanceDeltaKey(height)
+	for adr, delta := range deltas {
+		start, balance, err := db.balanceCheckpoint(table, adr[:], height-1)
+		if err != nil {
+			return nil, err
+		}
+		sum, count, err := db.sumBalanceDeltas(table, adr[:], start, height-1)
+		if err != nil {
+			return nil, err
+		}
+		if count+1 < BalanceCheckpointInterval {
+			continue
+		}
+		bucket := addressTransactionsBucket(balanceCheckpointTable(table), adr[:])
+		batch = append(batch, interfaces.Record{bucket, key, balanceValue(balance + sum + delta)})
+	}
+	return batch, nil
+}
+
+// BalanceDeltaRecordsFromFBlock returns the records of the changes the block
+// makes to factoid balances
+func BalanceDeltaRecordsFromFBlock(block interfaces.IFBlock) []interfaces.Record {
+	if block == nil {
+		return nil
+	}
+	return balanceDeltaRecords(FACTOID_BALANCE_DELTAS, block.GetDatabaseHeight(), fBlockBalanceDeltas(block))
+}
+
+func fBlockBalanceDeltas(block interfaces.IFBlock) map[[32]byte]int64 {
+	deltas := map[[32]byte]int64{}
+	for _, tx := range block.GetTransactions() {
+		for _, input := range tx.GetInputs() {
+			deltas[input.GetAddress().Fixed()] -= int64(input.GetAmount())
+		}
+		for _, output := range tx.GetOutputs() {
+			deltas[output.GetAddress().Fixed()] += int64(output.GetAmount())
+		}
+	}
+	return deltas
+}
+
+// BalanceDeltaRecordsFromECBlock returns the records of the changes the block
+// makes to entry credit balances
+func BalanceDeltaRecordsFromECBlock(block interfaces.IEntryCreditBlock) []interfaces.Record {
+	if block == nil {
+		return nil
+	}
+	return balanceDeltaRecords(EC_BALANCE_DELTAS, block.GetDatabaseHeight(), ecBlockBalanceDeltas(block))
+}
+
+func ecBlockBalanceDeltas(block interfaces.IEntryCreditBlock) map[[32]byte]int64 {
+	deltas := map[[32]byte]int64{}
+	for _, entry := range block.GetBody().GetEntries() {
+		switch entry.ECID() {
+		case constants.ECIDChainCommit:
+			e := entry.(*entryCreditBlock.CommitChain)
+			deltas[e.ECPubKey.Fixed()] -= int64(e.Credits)
+		case constants.ECIDEntryCommit:
+			e := entry.(*entryCreditBlock.CommitEntry)
+			deltas[e.ECPubKey.Fixed()] -= int64(e.Credits)
+		case constants.ECIDBalanceIncrease:
+			e := entry.(*entryCreditBlock.IncreaseBalance)
+			deltas[e.ECPubKey.Fixed()] += int64(e.NumEC)
+		}
+	}
+	return deltas
+}
+
+// balanceRecordsFromFBlock returns the records of the changes the block makes to
+// factoid balances, and of the checkpoints they make due
+func (db *Overlay) balanceRecordsFromFBlock(block interfaces.IFBlock) ([]interfaces.Record, error) {
+	if block == nil {
+		return nil, nil
+	}
+	deltas := fBlockBalanceDeltas(block)
+	checkpoints, err := db.balanceCheckpointRecords(FACTOID_BALANCE_DELTAS, block.GetDatabaseHeight(), deltas)
+	if err != nil {
+		return nil, err
+	}
+	return append(balanceDeltaRecords(FACTOID_BALANCE_DELTAS, block.GetDatabaseHeight(), deltas), checkpoints...), nil
+}
+
+// balanceRecordsFromECBlock returns the records of the changes the block makes to
+// entry credit balances, and of the checkpoints they make due
+func (db *Overlay) balanceRecordsFromECBlock(block interfaces.IEntryCreditBlock) ([]interfaces.Record, error) {
+	if block == nil {
+		return nil, nil
+	}
+	deltas := ecBlockBalanceDeltas(block)
+	checkpoints, err := db.balanceCheckpointRecords(EC_BALANCE_DELTAS, block.GetDatabaseHeight(), deltas)
+	if err != nil {
+		return nil, err
+	}
+	return append(balanceDeltaRecords(EC_BALANCE_DELTAS, block.GetDatabaseHeight(), deltas), checkpoints...), nil
+}
+
+func (db *Overlay) SaveBalanceDeltasFromFBlock(block interfaces.IFBlock) error {
+	batch, err := db.balanceRecordsFromFBlock(block)
+	if err != nil || len(batch) == 0 {
+		return err
+	}
+	return db.DB.PutInBatch(batch)
+}
+
+func (db *Overlay) SaveBalanceDeltasFromFBlockMultiBatch(block interfaces.IFBlock) error {
+	batch, err := db.balanceRecordsFromFBlock(block)
+	if err != nil || len(batch) == 0 {
+		return err
+	}
+	db.PutInMultiBatch(batch)
+	return nil
+}
+
+func (db *Overlay) SaveBalanceDeltasFromECBlock(block interfaces.IEntryCreditBlock) error {
+	batch, err := db.balanceRecordsFromECBlock(block)
+	if err != nil || len(batch) == 0 {
+		return err
+	}
+	return db.DB.PutInBatch(batch)
+}
+
+func (db *Overlay) SaveBalanceDeltasFromECBlockMultiBatch(block interfaces.IEntryCreditBlock) error {
+	batch, err := db.balanceRecordsFromECBlock(block)
+	if err != nil || len(batch) == 0 {
+		return err
+	}
+	db.PutInMultiBatch(batch)
+	return nil
+}
+
+// FetchFactoidBalanceAtHeight returns the balance of the factoid address (an RCD
+// hash) once the block at the height was processed
+func (db *Overlay) FetchFactoidBalanceAtHeight(address interfaces.IHash, height uint32) (int64, error) {
+	return db.fetchBalanceAtHeight(FACTOID_BALANCE_DELTAS, address, height)
+}
+
+// FetchECBalanceAtHeight returns the balance of the entry credit public key once
+// the block at the height was processed
+func (db *Overlay) FetchECBalanceAtHeight(address interfaces.IHash, height uint32) (int64, error) {
+	return db.fetchBalanceAtHeight(EC_BALANCE_DELTAS, address, height)
+}
+
+func (db *Overlay) fetchBalanceAtHeight(table []byte, address interfaces.IHash, height uint32) (int64, error) {
+	if address == nil {
+		return 0, nil
+	}
+	start, balance, err := db.balanceCheckpoint(table, address.Bytes(), height)
+	if err != nil {
+		return 0, err
+	}
+	sum, _, err := db.sumBalanceDeltas(table, address.Bytes(), start, height)
+	if err != nil {
+		return 0, err
+	}
+	return balance + sum, nil
+}
+
+// balanceCheckpoint returns the balance of the last checkpoint of the address at or
+// below the height, and the height the changes after it start at.  Without one,
+// the balance is 0 and the changes start at 0.
+func (db *Overlay) balanceCheckpoint(table []byte, address []byte, height uint32) (uint32, int64, error) {
+	bucket := addressTransactionsBucket(balanceCheckpointTable(table), address)
+	iter := db.DB.NewIterator(bucket, &interfaces.IterateOptions{Seek: balanceDeltaKey(height), Reverse: true, Limit: 1})
+	defer iter.Release()
+	if !iter.Next() {
+		return 0, 0, iter.Error()
+	}
+	key, value := iter.Key(), iter.Value()
+	if len(key) != 4 {
+		return 0, 0, fmt.Errorf("malformed balance checkpoint key %x", key)
+	}
+	if len(value) != 8 {
+		return 0, 0, fmt.Errorf("malformed balance checkpoint %x", value)
+	}
+	return binary.BigEndian.Uint32(key) + 1, int64(binary.BigEndian.Uint64(value)), nil
+}
+
+// sumBalanceDeltas adds up the changes to the balance of the address from the
+// start to the end height, inclusive, and counts them
+func (db *Overlay) sumBalanceDeltas(table []byte, address []byte, start uint32, end uint32) (int64, int, error) {
+	if start > end {
+		return 0, 0, nil
+	}
+	bucket := addressTransactionsBucket(table, address)
+	iter := db.DB.NewIterator(bucket, &interfaces.IterateOptions{Seek: balanceDeltaKey(start)})
+	defer iter.Release()
+
+	var sum int64
+	count := 0
+	for iter.Next() {
+		key, delta := iter.Key(), iter.Value()
+		if len(key) != 4 {
+			return 0, 0, fmt.Errorf("malformed balance delta key %x", key)
+		}
+		if binary.BigEndian.Uint32(key) > end {
+			break
+		}
+		if len(delta) != 8 {
+			return 0, 0, fmt.Errorf("malformed balance delta %x", delta)
+		}
+		sum += int64(binary.BigEndian.Uint64(delta))
+		count++
+	}
+	return sum, count, iter.Error()
+}
@@ -0,0 +1,184 @@
+package databaseOverlay_test
+
+import (
+	"testing"
+
+	"github.com/FactomProject/factomd/common/constants"
+	"github.com/FactomProject/factomd/common/entryCreditBlock"
+	"github.com/FactomProject/factomd/common/interfaces"
+	"github.com/FactomProject/factomd/common/primitives"
+	"github.com/FactomProject/factomd/database/databaseOverlay"
+	"github.com/FactomProject/factomd/database/mapdb"
+	. "github.com/FactomProject/factomd/testHelper"
+)
+
+func TestFetchBalanceAtHeight(t *testing.T) {
+	blocks := CreateFullTestBlockSet()
+	dbo := CreateAndPopulateTestDatabaseOverlay()
+
+	// Replay the blocks, checking every address seen so far after each one
+	fct := map[[32]byte]int64{}
+	ec := map[[32]byte]int64{}
+	for _, block := range blocks {
+		height := block.DBlock.GetDatabaseHeight()
+		for _, tx := range block.FBlock.GetTransactions() {
+			for _, in := range tx.GetInputs() {
+				fct[in.GetAddress().Fixed()] -= int64(in.GetAmount())
+			}
+			for _, out := range tx.GetOutputs() {
+				fct[out.GetAddress().Fixed()] += int64(out.GetAmount())
+			}
+		}
+		for _, entry := range block.ECBlock.GetEntries() {
+			switch entry.ECID() {
+			case constants.ECIDChainCommit:
+				e := entry.(*entryCreditBlock.CommitChain)
+				ec[e.ECPubKey.Fixed()] -= int64(e.Credits)
+			case constants.ECIDEntryCommit:
+				e := entry.(*entryCreditBlock.CommitEntry)
+				ec[e.ECPubKey.Fixed()] -= int64(e.Credits)
+			case constants.ECIDBalanceIncrease:
+				e := entry.(*entryCreditBlock.IncreaseBalance)
+				ec[e.ECPubKey.Fixed()] += int64(e.NumEC)
+			}
+		}
+
+		for adr, expected := range fct {
+			balance, err := dbo.FetchFactoidBalanceAtHeight(primitives.NewHash(adr[:]), height)
+			if err != nil {
+				t.Fatalf("%v", err)
+			}
+			if balance != expected {
+				t.Errorf("Factoid balance of %x at %v is %v, expected %v", adr, height, balance, expected)
+			}
+		}
+		for adr, expected := range ec {
+			balance, err := dbo.FetchECBalanceAtHeight(primitives.NewHash(adr[:]), height)
+			if err != nil {
+				t.Fatalf("%v", err)
+			}
+			if balance != expected {
+				t.Errorf("EC balance of %x at %v is %v, expected %v", adr, height, balance, expected)
+			}
+		}
+	}
+	if len(fct) == 0 || len(ec) == 0 {
+		t.Fatalf("Test blocks touch no addresses")
+	}
+
+	balance, err := dbo.FetchFactoidBalanceAtHeight(primitives.NewZeroHash(), 5)
+	if err != nil || balance != 0 {
+		t.Errorf("Unknown address has balance %v, err %v", balance, err)
+	}
+}
+
+func TestRebuildBalanceDeltas(t *testing.T) {
+	blocks := CreateFullTestBlockSet()
+	dbo := CreateAndPopulateTestDatabaseOverlay()
+	top := blocks[len(blocks)-1].DBlock.GetDatabaseHeight()
+
+	rebuilt := databaseOverlay.NewOverlay(new(mapdb.MapDB))
+	for _, block := range blocks {
+		err := rebuilt.ProcessDBlockBatch(block.DBlock)
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+		err = rebuilt.ProcessFBlockBatch(block.FBlock)
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+		err = rebuilt.ProcessECBlockBatch(block.ECBlock, false)
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+	}
+	// Drop the deltas, as in a database written before they were kept
+	for _, bucket := range [][]byte{databaseOverlay.FACTOID_BALANCE_DELTAS, databaseOverlay.EC_BALANCE_DELTAS,
+		databaseOverlay.FACTOID_BALANCE_CHECKPOINTS, databaseOverlay.EC_BALANCE_CHECKPOINTS} {
+		buckets, err := rebuilt.ListAllBuckets()
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+		for _, b := range buckets {
+			if len(b) > len(bucket) && string(b[:len(bucket)]) == string(bucket) {
+				err = rebuilt.Clear(b)
+				if err != nil {
+					t.Fatalf("%v", err)
+				}
+			}
+		}
+	}
+
+	adr := blocks[1].FBlock.GetTransactions()[0].GetOutputs()[0].GetAddress()
+	expected, err := dbo.FetchFactoidBalanceAtHeight(adr, top)
+	if err != nil || expected == 0 {
+		t.Fatalf("No balance to rebuild, err %v", err)
+	}
+	if balance, _ := rebuilt.FetchFactoidBalanceAtHeight(adr, top); balance != 0 {
+		t.Fatalf("Deltas were not dropped")
+	}
+
+	err = rebuilt.RebuildAddressTransactions()
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	if balance, _ := rebuilt.FetchFactoidBalanceAtHeight(adr, top); balance != expected {
+		t.Errorf("Rebuilt balance %v, expected %v", balance, expected)
+	}
+}
+
+func TestBalanceCheckpoints(t *testing.T) {
+	defer func(interval int) { databaseOverlay.BalanceCheckpointInterval = interval }(databaseOverlay.BalanceCheckpointInterval)
+	databaseOverlay.BalanceCheckpointInterval = 2
+
+	blocks := CreateFullTestBlockSet()
+	dbo := CreateAndPopulateTestDatabaseOverlay()
+	checkpointed := databaseOverlay.NewOverlay(new(mapdb.MapDB))
+	for _, block := range blocks {
+		if err := checkpointed.ProcessFBlockBatch(block.FBlock); err != nil {
+			t.Fatalf("%v", err)
+		}
+		if err := checkpointed.ProcessECBlockBatch(block.ECBlock, false); err != nil {
+			t.Fatalf("%v", err)
+		}
+	}
+
+	buckets, err := checkpointed.ListAllBuckets()
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	checkpoints := 0
+	prefix := string(databaseOverlay.FACTOID_BALANCE_CHECKPOINTS)
+	for _, b := range buckets {
+		if len(b) > len(prefix) && string(b[:len(prefix)]) == prefix {
+			checkpoints++
+		}
+	}
+	if checkpoints == 0 {
+		t.Fatalf("No balance checkpoints were saved")
+	}
+
+	// Every address has the same balance at every height as without checkpoints
+	for _, block := range blocks {
+		for _, tx := range block.FBlock.GetTransactions() {
+			for _, adrs := range [][]interfaces.ITransAddress{tx.GetInputs(), tx.GetOutputs()} {
+				for _, adr := range adrs {
+					for _, b := range blocks {
+						height := b.FBlock.GetDatabaseHeight()
+						expected, err := dbo.FetchFactoidBalanceAtHeight(adr.GetAddress(), height)
+						if err != nil {
+							t.Fatalf("%v", err)
+						}
+						balance, err := checkpointed.FetchFactoidBalanceAtHeight(adr.GetAddress(), height)
+						if err != nil {
+							t.Fatalf("%v", err)
+						}
+						if balance != expected {
+							t.Errorf("Balance of %v at %d is %d with checkpoints, expected %d", adr.GetAddress(), height, balance, expected)
+						}
+					}
+				}
+			}
+		}
+	}
+}
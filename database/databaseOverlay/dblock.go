@@ -26,7 +26,11 @@ func (db *Overlay) ProcessDBlockBatch(dblock interfaces.DatabaseBlockWithEntries
 		return err
 	}
 
-	return db.SaveIncludedInMultiFromBlock(dblock, false)
+	err = db.SaveIncludedInMultiFromBlock(dblock, false)
+	if err != nil {
+		return err
+	}
+	return db.SaveDBlockFilter(dblock)
 }
 
 func (db *Overlay) ProcessDBlockBatchWithoutHead(dblock interfaces.DatabaseBlockWithEntries) error {
@@ -37,7 +41,11 @@ func (db *Overlay) ProcessDBlockBatchWithoutHead(dblock interfaces.DatabaseBlock
 		return err
 	}
 
-	return db.SaveIncludedInMultiFromBlock(dblock, false)
+	err = db.SaveIncludedInMultiFromBlock(dblock, false)
+	if err != nil {
+		return err
+	}
+	return db.SaveDBlockFilter(dblock)
 }
 
 func (db *Overlay) ProcessDBlockMultiBatch(dblock interfaces.DatabaseBlockWithEntries) error {
@@ -48,7 +56,11 @@ func (db *Overlay) ProcessDBlockMultiBatch(dblock interfaces.DatabaseBlockWithEn
 		return err
 	}
 
-	return db.SaveIncludedInMultiFromBlockMultiBatch(dblock, true)
+	err = db.SaveIncludedInMultiFromBlockMultiBatch(dblock, true)
+	if err != nil {
+		return err
+	}
+	return db.SaveDBlockFilterMultiBatch(dblock)
 }
 
 // FetchHeightRange looks up a range of blocks by the start and ending
@@ -141,6 +153,13 @@ func (db *Overlay) FetchAllDBlocks() ([]interfaces.IDirectoryBlock, error) {
 	return toDBlocksList(list), nil
 }
 
+// IterateDBlocks calls f with the directory blocks in height order, one at a time
+func (db *Overlay) IterateDBlocks(f func(interfaces.IDirectoryBlock) error) error {
+	return db.iterateBlocksByHeight(DIRECTORYBLOCK_NUMBER, DIRECTORYBLOCK, new(directoryBlock.DirectoryBlock), func(block interfaces.DatabaseBatchable) error {
+		return f(block.(interfaces.IDirectoryBlock))
+	})
+}
+
 func (db *Overlay) FetchAllDBlockKeys() ([]interfaces.IHash, error) {
 	return db.FetchAllBlockKeysFromBucket(DIRECTORYBLOCK)
 }
@@ -0,0 +1,188 @@
+// Copyright 2017 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package databaseOverlay
+
+import (
+	"bytes"
+
+	"github.com/FactomProject/factomd/common/compactFilter"
+	"github.com/FactomProject/factomd/common/constants"
+	"github.com/FactomProject/factomd/common/interfaces"
+)
+
+// Every DBlock gets a compact filter (see the compactFilter package) over the chain
+// IDs of its entry blocks, the factoid and entry credit addresses in its FBlock, and
+// the entry credit public keys in its ECBlock.  Filters are stored under the DBlock
+// KeyMR.  They are built when the DBlock is saved if its FBlock and ECBlock are
+// already there (or in the same multi batch).  Blocks saved before filters existed
+// get theirs from ReindexDBlockFilters; until then they are built when fetched,
+// without saving them, so fetching never writes.
+
+// DBlockFilterItems returns what the filter of a DBlock covers
+func DBlockFilterItems(dblock interfaces.IDirectoryBlock, fblock interfaces.IFBlock, ecblock interfaces.IEntryCreditBlock) [][]byte {
+	items := [][]byte{}
+	for _, entry := range dblock.GetEBlockDBEntries() {
+		items = append(items, entry.GetChainID().Bytes())
+	}
+	if fblock != nil {
+		for _, tx := range fblock.GetTransactions() {
+			for _, adrs := range [][]interfaces.ITransAddress{tx.GetInputs(), tx.GetOutputs(), tx.GetECOutputs()} {
+				for _, adr := range adrs {
+					items = append(items, adr.GetAddress().Bytes())
+				}
+			}
+		}
+	}
+	if ecblock != nil {
+		for _, entry := range ecblock.GetEntries() {
+			if pubKey := ecEntryPubKey(entry); pubKey != nil {
+				items = append(items, pubKey[:])
+			}
+		}
+	}
+	return items
+}
+
+// NewDBlockFilter builds the filter of a DBlock
+func NewDBlockFilter(dblock interfaces.IDirectoryBlock, fblock interfaces.IFBlock, ecblock interfaces.IEntryCreditBlock) *compactFilter.Filter {
+	key := compactFilter.Key(dblock.GetKeyMR().Bytes())
+	return compactFilter.New(key, DBlockFilterItems(dblock, fblock, ecblock))
+}
+
+func (db *Overlay) SaveDBlockFilter(block interfaces.DatabaseBlockWithEntries) error {
+	dblock, ok := block.(interfaces.IDirectoryBlock)
+	if !ok {
+		return nil
+	}
+	filter, err := db.buildDBlockFilter(dblock, false)
+	if err != nil || filter == nil {
+		return err
+	}
+	return db.DB.Put(DBLOCK_FILTER, dblock.GetKeyMR().Bytes(), filter)
+}
+
+func (db *Overlay) SaveDBlockFilterMultiBatch(block interfaces.DatabaseBlockWithEntries) error {
+	dblock, ok := block.(interfaces.IDirectoryBlock)
+	if !ok {
+		return nil
+	}
+	filter, err := db.buildDBlockFilter(dblock, true)
+	if err != nil || filter == nil {
+		return err
+	}
+	db.PutInMultiBatch([]interfaces.Record{{DBLOCK_FILTER, dblock.GetKeyMR().Bytes(), filter}})
+	return nil
+}
+
+// buildDBlockFilter returns nil if the FBlock or ECBlock of the DBlock is not saved
+// yet.  The blocks of the multi batch are only looked at when saving in it, as the
+// batch lock is held then.
+func (db *Overlay) buildDBlockFilter(dblock interfaces.IDirectoryBlock, multiBatch bool) (*compactFilter.Filter, error) {
+	var fblock interfaces.IFBlock
+	var ecblock interfaces.IEntryCreditBlock
+	for _, entry := range dblock.GetDBEntries() {
+		chainID := entry.GetChainID().Bytes()
+		switch {
+		case bytes.Equal(chainID, constants.FACTOID_CHAINID):
+			if b, ok := db.fetchFromMultiBatch(multiBatch, FACTOIDBLOCK, entry.GetKeyMR()).(interfaces.IFBlock); ok {
+				fblock = b
+				continue
+			}
+			b, err := db.FetchFBlock(entry.GetKeyMR())
+			if err != nil {
+				return nil, err
+			}
+			fblock = b
+		case bytes.Equal(chainID, constants.EC_CHAINID):
+			if b, ok := db.fetchFromMultiBatch(multiBatch, ENTRYCREDITBLOCK, entry.GetKeyMR()).(interfaces.IEntryCreditBlock); ok {
+				ecblock = b
+				continue
+			}
+			b, err := db.FetchECBlock(entry.GetKeyMR())
+			if err != nil {
+				return nil, err
+			}
+			ecblock = b
+		}
+	}
+	if fblock == nil || ecblock == nil {
+		return nil, nil
+	}
+	return NewDBlockFilter(dblock, fblock, ecblock), nil
+}
+
+// fetchFromMultiBatch finds a record put in the multi batch being built, which the
+// caller has started
+func (db *Overlay) fetchFromMultiBatch(multiBatch bool, bucket []byte, key interfaces.IHash) interfaces.BinaryMarshallable {
+	if !multiBatch {
+		return nil
+	}
+	for _, r := range db.MultiBatch {
+		if bytes.Equal(r.Bucket, bucket) && bytes.Equal(r.Key, key.Bytes()) {
+			return r.Data
+		}
+	}
+	return nil
+}
+
+// FetchDBlockFilter returns the serialized filter of the DBlock with the given
+// KeyMR.  Returns nil if the DBlock or its FBlock or ECBlock is unknown.
+func (db *Overlay) FetchDBlockFilter(keyMR interfaces.IHash) ([]byte, error) {
+	filter, err := db.DB.Get(DBLOCK_FILTER, keyMR.Bytes(), new(compactFilter.Filter))
+	if err != nil {
+		return nil, err
+	}
+	if filter != nil {
+		return filter.MarshalBinary()
+	}
+
+	dblock, err := db.FetchDBlock(keyMR)
+	if err != nil || dblock == nil {
+		return nil, err
+	}
+	built, err := db.buildDBlockFilter(dblock, false)
+	if err != nil || built == nil {
+		return nil, err
+	}
+	return built.MarshalBinary()
+}
+
+func (db *Overlay) FetchDBlockFilterByHeight(height uint32) ([]byte, error) {
+	keyMR, err := db.FetchDBKeyMRByHeight(height)
+	if err != nil || keyMR == nil {
+		return nil, err
+	}
+	return db.FetchDBlockFilter(keyMR)
+}
+
+// ReindexDBlockFilters saves the filters of the DBlocks that do not have one, such
+// as the ones saved by older versions
+func (db *Overlay) ReindexDBlockFilters() error {
+	head, err := db.FetchDBlockHead()
+	if err != nil || head == nil {
+		return err
+	}
+	for height := uint32(0); height <= head.GetDatabaseHeight(); height++ {
+		dblock, err := db.FetchDBlockByHeight(height)
+		if err != nil {
+			return err
+		}
+		if dblock == nil {
+			continue
+		}
+		stored, err := db.DB.Get(DBLOCK_FILTER, dblock.GetKeyMR().Bytes(), new(compactFilter.Filter))
+		if err != nil {
+			return err
+		}
+		if stored != nil {
+			continue
+		}
+		err = db.SaveDBlockFilter(dblock)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
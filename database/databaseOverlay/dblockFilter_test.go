@@ -0,0 +1,111 @@
+package databaseOverlay_test
+
+import (
+	"testing"
+
+	"github.com/FactomProject/factomd/common/compactFilter"
+	"github.com/FactomProject/factomd/common/primitives"
+	"github.com/FactomProject/factomd/database/databaseOverlay"
+	"github.com/FactomProject/factomd/database/mapdb"
+	. "github.com/FactomProject/factomd/testHelper"
+)
+
+func TestDBlockFilterSaved(t *testing.T) {
+	blocks := CreateFullTestBlockSet()
+	dbo := CreateAndPopulateTestDatabaseOverlay()
+
+	for _, block := range blocks {
+		keyMR := block.DBlock.GetKeyMR()
+		// Built while saving, not on the first fetch
+		stored, err := dbo.DB.Get(databaseOverlay.DBLOCK_FILTER, keyMR.Bytes(), new(compactFilter.Filter))
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+		if stored == nil {
+			t.Fatalf("No filter saved for DBlock %v", block.DBlock.GetDatabaseHeight())
+		}
+
+		data, err := dbo.FetchDBlockFilterByHeight(block.DBlock.GetDatabaseHeight())
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+		if data == nil {
+			t.Fatalf("No filter at height %v", block.DBlock.GetDatabaseHeight())
+		}
+		filter := new(compactFilter.Filter)
+		if err := filter.UnmarshalBinary(data); err != nil {
+			t.Fatalf("%v", err)
+		}
+		items := databaseOverlay.DBlockFilterItems(block.DBlock, block.FBlock, block.ECBlock)
+		if len(items) == 0 {
+			t.Errorf("Nothing to filter at height %v", block.DBlock.GetDatabaseHeight())
+		}
+		key := compactFilter.Key(keyMR.Bytes())
+		for _, item := range items {
+			if !filter.Match(key, item) {
+				t.Errorf("Filter at height %v does not match %x", block.DBlock.GetDatabaseHeight(), item)
+			}
+		}
+		if !filter.Match(key, block.EBlock.GetChainID().Bytes()) {
+			t.Errorf("Filter at height %v does not match the EBlock chain", block.DBlock.GetDatabaseHeight())
+		}
+	}
+
+	filter, err := dbo.FetchDBlockFilterByHeight(uint32(len(blocks)) + 10)
+	if err != nil || filter != nil {
+		t.Errorf("Got a filter for a missing block: %v %v", filter, err)
+	}
+}
+
+func TestDBlockFilterReindexed(t *testing.T) {
+	blocks := CreateFullTestBlockSet()
+	dbo := databaseOverlay.NewOverlay(new(mapdb.MapDB))
+
+	// The DBlock goes in first, so there is nothing to build its filter from yet
+	for _, block := range blocks {
+		if err := dbo.ProcessDBlockBatch(block.DBlock); err != nil {
+			t.Fatalf("%v", err)
+		}
+		if err := dbo.ProcessFBlockBatch(block.FBlock); err != nil {
+			t.Fatalf("%v", err)
+		}
+		if err := dbo.ProcessECBlockBatch(block.ECBlock, false); err != nil {
+			t.Fatalf("%v", err)
+		}
+	}
+
+	for _, block := range blocks {
+		keyMR := block.DBlock.GetKeyMR()
+		want, err := databaseOverlay.NewDBlockFilter(block.DBlock, block.FBlock, block.ECBlock).MarshalBinary()
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+		data, err := dbo.FetchDBlockFilter(keyMR)
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+		if string(data) != string(want) {
+			t.Errorf("Filter at height %v differs from the one built from the blocks", block.DBlock.GetDatabaseHeight())
+		}
+		// Fetching does not write
+		stored, err := dbo.DB.Get(databaseOverlay.DBLOCK_FILTER, keyMR.Bytes(), new(compactFilter.Filter))
+		if err != nil || stored != nil {
+			t.Errorf("Filter built on fetch was saved: %v", err)
+		}
+	}
+
+	if err := dbo.ReindexDBlockFilters(); err != nil {
+		t.Fatalf("%v", err)
+	}
+	for _, block := range blocks {
+		stored, err := dbo.DB.Get(databaseOverlay.DBLOCK_FILTER, block.DBlock.GetKeyMR().Bytes(), new(compactFilter.Filter))
+		if err != nil || stored == nil {
+			t.Errorf("No filter saved at height %v: %v", block.DBlock.GetDatabaseHeight(), err)
+		}
+	}
+
+	filter, err := dbo.FetchDBlockFilter(primitives.RandomHash())
+	if err != nil || filter != nil {
+		t.Errorf("Got a filter for an unknown DBlock: %v %v", filter, err)
+	}
+}
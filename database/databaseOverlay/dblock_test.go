@@ -12,6 +12,7 @@ import (
 	"testing"
 
 	. "github.com/FactomProject/factomd/common/directoryBlock"
+	"github.com/FactomProject/factomd/common/interfaces"
 	"github.com/FactomProject/factomd/common/primitives"
 	. "github.com/FactomProject/factomd/database/databaseOverlay"
 	"github.com/FactomProject/factomd/database/mapdb"
@@ -191,3 +192,50 @@ func TestLoadUnknownDBlocks(t *testing.T) {
 		}
 	}
 }
+
+func TestIterateDBlocks(t *testing.T) {
+	dbo := testHelper.CreateAndPopulateTestDatabaseOverlay()
+
+	var height uint32
+	err := dbo.IterateDBlocks(func(block interfaces.IDirectoryBlock) error {
+		if block.GetDatabaseHeight() != height {
+			t.Errorf("Got the block at %v, expected %v", block.GetDatabaseHeight(), height)
+		}
+		height++
+		return nil
+	})
+	if err != nil {
+		t.Error(err)
+	}
+	if height != uint32(testHelper.BlockCount) {
+		t.Errorf("Iterated over %v blocks, expected %v", height, testHelper.BlockCount)
+	}
+
+	indexes, err := dbo.FetchBlockIndexesInHeightRange(DIRECTORYBLOCK_NUMBER, 2, -1)
+	if err != nil {
+		t.Error(err)
+	}
+	if len(indexes) != testHelper.BlockCount-2 {
+		t.Errorf("Fetched %v indexes, expected %v", len(indexes), testHelper.BlockCount-2)
+	}
+
+	// The range ends at the first missing height
+	key := []byte{0, 0, 0, 5}
+	err = dbo.Delete(DIRECTORYBLOCK_NUMBER, key)
+	if err != nil {
+		t.Error(err)
+	}
+	indexes, err = dbo.FetchBlockIndexesInHeightRange(DIRECTORYBLOCK_NUMBER, 2, -1)
+	if err != nil {
+		t.Error(err)
+	}
+	if len(indexes) != 3 {
+		t.Errorf("Fetched %v indexes past a missing height", len(indexes))
+	}
+	for i, index := range indexes {
+		keyMR, _ := dbo.FetchDBKeyMRByHeight(uint32(i + 2))
+		if !index.IsSameAs(keyMR) {
+			t.Errorf("Wrong index at %v", i+2)
+		}
+	}
+}
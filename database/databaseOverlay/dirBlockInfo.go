@@ -8,9 +8,10 @@ import (
 	"github.com/FactomProject/factomd/util"
 )
 
-// ProcessDirBlockInfoBatch inserts the dirblock info block
+// ProcessDirBlockInfoBatch inserts the dirblock info block.  It is confirmed once
+// it is anchored in Bitcoin or Ethereum.
 func (db *Overlay) ProcessDirBlockInfoBatch(block interfaces.IDirBlockInfo) error {
-	if block.GetBTCConfirmed() == true {
+	if block.GetBTCConfirmed() || block.GetEthConfirmed() {
 		err := db.Delete(DIRBLOCKINFO_UNCONFIRMED, block.DatabasePrimaryIndex().Bytes())
 		if err != nil {
 			return err
@@ -22,7 +23,7 @@ func (db *Overlay) ProcessDirBlockInfoBatch(block interfaces.IDirBlockInfo) erro
 }
 
 func (db *Overlay) ProcessDirBlockInfoMultiBatch(block interfaces.IDirBlockInfo) error {
-	if block.GetBTCConfirmed() == true {
+	if block.GetBTCConfirmed() || block.GetEthConfirmed() {
 		err := db.Delete(DIRBLOCKINFO_UNCONFIRMED, block.DatabasePrimaryIndex().Bytes())
 		if err != nil {
 			return err
@@ -102,6 +103,20 @@ func (db *Overlay) FetchAllDirBlockInfos() ([]interfaces.IDirBlockInfo, error) {
 	return all, nil
 }
 
+// IterateDirBlockInfos calls f with the unconfirmed and then the confirmed dirblock
+// info blocks, one at a time
+func (db *Overlay) IterateDirBlockInfos(f func(interfaces.IDirBlockInfo) error) error {
+	for _, bucket := range [][]byte{DIRBLOCKINFO_UNCONFIRMED, DIRBLOCKINFO} {
+		err := db.IterateBlocksFromBucket(bucket, dbInfo.NewDirBlockInfo(), func(block interfaces.BinaryMarshallableAndCopyable) error {
+			return f(block.(interfaces.IDirBlockInfo))
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func toDirBlockInfosList(source []interfaces.BinaryMarshallableAndCopyable) []interfaces.IDirBlockInfo {
 	answer := make([]interfaces.IDirBlockInfo, len(source))
 	for i, v := range source {
@@ -143,15 +143,25 @@ func TestFetchDirBlockInfoBatches(t *testing.T) {
 		t.Errorf("Returned %d infos, expected %d", len(all), max)
 	}
 
+	// Anchored in Bitcoin or Ethereum
+	var wantConfirmed, wantUnconfirmed []*dbInfo.DirBlockInfo
+	for _, block := range blocks {
+		if block.BTCConfirmed || block.EthConfirmed {
+			wantConfirmed = append(wantConfirmed, block)
+		} else {
+			wantUnconfirmed = append(wantUnconfirmed, block)
+		}
+	}
+
 	confirmed, err := dbo.FetchAllConfirmedDirBlockInfos()
 	if err != nil {
 		t.Error(err)
 	}
-	if len(confirmed) != max/2 {
-		t.Errorf("Returned %d infos, expected %d", len(confirmed), max/2)
+	if len(confirmed) != len(wantConfirmed) {
+		t.Errorf("Returned %d infos, expected %d", len(confirmed), len(wantConfirmed))
 	}
 	for _, info := range confirmed {
-		if info.GetBTCConfirmed() == false {
+		if info.GetBTCConfirmed() == false && info.GetEthConfirmed() == false {
 			t.Error("Confirmed transaction is unconfirmed")
 		}
 	}
@@ -160,11 +170,11 @@ func TestFetchDirBlockInfoBatches(t *testing.T) {
 	if err != nil {
 		t.Error(err)
 	}
-	if len(unconfirmed) != max/2 {
-		t.Errorf("Returned %d infos, expected %d", len(unconfirmed), max/2)
+	if len(unconfirmed) != len(wantUnconfirmed) {
+		t.Errorf("Returned %d infos, expected %d", len(unconfirmed), len(wantUnconfirmed))
 	}
 	for _, info := range unconfirmed {
-		if info.GetBTCConfirmed() == true {
+		if info.GetBTCConfirmed() == true || info.GetEthConfirmed() == true {
 			t.Error("Unconfirmed transaction is confirmed")
 		}
 	}
@@ -181,22 +191,32 @@ func TestFetchDirBlockInfoBatches(t *testing.T) {
 		if primitives.AreBytesEqual(m1, m2) == false {
 			t.Error("Blocks are not equal")
 		}
-
-		if i%2 == 0 {
-			m2, err = unconfirmed[i/2].MarshalBinary()
-			if err != nil {
-				t.Error(err)
-			}
-		} else {
-			m2, err = confirmed[i/2].MarshalBinary()
-			if err != nil {
-				t.Error(err)
-			}
+	}
+	for i := range wantConfirmed {
+		m1, err := wantConfirmed[i].MarshalBinary()
+		if err != nil {
+			t.Error(err)
+		}
+		m2, err := confirmed[i].MarshalBinary()
+		if err != nil {
+			t.Error(err)
+		}
+		if primitives.AreBytesEqual(m1, m2) == false {
+			t.Error("Blocks are not equal")
+		}
+	}
+	for i := range wantUnconfirmed {
+		m1, err := wantUnconfirmed[i].MarshalBinary()
+		if err != nil {
+			t.Error(err)
+		}
+		m2, err := unconfirmed[i].MarshalBinary()
+		if err != nil {
+			t.Error(err)
 		}
 		if primitives.AreBytesEqual(m1, m2) == false {
 			t.Error("Blocks are not equal")
 		}
-
 	}
 }
 
@@ -3,7 +3,6 @@ package databaseOverlay
 import (
 	"github.com/FactomProject/factomd/common/entryBlock"
 	"github.com/FactomProject/factomd/common/interfaces"
-	"github.com/FactomProject/factomd/common/primitives"
 	//"github.com/FactomProject/factomd/log"
 	//"github.com/FactomProject/factomd/util"
 	//"sort"
@@ -93,23 +92,30 @@ func (db *Overlay) FetchEBKeyMRByHash(hash interfaces.IHash) (interfaces.IHash,
 
 // FetchAllEBlocksByChain gets all of the blocks by chain id
 func (db *Overlay) FetchAllEBlocksByChain(chainID interfaces.IHash) ([]interfaces.IEntryBlock, error) {
-	bucket := append(ENTRYBLOCK_CHAIN_NUMBER, chainID.Bytes()...)
-	keyList, err := db.FetchAllBlocksFromBucket(bucket, new(primitives.Hash))
+	list := []interfaces.IEntryBlock{}
+	err := db.IterateEBlocksByChain(chainID, func(block interfaces.IEntryBlock) error {
+		list = append(list, block)
+		return nil
+	})
 	if err != nil {
 		return nil, err
 	}
+	return list, nil
+}
 
-	list := make([]interfaces.IEntryBlock, len(keyList))
-
-	for i, v := range keyList {
-		block, err := db.FetchEBlock(v.(interfaces.IHash))
+// IterateEBlocksByChain calls f with the blocks of the chain in height order, one at a time
+func (db *Overlay) IterateEBlocksByChain(chainID interfaces.IHash, f func(interfaces.IEntryBlock) error) error {
+	bucket := append(append([]byte{}, ENTRYBLOCK_CHAIN_NUMBER...), chainID.Bytes()...)
+	return db.iterateBlockIndexesByHeight(bucket, func(index interfaces.IHash) error {
+		block, err := db.FetchEBlock(index)
 		if err != nil {
-			return nil, err
+			return err
 		}
-		list[i] = block
-	}
-
-	return list, nil
+		if block == nil {
+			return nil
+		}
+		return f(block)
+	})
 }
 
 func (db *Overlay) SaveEBlockHead(block interfaces.DatabaseBlockWithEntries, checkForDuplicateEntries bool) error {
@@ -21,7 +21,15 @@ func (db *Overlay) ProcessECBlockBatch(block interfaces.IEntryCreditBlock, check
 	if err != nil {
 		return err
 	}
-	return db.SavePaidForMultiFromBlock(block, checkForDuplicateEntries)
+	err = db.SavePaidForMultiFromBlock(block, checkForDuplicateEntries)
+	if err != nil {
+		return err
+	}
+	err = db.SaveAddressTransactionsFromECBlock(block)
+	if err != nil {
+		return err
+	}
+	return db.SaveBalanceDeltasFromECBlock(block)
 }
 
 func (db *Overlay) ProcessECBlockBatchWithoutHead(block interfaces.IEntryCreditBlock, checkForDuplicateEntries bool) error {
@@ -35,7 +43,15 @@ func (db *Overlay) ProcessECBlockBatchWithoutHead(block interfaces.IEntryCreditB
 	if err != nil {
 		return err
 	}
-	return db.SavePaidForMultiFromBlock(block, checkForDuplicateEntries)
+	err = db.SavePaidForMultiFromBlock(block, checkForDuplicateEntries)
+	if err != nil {
+		return err
+	}
+	err = db.SaveAddressTransactionsFromECBlock(block)
+	if err != nil {
+		return err
+	}
+	return db.SaveBalanceDeltasFromECBlock(block)
 }
 
 func (db *Overlay) ProcessECBlockMultiBatch(block interfaces.IEntryCreditBlock, checkForDuplicateEntries bool) error {
@@ -49,7 +65,15 @@ func (db *Overlay) ProcessECBlockMultiBatch(block interfaces.IEntryCreditBlock,
 	if err != nil {
 		return err
 	}
-	return db.SavePaidForMultiFromBlockMultiBatch(block, checkForDuplicateEntries)
+	err = db.SavePaidForMultiFromBlockMultiBatch(block, checkForDuplicateEntries)
+	if err != nil {
+		return err
+	}
+	err = db.SaveAddressTransactionsFromECBlockMultiBatch(block)
+	if err != nil {
+		return err
+	}
+	return db.SaveBalanceDeltasFromECBlockMultiBatch(block)
 }
 
 func (db *Overlay) FetchECBlock(hash interfaces.IHash) (interfaces.IEntryCreditBlock, error) {
@@ -108,6 +132,13 @@ func (db *Overlay) FetchAllECBlocks() ([]interfaces.IEntryCreditBlock, error) {
 	return toECBlocksList(list), nil
 }
 
+// IterateECBlocks calls f with the entry credit blocks in height order, one at a time
+func (db *Overlay) IterateECBlocks(f func(interfaces.IEntryCreditBlock) error) error {
+	return db.iterateBlocksByHeight(ENTRYCREDITBLOCK_NUMBER, ENTRYCREDITBLOCK, entryCreditBlock.NewECBlock(), func(block interfaces.DatabaseBatchable) error {
+		return f(block.(interfaces.IEntryCreditBlock))
+	})
+}
+
 func (db *Overlay) FetchAllECBlockKeys() ([]interfaces.IHash, error) {
 	return db.FetchAllBlockKeysFromBucket(ENTRYCREDITBLOCK)
 }
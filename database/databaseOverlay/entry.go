@@ -50,6 +50,22 @@ func (db *Overlay) InsertEntryMultiBatch(entry interfaces.IEBEntry) error {
 	return nil
 }
 
+// PruneEntry deletes the content of an entry.  The entry block listing it, and
+// the index of the block it is included in, are kept.
+func (db *Overlay) PruneEntry(hash interfaces.IHash) error {
+	chainID, err := db.FetchPrimaryIndexBySecondaryIndex(ENTRY, hash)
+	if err != nil {
+		return err
+	}
+	if chainID == nil {
+		return nil
+	}
+	if err := db.Delete(chainID.Bytes(), hash.Bytes()); err != nil {
+		return err
+	}
+	return db.Delete(ENTRY, hash.Bytes())
+}
+
 // FetchEntry gets an entry by hash from the database.
 func (db *Overlay) FetchEntry(hash interfaces.IHash) (interfaces.IEBEntry, error) {
 	chainID, err := db.FetchPrimaryIndexBySecondaryIndex(ENTRY, hash)
@@ -72,11 +88,22 @@ func (db *Overlay) FetchEntry(hash interfaces.IHash) (interfaces.IEBEntry, error
 }
 
 func (db *Overlay) FetchAllEntriesByChainID(chainID interfaces.IHash) ([]interfaces.IEBEntry, error) {
-	list, err := db.FetchAllBlocksFromBucket(chainID.Bytes(), entryBlock.NewEntry())
+	answer := []interfaces.IEBEntry{}
+	err := db.IterateEntriesByChainID(chainID, func(entry interfaces.IEBEntry) error {
+		answer = append(answer, entry)
+		return nil
+	})
 	if err != nil {
 		return nil, err
 	}
-	return toEntryList(list), nil
+	return answer, nil
+}
+
+// IterateEntriesByChainID calls f with the entries of the chain, one at a time
+func (db *Overlay) IterateEntriesByChainID(chainID interfaces.IHash, f func(interfaces.IEBEntry) error) error {
+	return db.IterateBlocksFromBucket(chainID.Bytes(), entryBlock.NewEntry(), func(entry interfaces.BinaryMarshallableAndCopyable) error {
+		return f(entry.(interfaces.IEBEntry))
+	})
 }
 
 func (db *Overlay) FetchAllEntryIDsByChainID(chainID interfaces.IHash) ([]interfaces.IHash, error) {
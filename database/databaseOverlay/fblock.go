@@ -14,7 +14,18 @@ func (db *Overlay) ProcessFBlockBatch(block interfaces.DatabaseBlockWithEntries)
 	if err != nil {
 		return err
 	}
-	return db.SaveIncludedInMultiFromBlock(block, false)
+	err = db.SaveIncludedInMultiFromBlock(block, false)
+	if err != nil {
+		return err
+	}
+	if fBlock, ok := block.(interfaces.IFBlock); ok {
+		err = db.SaveAddressTransactionsFromFBlock(fBlock)
+		if err != nil {
+			return err
+		}
+		return db.SaveBalanceDeltasFromFBlock(fBlock)
+	}
+	return nil
 }
 
 func (db *Overlay) ProcessFBlockBatchWithoutHead(block interfaces.DatabaseBlockWithEntries) error {
@@ -22,7 +33,18 @@ func (db *Overlay) ProcessFBlockBatchWithoutHead(block interfaces.DatabaseBlockW
 	if err != nil {
 		return err
 	}
-	return db.SaveIncludedInMultiFromBlock(block, false)
+	err = db.SaveIncludedInMultiFromBlock(block, false)
+	if err != nil {
+		return err
+	}
+	if fBlock, ok := block.(interfaces.IFBlock); ok {
+		err = db.SaveAddressTransactionsFromFBlock(fBlock)
+		if err != nil {
+			return err
+		}
+		return db.SaveBalanceDeltasFromFBlock(fBlock)
+	}
+	return nil
 }
 
 func (db *Overlay) ProcessFBlockMultiBatch(block interfaces.DatabaseBlockWithEntries) error {
@@ -30,7 +52,18 @@ func (db *Overlay) ProcessFBlockMultiBatch(block interfaces.DatabaseBlockWithEnt
 	if err != nil {
 		return err
 	}
-	return db.SaveIncludedInMultiFromBlockMultiBatch(block, true)
+	err = db.SaveIncludedInMultiFromBlockMultiBatch(block, true)
+	if err != nil {
+		return err
+	}
+	if fBlock, ok := block.(interfaces.IFBlock); ok {
+		err = db.SaveAddressTransactionsFromFBlockMultiBatch(fBlock)
+		if err != nil {
+			return err
+		}
+		return db.SaveBalanceDeltasFromFBlockMultiBatch(fBlock)
+	}
+	return nil
 }
 
 func (db *Overlay) FetchFBlock(hash interfaces.IHash) (interfaces.IFBlock, error) {
@@ -86,6 +119,13 @@ func (db *Overlay) FetchAllFBlocks() ([]interfaces.IFBlock, error) {
 	return toFactoidList(list), nil
 }
 
+// IterateFBlocks calls f with the factoid blocks in height order, one at a time
+func (db *Overlay) IterateFBlocks(f func(interfaces.IFBlock) error) error {
+	return db.iterateBlocksByHeight(FACTOIDBLOCK_NUMBER, FACTOIDBLOCK, new(factoid.FBlock), func(block interfaces.DatabaseBatchable) error {
+		return f(block.(interfaces.IFBlock))
+	})
+}
+
 func (db *Overlay) FetchAllFBlockKeys() ([]interfaces.IHash, error) {
 	return db.FetchAllBlockKeysFromBucket(FACTOIDBLOCK)
 }
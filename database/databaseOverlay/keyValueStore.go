@@ -56,3 +56,30 @@ func (db *Overlay) FetchDatabaseEntryHeight() (uint32, error) {
 	}
 	return height, nil
 }
+
+var DatabasePrunedHeightKey = []byte("DatabasePrunedHeight")
+
+// SaveDatabasePrunedHeight records the height below which a pruned node deleted
+// the entries it does not keep
+func (db *Overlay) SaveDatabasePrunedHeight(height uint32) error {
+	buf := primitives.NewBuffer(nil)
+	buf.PushUInt32(height)
+	bs := new(primitives.ByteSlice)
+	bs.Bytes = buf.DeepCopyBytes()
+
+	return db.SaveKeyValueStore(bs, DatabasePrunedHeightKey)
+}
+
+func (db *Overlay) FetchDatabasePrunedHeight() (uint32, error) {
+	bs := new(primitives.ByteSlice)
+	_, err := db.FetchKeyValueStore(DatabasePrunedHeightKey, bs)
+	if err != nil {
+		return 0, err
+	}
+	buf := primitives.NewBuffer(bs.Bytes)
+	height, err := buf.PopUInt32()
+	if err != nil {
+		return 0, err
+	}
+	return height, nil
+}
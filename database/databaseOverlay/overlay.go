@@ -68,6 +68,21 @@ var (
 	//Which EC transaction paid for this Entry
 	PAID_FOR = []byte("PaidFor")
 
+	//Transactions touching an address, one bucket per address
+	FACTOID_ADDRESS_TRANSACTIONS = []byte("FactoidAddressTransactions")
+	EC_ADDRESS_TRANSACTIONS      = []byte("ECAddressTransactions")
+
+	//Changes to the balance of an address by block height, one bucket per address
+	FACTOID_BALANCE_DELTAS = []byte("FactoidBalanceDeltas")
+	EC_BALANCE_DELTAS      = []byte("ECBalanceDeltas")
+
+	//Balances of an address every so many changes, one bucket per address
+	FACTOID_BALANCE_CHECKPOINTS = []byte("FactoidBalanceCheckpoints")
+	EC_BALANCE_CHECKPOINTS      = []byte("ECBalanceCheckpoints")
+
+	//Compact filters over the chain IDs and addresses of a DBlock, by DBlock KeyMR
+	DBLOCK_FILTER = []byte("DBlockFilter")
+
 	KEY_VALUE_STORE = []byte("KeyValueStore")
 )
 
@@ -107,6 +122,13 @@ func init() {
 	ConstantNamesMap[string(INCLUDED_IN)] = "IncludedIn"
 
 	ConstantNamesMap[string(PAID_FOR)] = "PaidFor"
+	ConstantNamesMap[string(FACTOID_ADDRESS_TRANSACTIONS)] = "FactoidAddressTransactions"
+	ConstantNamesMap[string(EC_ADDRESS_TRANSACTIONS)] = "ECAddressTransactions"
+	ConstantNamesMap[string(FACTOID_BALANCE_DELTAS)] = "FactoidBalanceDeltas"
+	ConstantNamesMap[string(EC_BALANCE_DELTAS)] = "ECBalanceDeltas"
+	ConstantNamesMap[string(FACTOID_BALANCE_CHECKPOINTS)] = "FactoidBalanceCheckpoints"
+	ConstantNamesMap[string(EC_BALANCE_CHECKPOINTS)] = "ECBalanceCheckpoints"
+	ConstantNamesMap[string(DBLOCK_FILTER)] = "DBlockFilter"
 	ConstantNamesMap[string(KEY_VALUE_STORE)] = "KeyValueStore"
 
 	RegisterPrometheus()
@@ -169,6 +191,10 @@ func (db *Overlay) GetAll(bucket []byte, sample interfaces.BinaryMarshallableAnd
 	return db.DB.GetAll(bucket, sample)
 }
 
+func (db *Overlay) NewIterator(bucket []byte, options *interfaces.IterateOptions) interfaces.IIterator {
+	return db.DB.NewIterator(bucket, options)
+}
+
 func (db *Overlay) Get(bucket, key []byte, destination interfaces.BinaryMarshallable) (interfaces.BinaryMarshallable, error) {
 	GetBucket(bucket)
 	return db.DB.Get(bucket, key, destination)
@@ -258,13 +284,71 @@ func (db *Overlay) FetchBlock(bucket []byte, key interfaces.IHash, dst interface
 }
 
 func (db *Overlay) FetchAllBlocksFromBucket(bucket []byte, sample interfaces.BinaryMarshallableAndCopyable) ([]interfaces.BinaryMarshallableAndCopyable, error) {
-	answer, _, err := db.GetAll(bucket, sample)
+	answer := []interfaces.BinaryMarshallableAndCopyable{}
+	err := db.IterateBlocksFromBucket(bucket, sample, func(block interfaces.BinaryMarshallableAndCopyable) error {
+		answer = append(answer, block)
+		return nil
+	})
 	if err != nil {
 		return nil, err
 	}
 	return answer, nil
 }
 
+// IterateBlocksFromBucket calls f with every value of the bucket, unmarshalled into
+// a new copy of the sample, in key order.  Only one value is read at a time.  The
+// first error f returns stops the iteration and is returned.
+func (db *Overlay) IterateBlocksFromBucket(bucket []byte, sample interfaces.BinaryMarshallableAndCopyable, f func(interfaces.BinaryMarshallableAndCopyable) error) error {
+	iter := db.DB.NewIterator(bucket, nil)
+	defer iter.Release()
+	for iter.Next() {
+		block := sample.New()
+		err := block.UnmarshalBinary(append([]byte{}, iter.Value()...))
+		if err != nil {
+			return err
+		}
+		err = f(block)
+		if err != nil {
+			return err
+		}
+	}
+	return iter.Error()
+}
+
+// iterateBlockIndexesByHeight calls f with the indexes the number bucket holds, in
+// height order
+func (db *Overlay) iterateBlockIndexesByHeight(numberBucket []byte, f func(interfaces.IHash) error) error {
+	iter := db.DB.NewIterator(numberBucket, nil)
+	defer iter.Release()
+	for iter.Next() {
+		index := new(primitives.Hash)
+		err := index.UnmarshalBinary(iter.Value())
+		if err != nil {
+			return err
+		}
+		err = f(index)
+		if err != nil {
+			return err
+		}
+	}
+	return iter.Error()
+}
+
+// iterateBlocksByHeight calls f with the blocks of the number bucket in height order,
+// fetching one block at a time
+func (db *Overlay) iterateBlocksByHeight(numberBucket, blockBucket []byte, sample interfaces.DatabaseBatchable, f func(interfaces.DatabaseBatchable) error) error {
+	return db.iterateBlockIndexesByHeight(numberBucket, func(index interfaces.IHash) error {
+		block, err := db.FetchBlock(blockBucket, index, sample.New().(interfaces.DatabaseBatchable))
+		if err != nil {
+			return err
+		}
+		if block == nil {
+			return nil
+		}
+		return f(block)
+	})
+}
+
 func (db *Overlay) FetchAllBlockKeysFromBucket(bucket []byte) ([]interfaces.IHash, error) {
 	entries, err := db.ListAllKeys(bucket)
 	if err != nil {
@@ -451,17 +535,34 @@ func (db *Overlay) FetchBlockIndexesInHeightRange(numberBucket []byte, startHeig
 		endidx = endHeight
 	}
 
+	if endidx <= startHeight {
+		return []interfaces.IHash{}, nil
+	}
+
+	seek := make([]byte, 4)
+	binary.BigEndian.PutUint32(seek, uint32(startHeight))
+	iter := db.DB.NewIterator(numberBucket, &interfaces.IterateOptions{Seek: seek, Limit: int(endidx - startHeight)})
+	defer iter.Release()
+
 	shalist := make([]interfaces.IHash, 0, endidx-startHeight)
-	for height := startHeight; height < endidx; height++ {
-		dbhash, err := db.FetchBlockIndexByHeight(numberBucket, uint32(height))
+	height := uint32(startHeight)
+	for iter.Next() {
+		// Stop at the first missing height
+		if len(iter.Key()) != 4 || binary.BigEndian.Uint32(iter.Key()) != height {
+			break
+		}
+		dbhash := new(primitives.Hash)
+		err := dbhash.UnmarshalBinary(iter.Value())
 		if err != nil {
 			return nil, err
 		}
-		if dbhash == nil {
-			break
-		}
 
 		shalist = append(shalist, dbhash)
+		height++
+	}
+	err := iter.Error()
+	if err != nil {
+		return nil, err
 	}
 
 	return shalist, nil
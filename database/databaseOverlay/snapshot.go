@@ -0,0 +1,133 @@
+// Copyright 2017 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package databaseOverlay
+
+import (
+	"fmt"
+
+	"github.com/FactomProject/factomd/common/interfaces"
+	"github.com/FactomProject/factomd/common/primitives"
+)
+
+// Snapshot returns a read only overlay over the database as it is now, so a reader
+// never sees half of a block being saved.  Databases that cannot take snapshots
+// (the map database) are read as they change.  The returned overlay must be
+// closed, which releases the snapshot and leaves the database open.
+func (db *Overlay) Snapshot() (interfaces.DBOverlaySimple, error) {
+	var snap interfaces.IDatabaseSnapshot = &liveSnapshot{db: db.DB}
+	if snapshotter, ok := db.DB.(interfaces.ISnapshotDatabase); ok {
+		var err error
+		snap, err = snapshotter.Snapshot()
+		if err != nil {
+			return nil, err
+		}
+	}
+	return NewOverlay(&snapshotDB{snap: snap}), nil
+}
+
+// liveSnapshot reads a database that cannot take snapshots
+type liveSnapshot struct {
+	db interfaces.IDatabase
+}
+
+func (s *liveSnapshot) Get(bucket, key []byte, destination interfaces.BinaryMarshallable) (interfaces.BinaryMarshallable, error) {
+	return s.db.Get(bucket, key, destination)
+}
+
+func (s *liveSnapshot) NewIterator(bucket []byte, options *interfaces.IterateOptions) interfaces.IIterator {
+	return s.db.NewIterator(bucket, options)
+}
+
+func (s *liveSnapshot) Release() {
+}
+
+// snapshotDB is a read only IDatabase over a snapshot
+type snapshotDB struct {
+	snap interfaces.IDatabaseSnapshot
+}
+
+var _ interfaces.IDatabase = (*snapshotDB)(nil)
+
+var errReadOnly = fmt.Errorf("a database snapshot is read only")
+
+func (db *snapshotDB) Get(bucket, key []byte, destination interfaces.BinaryMarshallable) (interfaces.BinaryMarshallable, error) {
+	return db.snap.Get(bucket, key, destination)
+}
+
+func (db *snapshotDB) NewIterator(bucket []byte, options *interfaces.IterateOptions) interfaces.IIterator {
+	return db.snap.NewIterator(bucket, options)
+}
+
+func (db *snapshotDB) DoesKeyExist(bucket, key []byte) (bool, error) {
+	value, err := db.snap.Get(bucket, key, new(primitives.ByteSlice))
+	if err != nil {
+		return false, err
+	}
+	return value != nil, nil
+}
+
+func (db *snapshotDB) ListAllKeys(bucket []byte) ([][]byte, error) {
+	var keys [][]byte
+	iter := db.snap.NewIterator(bucket, nil)
+	for iter.Next() {
+		keys = append(keys, append([]byte{}, iter.Key()...))
+	}
+	iter.Release()
+	err := iter.Error()
+	if err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+func (db *snapshotDB) GetAll(bucket []byte, sample interfaces.BinaryMarshallableAndCopyable) ([]interfaces.BinaryMarshallableAndCopyable, [][]byte, error) {
+	answer := []interfaces.BinaryMarshallableAndCopyable{}
+	keys := [][]byte{}
+	iter := db.snap.NewIterator(bucket, nil)
+	defer iter.Release()
+	for iter.Next() {
+		tmp := sample.New()
+		err := tmp.UnmarshalBinary(append([]byte{}, iter.Value()...))
+		if err != nil {
+			return nil, nil, err
+		}
+		keys = append(keys, append([]byte{}, iter.Key()...))
+		answer = append(answer, tmp)
+	}
+	err := iter.Error()
+	if err != nil {
+		return nil, nil, err
+	}
+	return answer, keys, nil
+}
+
+func (db *snapshotDB) ListAllBuckets() ([][]byte, error) {
+	return nil, fmt.Errorf("Unable to fetch buckets from a database snapshot")
+}
+
+func (db *snapshotDB) Put(bucket, key []byte, data interfaces.BinaryMarshallable) error {
+	return errReadOnly
+}
+
+func (db *snapshotDB) PutInBatch(records []interfaces.Record) error {
+	return errReadOnly
+}
+
+func (db *snapshotDB) Delete(bucket, key []byte) error {
+	return errReadOnly
+}
+
+func (db *snapshotDB) Clear(bucket []byte) error {
+	return errReadOnly
+}
+
+func (db *snapshotDB) Trim() {
+}
+
+// Close releases the snapshot
+func (db *snapshotDB) Close() error {
+	db.snap.Release()
+	return nil
+}
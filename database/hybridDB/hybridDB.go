@@ -1,12 +1,14 @@
 package hybridDB
 
 import (
+	"fmt"
 	"sync"
 
 	"github.com/FactomProject/factomd/common/interfaces"
 
 	"github.com/FactomProject/factomd/database/boltdb"
 	"github.com/FactomProject/factomd/database/leveldb"
+	"github.com/FactomProject/factomd/database/lsmdb"
 	"github.com/FactomProject/factomd/database/mapdb"
 )
 
@@ -17,6 +19,7 @@ type HybridDB struct {
 }
 
 var _ interfaces.IDatabase = (*HybridDB)(nil)
+var _ interfaces.ISnapshotDatabase = (*HybridDB)(nil)
 
 func (db *HybridDB) ListAllBuckets() ([][]byte, error) {
 	db.Sem.RLock()
@@ -61,6 +64,22 @@ func NewLevelMapHybridDB(filename string, create bool) (*HybridDB, error) {
 	return answer, nil
 }
 
+func NewLSMMapHybridDB(filename string, create bool) (*HybridDB, error) {
+	answer := new(HybridDB)
+
+	m := new(mapdb.MapDB)
+	m.Init(nil)
+	answer.temporaryStorage = m
+
+	b, err := lsmdb.NewLSMDB(filename, create)
+	if err != nil {
+		return nil, err
+	}
+	answer.persistentStorage = b
+
+	return answer, nil
+}
+
 func NewBoltMapHybridDB(bucketList [][]byte, filename string) *HybridDB {
 	answer := new(HybridDB)
 
@@ -183,3 +202,23 @@ func (db *HybridDB) DoesKeyExist(bucket, key []byte) (bool, error) {
 	}
 	return exist, nil
 }
+
+func (db *HybridDB) NewIterator(bucket []byte, options *interfaces.IterateOptions) interfaces.IIterator {
+	db.Sem.RLock()
+	defer db.Sem.RUnlock()
+
+	return db.persistentStorage.NewIterator(bucket, options)
+}
+
+// Snapshot takes a snapshot of the persistent storage, which has everything the
+// map in front of it has
+func (db *HybridDB) Snapshot() (interfaces.IDatabaseSnapshot, error) {
+	db.Sem.RLock()
+	defer db.Sem.RUnlock()
+
+	snapshotter, ok := db.persistentStorage.(interfaces.ISnapshotDatabase)
+	if !ok {
+		return nil, fmt.Errorf("%T cannot take snapshots", db.persistentStorage)
+	}
+	return snapshotter.Snapshot()
+}
@@ -0,0 +1,98 @@
+// Copyright 2017 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package leveldb
+
+import (
+	"bytes"
+
+	"github.com/FactomProject/factomd/common/interfaces"
+	"github.com/FactomProject/goleveldb/leveldb/iterator"
+)
+
+// Iterator applies the seek, direction and limit of IterateOptions to a goleveldb
+// iterator over the keys of one bucket (and prefix).  The keys of the database
+// start with strip bytes naming the bucket, which are cut off the keys it returns.
+type Iterator struct {
+	iter    iterator.Iterator
+	strip   int
+	seek    []byte // the database key to start at, or nil
+	reverse bool
+	limit   int
+	count   int
+	started bool
+}
+
+var _ interfaces.IIterator = (*Iterator)(nil)
+
+func NewIterator(iter iterator.Iterator, strip int, seek []byte, options *interfaces.IterateOptions) *Iterator {
+	it := new(Iterator)
+	it.iter = iter
+	it.strip = strip
+	it.seek = seek
+	if options != nil {
+		it.reverse = options.Reverse
+		it.limit = options.Limit
+	}
+	return it
+}
+
+func (it *Iterator) Next() bool {
+	if it.limit > 0 && it.count >= it.limit {
+		return false
+	}
+	var ok bool
+	switch {
+	case !it.started:
+		it.started = true
+		ok = it.first()
+	case it.reverse:
+		ok = it.iter.Prev()
+	default:
+		ok = it.iter.Next()
+	}
+	if ok {
+		it.count++
+	}
+	return ok
+}
+
+func (it *Iterator) first() bool {
+	switch {
+	case it.seek == nil && it.reverse:
+		return it.iter.Last()
+	case it.seek == nil:
+		return it.iter.First()
+	case !it.reverse:
+		return it.iter.Seek(it.seek)
+	}
+	// The last key at or before seek
+	if !it.iter.Seek(it.seek) {
+		return it.iter.Last()
+	}
+	if bytes.Compare(it.iter.Key(), it.seek) > 0 {
+		return it.iter.Prev()
+	}
+	return true
+}
+
+func (it *Iterator) Key() []byte {
+	key := it.iter.Key()
+	if len(key) < it.strip {
+		return nil
+	}
+	return key[it.strip:]
+}
+
+func (it *Iterator) Value() []byte {
+	return it.iter.Value()
+}
+
+func (it *Iterator) Release() {
+	it.iter.Release()
+}
+
+func (it *Iterator) Error() error {
+	return it.iter.Error()
+}
@@ -14,6 +14,7 @@ import (
 
 	"github.com/FactomProject/factomd/common/interfaces"
 	"github.com/FactomProject/goleveldb/leveldb"
+	"github.com/FactomProject/goleveldb/leveldb/iterator"
 	"github.com/FactomProject/goleveldb/leveldb/opt"
 	"github.com/FactomProject/goleveldb/leveldb/util"
 )
@@ -28,6 +29,7 @@ type LevelDB struct {
 }
 
 var _ interfaces.IDatabase = (*LevelDB)(nil)
+var _ interfaces.ISnapshotDatabase = (*LevelDB)(nil)
 
 func (db *LevelDB) ListAllBuckets() ([][]byte, error) {
 	//TODO: fix Level to solve this issue
@@ -80,7 +82,8 @@ func (db *LevelDB) Close() error {
 }
 
 func ExtendBucket(bucket []byte) []byte {
-	return append(bucket, ';')
+	// Copy, so two keys of the same bucket never share the caller's array
+	return append(append([]byte{}, bucket...), ';')
 }
 
 func CombineBucketAndKey(bucket []byte, key []byte) []byte {
@@ -306,6 +309,30 @@ func addOneToByteArray(input []byte) (output []byte) {
 	return output
 }
 
+func (db *LevelDB) NewIterator(bucket []byte, options *interfaces.IterateOptions) interfaces.IIterator {
+	db.dbLock.RLock()
+	defer db.dbLock.RUnlock()
+
+	return newBucketIterator(db.lDB, db.ro, bucket, options)
+}
+
+// iterable is what the database and its snapshots have in common
+type iterable interface {
+	NewIterator(slice *util.Range, ro *opt.ReadOptions) iterator.Iterator
+}
+
+func newBucketIterator(source iterable, ro *opt.ReadOptions, bucket []byte, options *interfaces.IterateOptions) interfaces.IIterator {
+	if options == nil {
+		options = new(interfaces.IterateOptions)
+	}
+	var seek []byte
+	if options.Seek != nil {
+		seek = CombineBucketAndKey(bucket, options.Seek)
+	}
+	iter := source.NewIterator(util.BytesPrefix(CombineBucketAndKey(bucket, options.Prefix)), ro)
+	return NewIterator(iter, len(bucket)+1, seek, options)
+}
+
 func (db *LevelDB) DoesKeyExist(bucket, key []byte) (bool, error) {
 	ldbKey := CombineBucketAndKey(bucket, key)
 	return db.lDB.Has(ldbKey, db.ro)
@@ -0,0 +1,62 @@
+// Copyright 2017 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package leveldb
+
+import (
+	"github.com/FactomProject/factomd/common/interfaces"
+	"github.com/FactomProject/goleveldb/leveldb"
+	"github.com/FactomProject/goleveldb/leveldb/opt"
+)
+
+// Snapshot is a consistent view of the database at the time it was taken.  Writes
+// made after it was taken are not seen through it.
+type Snapshot struct {
+	snap *leveldb.Snapshot
+	ro   *opt.ReadOptions
+}
+
+var _ interfaces.IDatabaseSnapshot = (*Snapshot)(nil)
+
+// Snapshot takes a snapshot of the database
+func (db *LevelDB) Snapshot() (interfaces.IDatabaseSnapshot, error) {
+	db.dbLock.RLock()
+	defer db.dbLock.RUnlock()
+
+	snap, err := db.lDB.GetSnapshot()
+	if err != nil {
+		return nil, err
+	}
+	s := new(Snapshot)
+	s.snap = snap
+	s.ro = db.ro
+	return s, nil
+}
+
+func (s *Snapshot) Get(bucket []byte, key []byte, destination interfaces.BinaryMarshallable) (interfaces.BinaryMarshallable, error) {
+	LevelDBGets.Inc()
+
+	data, err := s.snap.Get(CombineBucketAndKey(bucket, key), s.ro)
+	if err == leveldb.ErrNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = destination.UnmarshalBinaryData(data)
+	if err != nil {
+		return nil, err
+	}
+	return destination, nil
+}
+
+// NewIterator is LevelDB.NewIterator as of the snapshot
+func (s *Snapshot) NewIterator(bucket []byte, options *interfaces.IterateOptions) interfaces.IIterator {
+	return newBucketIterator(s.snap, s.ro, bucket, options)
+}
+
+func (s *Snapshot) Release() {
+	s.snap.Release()
+}
@@ -0,0 +1,35 @@
+package lsmdb
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	LSMDBGets = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "factomd_database_lsmdb_gets",
+		Help: "Counts gets from the database",
+	})
+	LSMDBPuts = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "factomd_database_lsmdb_puts",
+		Help: "Count puts to the database",
+	})
+	LSMDBCacheblock = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "factomd_database_lsmdb_cacheblock",
+		Help: "Memory used by the LSM DB for caching",
+	})
+)
+
+var registered = false
+
+// RegisterPrometheus registers the variables to be exposed. This can only be run once, hence the
+// boolean flag to prevent panics if launched more than once. This is called in NetStart
+func RegisterPrometheus() {
+	if registered {
+		return
+	}
+	registered = true
+
+	prometheus.MustRegister(LSMDBGets)
+	prometheus.MustRegister(LSMDBPuts)
+	prometheus.MustRegister(LSMDBCacheblock)
+}
@@ -0,0 +1,407 @@
+// Copyright 2017 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package lsmdb
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"sync"
+
+	"github.com/FactomProject/factomd/common/interfaces"
+	ldb "github.com/FactomProject/factomd/database/leveldb"
+	"github.com/FactomProject/goleveldb/leveldb"
+	"github.com/FactomProject/goleveldb/leveldb/iterator"
+	"github.com/FactomProject/goleveldb/leveldb/opt"
+	"github.com/FactomProject/goleveldb/leveldb/util"
+)
+
+// LSMDB is a log structured merge tree database where every bucket is its own
+// column family.  Each family gets a 4 byte id when its bucket is first written,
+// and every key of the family is stored behind that id, so one bucket can never
+// see the keys of another (the LDB backend separates buckets with a ';', which
+// fails for buckets that are a prefix of each other).  The families are kept in
+// a registry in the reserved family 0, which also lets the database list its
+// buckets.
+//
+// On top of IDatabase the database can delete a range of keys in one write, and
+// take snapshots that keep reading the database as it was when they were taken.
+type LSMDB struct {
+	// lock preventing multiple entry
+	dbLock sync.RWMutex
+	lDB    *leveldb.DB
+	ro     *opt.ReadOptions
+	wo     *opt.WriteOptions
+
+	families   map[string]uint32
+	nextFamily uint32
+}
+
+var _ interfaces.IDatabase = (*LSMDB)(nil)
+var _ interfaces.ISnapshotDatabase = (*LSMDB)(nil)
+
+// registryFamily holds the bucket name to family id mapping
+const registryFamily uint32 = 0
+
+var registryPrefix = []byte("cf:")
+
+func familyPrefix(family uint32) []byte {
+	prefix := make([]byte, 4)
+	binary.BigEndian.PutUint32(prefix, family)
+	return prefix
+}
+
+func familyKey(family uint32, key []byte) []byte {
+	return append(familyPrefix(family), key...)
+}
+
+// familyRange is the range of the keys of the family starting with the prefix
+func familyRange(family uint32, prefix []byte) *util.Range {
+	return util.BytesPrefix(familyKey(family, prefix))
+}
+
+func registryKey(bucket []byte) []byte {
+	return familyKey(registryFamily, append(append([]byte{}, registryPrefix...), bucket...))
+}
+
+func NewLSMDB(filename string, create bool) (*LSMDB, error) {
+	if create == true {
+		err := os.MkdirAll(filename, 0750)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		_, err := os.Stat(filename)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	opts := &opt.Options{
+		OpenFilesCacheCapacity: 50, // see NewLevelDB
+	}
+
+	tlDB, err := leveldb.OpenFile(filename, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	db := new(LSMDB)
+	db.lDB = tlDB
+	err = db.loadFamilies()
+	if err != nil {
+		tlDB.Close()
+		return nil, err
+	}
+	return db, nil
+}
+
+func (db *LSMDB) loadFamilies() error {
+	db.families = make(map[string]uint32)
+	db.nextFamily = registryFamily + 1
+
+	iter := db.lDB.NewIterator(familyRange(registryFamily, registryPrefix), db.ro)
+	defer iter.Release()
+	for iter.Next() {
+		name := string(iter.Key()[4+len(registryPrefix):])
+		if len(iter.Value()) != 4 {
+			return fmt.Errorf("malformed column family %q", name)
+		}
+		family := binary.BigEndian.Uint32(iter.Value())
+		db.families[name] = family
+		if family >= db.nextFamily {
+			db.nextFamily = family + 1
+		}
+	}
+	return iter.Error()
+}
+
+// family returns the id of the bucket's column family, if it has one
+func (db *LSMDB) family(bucket []byte) (uint32, bool) {
+	family, ok := db.families[string(bucket)]
+	return family, ok
+}
+
+// createFamily returns the id of the bucket's column family, adding the family to
+// the registry in the batch if it is new.  Must hold the write lock.
+func (db *LSMDB) createFamily(bucket []byte, batch *leveldb.Batch, created map[string]uint32) uint32 {
+	if family, ok := db.families[string(bucket)]; ok {
+		return family
+	}
+	if family, ok := created[string(bucket)]; ok {
+		return family
+	}
+	family := db.nextFamily
+	db.nextFamily++
+	created[string(bucket)] = family
+	batch.Put(registryKey(bucket), familyPrefix(family))
+	return family
+}
+
+// write writes the batch and registers the families it created
+func (db *LSMDB) write(batch *leveldb.Batch, created map[string]uint32) error {
+	err := db.lDB.Write(batch, db.wo)
+	if err != nil {
+		// The ids are not reused, a gap in them is harmless
+		return err
+	}
+	for name, family := range created {
+		db.families[name] = family
+	}
+	return nil
+}
+
+func (db *LSMDB) ListAllBuckets() ([][]byte, error) {
+	db.dbLock.RLock()
+	defer db.dbLock.RUnlock()
+
+	answer := [][]byte{}
+	for name := range db.families {
+		answer = append(answer, []byte(name))
+	}
+	sort.Slice(answer, func(i, j int) bool {
+		return bytes.Compare(answer[i], answer[j]) < 0
+	})
+	return answer, nil
+}
+
+// Can't trim a real database
+func (db *LSMDB) Trim() {
+	cache, _ := db.lDB.GetProperty("leveldb.cachedblock")
+	v, err := strconv.Atoi(cache)
+	if err == nil {
+		LSMDBCacheblock.Set(float64(v))
+	}
+}
+
+func (db *LSMDB) Close() error {
+	db.dbLock.Lock()
+	defer db.dbLock.Unlock()
+
+	return db.lDB.Close()
+}
+
+func (db *LSMDB) Get(bucket []byte, key []byte, destination interfaces.BinaryMarshallable) (interfaces.BinaryMarshallable, error) {
+	db.dbLock.RLock()
+	defer db.dbLock.RUnlock()
+
+	LSMDBGets.Inc()
+
+	family, ok := db.family(bucket)
+	if !ok {
+		return nil, nil
+	}
+	return get(db.lDB, db.ro, family, key, destination)
+}
+
+// getter is what the database and its snapshots have in common
+type getter interface {
+	Get(key []byte, ro *opt.ReadOptions) ([]byte, error)
+}
+
+func get(g getter, ro *opt.ReadOptions, family uint32, key []byte, destination interfaces.BinaryMarshallable) (interfaces.BinaryMarshallable, error) {
+	data, err := g.Get(familyKey(family, key), ro)
+	if err == leveldb.ErrNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = destination.UnmarshalBinaryData(data)
+	if err != nil {
+		return nil, err
+	}
+	return destination, nil
+}
+
+func (db *LSMDB) Put(bucket []byte, key []byte, data interfaces.BinaryMarshallable) error {
+	return db.PutInBatch([]interfaces.Record{{Bucket: bucket, Key: key, Data: data}})
+}
+
+func (db *LSMDB) PutInBatch(records []interfaces.Record) error {
+	db.dbLock.Lock()
+	defer db.dbLock.Unlock()
+
+	batch := new(leveldb.Batch)
+	created := map[string]uint32{}
+	for _, v := range records {
+		hex, err := v.Data.MarshalBinary()
+		if err != nil {
+			return err
+		}
+		family := db.createFamily(v.Bucket, batch, created)
+		batch.Put(familyKey(family, v.Key), hex)
+		LSMDBPuts.Inc()
+	}
+	return db.write(batch, created)
+}
+
+func (db *LSMDB) Delete(bucket []byte, key []byte) error {
+	db.dbLock.Lock()
+	defer db.dbLock.Unlock()
+
+	family, ok := db.family(bucket)
+	if !ok {
+		return nil
+	}
+	return db.lDB.Delete(familyKey(family, key), db.wo)
+}
+
+// Clear deletes every key of the bucket.  The bucket keeps its column family.
+func (db *LSMDB) Clear(bucket []byte) error {
+	return db.DeleteRange(bucket, nil, nil)
+}
+
+// DeleteRange deletes the keys of the bucket from start up to but not including
+// limit in a single write.  A nil start or limit leaves that end of the range open.
+func (db *LSMDB) DeleteRange(bucket []byte, start []byte, limit []byte) error {
+	db.dbLock.Lock()
+	defer db.dbLock.Unlock()
+
+	family, ok := db.family(bucket)
+	if !ok {
+		return nil
+	}
+	r := familyRange(family, nil)
+	if start != nil {
+		r.Start = familyKey(family, start)
+	}
+	if limit != nil {
+		r.Limit = familyKey(family, limit)
+	}
+
+	batch := new(leveldb.Batch)
+	iter := db.lDB.NewIterator(r, db.ro)
+	for iter.Next() {
+		batch.Delete(iter.Key())
+	}
+	iter.Release()
+	err := iter.Error()
+	if err != nil {
+		return err
+	}
+	return db.lDB.Write(batch, db.wo)
+}
+
+func (db *LSMDB) DoesKeyExist(bucket, key []byte) (bool, error) {
+	db.dbLock.RLock()
+	defer db.dbLock.RUnlock()
+
+	family, ok := db.family(bucket)
+	if !ok {
+		return false, nil
+	}
+	return db.lDB.Has(familyKey(family, key), db.ro)
+}
+
+func (db *LSMDB) ListAllKeys(bucket []byte) ([][]byte, error) {
+	var keys [][]byte
+	iter := db.NewIterator(bucket, nil)
+	for iter.Next() {
+		keys = append(keys, append([]byte{}, iter.Key()...))
+	}
+	iter.Release()
+	err := iter.Error()
+	if err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+func (db *LSMDB) GetAll(bucket []byte, sample interfaces.BinaryMarshallableAndCopyable) ([]interfaces.BinaryMarshallableAndCopyable, [][]byte, error) {
+	answer := []interfaces.BinaryMarshallableAndCopyable{}
+	keys := [][]byte{}
+	iter := db.NewIterator(bucket, nil)
+	defer iter.Release()
+	for iter.Next() {
+		tmp := sample.New()
+		err := tmp.UnmarshalBinary(append([]byte{}, iter.Value()...))
+		if err != nil {
+			return nil, nil, err
+		}
+		keys = append(keys, append([]byte{}, iter.Key()...))
+		answer = append(answer, tmp)
+	}
+	err := iter.Error()
+	if err != nil {
+		return nil, nil, err
+	}
+	return answer, keys, nil
+}
+
+// iterable is what the database and its snapshots have in common
+type iterable interface {
+	NewIterator(slice *util.Range, ro *opt.ReadOptions) iterator.Iterator
+}
+
+func newIterator(source iterable, ro *opt.ReadOptions, families map[string]uint32, bucket []byte, options *interfaces.IterateOptions) interfaces.IIterator {
+	if options == nil {
+		options = new(interfaces.IterateOptions)
+	}
+	family, ok := families[string(bucket)]
+	if !ok {
+		return ldb.NewIterator(iterator.NewEmptyIterator(nil), 4, nil, options)
+	}
+	var seek []byte
+	if options.Seek != nil {
+		seek = familyKey(family, options.Seek)
+	}
+	return ldb.NewIterator(source.NewIterator(familyRange(family, options.Prefix), ro), 4, seek, options)
+}
+
+func (db *LSMDB) NewIterator(bucket []byte, options *interfaces.IterateOptions) interfaces.IIterator {
+	db.dbLock.RLock()
+	defer db.dbLock.RUnlock()
+
+	return newIterator(db.lDB, db.ro, db.families, bucket, options)
+}
+
+// Snapshot is a consistent view of the database at the time it was taken.  It must
+// be released once it is no longer needed.
+type Snapshot struct {
+	snap     *leveldb.Snapshot
+	ro       *opt.ReadOptions
+	families map[string]uint32
+}
+
+// Snapshot takes a snapshot of the database
+func (db *LSMDB) Snapshot() (interfaces.IDatabaseSnapshot, error) {
+	db.dbLock.RLock()
+	defer db.dbLock.RUnlock()
+
+	snap, err := db.lDB.GetSnapshot()
+	if err != nil {
+		return nil, err
+	}
+	s := new(Snapshot)
+	s.snap = snap
+	s.ro = db.ro
+	s.families = make(map[string]uint32, len(db.families))
+	for name, family := range db.families {
+		s.families[name] = family
+	}
+	return s, nil
+}
+
+func (s *Snapshot) Get(bucket []byte, key []byte, destination interfaces.BinaryMarshallable) (interfaces.BinaryMarshallable, error) {
+	family, ok := s.families[string(bucket)]
+	if !ok {
+		return nil, nil
+	}
+	return get(s.snap, s.ro, family, key, destination)
+}
+
+// NewIterator is LSMDB.NewIterator as of the snapshot
+func (s *Snapshot) NewIterator(bucket []byte, options *interfaces.IterateOptions) interfaces.IIterator {
+	return newIterator(s.snap, s.ro, s.families, bucket, options)
+}
+
+func (s *Snapshot) Release() {
+	s.snap.Release()
+}
@@ -0,0 +1,227 @@
+// Copyright 2017 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package lsmdb_test
+
+import (
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/FactomProject/factomd/common/interfaces"
+	"github.com/FactomProject/factomd/common/primitives"
+	"github.com/FactomProject/factomd/database/databaseOverlay"
+	. "github.com/FactomProject/factomd/database/lsmdb"
+	"github.com/FactomProject/factomd/testHelper"
+)
+
+type TestData struct {
+	Str string
+}
+
+func (t *TestData) New() interfaces.BinaryMarshallableAndCopyable {
+	return new(TestData)
+}
+
+func (t *TestData) MarshalBinary() (rval []byte, err error) {
+	return []byte(t.Str), nil
+}
+
+func (t *TestData) UnmarshalBinaryData(data []byte) ([]byte, error) {
+	t.Str = string(data)
+	return nil, nil
+}
+
+func (t *TestData) UnmarshalBinary(data []byte) (err error) {
+	_, err = t.UnmarshalBinaryData(data)
+	return
+}
+
+var _ interfaces.BinaryMarshallable = (*TestData)(nil)
+
+var dbFilename string = "lsmTest.db"
+
+func CleanupTest(t *testing.T, b interfaces.IDatabase) {
+	err := b.Close()
+	if err != nil {
+		t.Errorf("%v", err)
+	}
+	err = os.RemoveAll(dbFilename)
+	if err != nil {
+		t.Errorf("%v", err)
+	}
+}
+
+func putKeys(t *testing.T, m *LSMDB, bucket []byte, n int) {
+	batch := []interfaces.Record{}
+	for i := 0; i < n; i++ {
+		r := interfaces.Record{}
+		r.Key = []byte(fmt.Sprintf("%02d", i))
+		r.Bucket = bucket
+		r.Data = &TestData{Str: fmt.Sprintf("Data %v", i)}
+		batch = append(batch, r)
+	}
+	err := m.PutInBatch(batch)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestColumnFamilies(t *testing.T) {
+	m, err := NewLSMDB(dbFilename, true)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	defer func() { CleanupTest(t, m) }()
+
+	// With a separator these would share keys
+	putKeys(t, m, []byte("a"), 10)
+	putKeys(t, m, []byte("a;"), 5)
+
+	keys, err := m.ListAllKeys([]byte("a"))
+	if err != nil {
+		t.Error(err)
+	}
+	if len(keys) != 10 {
+		t.Errorf("Invalid length of keys - %v vs %v", len(keys), 10)
+	}
+	err = m.Clear([]byte("a"))
+	if err != nil {
+		t.Error(err)
+	}
+	keys, _ = m.ListAllKeys([]byte("a;"))
+	if len(keys) != 5 {
+		t.Errorf("Clearing a bucket cleared another")
+	}
+
+	buckets, err := m.ListAllBuckets()
+	if err != nil {
+		t.Error(err)
+	}
+	if len(buckets) != 2 || string(buckets[0]) != "a" || string(buckets[1]) != "a;" {
+		t.Errorf("Wrong buckets %q", buckets)
+	}
+
+	// The families survive a restart
+	err = m.Close()
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	m, err = NewLSMDB(dbFilename, false)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	putKeys(t, m, []byte("b"), 1)
+	buckets, _ = m.ListAllBuckets()
+	if len(buckets) != 3 {
+		t.Errorf("Wrong buckets %q", buckets)
+	}
+	keys, _ = m.ListAllKeys([]byte("a;"))
+	if len(keys) != 5 {
+		t.Errorf("Lost keys on restart")
+	}
+	keys, _ = m.ListAllKeys([]byte("b"))
+	if len(keys) != 1 {
+		t.Errorf("New family shares keys with an old one")
+	}
+}
+
+func TestPrefixAndDeleteRange(t *testing.T) {
+	m, err := NewLSMDB(dbFilename, true)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	defer CleanupTest(t, m)
+
+	bucket := []byte("bucket")
+	putKeys(t, m, bucket, 30)
+
+	var keys []string
+	iter := m.NewIterator(bucket, &interfaces.IterateOptions{Prefix: []byte("1")})
+	for iter.Next() {
+		keys = append(keys, string(iter.Key()))
+	}
+	iter.Release()
+	if err = iter.Error(); err != nil {
+		t.Error(err)
+	}
+	if len(keys) != 10 || keys[0] != "10" || keys[9] != "19" {
+		t.Errorf("Wrong keys for the prefix %v", keys)
+	}
+
+	err = m.DeleteRange(bucket, []byte("05"), []byte("25"))
+	if err != nil {
+		t.Error(err)
+	}
+	all, keyList, err := m.GetAll(bucket, new(TestData))
+	if err != nil {
+		t.Error(err)
+	}
+	if len(all) != 10 || string(keyList[4]) != "04" || string(keyList[5]) != "25" {
+		t.Errorf("Wrong keys left %q", keyList)
+	}
+}
+
+func TestSnapshot(t *testing.T) {
+	m, err := NewLSMDB(dbFilename, true)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	defer CleanupTest(t, m)
+
+	bucket := []byte("bucket")
+	key := []byte("key")
+	m.Put(bucket, key, &TestData{Str: "before"})
+
+	snap, err := m.Snapshot()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer snap.Release()
+
+	m.Put(bucket, key, &TestData{Str: "after"})
+	m.Put(bucket, []byte("other"), &TestData{Str: "after"})
+	m.Put([]byte("new"), key, &TestData{Str: "after"})
+
+	resp, err := snap.Get(bucket, key, new(TestData))
+	if err != nil || resp == nil || resp.(*TestData).Str != "before" {
+		t.Errorf("Snapshot sees a later write %v %v", resp, err)
+	}
+	resp, _ = m.Get(bucket, key, new(TestData))
+	if resp == nil || resp.(*TestData).Str != "after" {
+		t.Errorf("Database misses a write %v", resp)
+	}
+	if resp, _ = snap.Get([]byte("new"), key, new(TestData)); resp != nil {
+		t.Errorf("Snapshot sees a later bucket")
+	}
+
+	count := 0
+	iter := snap.NewIterator(bucket, nil)
+	for iter.Next() {
+		count++
+	}
+	iter.Release()
+	if count != 1 {
+		t.Errorf("Snapshot iterates over %d keys", count)
+	}
+}
+
+func TestGetAll(t *testing.T) {
+	m, err := NewLSMDB(dbFilename, true)
+	if err != nil {
+		t.Fatalf("%v", err)
+	}
+	defer CleanupTest(t, m)
+
+	dbo := databaseOverlay.NewOverlay(m)
+	testHelper.PopulateTestDatabaseOverlay(dbo)
+
+	_, keys, err := dbo.GetAll(databaseOverlay.INCLUDED_IN, primitives.NewZeroHash())
+	if err != nil {
+		t.Errorf("%v", err)
+	}
+	if len(keys) != 150 {
+		t.Errorf("Invalid amount of keys returned - expected 150, got %v", len(keys))
+	}
+}
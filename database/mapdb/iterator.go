@@ -0,0 +1,100 @@
+// Copyright 2017 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package mapdb
+
+import (
+	"bytes"
+	"sort"
+
+	"github.com/FactomProject/factomd/common/interfaces"
+	"github.com/FactomProject/factomd/util"
+)
+
+// Iterator walks over the keys the bucket had when the iterator was made.  The
+// values are read as it goes, so keys deleted since are skipped.
+type Iterator struct {
+	db     *MapDB
+	bucket string
+	keys   [][]byte
+	pos    int
+	value  []byte
+	limit  int
+	count  int
+}
+
+var _ interfaces.IIterator = (*Iterator)(nil)
+
+func (db *MapDB) NewIterator(bucket []byte, options *interfaces.IterateOptions) interfaces.IIterator {
+	if options == nil {
+		options = new(interfaces.IterateOptions)
+	}
+	it := new(Iterator)
+	it.db = db
+	it.bucket = string(bucket)
+	it.pos = -1
+
+	db.Sem.RLock()
+	for k := range db.Cache[it.bucket] {
+		key := []byte(k)
+		if !bytes.HasPrefix(key, options.Prefix) {
+			continue
+		}
+		if options.Seek != nil {
+			c := bytes.Compare(key, options.Seek)
+			if (c < 0 && !options.Reverse) || (c > 0 && options.Reverse) {
+				continue
+			}
+		}
+		it.keys = append(it.keys, key)
+	}
+	db.Sem.RUnlock()
+
+	if options.Reverse {
+		sort.Sort(sort.Reverse(util.ByByteArray(it.keys)))
+	} else {
+		sort.Sort(util.ByByteArray(it.keys))
+	}
+	it.limit = options.Limit
+	return it
+}
+
+func (it *Iterator) Next() bool {
+	if it.limit > 0 && it.count >= it.limit {
+		return false
+	}
+	it.db.Sem.RLock()
+	defer it.db.Sem.RUnlock()
+
+	for it.pos++; it.pos < len(it.keys); it.pos++ {
+		value, ok := it.db.Cache[it.bucket][string(it.keys[it.pos])]
+		if ok && value != nil {
+			it.value = value
+			it.count++
+			return true
+		}
+	}
+	it.value = nil
+	return false
+}
+
+func (it *Iterator) Key() []byte {
+	if it.pos < 0 || it.pos >= len(it.keys) {
+		return nil
+	}
+	return it.keys[it.pos]
+}
+
+func (it *Iterator) Value() []byte {
+	return it.value
+}
+
+func (it *Iterator) Release() {
+	it.keys = nil
+	it.value = nil
+}
+
+func (it *Iterator) Error() error {
+	return nil
+}
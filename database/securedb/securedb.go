@@ -13,6 +13,7 @@ import (
 	"github.com/FactomProject/factomd/common/primitives"
 	"github.com/FactomProject/factomd/database/boltdb"
 	"github.com/FactomProject/factomd/database/leveldb"
+	"github.com/FactomProject/factomd/database/lsmdb"
 	"github.com/FactomProject/factomd/database/mapdb"
 )
 
@@ -207,13 +208,68 @@ func (db *EncryptedDB) Init(filename string, dbtype string) {
 		if err != nil {
 			panic(err)
 		}
+	case "LSM":
+		db.db, err = lsmdb.NewLSMDB(filename, true)
+		if err != nil {
+			panic(err)
+		}
 	case "Bolt":
 		db.db = boltdb.NewBoltDB(nil, filename)
 	default:
-		panic(fmt.Sprintf("%s is not a valid option. Expect 'Map', 'LDB', or 'Bolt'", dbtype))
+		panic(fmt.Sprintf("%s is not a valid option. Expect 'Map', 'LDB', 'LSM', or 'Bolt'", dbtype))
 	}
 }
 
 func (db *EncryptedDB) DoesKeyExist(bucket, key []byte) (bool, error) {
 	return db.db.DoesKeyExist(bucket, key)
 }
+
+func (db *EncryptedDB) NewIterator(bucket []byte, options *interfaces.IterateOptions) interfaces.IIterator {
+	it := new(Iterator)
+	it.iter = db.db.NewIterator(bucket, options)
+	it.encryptionkey = db.encryptionkey
+	return it
+}
+
+// Iterator decrypts the values of the underlying iterator
+type Iterator struct {
+	iter          interfaces.IIterator
+	encryptionkey []byte
+	value         []byte
+	err           error
+}
+
+var _ interfaces.IIterator = (*Iterator)(nil)
+
+func (it *Iterator) Next() bool {
+	it.value = nil
+	if it.err != nil || !it.iter.Next() {
+		return false
+	}
+	plain := new(primitives.ByteSlice)
+	it.err = NewEncryptedMarshaler(it.encryptionkey, plain).UnmarshalBinary(it.iter.Value())
+	if it.err != nil {
+		return false
+	}
+	it.value = plain.Bytes
+	return true
+}
+
+func (it *Iterator) Key() []byte {
+	return it.iter.Key()
+}
+
+func (it *Iterator) Value() []byte {
+	return it.value
+}
+
+func (it *Iterator) Release() {
+	it.iter.Release()
+}
+
+func (it *Iterator) Error() error {
+	if it.err != nil {
+		return it.err
+	}
+	return it.iter.Error()
+}
@@ -7,14 +7,18 @@ package database_test
 import (
 	"fmt"
 	"os"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/FactomProject/factomd/common/interfaces"
 	"github.com/FactomProject/factomd/common/primitives"
 	"github.com/FactomProject/factomd/common/primitives/random"
 	"github.com/FactomProject/factomd/database/boltdb"
 	"github.com/FactomProject/factomd/database/databaseOverlay"
+	"github.com/FactomProject/factomd/database/hybridDB"
 	"github.com/FactomProject/factomd/database/leveldb"
+	"github.com/FactomProject/factomd/database/lsmdb"
 	"github.com/FactomProject/factomd/database/mapdb"
 	"github.com/FactomProject/factomd/database/securedb"
 	"github.com/FactomProject/factomd/testHelper"
@@ -72,6 +76,16 @@ func TestAllDatabases(t *testing.T) {
 		CleanupTest(t, m)
 	}
 
+	// Secure LSM
+	for i := 0; i < 5; i++ {
+		m, err := securedb.NewEncryptedDB(dbFilename, "LSM", random.RandomString())
+		if err != nil {
+			t.Error(err)
+		}
+		testDB(t, m, i)
+		CleanupTest(t, m)
+	}
+
 	// Secure Map
 	for i := 0; i < 5; i++ {
 		m, err := securedb.NewEncryptedDB(dbFilename, "Map", random.RandomString())
@@ -99,6 +113,16 @@ func TestAllDatabases(t *testing.T) {
 		CleanupTest(t, m)
 	}
 
+	// LSM
+	for i := 0; i < 5; i++ {
+		m, err := lsmdb.NewLSMDB(dbFilename, true)
+		if err != nil {
+			t.Error(err)
+		}
+		testDB(t, m, i)
+		CleanupTest(t, m)
+	}
+
 	// Map
 	for i := 0; i < 5; i++ {
 		m := new(mapdb.MapDB)
@@ -117,6 +141,8 @@ func testDB(t *testing.T, m interfaces.IDatabase, i int) {
 		testDoesKeyExist(t, m)
 	case 3:
 		testGetAll(t, m)
+	case 4:
+		testIterator(t, m)
 	}
 }
 
@@ -291,3 +317,160 @@ func testGetAll(t *testing.T, m interfaces.IDatabase) {
 		}
 	}
 }
+
+func iteratedKeys(t *testing.T, m interfaces.IDatabase, bucket []byte, options *interfaces.IterateOptions) string {
+	keys := ""
+	iter := m.NewIterator(bucket, options)
+	for iter.Next() {
+		// The database can be read while iterating
+		resp, err := m.Get(bucket, iter.Key(), new(TestData))
+		if err != nil || resp == nil || resp.(*TestData).Str != string(iter.Value()) {
+			t.Errorf("Value of %s is %q, database has %v %v", iter.Key(), iter.Value(), resp, err)
+		}
+		keys += string(iter.Key()) + " "
+	}
+	iter.Release()
+	if err := iter.Error(); err != nil {
+		t.Error(err)
+	}
+	return keys
+}
+
+func testIterator(t *testing.T, m interfaces.IDatabase) {
+	defer CleanupTest(t, m)
+
+	// Read the bolt database a few keys at a time
+	defer func(size int) { boltdb.IteratorPageSize = size }(boltdb.IteratorPageSize)
+	boltdb.IteratorPageSize = 3
+
+	bucket := []byte("bucket")
+	batch := []interfaces.Record{}
+	for i := 10; i < 40; i += 2 {
+		key := fmt.Sprintf("%v", i)
+		batch = append(batch, interfaces.Record{Bucket: bucket, Key: []byte(key), Data: &TestData{Str: "Data " + key}})
+	}
+	batch = append(batch, interfaces.Record{Bucket: []byte("bucket2"), Key: []byte("20"), Data: &TestData{Str: "other"}})
+	err := m.PutInBatch(batch)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		options  *interfaces.IterateOptions
+		expected string
+	}{
+		{nil, "10 12 14 16 18 20 22 24 26 28 30 32 34 36 38 "},
+		{&interfaces.IterateOptions{Prefix: []byte("2")}, "20 22 24 26 28 "},
+		{&interfaces.IterateOptions{Seek: []byte("25")}, "26 28 30 32 34 36 38 "},
+		{&interfaces.IterateOptions{Prefix: []byte("2"), Seek: []byte("24")}, "24 26 28 "},
+		{&interfaces.IterateOptions{Prefix: []byte("2"), Seek: []byte("1")}, "20 22 24 26 28 "},
+		{&interfaces.IterateOptions{Prefix: []byte("2"), Seek: []byte("3")}, ""},
+		{&interfaces.IterateOptions{Limit: 4}, "10 12 14 16 "},
+		{&interfaces.IterateOptions{Reverse: true, Limit: 4}, "38 36 34 32 "},
+		{&interfaces.IterateOptions{Reverse: true, Seek: []byte("25")}, "24 22 20 18 16 14 12 10 "},
+		{&interfaces.IterateOptions{Reverse: true, Seek: []byte("24")}, "24 22 20 18 16 14 12 10 "},
+		{&interfaces.IterateOptions{Reverse: true, Prefix: []byte("2")}, "28 26 24 22 20 "},
+		{&interfaces.IterateOptions{Reverse: true, Prefix: []byte("2"), Seek: []byte("9")}, "28 26 24 22 20 "},
+		{&interfaces.IterateOptions{Reverse: true, Prefix: []byte("2"), Seek: []byte("23"), Limit: 2}, "22 20 "},
+		{&interfaces.IterateOptions{Reverse: true, Prefix: []byte("2"), Seek: []byte("1")}, ""},
+		{&interfaces.IterateOptions{Prefix: []byte("4")}, ""},
+	}
+	for i, test := range tests {
+		keys := iteratedKeys(t, m, bucket, test.options)
+		if keys != test.expected {
+			t.Errorf("Test %v iterated over %q, expected %q", i, keys, test.expected)
+		}
+	}
+
+	if keys := iteratedKeys(t, m, []byte("nobucket"), nil); keys != "" {
+		t.Errorf("Iterated over %q in a missing bucket", keys)
+	}
+}
+
+func TestSnapshots(t *testing.T) {
+	level, err := leveldb.NewLevelDB(dbFilename, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	testSnapshot(t, level, true)
+
+	lsm, err := lsmdb.NewLSMDB(dbFilename, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	testSnapshot(t, lsm, true)
+
+	hybrid, err := hybridDB.NewLevelMapHybridDB(dbFilename, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	testSnapshot(t, hybrid, true)
+
+	// Bolt only holds up a write that has to grow its memory map, so leave room
+	bolt := boltdb.NewBoltDB(nil, dbFilename)
+	filler := []byte("filler")
+	for i := 0; i < 200; i++ {
+		bolt.Put(filler, []byte(fmt.Sprintf("%v", i)), &TestData{Str: strings.Repeat("x", 1000)})
+	}
+	bolt.Clear(filler)
+	testSnapshot(t, bolt, true)
+
+	// Read as it changes
+	testSnapshot(t, new(mapdb.MapDB), false)
+}
+
+func testSnapshot(t *testing.T, m interfaces.IDatabase, isolated bool) {
+	defer CleanupTest(t, m)
+
+	bucket := []byte("bucket")
+	key := []byte("key")
+	m.Put(bucket, key, &TestData{Str: "before"})
+
+	snap, err := databaseOverlay.NewOverlay(m).Snapshot()
+	if err != nil {
+		t.Fatalf("%T %v", m, err)
+	}
+
+	written := make(chan struct{})
+	go func() {
+		m.Put(bucket, key, &TestData{Str: "after"})
+		m.Put(bucket, []byte("other"), &TestData{Str: "after"})
+		close(written)
+	}()
+	// Writes go on while the snapshot is open
+	select {
+	case <-written:
+	case <-time.After(5 * time.Second):
+		t.Errorf("%T writes waited for a snapshot", m)
+		<-written
+	}
+
+	expected, count := "before", 1
+	if !isolated {
+		expected, count = "after", 2
+	}
+	overlay := snap.(*databaseOverlay.Overlay)
+	resp, err := overlay.Get(bucket, key, new(TestData))
+	if err != nil || resp == nil || resp.(*TestData).Str != expected {
+		t.Errorf("%T snapshot read %v %v, expected %v", m, resp, err, expected)
+	}
+	keys, err := overlay.ListAllKeys(bucket)
+	if err != nil || len(keys) != count {
+		t.Errorf("%T snapshot lists %q %v", m, keys, err)
+	}
+
+	err = overlay.Put(bucket, key, &TestData{Str: "snapshot"})
+	if err == nil {
+		t.Errorf("%T snapshot took a write", m)
+	}
+
+	// Closing the snapshot leaves the database open
+	err = snap.Close()
+	if err != nil {
+		t.Error(err)
+	}
+	resp, err = m.Get(bucket, key, new(TestData))
+	if err != nil || resp == nil || resp.(*TestData).Str != "after" {
+		t.Errorf("%T database read %v %v after closing a snapshot", m, resp, err)
+	}
+}
@@ -12,6 +12,7 @@ import (
 	"io/ioutil"
 	"math"
 	"os"
+	"path/filepath"
 	"strings"
 	"time"
 
@@ -22,6 +23,7 @@ import (
 	"github.com/FactomProject/factomd/common/primitives"
 	"github.com/FactomProject/factomd/controlPanel"
 	"github.com/FactomProject/factomd/database/leveldb"
+	"github.com/FactomProject/factomd/database/lsmdb"
 	"github.com/FactomProject/factomd/p2p"
 	"github.com/FactomProject/factomd/state"
 	"github.com/FactomProject/factomd/util"
@@ -79,6 +81,8 @@ func NetStart(s *state.State, p *FactomParams, listenToStdin bool) {
 	s.TimeOffset = primitives.NewTimestampFromMilliseconds(uint64(p.TimeOffset))
 	s.StartDelayLimit = p.StartDelay * 1000
 	s.Journaling = p.Journaling
+	s.JournalMaxSize = p.JournalMaxSize
+	s.JournalMaxFiles = p.JournalMaxFiles
 	s.FactomdVersion = FactomdVersion
 	s.EFactory = new(electionMsgs.ElectionsFactory)
 
@@ -207,7 +211,9 @@ func NetStart(s *state.State, p *FactomParams, listenToStdin bool) {
 		}
 	}
 	if p.Follower {
-		s.NodeMode = "FULL"
+		if s.NodeMode != "PRUNED" {
+			s.NodeMode = "FULL"
+		}
 		leadID := primitives.Sha([]byte(s.Prefix + "FNode0"))
 		if s.IdentityChainID.IsSameAs(leadID) {
 			s.SetIdentityChainID(primitives.Sha([]byte(time.Now().String()))) // Make sure this node is NOT a leader
@@ -275,6 +281,8 @@ func NetStart(s *state.State, p *FactomParams, listenToStdin bool) {
 	os.Stderr.WriteString(fmt.Sprintf("%20s \"%s\"\n", "peers", p.Peers))
 	os.Stderr.WriteString(fmt.Sprintf("%20s \"%t\"\n", "exclusive", p.Exclusive))
 	os.Stderr.WriteString(fmt.Sprintf("%20s \"%t\"\n", "exclusive_in", p.ExclusiveIn))
+	os.Stderr.WriteString(fmt.Sprintf("%20s \"%t\"\n", "encryptp2p", p.EncryptP2P))
+	os.Stderr.WriteString(fmt.Sprintf("%20s \"%t\"\n", "rejectplaintext", p.RejectPlaintextPeers))
 	os.Stderr.WriteString(fmt.Sprintf("%20s %d\n", "block time", p.BlkTime))
 	//os.Stderr.WriteString(fmt.Sprintf("%20s %d\n", "faultTimeout", p.FaultTimeout)) // TODO old fault timeout mechanism to be removed
 	os.Stderr.WriteString(fmt.Sprintf("%20s %v\n", "runtimeLog", p.RuntimeLog))
@@ -373,6 +381,11 @@ func NetStart(s *state.State, p *FactomParams, listenToStdin bool) {
 			networkPort = fmt.Sprintf("%d", p.NetworkPortOverride)
 		}
 
+		banDuration, err := time.ParseDuration(s.PeerBanDuration)
+		if err != nil {
+			panic("Invalid PeerBanDuration in the config file: " + err.Error())
+		}
+
 		ci := p2p.ControllerInit{
 			NodeName:                 nodeName,
 			Port:                     networkPort,
@@ -380,6 +393,12 @@ func NetStart(s *state.State, p *FactomParams, listenToStdin bool) {
 			Network:                  networkID,
 			Exclusive:                p.Exclusive,
 			ExclusiveIn:              p.ExclusiveIn,
+			Encrypted:                p.EncryptP2P,
+			RejectPlaintext:          p.RejectPlaintextPeers,
+			NodeKeyFile:              strings.TrimSuffix(s.PeersFile, filepath.Ext(s.PeersFile)) + ".nodekey",
+			BansFile:                 strings.TrimSuffix(s.PeersFile, filepath.Ext(s.PeersFile)) + ".bans",
+			BanDuration:              banDuration,
+			Penalties:                s.PeerPenalties,
 			SeedURL:                  seedURL,
 			ConfigPeers:              configPeers,
 			CmdLinePeers:             p.Peers,
@@ -528,6 +547,12 @@ func NetStart(s *state.State, p *FactomParams, listenToStdin bool) {
 	} else {
 		fnodes[0].State.SetUseTorrent(false)
 	}
+	if p.Indexer != "" {
+		_, err := LaunchIndexerPlugin(p.PluginPath, p.Indexer, fnodes[0].State)
+		if err != nil {
+			panic("Encountered an error while trying to launch the indexer plugin: " + err.Error())
+		}
+	}
 
 	if p.Journal != "" {
 		go LoadJournal(s, p.Journal)
@@ -545,6 +570,7 @@ func NetStart(s *state.State, p *FactomParams, listenToStdin bool) {
 	state.RegisterPrometheus()
 	p2p.RegisterPrometheus()
 	leveldb.RegisterPrometheus()
+	lsmdb.RegisterPrometheus()
 	RegisterPrometheus()
 
 	go controlPanel.ServeControlPanel(fnodes[0].State.ControlPanelChannel, fnodes[0].State, connectionMetricsChannel, p2pNetwork, Build)
@@ -14,6 +14,7 @@ import (
 	"github.com/FactomProject/factomd/common/messages"
 	"github.com/FactomProject/factomd/common/primitives"
 	"github.com/FactomProject/factomd/log"
+	"github.com/FactomProject/factomd/p2p"
 )
 
 var _ = log.Printf
@@ -411,14 +412,13 @@ func NetworkOutputs(fnode *FactomNode) {
 func InvalidOutputs(fnode *FactomNode) {
 	for {
 		time.Sleep(1 * time.Millisecond)
-		_ = <-fnode.State.NetworkInvalidMsgQueue()
+		invalidMsg := <-fnode.State.NetworkInvalidMsgQueue()
 		//fmt.Println(invalidMsg)
 
-		// The following code was giving a demerit for each instance of a message in the NetworkInvalidMsgQueue.
-		// However the consensus system is not properly limiting the messages going into this queue to be ones
-		//  indicating an attack.  So the demerits are turned off for now.
-		// if len(invalidMsg.GetNetworkOrigin()) > 0 {
-		// 	p2pNetwork.AdjustPeerQuality(invalidMsg.GetNetworkOrigin(), -2)
-		// }
+		// The consensus system is not properly limiting the messages going into this queue to be ones
+		// indicating an attack, so the "invalid" penalty is 0 unless set in the PeerPenalties config.
+		if network := fnode.State.NetworkController; network != nil && len(invalidMsg.GetNetworkOrigin()) > 0 {
+			network.ReportMisbehavior(invalidMsg.GetNetworkOrigin(), p2p.MisbehaviorInvalidMessage)
+		}
 	}
 }
@@ -13,6 +13,7 @@ import (
 	"github.com/FactomProject/factomd/common/constants"
 	"github.com/FactomProject/factomd/common/interfaces"
 	"github.com/FactomProject/factomd/common/messages/msgsupport"
+	"github.com/FactomProject/factomd/util/atomic"
 )
 
 var _ = fmt.Print
@@ -44,6 +45,8 @@ type SimPeer struct {
 
 	RateOut int // Rate of Bytes output per ms
 	RateIn  int // Rate of Bytes input per ms
+
+	cut atomic.AtomicBool // Partitioned away, everything sent is dropped
 }
 
 var _ interfaces.IPeer = (*SimPeer)(nil)
@@ -114,7 +117,19 @@ func (f *SimPeer) computeBandwidth() {
 	f.Last = now
 }
 
+// SetCut partitions the connection away (or heals it), dropping everything sent meanwhile
+func (f *SimPeer) SetCut(cut bool) {
+	f.cut.Store(cut)
+}
+
+func (f *SimPeer) IsCut() bool {
+	return f.cut.Load()
+}
+
 func (f *SimPeer) Send(msg interfaces.IMsg) error {
+	if f.cut.Load() {
+		return nil
+	}
 	data, err := msg.MarshalBinary()
 	f.bytesOut += len(data)
 	f.computeBandwidth()
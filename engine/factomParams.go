@@ -33,9 +33,12 @@ func ParseCmdLine(args []string) *FactomParams {
 	dropPtr := flag.Int("drop", 0, "Number of messages to drop out of every thousand")
 	journalPtr := flag.String("journal", "", "Rerun a Journal of messages")
 	journalingPtr := flag.Bool("journaling", false, "Write a journal of all messages received. Default is off.")
+	journalSizePtr := flag.Int("journalsize", 100, "Megabytes of messages in a journal file before it is rotated, 0 for no limit")
+	journalFilesPtr := flag.Int("journalfiles", 5, "Number of journal files kept, including the current one")
+	journalSpeedPtr := flag.Float64("journalspeed", 1, "Speed to replay a journal at, 1 is the original timing, 0 as fast as possible")
 	followerPtr := flag.Bool("follower", false, "If true, force node to be a follower.  Only used when replaying a journal.")
 	leaderPtr := flag.Bool("leader", true, "If true, force node to be a leader.  Only used when replaying a journal.")
-	dbPtr := flag.String("db", "", "Override the Database in the Config file and use this Database implementation. Options Map, LDB, or Bolt")
+	dbPtr := flag.String("db", "", "Override the Database in the Config file and use this Database implementation. Options Map, LDB, Bolt, or LSM")
 	cloneDBPtr := flag.String("clonedb", "", "Override the main node and use this database for the clones in a Network.")
 	networkNamePtr := flag.String("network", "", "Network to join: MAIN, TEST or LOCAL")
 	peersPtr := flag.String("peers", "", "Array of peer addresses. ")
@@ -43,6 +46,8 @@ func ParseCmdLine(args []string) *FactomParams {
 	runtimeLogPtr := flag.Bool("runtimeLog", false, "If true, maintain runtime logs of messages passed.")
 	exclusivePtr := flag.Bool("exclusive", false, "If true, we only dial out to special/trusted peers.")
 	exclusiveInPtr := flag.Bool("exclusive_in", false, "If true, we only dial out to special/trusted peers and no incoming connections are accepted.")
+	encryptP2PPtr := flag.Bool("encryptp2p", false, "If true, handshake with peers and encrypt the p2p connections.")
+	rejectPlaintextPtr := flag.Bool("rejectplaintext", false, "If true (with encryptp2p), refuse peers that do not handshake instead of falling back to plaintext.")
 	PrefixNodePtr := flag.String("prefix", "", "Prefix the Factom Node Names with this value; used to create leaderless networks.")
 	RotatePtr := flag.Bool("rotate", false, "If true, responsibility is owned by one leader, and Rotated over the leaders.")
 	TimeOffsetPtr := flag.Int("timedelta", 0, "Maximum timeDelta in milliseconds to offset each node.  Simulates deltas in system clocks over a network.")
@@ -78,6 +83,9 @@ func ParseCmdLine(args []string) *FactomParams {
 	tormanager := flag.Bool("tormanage", false, "Use torrent dbstate manager. Must have plugin binary installed and in $PATH")
 	TorUploader := flag.Bool("torupload", false, "Be a torrent uploader")
 
+	// 	Indexer Plugin
+	indexer := flag.String("indexer", "", "Name of an indexer plugin binary in the plugin path, that is sent every saved block")
+
 	// Logstash connection (if used)
 	logstash := flag.Bool("logstash", false, "If true, use Logstash")
 	LogstashURL := flag.String("logurl", "localhost:8345", "Endpoint URL for Logstash")
@@ -109,6 +117,9 @@ func ParseCmdLine(args []string) *FactomParams {
 	p.DropRate = *dropPtr
 	p.Journal = *journalPtr
 	p.Journaling = *journalingPtr
+	p.JournalMaxSize = int64(*journalSizePtr) * 1024 * 1024
+	p.JournalMaxFiles = *journalFilesPtr
+	p.JournalSpeed = *journalSpeedPtr
 	p.Follower = *followerPtr
 	p.Leader = *leaderPtr
 	p.Db = *dbPtr
@@ -123,6 +134,8 @@ func ParseCmdLine(args []string) *FactomParams {
 	p.RuntimeLog = *runtimeLogPtr
 	p.Exclusive = *exclusivePtr
 	p.ExclusiveIn = *exclusiveInPtr
+	p.EncryptP2P = *encryptP2PPtr
+	p.RejectPlaintextPeers = *rejectPlaintextPtr
 	p.Prefix = *PrefixNodePtr
 	p.Rotate = *RotatePtr
 	p.TimeOffset = *TimeOffsetPtr
@@ -146,6 +159,7 @@ func ParseCmdLine(args []string) *FactomParams {
 	p.PluginPath = *PluginPath
 	p.TorManage = *tormanager
 	p.TorUpload = *TorUploader
+	p.Indexer = *indexer
 
 	p.UseLogstash = *logstash
 	p.LogstashURL = *LogstashURL
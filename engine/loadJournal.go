@@ -13,10 +13,14 @@ import (
 	"time"
 
 	"github.com/FactomProject/factomd/common/constants"
+	"github.com/FactomProject/factomd/common/globals"
 	"github.com/FactomProject/factomd/common/interfaces"
 	"github.com/FactomProject/factomd/common/messages/msgsupport"
+	"github.com/FactomProject/factomd/state"
 )
 
+// LoadJournal replays a journal into the state.  A binary journal is replayed
+// with its rotated files before it, at the speed of globals.Params.JournalSpeed.
 func LoadJournal(s interfaces.IState, journal string) {
 	f, err := os.Open(journal)
 	if err != nil {
@@ -26,9 +30,67 @@ func LoadJournal(s interfaces.IState, journal string) {
 	defer f.Close()
 	r := bufio.NewReaderSize(f, 4*1024)
 
+	if state.IsBinaryJournal(r) {
+		time.Sleep(time.Second * 5) // Let the node start first, like LoadJournalFromReader
+		if err := ReplayJournalFiles(s, state.JournalFiles(journal), globals.Params.JournalSpeed); err != nil {
+			fmt.Println(err)
+		}
+		return
+	}
 	LoadJournalFromReader(s, r)
 }
 
+// ReplayJournalFiles replays the binary journal files in order, see ReplayJournal
+func ReplayJournalFiles(s interfaces.IState, files []string, speed float64) error {
+	s.SetIsReplaying()
+	defer s.SetIsDoneReplaying()
+
+	var records []*state.JournalRecord
+	for _, file := range files {
+		fileRecords, err := state.ReadJournalFile(file)
+		records = append(records, fileRecords...)
+		if err != nil {
+			return fmt.Errorf("%s: %v", file, err)
+		}
+	}
+	fmt.Println("Replaying Journal of", len(records), "messages")
+	return ReplayJournal(s, records, speed)
+}
+
+// ReplayJournal feeds the messages of the records to the state in order, with
+// the peers and VM indexes they were received with.  The messages are spaced as
+// they were received, sped up by speed; a speed of 0 replays them as fast as the
+// state takes them.
+func ReplayJournal(s interfaces.IState, records []*state.JournalRecord, speed float64) error {
+	if len(records) == 0 {
+		return nil
+	}
+	first := records[0].Received
+	start := time.Now()
+	for i, record := range records {
+		msg, err := msgsupport.UnmarshalMessage(record.Message)
+		if err != nil {
+			return fmt.Errorf("journal record %d: %v", i, err)
+		}
+		msg.SetNetworkOrigin(record.Peer)
+		msg.SetVMIndex(record.VMIndex)
+
+		if speed > 0 {
+			due := start.Add(time.Duration(float64(record.Received.Sub(first)) / speed))
+			if wait := time.Until(due); wait > 0 {
+				time.Sleep(wait)
+			}
+		}
+		s.InMsgQueue().Enqueue(msg)
+		if speed <= 0 && s.InMsgQueue().Length() > constants.INMSGQUEUE_MED {
+			for s.InMsgQueue().Length() > constants.INMSGQUEUE_LOW {
+				time.Sleep(time.Millisecond * 10)
+			}
+		}
+	}
+	return nil
+}
+
 func LoadJournalFromString(s interfaces.IState, journalStr string) {
 	f := strings.NewReader(journalStr)
 	r := bufio.NewReaderSize(f, 4*1024)
@@ -0,0 +1,61 @@
+package engine_test
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/FactomProject/factomd/common/messages"
+	"github.com/FactomProject/factomd/common/primitives"
+	. "github.com/FactomProject/factomd/engine"
+	"github.com/FactomProject/factomd/state"
+	"github.com/FactomProject/factomd/testHelper"
+)
+
+func TestReplayJournal(t *testing.T) {
+	start := time.Now()
+	var records []*state.JournalRecord
+	for i := 0; i < 5; i++ {
+		msg := new(messages.Bounce)
+		msg.Name = fmt.Sprintf("msg%d", i)
+		msg.Timestamp = primitives.NewTimestampNow()
+		data, err := msg.MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+		records = append(records, &state.JournalRecord{
+			Received: start.Add(time.Duration(i) * 50 * time.Millisecond),
+			Peer:     fmt.Sprintf("peer%d", i%2),
+			VMIndex:  i,
+			Message:  data,
+		})
+	}
+
+	s := testHelper.CreateEmptyTestState()
+	replayed := time.Now()
+	if err := ReplayJournal(s, records, 1); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(replayed); elapsed < 200*time.Millisecond {
+		t.Errorf("Replayed in %v, the messages were received over 200ms", elapsed)
+	}
+
+	for i := 0; i < 5; i++ {
+		msg, ok := s.InMsgQueue().Dequeue().(*messages.Bounce)
+		if !ok {
+			t.Fatalf("Message %d is missing", i)
+		}
+		if msg.Name != fmt.Sprintf("msg%d", i) || msg.GetNetworkOrigin() != fmt.Sprintf("peer%d", i%2) || msg.GetVMIndex() != i {
+			t.Errorf("Message %d is %s from %s in VM %d", i, msg.Name, msg.GetNetworkOrigin(), msg.GetVMIndex())
+		}
+	}
+
+	// Twice the speed takes half the time
+	replayed = time.Now()
+	if err := ReplayJournal(s, records, 2); err != nil {
+		t.Fatal(err)
+	}
+	if elapsed := time.Since(replayed); elapsed < 100*time.Millisecond {
+		t.Errorf("Replayed at twice the speed in %v", elapsed)
+	}
+}
@@ -172,3 +172,86 @@ func (p *IManagerPlugin) Server(*plugin.MuxBroker) (interface{}, error) {
 func (IManagerPlugin) Client(b *plugin.MuxBroker, c *rpc.Client) (interface{}, error) {
 	return &IManagerPluginRPC{client: c}, nil
 }
+
+/*****************************************
+ *										**
+ *				Indexers				**
+ *		interfaces.IIndexerPlugin		**
+ *										**
+ *****************************************/
+
+// Here is an implementation that talks over RPC
+type IIndexerPluginRPC struct{ client *rpc.Client }
+
+type IndexDBStateArgs struct {
+	Height uint32
+	Data   []byte
+}
+
+func (g *IIndexerPluginRPC) IndexDBState(height uint32, data []byte) error {
+	var resp error
+	args := IndexDBStateArgs{
+		Height: height,
+		Data:   data,
+	}
+	err := g.client.Call("Plugin.IndexDBState", &args, &resp)
+	if err != nil {
+		return err
+	}
+
+	return resp
+}
+
+func (g *IIndexerPluginRPC) AckedHeight() (int64, error) {
+	var resp int64
+	err := g.client.Call("Plugin.AckedHeight", new(interface{}), &resp)
+	if err != nil {
+		return -1, err
+	}
+
+	return resp, nil
+}
+
+func (g *IIndexerPluginRPC) Alive() error {
+	var resp error
+	err := g.client.Call("Plugin.Alive", new(interface{}), &resp)
+	return err
+}
+
+// Here is the RPC server that IIndexerPluginRPC talks to, conforming to
+// the requirements of net/rpc
+type IIndexerPluginRPCServer struct {
+	// This is the real implementation
+	Impl interfaces.IIndexerController
+}
+
+func (s *IIndexerPluginRPCServer) IndexDBState(args *IndexDBStateArgs, resp *error) error {
+	*resp = s.Impl.IndexDBState(args.Height, args.Data)
+	return *resp
+}
+
+func (s *IIndexerPluginRPCServer) AckedHeight(args interface{}, resp *int64) error {
+	var err error
+	*resp, err = s.Impl.AckedHeight()
+	return err
+}
+
+func (s *IIndexerPluginRPCServer) Alive(args interface{}, resp *error) error {
+	*resp = s.Impl.Alive()
+	return *resp
+}
+
+// IIndexerPlugin is the implementation of plugin.Plugin for indexers, see
+// IManagerPlugin
+type IIndexerPlugin struct {
+	// Impl Injection
+	Impl interfaces.IIndexerController
+}
+
+func (p *IIndexerPlugin) Server(*plugin.MuxBroker) (interface{}, error) {
+	return &IIndexerPluginRPCServer{Impl: p.Impl}, nil
+}
+
+func (IIndexerPlugin) Client(b *plugin.MuxBroker, c *rpc.Client) (interface{}, error) {
+	return &IIndexerPluginRPC{client: c}, nil
+}
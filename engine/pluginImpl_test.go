@@ -2,10 +2,15 @@ package engine_test
 
 import (
 	"bytes"
+	"fmt"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/FactomProject/factomd/common/interfaces"
 	. "github.com/FactomProject/factomd/engine"
+	"github.com/FactomProject/factomd/state"
+	. "github.com/FactomProject/factomd/testHelper"
 	"github.com/hashicorp/go-plugin"
 )
 
@@ -132,3 +137,87 @@ func TestTorrentImpl(t *testing.T) {
 		t.Error("Should be false")
 	}
 }
+
+// FakeIndexer is a fake indexer plugin, that only takes heights in order
+type FakeIndexer struct {
+	sync.Mutex
+	Acked   int64
+	Heights []uint32
+}
+
+func (f *FakeIndexer) IndexDBState(height uint32, data []byte) error {
+	f.Lock()
+	defer f.Unlock()
+	if int64(height) != f.Acked+1 {
+		return fmt.Errorf("expected height %d, got %d", f.Acked+1, height)
+	}
+	block := state.NewWholeBlock()
+	if err := block.UnmarshalBinary(data); err != nil {
+		return err
+	}
+	if block.DBlock.GetDatabaseHeight() != height {
+		return fmt.Errorf("block at height %d sent as %d", block.DBlock.GetDatabaseHeight(), height)
+	}
+	f.Acked = int64(height)
+	f.Heights = append(f.Heights, height)
+	return nil
+}
+
+func (f *FakeIndexer) AckedHeight() (int64, error) {
+	f.Lock()
+	defer f.Unlock()
+	return f.Acked, nil
+}
+
+func (f *FakeIndexer) Alive() error { return nil }
+
+func TestIndexerImpl(t *testing.T) {
+	fake := &FakeIndexer{Acked: 4}
+	client, _ := PluginRPCConn(t, map[string]plugin.Plugin{
+		"indexer": &IIndexerPlugin{Impl: fake},
+	})
+
+	raw, err := client.Dispense("indexer")
+	if err != nil {
+		t.Fatalf("err: %s", err)
+	}
+	ic := raw.(interfaces.IIndexerController)
+
+	acked, err := ic.AckedHeight()
+	if err != nil {
+		t.Error(err)
+	}
+	if acked != 4 {
+		t.Errorf("Acked height is %d, not 4", acked)
+	}
+	if ic.IndexDBState(1, nil) == nil {
+		t.Error("Indexed a height out of order")
+	}
+
+	// The indexer acknowledged height 4 before a "restart", so the state
+	// carries on from 5
+	s := CreateAndPopulateTestState()
+	s.EntryDBHeightComplete = s.GetHighestSavedBlk()
+	top := int64(s.GetHighestSavedBlk())
+	s.Indexer = state.NewIndexController(ic)
+	go s.RunIndexController()
+	defer s.Indexer.Close()
+
+	for i := 0; i < 100 && s.Indexer.AckedHeight() < top; i++ {
+		time.Sleep(100 * time.Millisecond)
+	}
+	if s.Indexer.AckedHeight() != top {
+		t.Fatalf("Indexer is at %d, not %d", s.Indexer.AckedHeight(), top)
+	}
+
+	fake.Lock()
+	defer fake.Unlock()
+	if len(fake.Heights) != int(top-4) || fake.Heights[0] != 5 {
+		t.Errorf("Indexed heights %v, expected 5 to %d", fake.Heights, top)
+	}
+
+	client.Close()
+	if ic.Alive() == nil {
+		t.Error("Stream closed, this should fail")
+	}
+}
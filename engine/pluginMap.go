@@ -25,6 +25,7 @@ var _ = ioutil.Discard
 // pluginMap is the map of plugins we can dispense.
 var pluginMap = map[string]plugin.Plugin{
 	"manager": &IManagerPlugin{},
+	"indexer": &IIndexerPlugin{},
 }
 
 var managerHandshakeConfig = plugin.HandshakeConfig{
@@ -33,6 +34,49 @@ var managerHandshakeConfig = plugin.HandshakeConfig{
 	MagicCookieValue: "factom_torrent",
 }
 
+var indexerHandshakeConfig = plugin.HandshakeConfig{
+	ProtocolVersion:  1,
+	MagicCookieKey:   "Block_Indexer",
+	MagicCookieValue: "factom_indexer",
+}
+
+// LaunchIndexerPlugin launches the indexer binary found at path+name, and starts
+// pushing the saved DBStates to it, from the height after the one it last
+// acknowledged.
+func LaunchIndexerPlugin(path string, name string, s *state.State) (interfaces.IIndexerController, error) {
+	log.SetOutput(ioutil.Discard)
+
+	client := plugin.NewClient(&plugin.ClientConfig{
+		HandshakeConfig: indexerHandshakeConfig,
+		Plugins:         pluginMap,
+		Cmd:             exec.Command(path + name),
+	})
+
+	rpcClient, err := client.Client()
+	if err != nil {
+		client.Kill()
+		return nil, err
+	}
+
+	raw, err := rpcClient.Dispense("indexer")
+	if err != nil {
+		client.Kill()
+		return nil, err
+	}
+	indexer := raw.(interfaces.IIndexerController)
+
+	s.Indexer = state.NewIndexController(indexer)
+	AddInterruptHandler(func() {
+		fmt.Println("Indexer plugin is now closing...")
+		s.Indexer.Close()
+		client.Kill()
+	})
+
+	go s.RunIndexController()
+
+	return indexer, nil
+}
+
 // LaunchDBStateManagePlugin launches the plugin and returns an interface that
 // can be interacted with like a usual interface. The client returned must be
 // killed before we exit
@@ -0,0 +1,551 @@
+// Copyright 2017 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package engine
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"time"
+
+	"github.com/FactomProject/factomd/common/globals"
+	"github.com/FactomProject/factomd/common/primitives"
+	"github.com/FactomProject/factomd/state"
+)
+
+// A Scenario drives the simulator from a JSON file instead of commands typed
+// on stdin.  It starts the nodes, then runs its steps in order, each after
+// waiting for its time.  For example
+//
+//	{
+//		"name": "Kill a leader",
+//		"nodes": "LLLAF",
+//		"steps": [
+//			{"blocks": 1, "action": "kill", "nodes": [2]},
+//			{"blocks": 2, "action": "assert-authorities", "leaders": 3, "audits": 0}
+//		]
+//	}
+type Scenario struct {
+	Name    string            `json:"name"`
+	Nodes   string            `json:"nodes"`   // A letter a node, L leader, A audit and F follower, like "LLLAAF"
+	Net     string            `json:"net"`     // Topology of the nodes, as --net (alot+ if empty)
+	Options map[string]string `json:"options"` // Command line options, like {"--blktime": "10"}
+	Steps   []ScenarioStep    `json:"steps"`
+}
+
+// A ScenarioStep waits Blocks and Minutes from the step before, then for the
+// leader height Block and the minute Minute (if it is not there yet), and then
+// does its action.  Nodes are the indexes of the nodes the action is about; the
+// assertions check every node still on the network if there are none.
+type ScenarioStep struct {
+	Blocks  int  `json:"blocks,omitempty"`
+	Minutes int  `json:"minutes,omitempty"`
+	Block   int  `json:"block,omitempty"`
+	Minute  *int `json:"minute,omitempty"`
+
+	Action  string  `json:"action"`
+	Nodes   []int   `json:"nodes,omitempty"`
+	Groups  [][]int `json:"groups,omitempty"`  // partition: nodes of a group only talk to each other
+	Rate    float64 `json:"rate,omitempty"`    // load: entries per second, 0 stops the load
+	Tight   bool    `json:"tight,omitempty"`   // load: buy entry credits as needed
+	Command string  `json:"command,omitempty"` // command: a simulator command, as typed on stdin
+
+	Height  int    `json:"height,omitempty"`  // assert-height: the least height
+	Leaders int    `json:"leaders,omitempty"` // assert-authorities
+	Audits  int    `json:"audits,omitempty"`  // assert-authorities
+	Address string `json:"address,omitempty"` // assert-balance: FA or EC address
+	Balance int64  `json:"balance,omitempty"` // assert-balance: factoshis or entry credits
+	Within  int    `json:"within,omitempty"`  // Seconds an assertion may take to hold, two blocks if 0
+}
+
+// The actions of a scenario step
+const (
+	ScenarioLeader    = "leader"    // Promote the nodes to leaders
+	ScenarioAudit     = "audit"     // Promote the nodes to audit servers
+	ScenarioRemove    = "remove"    // Remove the nodes as servers
+	ScenarioKill      = "kill"      // Take the nodes off the network
+	ScenarioRevive    = "revive"    // Bring the nodes back onto the network
+	ScenarioPartition = "partition" // Cut the network between the groups
+	ScenarioHeal      = "heal"      // Undo all partitions
+	ScenarioLoad      = "load"      // Set the rate of the load generator
+	ScenarioCommand   = "command"   // Run a simulator command
+	ScenarioWait      = "wait"      // Just wait for the time of the step
+	AssertHeight      = "assert-height"
+	AssertSynced      = "assert-synced"
+	AssertAuthorities = "assert-authorities"
+	AssertBalance     = "assert-balance"
+)
+
+// Blocks a scenario waits for the next minute before it calls the network stalled
+const scenarioMaxWaitFor = 3
+
+// ScenarioStepResult is how one step of a scenario went
+type ScenarioStepResult struct {
+	Step    int
+	Action  string
+	Height  uint32 // Leader height and minute when the step ran
+	Minute  int
+	Passed  bool
+	Message string
+}
+
+// ScenarioReport is how a scenario went, step by step
+type ScenarioReport struct {
+	Name    string
+	Passed  bool
+	Elapsed time.Duration
+	Results []ScenarioStepResult
+	Skipped int // Steps not run because the network stalled
+}
+
+func (r *ScenarioReport) String() string {
+	var out bytes.Buffer
+	verdict := "PASS"
+	if !r.Passed {
+		verdict = "FAIL"
+	}
+	out.WriteString(fmt.Sprintf("Scenario %q: %s in %s\n", r.Name, verdict, r.Elapsed.Round(time.Second)))
+	for _, res := range r.Results {
+		verdict = "PASS"
+		if !res.Passed {
+			verdict = "FAIL"
+		}
+		out.WriteString(fmt.Sprintf("  %3d %4d/%d %-20s %s %s\n", res.Step, res.Height, res.Minute, res.Action, verdict, res.Message))
+	}
+	if r.Skipped > 0 {
+		out.WriteString(fmt.Sprintf("  %d steps not run\n", r.Skipped))
+	}
+	return out.String()
+}
+
+// LoadScenario reads and checks a scenario file
+func LoadScenario(path string) (*Scenario, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	sc := new(Scenario)
+	if err := dec.Decode(sc); err != nil {
+		return nil, fmt.Errorf("%s: %s", path, err.Error())
+	}
+	if err := sc.Validate(); err != nil {
+		return nil, fmt.Errorf("%s: %s", path, err.Error())
+	}
+	return sc, nil
+}
+
+// Validate checks the scenario can be run, before any node is started
+func (sc *Scenario) Validate() error {
+	if len(sc.Nodes) == 0 {
+		return errors.New("a scenario needs nodes")
+	}
+	if strings.Trim(strings.ToUpper(sc.Nodes), "LAF") != "" {
+		return fmt.Errorf("nodes %q may only be L, A or F", sc.Nodes)
+	}
+	if !strings.ContainsAny(strings.ToUpper(sc.Nodes), "L") {
+		return errors.New("a scenario needs a leader")
+	}
+	count := len(sc.Nodes)
+	for i, step := range sc.Steps {
+		if err := step.validate(count); err != nil {
+			return fmt.Errorf("step %d: %s", i, err.Error())
+		}
+	}
+	return nil
+}
+
+func (step *ScenarioStep) validate(count int) error {
+	if step.Blocks < 0 || step.Minutes < 0 || step.Block < 0 || step.Within < 0 {
+		return errors.New("times can not be negative")
+	}
+	if step.Minute != nil && (*step.Minute < 0 || *step.Minute > 9) {
+		return fmt.Errorf("minute %d is not 0 to 9", *step.Minute)
+	}
+	for _, n := range step.Nodes {
+		if n < 0 || n >= count {
+			return fmt.Errorf("there is no node %d", n)
+		}
+	}
+
+	switch step.Action {
+	case ScenarioLeader, ScenarioAudit, ScenarioRemove, ScenarioKill, ScenarioRevive:
+		if len(step.Nodes) == 0 {
+			return fmt.Errorf("%s needs nodes", step.Action)
+		}
+	case ScenarioPartition:
+		if len(step.Groups) < 2 {
+			return errors.New("partition needs at least two groups")
+		}
+		seen := map[int]bool{}
+		for _, group := range step.Groups {
+			for _, n := range group {
+				if n < 0 || n >= count {
+					return fmt.Errorf("there is no node %d", n)
+				}
+				if seen[n] {
+					return fmt.Errorf("node %d is in two groups", n)
+				}
+				seen[n] = true
+			}
+		}
+	case ScenarioLoad:
+		if step.Rate < 0 {
+			return errors.New("load rate can not be negative")
+		}
+	case ScenarioCommand:
+		if step.Command == "" {
+			return errors.New("command needs a command")
+		}
+	case ScenarioHeal, ScenarioWait, AssertSynced:
+	case AssertHeight:
+		if step.Height <= 0 {
+			return errors.New("assert-height needs a height")
+		}
+	case AssertAuthorities:
+		if step.Leaders <= 0 {
+			return errors.New("assert-authorities needs leaders")
+		}
+	case AssertBalance:
+		if !primitives.ValidateFUserStr(step.Address) && !primitives.ValidateECUserStr(step.Address) {
+			return fmt.Errorf("%q is not a FA or EC address", step.Address)
+		}
+	default:
+		return fmt.Errorf("unknown action %q", step.Action)
+	}
+	return nil
+}
+
+// RunScenario starts the nodes of the scenario and runs its steps, without
+// listening to stdin.  The nodes are left running, and as the simulator keeps
+// its nodes in globals, only one scenario (or simulation) can run in a process.
+// An error means the scenario could not be run at all; a failed step only
+// fails the report.
+func RunScenario(sc *Scenario) (*ScenarioReport, error) {
+	if err := sc.Validate(); err != nil {
+		return nil, err
+	}
+	if len(fnodes) > 0 {
+		return nil, errors.New("a simulation is already running in this process")
+	}
+
+	start := time.Now()
+	state0 := startScenarioNodes(sc)
+	if len(fnodes) != len(sc.Nodes) {
+		return nil, fmt.Errorf("started %d nodes, the scenario has %d", len(fnodes), len(sc.Nodes))
+	}
+	if err := promoteScenarioNodes(state0, sc.Nodes); err != nil {
+		return nil, err
+	}
+
+	// The scenario has its own load generator, funded from the first node, so it
+	// does not change the one of the simulator's commands
+	load := NewLoadGenerator(state0)
+	report := &ScenarioReport{Name: sc.Name, Passed: true}
+	for i := range sc.Steps {
+		step := &sc.Steps[i]
+		result := ScenarioStepResult{Step: i, Action: step.Action}
+		if err := waitForStep(state0, step); err != nil {
+			result.Height, result.Minute = state0.LLeaderHeight, state0.CurrentMinute
+			result.Message = err.Error()
+			report.Results = append(report.Results, result)
+			report.Passed = false
+			report.Skipped = len(sc.Steps) - i - 1
+			break
+		}
+		result.Height, result.Minute = state0.LLeaderHeight, state0.CurrentMinute
+		if err := runScenarioStep(step, load); err != nil {
+			result.Message = err.Error()
+			report.Passed = false
+		} else {
+			result.Passed = true
+		}
+		report.Results = append(report.Results, result)
+	}
+	report.Elapsed = time.Since(start)
+	return report, nil
+}
+
+// startScenarioNodes starts the simulator with the options of the scenario
+func startScenarioNodes(sc *Scenario) *state.State {
+	options := map[string]string{
+		"--db":           "Map",
+		"--network":      "LOCAL",
+		"--net":          "alot+",
+		"--enablenet":    "false",
+		"--blktime":      "8",
+		"--faulttimeout": "2",
+		"--roundtimeout": "2",
+		"--startdelay":   "1",
+		"--stdoutlog":    "out.txt",
+		"--stderrlog":    "err.txt",
+		"--checkheads":   "false",
+	}
+	if sc.Net != "" {
+		options["--net"] = sc.Net
+	}
+	for key, value := range sc.Options {
+		options[key] = value
+	}
+	options["--count"] = fmt.Sprintf("%d", len(sc.Nodes))
+
+	args := []string{}
+	for key, value := range options {
+		args = append(args, key+"="+value)
+	}
+	state0 := Factomd(ParseCmdLine(args), false).(*state.State)
+	state0.MessageTally = true
+	time.Sleep(3 * time.Second)
+	return state0
+}
+
+// promoteScenarioNodes makes the identities of the nodes, and then their
+// leaders and audit servers
+func promoteScenarioNodes(state0 *state.State, nodes string) error {
+	InputChan <- fmt.Sprintf("g%d", len(nodes))
+	// Wait till the identities are in the blockchain
+	for waited := 0; ; waited++ {
+		pending := 0
+		for _, fnode := range fnodes {
+			pending += fnode.State.InMsgQueue2().Length() + fnode.State.Commits.Len()
+		}
+		if pending == 0 {
+			break
+		}
+		if waited > 10*scenarioMaxWaitFor {
+			return errors.New("the identities of the nodes never made it into the blockchain")
+		}
+		if err := waitForMinutes(state0, 1); err != nil {
+			return err
+		}
+	}
+	if err := waitForBlocks(state0, 1); err != nil {
+		return err
+	}
+	if err := waitForMinute(state0, 1); err != nil {
+		return err
+	}
+
+	for i, c := range strings.ToUpper(nodes) {
+		switch c {
+		case 'L':
+			promoteNode(i, "l")
+		case 'A':
+			promoteNode(i, "o")
+		}
+	}
+	if err := waitForBlocks(state0, 1); err != nil {
+		return err
+	}
+	return waitForMinute(state0, 1)
+}
+
+// promoteNode focuses the simulator on the node and runs the command, which is
+// one of the commands that send an AddServer or RemoveServer message
+func promoteNode(node int, cmd string) {
+	InputChan <- fmt.Sprintf("%d", node)
+	InputChan <- cmd
+}
+
+func waitForStep(state0 *state.State, step *ScenarioStep) error {
+	if step.Blocks > 0 {
+		if err := waitForBlocks(state0, step.Blocks); err != nil {
+			return err
+		}
+	}
+	if step.Minutes > 0 {
+		if err := waitForMinutes(state0, step.Minutes); err != nil {
+			return err
+		}
+	}
+	if ht := int(state0.LLeaderHeight); step.Block > ht {
+		if err := waitForBlocks(state0, step.Block-ht); err != nil {
+			return err
+		}
+	}
+	if step.Minute != nil && state0.CurrentMinute != *step.Minute {
+		return waitForMinute(state0, *step.Minute)
+	}
+	return nil
+}
+
+func runScenarioStep(step *ScenarioStep, load *LoadGenerator) error {
+	switch step.Action {
+	case ScenarioLeader:
+		for _, n := range step.Nodes {
+			promoteNode(n, "l")
+		}
+	case ScenarioAudit:
+		for _, n := range step.Nodes {
+			promoteNode(n, "o")
+		}
+	case ScenarioRemove:
+		for _, n := range step.Nodes {
+			promoteNode(n, "z")
+		}
+	case ScenarioKill, ScenarioRevive:
+		for _, n := range step.Nodes {
+			fnodes[n].State.SetNetStateOff(step.Action == ScenarioKill)
+		}
+	case ScenarioPartition:
+		partitionNodes(step.Groups)
+	case ScenarioHeal:
+		partitionNodes(nil)
+	case ScenarioLoad:
+		load.tight.Store(step.Tight)
+		load.PerSecond.Store(int(step.Rate * 10))
+		if step.Rate > 0 {
+			go load.Run()
+		}
+	case ScenarioCommand:
+		InputChan <- step.Command
+	case ScenarioWait:
+	case AssertHeight, AssertSynced, AssertAuthorities, AssertBalance:
+		return assertScenarioStep(step)
+	}
+	return nil
+}
+
+// partitionNodes cuts every connection between nodes of different groups.  Nodes
+// in no group keep talking to everyone; no groups heals the network.
+func partitionNodes(groups [][]int) {
+	group := map[string]int{}
+	for g, nodes := range groups {
+		for _, n := range nodes {
+			group[fnodes[n].State.FactomNodeName] = g + 1
+		}
+	}
+	for _, fnode := range fnodes {
+		for _, p := range fnode.Peers {
+			if peer, ok := p.(*SimPeer); ok {
+				from, to := group[peer.FromName], group[peer.ToName]
+				peer.SetCut(from != 0 && to != 0 && from != to)
+			}
+		}
+	}
+}
+
+// assertScenarioStep checks the assertion until it holds, or the step's time is up
+func assertScenarioStep(step *ScenarioStep) error {
+	within := time.Duration(step.Within) * time.Second
+	if within == 0 {
+		within = 2 * time.Duration(globals.Params.BlkTime) * time.Second
+	}
+	deadline := time.Now().Add(within)
+	for {
+		err := checkScenarioAssertion(step)
+		if err == nil || time.Now().After(deadline) {
+			return err
+		}
+		time.Sleep(250 * time.Millisecond)
+	}
+}
+
+func checkScenarioAssertion(step *ScenarioStep) error {
+	var nodes []*FactomNode
+	if len(step.Nodes) == 0 {
+		for _, fnode := range fnodes {
+			if !fnode.State.GetNetStateOff() {
+				nodes = append(nodes, fnode)
+			}
+		}
+	} else {
+		for _, n := range step.Nodes {
+			nodes = append(nodes, fnodes[n])
+		}
+	}
+
+	switch step.Action {
+	case AssertHeight:
+		for _, fnode := range nodes {
+			if ht := int(fnode.State.LLeaderHeight); ht < step.Height {
+				return fmt.Errorf("%s is at height %d, expected %d", fnode.State.FactomNodeName, ht, step.Height)
+			}
+		}
+	case AssertSynced:
+		for _, fnode := range nodes {
+			if fnode.State.LLeaderHeight != nodes[0].State.LLeaderHeight {
+				return fmt.Errorf("%s is at height %d, %s at %d", fnode.State.FactomNodeName, fnode.State.LLeaderHeight,
+					nodes[0].State.FactomNodeName, nodes[0].State.LLeaderHeight)
+			}
+		}
+	case AssertAuthorities:
+		leaders, audits := 0, 0
+		for _, fnode := range nodes {
+			s := fnode.State
+			if s.Leader {
+				leaders++
+			}
+			list := s.ProcessLists.Get(s.LLeaderHeight)
+			if list == nil {
+				continue
+			}
+			if foundAudit, _ := list.GetAuditServerIndexHash(s.GetIdentityChainID()); foundAudit {
+				audits++
+			}
+		}
+		if leaders != step.Leaders || audits != step.Audits {
+			return fmt.Errorf("found %d leaders and %d audit servers, expected %d and %d", leaders, audits, step.Leaders, step.Audits)
+		}
+	case AssertBalance:
+		var address [32]byte
+		copy(address[:], primitives.ConvertUserStrToAddress(step.Address))
+		for _, fnode := range nodes {
+			var balance int64
+			if primitives.ValidateECUserStr(step.Address) {
+				balance = fnode.State.FactoidState.GetECBalance(address)
+			} else {
+				balance = fnode.State.FactoidState.GetFactoidBalance(address)
+			}
+			if balance != step.Balance {
+				return fmt.Errorf("%s has a balance of %d, expected %d", fnode.State.FactomNodeName, balance, step.Balance)
+			}
+		}
+	}
+	return nil
+}
+
+// waitForBlocks waits until the leader height of the state has grown by blocks
+func waitForBlocks(s *state.State, blocks int) error {
+	target := s.LLeaderHeight + uint32(blocks)
+	return waitForScenario(s, func() bool { return s.LLeaderHeight >= target })
+}
+
+// waitForMinutes waits until the state is minutes further along
+func waitForMinutes(s *state.State, minutes int) error {
+	target := int(s.LLeaderHeight)*10 + s.CurrentMinute + minutes
+	return waitForScenario(s, func() bool { return int(s.LLeaderHeight)*10+s.CurrentMinute >= target })
+}
+
+// waitForMinute waits for the minute, in the next block if the state is past it
+func waitForMinute(s *state.State, minute int) error {
+	if s.CurrentMinute >= minute {
+		ht := s.LLeaderHeight
+		if err := waitForScenario(s, func() bool { return s.LLeaderHeight > ht }); err != nil {
+			return err
+		}
+	}
+	return waitForScenario(s, func() bool { return s.CurrentMinute >= minute })
+}
+
+// waitForScenario waits until done, or fails if the state did not move on to
+// the next minute for scenarioMaxWaitFor blocks
+func waitForScenario(s *state.State, done func() bool) error {
+	stalled := scenarioMaxWaitFor * time.Duration(globals.Params.BlkTime) * time.Second
+	at, moved := int(s.LLeaderHeight)*10+s.CurrentMinute, time.Now()
+	for !done() {
+		if now := int(s.LLeaderHeight)*10 + s.CurrentMinute; now != at {
+			at, moved = now, time.Now()
+		} else if time.Since(moved) > stalled {
+			return fmt.Errorf("%s stalled at %d/%d", s.FactomNodeName, s.LLeaderHeight, s.CurrentMinute)
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+package engine_test
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	. "github.com/FactomProject/factomd/engine"
+)
+
+func TestLoadScenarios(t *testing.T) {
+	files, err := filepath.Glob("scenarios/*.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) == 0 {
+		t.Fatal("No scenarios found")
+	}
+	for _, file := range files {
+		if _, err := LoadScenario(file); err != nil {
+			t.Error(err)
+		}
+	}
+}
+
+func TestValidateScenario(t *testing.T) {
+	minute := 10
+	bad := map[string]ScenarioStep{
+		"no node 6":              {Action: ScenarioKill, Nodes: []int{6}},
+		"needs nodes":            {Action: ScenarioLeader},
+		"two groups":             {Action: ScenarioPartition, Groups: [][]int{{0, 1}}},
+		"node 1 is in two":       {Action: ScenarioPartition, Groups: [][]int{{0, 1}, {1, 2}}},
+		"not 0 to 9":             {Action: ScenarioWait, Minute: &minute},
+		"needs a height":         {Action: AssertHeight},
+		"not a FA or EC address": {Action: AssertBalance, Address: "FA1234"},
+		"unknown action":         {Action: "explode"},
+	}
+	for want, step := range bad {
+		sc := &Scenario{Name: want, Nodes: "LLAF", Steps: []ScenarioStep{step}}
+		if err := sc.Validate(); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("Expected an error with %q, got %v", want, err)
+		}
+	}
+
+	sc := &Scenario{Name: "followers", Nodes: "FF"}
+	if err := sc.Validate(); err == nil {
+		t.Error("A scenario without leaders is valid")
+	}
+
+	sc = &Scenario{Name: "good", Nodes: "LLAF", Steps: []ScenarioStep{
+		{Blocks: 1, Action: ScenarioPartition, Groups: [][]int{{0, 1}, {2, 3}}},
+		{Minutes: 2, Action: ScenarioHeal},
+		{Action: AssertBalance, Address: "EC2DKSYyRcNWf7RS963VFYgMExoHRYLHVeCfQ9PGPmNzwrcmgm2r", Balance: 100},
+		{Action: AssertAuthorities, Leaders: 2, Audits: 1},
+	}}
+	if err := sc.Validate(); err != nil {
+		t.Error(err)
+	}
+}
+
+// scenarioProcessEnv names the scenario test a process was started to run
+const scenarioProcessEnv = "FACTOMD_SCENARIO_TEST"
+
+// runScenarioFile runs the scenario in a process of its own, as the simulator keeps
+// its nodes in globals and the other simulation tests of the package run first
+func runScenarioFile(file string, t *testing.T) {
+	if os.Getenv(scenarioProcessEnv) != t.Name() {
+		cmd := exec.Command(os.Args[0], "-test.run=^"+t.Name()+"$", "-test.v")
+		cmd.Env = append(os.Environ(), scenarioProcessEnv+"="+t.Name())
+		out, err := cmd.CombinedOutput()
+		t.Log(string(out))
+		if err != nil {
+			t.Fatalf("Scenario %s failed: %v", file, err)
+		}
+		return
+	}
+
+	sc, err := LoadScenario(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	report, err := RunScenario(sc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Log(report.String())
+	if !report.Passed {
+		t.Fatalf("Scenario %q failed", sc.Name)
+	}
+}
+
+func TestScenarioAnElection(t *testing.T) {
+	runScenarioFile("scenarios/an_election.json", t)
+}
+
+func TestScenarioPartition(t *testing.T) {
+	runScenarioFile("scenarios/partition.json", t)
+}
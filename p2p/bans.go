@@ -0,0 +1,288 @@
+// Copyright 2017 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package p2p
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Misbehavior is something a peer did wrong, that costs it quality score
+type Misbehavior uint8
+
+const (
+	MisbehaviorChecksum       Misbehavior = iota // The parcel failed its CRC check
+	MisbehaviorLength                            // The parcel was not as long as its header says
+	MisbehaviorNetwork                           // The peer is on another network
+	MisbehaviorVersion                           // The peer runs a protocol version we no longer support
+	MisbehaviorPanic                             // The parcel made us panic
+	MisbehaviorInvalidMessage                    // The application found the message invalid (reported from state)
+)
+
+var misbehaviorNames = map[Misbehavior]string{
+	MisbehaviorChecksum:       "checksum",
+	MisbehaviorLength:         "length",
+	MisbehaviorNetwork:        "network",
+	MisbehaviorVersion:        "version",
+	MisbehaviorPanic:          "panic",
+	MisbehaviorInvalidMessage: "invalid",
+}
+
+func (m Misbehavior) String() string {
+	name, ok := misbehaviorNames[m]
+	if !ok {
+		return fmt.Sprintf("misbehavior(%d)", uint8(m))
+	}
+	return name
+}
+
+// MisbehaviorPenalties are the quality score changes for each misbehavior.  A peer
+// that drops below MinumumQualityScore through misbehavior is banned for
+// AutoBanDuration, if that is set.  Invalid messages are not penalized by default,
+// as the consensus system also reports messages honest peers relay to us too late.
+var MisbehaviorPenalties = map[Misbehavior]int32{
+	MisbehaviorChecksum:       -20,
+	MisbehaviorLength:         -20,
+	MisbehaviorNetwork:        -250,
+	MisbehaviorVersion:        -50,
+	MisbehaviorPanic:          -5,
+	MisbehaviorInvalidMessage: 0,
+}
+
+// ParsePenalties sets MisbehaviorPenalties from a config string like
+// "checksum=-20,network=-250,invalid=-2".  Misbehaviors not named keep their penalty.
+func ParsePenalties(config string) error {
+	penalties := map[Misbehavior]int32{}
+	for _, field := range strings.FieldsFunc(config, func(r rune) bool { return r == ',' || r == ' ' }) {
+		parts := strings.SplitN(field, "=", 2)
+		if len(parts) != 2 {
+			return fmt.Errorf("%s is not a valid penalty, use name=penalty", field)
+		}
+		misbehavior, ok := misbehaviorByName(parts[0])
+		if !ok {
+			return fmt.Errorf("%s is not a known misbehavior", parts[0])
+		}
+		penalty, err := strconv.ParseInt(parts[1], 10, 32)
+		if err != nil {
+			return fmt.Errorf("%s is not a valid penalty: %v", field, err)
+		}
+		if penalty > 0 {
+			return fmt.Errorf("%s is not a valid penalty, penalties can not be positive", field)
+		}
+		penalties[misbehavior] = int32(penalty)
+	}
+	for misbehavior, penalty := range penalties {
+		MisbehaviorPenalties[misbehavior] = penalty
+	}
+	return nil
+}
+
+func misbehaviorByName(name string) (Misbehavior, bool) {
+	for misbehavior, n := range misbehaviorNames {
+		if n == name {
+			return misbehavior, true
+		}
+	}
+	return 0, false
+}
+
+// Ban keeps a peer address, or all the addresses of a subnet, from connecting
+// to us, and from being dialed.
+type Ban struct {
+	Address string    `json:"address"` // An IP address, or a subnet in CIDR notation
+	Reason  string    `json:"reason"`
+	Created time.Time `json:"created"`
+	Expires time.Time `json:"expires"` // Zero if the ban never expires
+}
+
+func (b *Ban) Expired(now time.Time) bool {
+	return !b.Expires.IsZero() && now.After(b.Expires)
+}
+
+// BanList is the list of banned addresses and subnets, that is saved to disk
+// whenever it changes, so bans survive restarts.  It is safe for concurrent use,
+// as it is checked from the accept loop as well as the controller.
+type BanList struct {
+	lock sync.RWMutex
+	path string // empty if the list is not saved
+	bans map[string]Ban
+}
+
+// NewBanList creates a ban list, and loads the bans saved at the path
+func NewBanList(path string) *BanList {
+	b := new(BanList)
+	b.path = path
+	b.bans = map[string]Ban{}
+	b.load()
+	return b
+}
+
+// normalizeBanAddress checks the address is an IP or CIDR subnet, and returns
+// its canonical form, so the same ban is not stored twice
+func normalizeBanAddress(address string) (string, error) {
+	address = strings.TrimSpace(address)
+	if strings.Contains(address, "/") {
+		_, subnet, err := net.ParseCIDR(address)
+		if err != nil {
+			return "", err
+		}
+		return subnet.String(), nil
+	}
+	ip := net.ParseIP(strings.Trim(address, "[]"))
+	if ip == nil {
+		return "", fmt.Errorf("%s is not an IP address or subnet", address)
+	}
+	return ip.String(), nil
+}
+
+// Add bans the address or subnet for the duration, or forever if the duration
+// is 0.  An existing ban of the same address is replaced.
+func (b *BanList) Add(address string, reason string, duration time.Duration) (Ban, error) {
+	address, err := normalizeBanAddress(address)
+	if err != nil {
+		return Ban{}, err
+	}
+	ban := Ban{Address: address, Reason: reason, Created: time.Now()}
+	if duration > 0 {
+		ban.Expires = ban.Created.Add(duration)
+	}
+
+	b.lock.Lock()
+	b.bans[address] = ban
+	b.lock.Unlock()
+	b.save()
+	return ban, nil
+}
+
+// Remove lifts the ban of the address or subnet, and returns false if there
+// was none
+func (b *BanList) Remove(address string) bool {
+	address, err := normalizeBanAddress(address)
+	if err != nil {
+		return false
+	}
+
+	b.lock.Lock()
+	_, present := b.bans[address]
+	delete(b.bans, address)
+	b.lock.Unlock()
+	if present {
+		b.save()
+	}
+	return present
+}
+
+// List returns the bans that have not expired, sorted by address
+func (b *BanList) List() []Ban {
+	b.expire()
+	b.lock.RLock()
+	list := make([]Ban, 0, len(b.bans))
+	for _, ban := range b.bans {
+		list = append(list, ban)
+	}
+	b.lock.RUnlock()
+	sort.Slice(list, func(i, j int) bool { return list[i].Address < list[j].Address })
+	return list
+}
+
+// IsBanned returns the ban of the address (host or host:port), either of the
+// address itself or of a subnet it is in
+func (b *BanList) IsBanned(address string) (Ban, bool) {
+	if host, _, err := net.SplitHostPort(address); err == nil {
+		address = host
+	}
+	ip := net.ParseIP(strings.Trim(address, "[]"))
+	if ip == nil {
+		return Ban{}, false
+	}
+
+	now := time.Now()
+	b.lock.RLock()
+	defer b.lock.RUnlock()
+	if ban, present := b.bans[ip.String()]; present && !ban.Expired(now) {
+		return ban, true
+	}
+	for _, ban := range b.bans {
+		if !strings.Contains(ban.Address, "/") || ban.Expired(now) {
+			continue
+		}
+		_, subnet, err := net.ParseCIDR(ban.Address)
+		if err == nil && subnet.Contains(ip) {
+			return ban, true
+		}
+	}
+	return Ban{}, false
+}
+
+// expire drops the bans that expired
+func (b *BanList) expire() {
+	now := time.Now()
+	expired := false
+	b.lock.Lock()
+	for address, ban := range b.bans {
+		if ban.Expired(now) {
+			delete(b.bans, address)
+			expired = true
+		}
+	}
+	b.lock.Unlock()
+	if expired {
+		b.save()
+	}
+}
+
+func (b *BanList) load() {
+	if b.path == "" {
+		return
+	}
+	data, err := ioutil.ReadFile(b.path)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			discoLogger.Errorf("BanList.load() File read error on file: %s, Error: %+v", b.path, err)
+		}
+		return
+	}
+	var bans []Ban
+	if err := json.Unmarshal(data, &bans); err != nil {
+		discoLogger.Errorf("BanList.load() could not decode %s: %+v", b.path, err)
+		return
+	}
+	b.lock.Lock()
+	for _, ban := range bans {
+		b.bans[ban.Address] = ban
+	}
+	b.lock.Unlock()
+	b.expire()
+	discoLogger.Debugf("BanList.load() found %d bans in %s", len(b.bans), b.path)
+}
+
+func (b *BanList) save() {
+	if b.path == "" {
+		return
+	}
+	b.lock.RLock()
+	bans := make([]Ban, 0, len(b.bans))
+	for _, ban := range b.bans {
+		bans = append(bans, ban)
+	}
+	b.lock.RUnlock()
+
+	data, err := json.MarshalIndent(bans, "", "\t")
+	if err != nil {
+		discoLogger.Errorf("BanList.save() could not encode the bans: %+v", err)
+		return
+	}
+	if err := ioutil.WriteFile(b.path, data, 0600); err != nil {
+		discoLogger.Errorf("BanList.save() File write error on file: %s, Error: %+v", b.path, err)
+	}
+}
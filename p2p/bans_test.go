@@ -0,0 +1,95 @@
+package p2p_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/FactomProject/factomd/p2p"
+)
+
+func TestBanList(t *testing.T) {
+	dir, err := ioutil.TempDir("", "bans")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "peers.bans")
+
+	bans := p2p.NewBanList(path)
+	if _, err := bans.Add("not an address", "", 0); err == nil {
+		t.Error("Banned an invalid address")
+	}
+	if _, err := bans.Add("10.1.2.3", "bad checksums", time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := bans.Add("192.168.7.0/24", "spam", 0); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := bans.Add("172.16.0.1", "long ago", time.Nanosecond); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(time.Millisecond)
+
+	for address, expected := range map[string]bool{
+		"10.1.2.3":         true,
+		"10.1.2.3:8108":    true,
+		"10.1.2.4":         false,
+		"192.168.7.200":    true,
+		"192.168.8.1:8108": false,
+		"172.16.0.1":       false, // expired
+	} {
+		if _, banned := bans.IsBanned(address); banned != expected {
+			t.Errorf("IsBanned(%s) is %v, expected %v", address, banned, expected)
+		}
+	}
+
+	// The bans survive a restart, without the expired one
+	bans = p2p.NewBanList(path)
+	list := bans.List()
+	if len(list) != 2 {
+		t.Fatalf("Expected 2 bans after loading, got %v", list)
+	}
+	if list[0].Address != "10.1.2.3" || list[0].Reason != "bad checksums" || list[0].Expires.IsZero() {
+		t.Errorf("Wrong ban after loading %+v", list[0])
+	}
+	if list[1].Address != "192.168.7.0/24" || !list[1].Expires.IsZero() {
+		t.Errorf("Wrong ban after loading %+v", list[1])
+	}
+
+	if !bans.Remove("192.168.7.5/24") {
+		t.Error("Could not remove the subnet ban")
+	}
+	if bans.Remove("192.168.7.0/24") {
+		t.Error("Removed the subnet ban twice")
+	}
+	if _, banned := p2p.NewBanList(path).IsBanned("192.168.7.200"); banned {
+		t.Error("Removed ban is back after loading")
+	}
+}
+
+func TestParsePenalties(t *testing.T) {
+	defer func(saved int32) { p2p.MisbehaviorPenalties[p2p.MisbehaviorInvalidMessage] = saved }(p2p.MisbehaviorPenalties[p2p.MisbehaviorInvalidMessage])
+	checksum := p2p.MisbehaviorPenalties[p2p.MisbehaviorChecksum]
+
+	if err := p2p.ParsePenalties("invalid=-2"); err != nil {
+		t.Fatal(err)
+	}
+	if p2p.MisbehaviorPenalties[p2p.MisbehaviorInvalidMessage] != -2 {
+		t.Errorf("Invalid message penalty is %d", p2p.MisbehaviorPenalties[p2p.MisbehaviorInvalidMessage])
+	}
+	if p2p.MisbehaviorPenalties[p2p.MisbehaviorChecksum] != checksum {
+		t.Error("Penalty not in the config changed")
+	}
+
+	for _, bad := range []string{"invalid", "unknown=-2", "invalid=5", "invalid=x"} {
+		if err := p2p.ParsePenalties(bad); err == nil {
+			t.Errorf("Parsed %s", bad)
+		}
+	}
+	if p2p.MisbehaviorPenalties[p2p.MisbehaviorInvalidMessage] != -2 {
+		t.Error("Invalid config changed the penalties")
+	}
+}
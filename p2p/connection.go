@@ -35,6 +35,9 @@ type Connection struct {
 	// and as "address" for sending messages to specific nodes.
 	encoder         *gob.Encoder      // Wire format is gobs in this version, may switch to binary
 	decoder         *gob.Decoder      // Wire format is gobs in this version, may switch to binary
+	stream          io.ReadWriter     // what the gobs go over: conn, or the encrypted session on top of it
+	plaintextPeer   bool              // the peer does not handshake, so we talk to it in the clear
+	remoteNodeKey   string            // node key the peer proved in the handshake, if any
 	peer            Peer              // the data structure representing the peer we are talking to. defined in peer.go
 	attempts        int               // reconnection attempts
 	TimeLastpacket  time.Time         // Time we last successfully received a packet or command.
@@ -115,10 +118,11 @@ type ConnectionMetrics struct {
 
 // ConnectionCommand is used to instruct the Connection to carry out some functionality.
 type ConnectionCommand struct {
-	Command uint8
-	Peer    Peer
-	Delta   int32
-	Metrics ConnectionMetrics
+	Command     uint8
+	Peer        Peer
+	Delta       int32
+	Metrics     ConnectionMetrics
+	Misbehavior Misbehavior
 }
 
 func (e *ConnectionCommand) JSONByte() ([]byte, error) {
@@ -141,7 +145,9 @@ const (
 	ConnectionUpdatingPeer
 	ConnectionAdjustPeerQuality
 	ConnectionUpdateMetrics
-	ConnectionGoOffline // Notifies the connection it should go offinline (eg from another goroutine)
+	ConnectionGoOffline   // Notifies the connection it should go offinline (eg from another goroutine)
+	ConnectionPenalize    // Notifies the connection its peer misbehaved (eg from the application)
+	ConnectionMisbehaving // Notifies the controller the peer misbehaved below MinumumQualityScore, so it can be banned
 )
 
 //////////////////////////////
@@ -156,6 +162,10 @@ func (c *Connection) InitWithConn(conn net.Conn, peer Peer) *Connection {
 	c.isOutGoing = false // InitWithConn is called by controller's accept() loop
 	c.commonInit(peer)
 	c.isPersistent = false
+	if EncryptedTransport {
+		// The handshake blocks, so it is left to the runloop rather than the controller
+		return c
+	}
 	c.goOnline()
 	return c
 }
@@ -260,6 +270,12 @@ func (c *Connection) runLoop() {
 				stateLogger.WithField("quality_score", c.peer.QualityScore).Info("Shutting down connection due to not reaching minimum quality score")
 				c.updatePeer() // every PeerSaveInterval * 0.90 we send an update peer to the controller.
 				c.goShutdown()
+			} else if !c.isOutGoing { // accepted, but still waiting on the handshake
+				if c.handshake() {
+					c.goOnline()
+				} else {
+					c.goShutdown()
+				}
 			} else {
 				c.dialLoop() // dialLoop dials until it connects or shuts down.
 			}
@@ -329,23 +345,99 @@ func (c *Connection) dialLoop() {
 
 // dial() handles connection logic and shifts states based on results.
 func (c *Connection) dial() bool {
+	wasPlaintext := c.plaintextPeer
+	if c.dialOnce() {
+		return true
+	}
+	if c.plaintextPeer && !wasPlaintext && AllowPlaintextPeers {
+		// We already sent our handshake, which the peer can't read. Start over in the
+		// clear, once; if that fails too, dialLoop decides when to try again.
+		return c.dialOnce()
+	}
+	return false
+}
+
+// dialOnce connects to the peer and runs the handshake on the connection
+func (c *Connection) dialOnce() bool {
 	address := c.peer.AddressPort()
 	// conn, err := net.Dial("tcp", c.peer.Address)
 	conn, err := net.DialTimeout("tcp", address, time.Second*10)
-	if nil == err {
-		c.conn = conn
+	if nil != err {
+		return false
+	}
+	c.conn = conn
+	if c.handshake() {
 		return true
 	}
+	conn.Close()
 	return false
 }
 
+// handshake runs the handshake (see handshake.go) on a new network conn, and sets the
+// stream parcels will be sent over.  Returns false if we should not talk to the peer.
+func (c *Connection) handshake() bool {
+	c.stream = c.conn
+	if !EncryptedTransport || c.plaintextPeer {
+		return true
+	}
+	c.conn.SetDeadline(time.Now().Add(HandshakeTimeout))
+	defer c.conn.SetDeadline(time.Time{})
+
+	stream, remote, err := DoHandshake(c.conn, LocalNodeKey, c.isOutGoing)
+
+	// A plaintext peer we dialed may well hang up on our handshake before it
+	// sends anything, so anything but a timeout counts as not handshaking.
+	nerr, isNetError := err.(net.Error)
+	hungUp := c.isOutGoing && err != nil && remote == nil && !(isNetError && nerr.Timeout())
+
+	switch {
+	case err == errPlaintextPeer && !AllowPlaintextPeers:
+		c.logger.Warn("Rejecting peer that does not handshake")
+		c.notes = "rejected: no handshake"
+		c.attempts = MaxNumberOfRedialAttempts + 50 // so we don't redial invalid Peer
+		return false
+	case (err == errPlaintextPeer || hungUp) && AllowPlaintextPeers:
+		c.plaintextPeer = true
+		c.logger.Info("Peer does not handshake, talking in the clear")
+		c.notes = "plaintext"
+		if c.isOutGoing {
+			return false // dial() starts over
+		}
+		c.stream = stream
+		return true
+	case err != nil:
+		c.logger.Warnf("Handshake failed: %v", err)
+		c.notes = fmt.Sprintf("handshake failed: %v", err)
+		if remote != nil {
+			c.attempts = MaxNumberOfRedialAttempts + 50 // they answered, but we won't talk to them
+		}
+		return false
+	}
+	if !c.isOutGoing {
+		c.peer.Port = remote.ListenPort
+	}
+	c.remoteNodeKey = fmt.Sprintf("%x", remote.NodeKey)
+	c.notes = "encrypted, node key " + c.remoteNodeKey[:16]
+	c.stream = stream
+	return true
+}
+
+// RemoteNodeKey is the node key the peer proved in the handshake, or "" for peers
+// that did not handshake
+func (c *Connection) RemoteNodeKey() string {
+	return c.remoteNodeKey
+}
+
 // Called when we are online and connected to the peer.
 func (c *Connection) goOnline() {
 	c.logger.Info("Connected to a remote peer")
 	p2pConnectionOnlineCall.Inc()
 	now := time.Now()
-	c.encoder = gob.NewEncoder(c.conn)
-	c.decoder = gob.NewDecoder(c.conn)
+	if c.stream == nil {
+		c.stream = c.conn
+	}
+	c.encoder = gob.NewEncoder(c.stream)
+	c.decoder = gob.NewDecoder(c.stream)
 	c.attempts = 0
 	c.timeLastPing = now
 	c.timeLastAttempt = now
@@ -372,6 +464,7 @@ func (c *Connection) goOffline() {
 	}
 	c.decoder = nil
 	c.encoder = nil
+	c.stream = nil
 	c.state = ConnectionOffline
 	c.attempts = 0
 	c.peer.demerit()
@@ -455,6 +548,8 @@ func (c *Connection) handleCommand() {
 		case ConnectionGoOffline:
 			c.logger.Debugf("handleCommand() disconnecting peer: %s goOffline command received", c.peer.PeerIdent())
 			c.goOffline()
+		case ConnectionPenalize:
+			c.penalize(command.Misbehavior)
 		default:
 			c.logger.Errorf("handleCommand() unknown command?: %+v ", command)
 		}
@@ -559,14 +654,17 @@ func (c *Connection) handleNetErrors(toss bool) {
 func (c *Connection) handleParcel(parcel Parcel) {
 	defer func() {
 		if r := recover(); r != nil {
-			c.peer.demerit() /// so someone DDoS or just incompatible will eventually be cut off after 200+ panics
+			c.penalize(MisbehaviorPanic) /// so someone DDoS or just incompatible will eventually be cut off
 			fmt.Fprintf(os.Stdout, "Caught Exception in connection %s: %v\n", c.peer.PeerFixedIdent(), r)
 			return
 		}
 	}()
 
 	c.peer.Port = parcel.Header.PeerPort // Peers communicate their port in the header. Could be moved to a handshake
-	validity := c.parcelValidity(parcel)
+	validity, misbehavior, misbehaved := c.parcelValidity(parcel)
+	if misbehaved {
+		c.penalize(misbehavior)
+	}
 	switch validity {
 	case InvalidDisconnectPeer:
 		parcel.LogEntry().Debug("Connection.handleParcel()-InvalidDisconnectPeer")
@@ -578,7 +676,6 @@ func (c *Connection) handleParcel(parcel Parcel) {
 	case InvalidPeerDemerit:
 		parcel.LogEntry().Debug("Connection.handleParcel()-InvalidPeerDemerit")
 		c.logger.Debug("Connection.handleParcel() got invalid message")
-		c.peer.demerit()
 		return
 	case ParcelValid:
 		parcel.LogEntry().Debug("Connection.handleParcel()-ParcelValid")
@@ -602,7 +699,8 @@ const (
 	InvalidDisconnectPeer       // Eg they are on the wrong network or wrong version of the software
 )
 
-func (c *Connection) parcelValidity(parcel Parcel) uint8 {
+// parcelValidity checks the parcel, and returns the misbehavior to penalize the peer for if any
+func (c *Connection) parcelValidity(parcel Parcel) (validity uint8, misbehavior Misbehavior, misbehaved bool) {
 	c.logger.Debugf("Connection.isValidParcel(%s)", parcel.MessageType())
 	crc := crc32.Checksum(parcel.Payload, CRCKoopmanTable)
 	switch {
@@ -610,26 +708,26 @@ func (c *Connection) parcelValidity(parcel Parcel) uint8 {
 		parcel.LogEntry().Debug("Connection.isValidParcel()-loopback")
 		c.logger.Warnf("Connection.isValidParcel(), failed due to loopback!: %+v", parcel.Header)
 		c.peer.QualityScore = MinumumQualityScore - 50 // Ban ourselves for a week
-		return InvalidDisconnectPeer
+		return InvalidDisconnectPeer, 0, false
 	case parcel.Header.Network != CurrentNetwork:
 		parcel.LogEntry().Debug("Connection.isValidParcel()-network")
 		c.logger.Warnf("Connection.isValidParcel(), failed due to wrong network. Remote: %0x Us: %0x", parcel.Header.Network, CurrentNetwork)
-		return InvalidDisconnectPeer
+		return InvalidDisconnectPeer, MisbehaviorNetwork, true
 	case parcel.Header.Version < ProtocolVersionMinimum:
 		parcel.LogEntry().Debug("Connection.isValidParcel()-version")
 		c.logger.Warnf("Connection.isValidParcel(), failed due to wrong version: %+v", parcel.Header)
-		return InvalidDisconnectPeer
+		return InvalidDisconnectPeer, MisbehaviorVersion, true
 	case parcel.Header.Length != uint32(len(parcel.Payload)):
 		parcel.LogEntry().Debug("Connection.isValidParcel()-length")
 		c.logger.Warnf("Connection.isValidParcel(), failed due to wrong length: %+v", parcel.Header)
-		return InvalidPeerDemerit
+		return InvalidPeerDemerit, MisbehaviorLength, true
 	case parcel.Header.Crc32 != crc:
 		parcel.LogEntry().Debug("Connection.isValidParcel()-checksum")
 		c.logger.Warnf("Connection.isValidParcel(), failed due to bad checksum: %+v", parcel.Header)
-		return InvalidPeerDemerit
+		return InvalidPeerDemerit, MisbehaviorChecksum, true
 	default:
 		parcel.LogEntry().Debug("Connection.isValidParcel()-ParcelValid")
-		return ParcelValid
+		return ParcelValid, 0, false
 	}
 }
 func (c *Connection) handleParcelTypes(parcel Parcel) {
@@ -681,6 +779,25 @@ func (c *Connection) pingPeer() {
 	}
 }
 
+// penalize lowers the quality score of the peer for the misbehavior.  If that drops
+// it below MinumumQualityScore, the controller is told so it can ban the peer.
+func (c *Connection) penalize(misbehavior Misbehavior) {
+	penalty := MisbehaviorPenalties[misbehavior]
+	if penalty == 0 {
+		return
+	}
+	score := int64(c.peer.QualityScore) + int64(penalty)
+	if score < -2147483000 {
+		score = -2147483000
+	}
+	c.logger.Infof("Connection(%s) penalized %d for %s, quality score is now %d", c.peer.AddressPort(), penalty, misbehavior, score)
+	c.peer.QualityScore = int32(score)
+
+	if MinumumQualityScore > c.peer.QualityScore && !c.isPersistent && !c.peer.IsSpecial() {
+		BlockFreeChannelSend(c.ReceiveChannel, ConnectionCommand{Command: ConnectionMisbehaving, Peer: c.peer, Misbehavior: misbehavior})
+	}
+}
+
 func (c *Connection) updatePeer() {
 	c.timeLastUpdate = time.Now()
 	BlockFreeChannelSend(c.ReceiveChannel, ConnectionCommand{Command: ConnectionUpdatingPeer, Peer: c.peer})
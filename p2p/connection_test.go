@@ -43,7 +43,7 @@ func TestConnectionCommandString(t *testing.T) {
 	c.Command = 4
 	c.Delta = 2
 
-	correct := `{"Command":4,"Peer":{"QualityScore":0,"Address":"","Port":"","NodeID":0,"Hash":"","Location":0,"Network":0,"Type":0,"Connections":0,"LastContact":"0001-01-01T00:00:00Z","Source":null},"Delta":2,"Metrics":{"MomentConnected":"0001-01-01T00:00:00Z","BytesSent":0,"BytesReceived":0,"MessagesSent":0,"MessagesReceived":0,"PeerAddress":"","PeerQuality":0,"PeerType":"","ConnectionState":"","ConnectionNotes":""}}`
+	correct := `{"Command":4,"Peer":{"QualityScore":0,"Address":"","Port":"","NodeID":0,"Hash":"","Location":0,"Network":0,"Type":0,"Connections":0,"LastContact":"0001-01-01T00:00:00Z","Source":null},"Delta":2,"Metrics":{"MomentConnected":"0001-01-01T00:00:00Z","BytesSent":0,"BytesReceived":0,"MessagesSent":0,"MessagesReceived":0,"PeerAddress":"","PeerQuality":0,"PeerType":"","ConnectionState":"","ConnectionNotes":""},"Misbehavior":0}`
 
 	data, err := c.JSONByte()
 	if err != nil {
@@ -96,3 +96,27 @@ func TestConnectionLoopBack(t *testing.T) {
 	con1.Close()
 	con2.Close()
 }
+
+func TestIncomingLowQualityPeerNotHandshaked(t *testing.T) {
+	encrypted, key := EncryptedTransport, LocalNodeKey
+	defer func() { EncryptedTransport, LocalNodeKey = encrypted, key }()
+	EncryptedTransport = true
+	LocalNodeKey, _ = NewNodeKey()
+
+	peer := new(Peer).Init("3.3.3.3", "1111", MinumumQualityScore-1, RegularPeer, 0)
+	peer.Source["Accept()"] = time.Now()
+
+	con1, con2 := net.Pipe()
+	defer con2.Close()
+	c := new(Connection)
+	c.InitWithConn(con1, *peer)
+	c.Start()
+
+	con2.SetReadDeadline(time.Now().Add(400 * time.Millisecond))
+	if n, _ := con2.Read(make([]byte, 64)); n > 0 {
+		t.Error("Handshake sent to a peer below the minimum quality score")
+	}
+	if c.IsOnline() {
+		t.Error("Peer below the minimum quality score is online")
+	}
+}
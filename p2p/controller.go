@@ -58,6 +58,7 @@ type Controller struct {
 	lastPeerRequest      time.Time        // Last time we asked peers about the peers they know about.
 	specialPeers         map[string]*Peer // special peers (from config file and from the command line params) by peer address
 	partsAssembler       *PartsAssembler  // a data structure that assembles full messages from received message parts
+	bans                 *BanList         // addresses and subnets we do not talk to
 
 	// logging
 	logger *log.Entry
@@ -70,12 +71,18 @@ type ControllerInit struct {
 	Network                  NetworkID        // Network - eg MainNet, TestNet etc.
 	Exclusive                bool             // flag to indicate we should only connect to trusted peers
 	ExclusiveIn              bool             // flag to indicate we should only connect to trusted peers and disallow incoming connections
-	SeedURL                  string           // URL to a source of peer info
+	SeedURL                  string           // Comma separated sources of peer info, tried in order (see SeedSource)
 	ConfigPeers              string           // Peers to always connect to at startup, and stay persistent, passed from the config file
 	CmdLinePeers             string           // Additional special peers passed from the command line
 	ConnectionMetricsChannel chan interface{} // Channel on which we put the connection metrics map, periodically.
 	LogPath                  string           // Path for logs
 	LogLevel                 string           // Logging level
+	Encrypted                bool             // flag to indicate we should handshake with peers and encrypt connections
+	RejectPlaintext          bool             // flag to indicate we should refuse peers that don't handshake (with Encrypted)
+	NodeKeyFile              string           // Path to the node key used in handshakes, created if missing
+	BansFile                 string           // Path to the file the ban list is saved in
+	BanDuration              time.Duration    // How long peers are banned for after misbehaving, 0 to not ban them
+	Penalties                string           // Quality score penalties for misbehavior, eg "checksum=-20,invalid=-2"
 }
 
 // CommandDialPeer is used to instruct the Controller to dial a peer address
@@ -132,6 +139,31 @@ func (e *CommandBan) String() string {
 	return str
 }
 
+// CommandPenalize is used to instruct the Controller to penalize a peer for misbehavior
+type CommandPenalize struct {
+	PeerHash    string
+	Misbehavior Misbehavior
+}
+
+func (e *CommandPenalize) JSONByte() ([]byte, error) {
+	return primitives.EncodeJSON(e)
+}
+
+func (e *CommandPenalize) JSONString() (string, error) {
+	return primitives.EncodeJSONString(e)
+}
+
+func (e *CommandPenalize) String() string {
+	str, _ := e.JSONString()
+	return str
+}
+
+// CommandDisconnectBanned is used to instruct the Controller to disconnect from the peers
+// that were banned since they connected
+type CommandDisconnectBanned struct {
+	_ uint8
+}
+
 // CommandDisconnect is used to instruct the Controller to disconnect from a peer
 type CommandDisconnect struct {
 	PeerHash string
@@ -182,10 +214,20 @@ func (c *Controller) Init(ci ControllerInit) *Controller {
 	CurrentNetwork = ci.Network
 	OnlySpecialPeers = ci.Exclusive || ci.ExclusiveIn
 	AllowUnknownIncomingPeers = !ci.ExclusiveIn
+	EncryptedTransport = ci.Encrypted
+	AllowPlaintextPeers = !ci.RejectPlaintext
+	if EncryptedTransport {
+		c.initNodeKey(ci.NodeKeyFile)
+	}
 	c.initSpecialPeers(ci)
 	c.lastDiscoveryRequest = time.Now() // Discovery does its own on startup.
 	c.lastConnectionMetricsUpdate = time.Now()
 	c.partsAssembler = new(PartsAssembler).Init()
+	c.bans = NewBanList(ci.BansFile)
+	AutoBanDuration = ci.BanDuration
+	if err := ParsePenalties(ci.Penalties); err != nil {
+		c.logger.Errorf("Controller.Init() invalid penalties, using the defaults: %v", err)
+	}
 	discovery := new(Discovery).Init(ci.PeersFile, ci.SeedURL)
 	c.discovery = *discovery
 	return c
@@ -229,6 +271,40 @@ func (c *Controller) Disconnect(peerHash string) {
 	BlockFreeChannelSend(c.commandChannel, CommandDisconnect{PeerHash: peerHash})
 }
 
+// ReportMisbehavior penalizes the peer, eg for sending a message the application found invalid
+func (c *Controller) ReportMisbehavior(peerHash string, misbehavior Misbehavior) {
+	if MisbehaviorPenalties[misbehavior] == 0 {
+		return
+	}
+	BlockFreeChannelSend(c.commandChannel, CommandPenalize{PeerHash: peerHash, Misbehavior: misbehavior})
+}
+
+// ListBans returns the bans that have not expired
+func (c *Controller) ListBans() []Ban {
+	return c.bans.List()
+}
+
+// AddBan bans an IP address or CIDR subnet for the duration, or forever if it is 0,
+// and disconnects the peers it covers
+func (c *Controller) AddBan(address string, reason string, duration time.Duration) (Ban, error) {
+	ban, err := c.bans.Add(address, reason, duration)
+	if err != nil {
+		return ban, err
+	}
+	c.logger.WithFields(log.Fields{"address": ban.Address, "reason": reason, "expires": ban.Expires}).Info("Banned address")
+	BlockFreeChannelSend(c.commandChannel, CommandDisconnectBanned{})
+	return ban, nil
+}
+
+// RemoveBan lifts the ban of an IP address or subnet, and returns false if it was not banned
+func (c *Controller) RemoveBan(address string) bool {
+	removed := c.bans.Remove(address)
+	if removed {
+		c.logger.WithField("address", address).Info("Lifted ban")
+	}
+	return removed
+}
+
 func (c *Controller) GetNumberOfConnections() int {
 	return c.connections.Count()
 }
@@ -342,6 +418,10 @@ func (c *Controller) canConnectTo(conn net.Conn) (bool, string) {
 		return false, "not a special peer and unknown incoming connections are not allowed"
 	}
 
+	if ban, banned := c.bans.IsBanned(conn.RemoteAddr().String()); banned && !c.isSpecialPeer(conn) {
+		return false, fmt.Sprintf("banned (%s): %s", ban.Address, ban.Reason)
+	}
+
 	return true, ""
 }
 
@@ -354,6 +434,19 @@ func (c *Controller) isSpecialPeer(conn net.Conn) bool {
 	return false
 }
 
+func (c *Controller) initNodeKey(path string) {
+	key, err := LoadNodeKey(path)
+	if err != nil {
+		c.logger.Errorf("Controller.initNodeKey() could not load %s, using a temporary key: %v", path, err)
+		key, err = NewNodeKey()
+		if err != nil {
+			panic(err)
+		}
+	}
+	LocalNodeKey = key
+	c.logger.WithField("node_key", key.String()).Info("Encrypting peer connections")
+}
+
 func (c *Controller) initSpecialPeers(ci ControllerInit) {
 	c.specialPeers = make(map[string]*Peer)
 	configPeers := c.parseSpecialPeers(ci.ConfigPeers, SpecialPeerConfig)
@@ -377,7 +470,7 @@ func (c *Controller) parseSpecialPeers(peersString string, peerType uint8) []*Pe
 	for _, peerAddress := range peerAddresses {
 		address, port, err := net.SplitHostPort(peerAddress)
 		if err != nil {
-			c.logger.Errorf("%s is not a valid peer (%v), use format: 127.0.0.1:8999", peersString, err)
+			c.logger.Errorf("%s is not a valid peer (%v), use format: 127.0.0.1:8999 or [::1]:8999", peerAddress, err)
 		} else {
 			peer := new(Peer).Init(address, port, 0, peerType, 0)
 			peer.Source["Local-Configuration"] = time.Now()
@@ -511,6 +604,11 @@ func (c *Controller) handleConnectionCommand(command ConnectionCommand, connecti
 		go connection.goShutdown()
 	case ConnectionUpdatingPeer:
 		c.discovery.updatePeer(command.Peer)
+	case ConnectionMisbehaving:
+		if AutoBanDuration > 0 && !command.Peer.IsSpecial() {
+			reason := fmt.Sprintf("quality score %d after %s", command.Peer.QualityScore, command.Misbehavior)
+			c.AddBan(command.Peer.Address, reason, AutoBanDuration)
+		}
 	default:
 		c.logger.Errorf("handleParcelReceive() unknown command.command?: %+v ", command.Command)
 	}
@@ -520,15 +618,24 @@ func (c *Controller) handleCommand(command interface{}) {
 	switch commandType := command.(type) {
 	case CommandDialPeer: // parameter is the peer address
 		parameters := command.(CommandDialPeer)
+		if _, banned := c.bans.IsBanned(parameters.peer.Address); banned && !parameters.persistent {
+			c.logger.Debugf("handleCommand() not dialing banned peer %s", parameters.peer.AddressPort())
+			break
+		}
 		conn := new(Connection).Init(parameters.peer, parameters.persistent)
 		c.handleNewConnection(conn)
 	case CommandAddPeer: // parameter is a Connection. This message is sent by the accept loop which is in a different goroutine
 
 		parameters := command.(CommandAddPeer)
 		conn := parameters.conn // net.Conn
-		addPort := strings.Split(conn.RemoteAddr().String(), ":")
+		address, port, err := net.SplitHostPort(conn.RemoteAddr().String())
+		if err != nil {
+			c.logger.Errorf("handleCommand() could not add peer %s: %v", conn.RemoteAddr(), err)
+			conn.Close()
+			break
+		}
 		// Port initially stored will be the connection port (not the listen port), but peer will update it on first message.
-		peer := new(Peer).Init(addPort[0], addPort[1], 0, RegularPeer, 0)
+		peer := new(Peer).Init(address, port, 0, RegularPeer, 0)
 		peer.Source["Accept()"] = time.Now()
 		connection := new(Connection).InitWithConn(conn, *peer)
 		c.handleNewConnection(connection)
@@ -542,6 +649,18 @@ func (c *Controller) handleCommand(command interface{}) {
 		parameters := command.(CommandBan)
 		peerHash := parameters.PeerHash
 		c.applicationPeerUpdate(BannedQualityScore, peerHash)
+		connection, present := c.connections.GetByHash(peerHash)
+		if present && !connection.peer.IsSpecial() {
+			c.bans.Add(connection.peer.Address, "banned by the application", ApplicationBanDuration)
+		}
+	case CommandPenalize:
+		parameters := command.(CommandPenalize)
+		connection, present := c.connections.GetByHash(parameters.PeerHash)
+		if present {
+			BlockFreeChannelSend(connection.SendChannel, ConnectionCommand{Command: ConnectionPenalize, Misbehavior: parameters.Misbehavior})
+		}
+	case CommandDisconnectBanned:
+		c.disconnectBanned()
 	case CommandDisconnect:
 		parameters := command.(CommandDisconnect)
 		connection, present := c.connections.GetByHash(parameters.PeerHash)
@@ -569,6 +688,19 @@ func (c *Controller) handleNewConnection(connection *Connection) {
 	c.connections.Add(connection)
 }
 
+// disconnectBanned shuts down the connections to banned peers, other than special peers
+func (c *Controller) disconnectBanned() {
+	for _, connection := range c.connections.All() {
+		if connection.peer.IsSpecial() {
+			continue
+		}
+		if ban, banned := c.bans.IsBanned(connection.peer.Address); banned {
+			c.logger.Infof("Disconnecting %s, it is banned (%s): %s", connection.peer.AddressPort(), ban.Address, ban.Reason)
+			BlockFreeChannelSend(connection.SendChannel, ConnectionCommand{Command: ConnectionShutdownNow})
+		}
+	}
+}
+
 func (c *Controller) applicationPeerUpdate(qualityDelta int32, peerHash string) {
 	connection, present := c.connections.GetByHash(peerHash)
 	if present {
@@ -617,6 +749,9 @@ func (c *Controller) fillOutgoingSlots(openSlots int) {
 	// To avoid dialing "too many" peers, we are keeping a count and only dialing the number of peers we need to add.
 	newPeers := 0
 	for _, peer := range peers {
+		if _, banned := c.bans.IsBanned(peer.Address); banned {
+			continue
+		}
 		if !c.connections.ConnectedTo(peer.Address) && newPeers < openSlots {
 			c.logger.Debugf("newPeers: %d < openSlots: %d We think we are not already connected to: %s so dialing.", newPeers, openSlots, peer.AddressPort())
 			newPeers = newPeers + 1
@@ -10,10 +10,10 @@ import (
 	"encoding/json"
 	"math/rand"
 	"net"
-	"net/http"
 	"os"
 	"sort"
 	"strconv"
+	"strings"
 	"sync"
 	"time"
 
@@ -25,10 +25,10 @@ var discoLogger = packageLogger.WithField("subpack", "discovery")
 type Discovery struct {
 	knownPeers map[string]Peer // peers we know about indexed by hash
 
-	peersFilePath string     // the path to the peers.
-	lastPeerSave  time.Time  // Last time we saved known peers.
-	rng           *rand.Rand // RNG = random number generator
-	seedURL       string     // URL to the source of a list of peers
+	peersFilePath string       // the path to the peers.
+	lastPeerSave  time.Time    // Last time we saved known peers.
+	rng           *rand.Rand   // RNG = random number generator
+	seeds         []SeedSource // the sources of lists of peers, in the order they are tried
 
 	// logging
 	logger *log.Entry
@@ -49,7 +49,11 @@ func (d *Discovery) Init(peersFile string, seed string) *Discovery {
 	UpdateKnownPeers.Unlock()
 	d.rng = rand.New(rand.NewSource(time.Now().UnixNano()))
 	d.peersFilePath = peersFile
-	d.seedURL = seed
+	seeds, err := ParseSeedSources(seed)
+	if err != nil {
+		d.logger.Errorf("Discovery.Init() %v", err)
+	}
+	d.seeds = seeds
 	//d.LoadPeers()
 	d.DiscoverPeersFromSeed()
 	return d
@@ -103,6 +107,7 @@ func (d *Discovery) LoadPeers() {
 	// since this is run at startup, reset quality scores.
 	for _, peer := range d.knownPeers {
 		peer.QualityScore = 0
+		peer.Address = NormalizeAddress(peer.Address)
 		peer.Location = peer.LocationFromAddress()
 		d.knownPeers[peer.Address] = peer
 	}
@@ -160,6 +165,14 @@ func (d *Discovery) LearnPeers(parcel Parcel) {
 	}
 	filteredArray := d.filterPeersFromOtherNetworks(peerArray)
 	for _, value := range filteredArray {
+		// Peers share IP addresses, IPv4 or IPv6, which may not be in our canonical form
+		ip := net.ParseIP(strings.Trim(value.Address, "[]"))
+		if ip == nil {
+			d.logger.Debugf("Discovery.LearnPeers ignoring peer with invalid address %s from %s", strconv.Quote(value.Address), parcel.Header.PeerAddress)
+			continue
+		}
+		value.Address = ip.String()
+		value.Location = locationFromIP(ip)
 		value.QualityScore = 0
 		switch d.isPeerPresent(value) {
 		case true:
@@ -198,10 +211,11 @@ func (d *Discovery) filterPeersFromOtherNetworks(peers []Peer) (filtered []Peer)
 func (d *Discovery) filterForUniqueIPAdresses(peers []Peer) (filtered []Peer) {
 	unique := map[string]Peer{}
 	for _, peer := range peers {
-		_, present := unique[peer.Address]
+		address := NormalizeAddress(peer.Address)
+		_, present := unique[address]
 		if !present {
 			filtered = append(filtered, peer)
-			unique[peer.Address] = peer
+			unique[address] = peer
 		}
 	}
 	return
@@ -320,32 +334,41 @@ func (d *Discovery) getPeerSelection() []byte {
 	return json
 }
 
-// DiscoverPeers gets a set of peers from a DNS Seed
+// DiscoverPeersFromSeed gets a set of peers from the seed sources.  The sources are
+// tried in order, until one of them gives us peers.  The peers remember which
+// source they came from.
 func (d *Discovery) DiscoverPeersFromSeed() {
-	d.logger.Info("Contacting seed URL to get peers")
-	resp, err := http.Get(d.seedURL)
-	if nil != err {
-		d.logger.Errorf("DiscoverPeersFromSeed getting peers from %s produced error %+v", d.seedURL, err)
-		return
+	for _, source := range d.seeds {
+		if d.discoverPeersFromSource(source) > 0 {
+			return
+		}
 	}
-	defer resp.Body.Close()
-	var lines []string
-	scanner := bufio.NewScanner(resp.Body)
-	for scanner.Scan() {
-		lines = append(lines, scanner.Text())
+	if len(d.seeds) > 0 {
+		d.logger.Errorf("DiscoverPeersFromSeed got no peers from any of the %d seed sources", len(d.seeds))
+	}
+}
+
+// discoverPeersFromSource adds the peers of the source, and returns how many it found
+func (d *Discovery) discoverPeersFromSource(source SeedSource) int {
+	d.logger.WithField("seed", source.String()).Info("Contacting seed source to get peers")
+	lines, err := source.Fetch()
+	if nil != err {
+		d.logger.Errorf("DiscoverPeersFromSeed getting peers from %s produced error %+v", source, err)
+		return 0
 	}
-	bad := 0
+	found := 0
 	for _, line := range lines {
 		address, port, err := net.SplitHostPort(line)
 		if err == nil {
 			peerp := new(Peer).Init(address, port, 0, RegularPeer, 0)
 			peer := *peerp
 			peer.LastContact = time.Now()
-			d.updatePeer(d.updatePeerSource(peer, "DNS-Seed"))
+			d.updatePeer(d.updatePeerSource(peer, "Seed "+source.String()))
+			found++
 		} else {
-			bad++
-			d.logger.Errorf("Bad peer in " + d.seedURL + " [" + line + "]")
+			d.logger.Errorf("Bad peer in " + source.String() + " [" + line + "]")
 		}
 	}
-	d.logger.Debugf("DiscoverPeersFromSeed got peers: %+v", lines)
+	d.logger.Debugf("DiscoverPeersFromSeed got peers from %s: %+v", source, lines)
+	return found
 }
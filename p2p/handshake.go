@@ -0,0 +1,390 @@
+// Copyright 2017 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package p2p
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/FactomProject/ed25519"
+	"golang.org/x/crypto/curve25519"
+)
+
+// The handshake is the first thing exchanged on a connection when EncryptedTransport
+// is on.  The dialing side sends its Handshake, the accepting side checks it and answers
+// with its own.  Each Handshake is signed by the node key of its sender, and carries a
+// fresh curve25519 key for the connection.  Both sides derive the session keys from
+// those, and from then on every byte of the gob stream goes through AES-GCM frames.
+// A connection that fails the handshake is closed before a single parcel is decoded.
+//
+// Peers running without EncryptedTransport start the gob stream right away.  With
+// AllowPlaintextPeers set we notice that (what they send first is not a handshake)
+// and fall back to the plaintext stream, so mixed networks keep working.
+
+// HandshakeMagic follows the length prefix of every handshake.
+var HandshakeMagic = [4]byte{0xfa, 0xc7, 0x0d, 0x00}
+
+var (
+	errPlaintextPeer  = errors.New("peer does not support the handshake")
+	errHandshakeBad   = errors.New("malformed handshake")
+	errHandshakeSig   = errors.New("handshake signature is invalid")
+	errHandshakeLoop  = errors.New("handshake from ourselves")
+	errSessionKey     = errors.New("session key agreement failed")
+	errFrameTooLarge  = errors.New("encrypted frame too large")
+	maxHandshakeSize  = 1024
+	maxSecureFrame    = 1 << 16 // Writes are split in frames no larger than this
+	sessionKeyContext = "factomd p2p session"
+)
+
+// Handshake is what a node says about itself when a connection is opened.
+type Handshake struct {
+	Version    uint16    // ProtocolVersion of the sender
+	Network    NetworkID // network the sender is on
+	NodeID     uint64    // the sender's NodeID
+	ListenPort string    // port the sender accepts connections on
+	NodeKey    [ed25519.PublicKeySize]byte
+	SessionKey [32]byte // curve25519 public key used for this connection only
+	Signature  [ed25519.SignatureSize]byte
+}
+
+// NodeKey is the ed25519 key pair a node signs its handshakes with.
+type NodeKey struct {
+	Private *[ed25519.PrivateKeySize]byte
+	Public  *[ed25519.PublicKeySize]byte
+}
+
+// LocalNodeKey identifies this node in handshakes.  Set by the Controller.
+var LocalNodeKey *NodeKey
+
+// NewNodeKey creates a random node key
+func NewNodeKey() (*NodeKey, error) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	return &NodeKey{Private: priv, Public: pub}, nil
+}
+
+// LoadNodeKey reads the node key from the given file, creating the file with a new
+// key if it does not exist.  An empty path gives a new key every run.
+func LoadNodeKey(path string) (*NodeKey, error) {
+	if path == "" {
+		return NewNodeKey()
+	}
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		key, err := NewNodeKey()
+		if err != nil {
+			return nil, err
+		}
+		err = ioutil.WriteFile(path, []byte(hex.EncodeToString(key.Private[:])), 0600)
+		if err != nil {
+			return nil, err
+		}
+		return key, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	raw, err := hex.DecodeString(strings.TrimSpace(string(data)))
+	if err != nil || len(raw) != ed25519.PrivateKeySize {
+		return nil, fmt.Errorf("%s does not hold a valid node key", path)
+	}
+	key := new(NodeKey)
+	key.Private = new([ed25519.PrivateKeySize]byte)
+	copy(key.Private[:], raw)
+	key.Public = ed25519.GetPublicKey(key.Private)
+	return key, nil
+}
+
+// String returns the public half of the key
+func (k *NodeKey) String() string {
+	return hex.EncodeToString(k.Public[:])
+}
+
+// signedData is everything in the handshake but the signature
+func (h *Handshake) signedData() []byte {
+	var buf bytes.Buffer
+	buf.Write(HandshakeMagic[:])
+	binary.Write(&buf, binary.BigEndian, h.Version)
+	binary.Write(&buf, binary.BigEndian, uint32(h.Network))
+	binary.Write(&buf, binary.BigEndian, h.NodeID)
+	buf.WriteByte(byte(len(h.ListenPort)))
+	buf.WriteString(h.ListenPort)
+	buf.Write(h.NodeKey[:])
+	buf.Write(h.SessionKey[:])
+	return buf.Bytes()
+}
+
+func (h *Handshake) Sign(key *NodeKey) {
+	copy(h.NodeKey[:], key.Public[:])
+	h.Signature = *ed25519.Sign(key.Private, h.signedData())
+}
+
+func (h *Handshake) Verify() bool {
+	return ed25519.VerifyCanonical(&h.NodeKey, h.signedData(), &h.Signature)
+}
+
+func (h *Handshake) MarshalBinary() ([]byte, error) {
+	if len(h.ListenPort) > 255 {
+		return nil, errHandshakeBad
+	}
+	data := append(h.signedData(), h.Signature[:]...)
+	return data, nil
+}
+
+func (h *Handshake) UnmarshalBinary(data []byte) error {
+	fixed := len(HandshakeMagic) + 2 + 4 + 8 + 1
+	if len(data) < fixed || !bytes.Equal(data[:len(HandshakeMagic)], HandshakeMagic[:]) {
+		return errHandshakeBad
+	}
+	data = data[len(HandshakeMagic):]
+	h.Version, data = binary.BigEndian.Uint16(data), data[2:]
+	h.Network, data = NetworkID(binary.BigEndian.Uint32(data)), data[4:]
+	h.NodeID, data = binary.BigEndian.Uint64(data), data[8:]
+	portLen := int(data[0])
+	data = data[1:]
+	if len(data) != portLen+len(h.NodeKey)+len(h.SessionKey)+len(h.Signature) {
+		return errHandshakeBad
+	}
+	h.ListenPort, data = string(data[:portLen]), data[portLen:]
+	copy(h.NodeKey[:], data)
+	data = data[len(h.NodeKey):]
+	copy(h.SessionKey[:], data)
+	data = data[len(h.SessionKey):]
+	copy(h.Signature[:], data)
+	return nil
+}
+
+// newHandshake makes our signed handshake and the private half of its session key
+func newHandshake(key *NodeKey) (*Handshake, *[32]byte, error) {
+	h := new(Handshake)
+	h.Version = ProtocolVersion
+	h.Network = CurrentNetwork
+	h.NodeID = NodeID
+	h.ListenPort = NetworkListenPort
+	secret := new([32]byte)
+	if _, err := io.ReadFull(rand.Reader, secret[:]); err != nil {
+		return nil, nil, err
+	}
+	curve25519.ScalarBaseMult(&h.SessionKey, secret)
+	h.Sign(key)
+	return h, secret, nil
+}
+
+// writeHandshake sends the handshake, length prefixed
+func writeHandshake(w io.Writer, h *Handshake) error {
+	data, err := h.MarshalBinary()
+	if err != nil {
+		return err
+	}
+	frame := make([]byte, 2, 2+len(data))
+	binary.BigEndian.PutUint16(frame, uint16(len(data)))
+	_, err = w.Write(append(frame, data...))
+	return err
+}
+
+// readHandshake reads the remote handshake.  If the peer started with anything else,
+// it returns errPlaintextPeer and the bytes consumed so they can be replayed.
+func readHandshake(r io.Reader) (*Handshake, []byte, error) {
+	prefix := make([]byte, 2+len(HandshakeMagic))
+	n, err := io.ReadFull(r, prefix)
+	if err != nil {
+		return nil, prefix[:n], err
+	}
+	if !bytes.Equal(prefix[2:], HandshakeMagic[:]) {
+		return nil, prefix, errPlaintextPeer
+	}
+	size := int(binary.BigEndian.Uint16(prefix))
+	if size < len(HandshakeMagic) || size > maxHandshakeSize {
+		return nil, nil, errHandshakeBad
+	}
+	data := make([]byte, size)
+	copy(data, HandshakeMagic[:])
+	if _, err = io.ReadFull(r, data[len(HandshakeMagic):]); err != nil {
+		return nil, nil, err
+	}
+	h := new(Handshake)
+	if err = h.UnmarshalBinary(data); err != nil {
+		return nil, nil, err
+	}
+	return h, nil, nil
+}
+
+// checkHandshake decides whether we talk to the sender of the handshake
+func checkHandshake(h *Handshake, key *NodeKey) error {
+	switch {
+	case !h.Verify():
+		return errHandshakeSig
+	case h.NodeKey == *key.Public:
+		return errHandshakeLoop
+	case h.Network != CurrentNetwork:
+		return fmt.Errorf("wrong network. Remote: %#x Us: %#x", uint32(h.Network), uint32(CurrentNetwork))
+	case h.Version < ProtocolVersionMinimum:
+		return fmt.Errorf("protocol version %d is below the minimum %d", h.Version, ProtocolVersionMinimum)
+	}
+	return nil
+}
+
+// DoHandshake runs the handshake over conn.  The dialing side (outgoing) speaks
+// first.  On success it returns the stream to send parcels over and the remote
+// handshake.  If the remote is a plaintext peer, err is errPlaintextPeer; an accepting
+// side still gets a stream that replays what the remote already sent.
+func DoHandshake(conn io.ReadWriter, key *NodeKey, outgoing bool) (io.ReadWriter, *Handshake, error) {
+	local, secret, err := newHandshake(key)
+	if err != nil {
+		return nil, nil, err
+	}
+	if outgoing {
+		if err = writeHandshake(conn, local); err != nil {
+			return nil, nil, err
+		}
+	}
+	remote, consumed, err := readHandshake(conn)
+	if err == errPlaintextPeer {
+		return &replayStream{Reader: io.MultiReader(bytes.NewReader(consumed), conn), Writer: conn}, nil, err
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	if err = checkHandshake(remote, key); err != nil {
+		return nil, remote, err
+	}
+	if !outgoing {
+		if err = writeHandshake(conn, local); err != nil {
+			return nil, remote, err
+		}
+	}
+
+	var shared [32]byte
+	curve25519.ScalarMult(&shared, secret, &remote.SessionKey)
+	if shared == [32]byte{} {
+		return nil, remote, errSessionKey
+	}
+	initiator, responder := local, remote
+	if !outgoing {
+		initiator, responder = remote, local
+	}
+	toResponder := sessionKey(shared, "initiator", initiator, responder)
+	toInitiator := sessionKey(shared, "responder", initiator, responder)
+	if outgoing {
+		s, err := newSecureStream(conn, toResponder, toInitiator)
+		return s, remote, err
+	}
+	s, err := newSecureStream(conn, toInitiator, toResponder)
+	return s, remote, err
+}
+
+func sessionKey(shared [32]byte, direction string, initiator, responder *Handshake) []byte {
+	h := sha256.New()
+	h.Write([]byte(sessionKeyContext))
+	h.Write([]byte(direction))
+	h.Write(shared[:])
+	h.Write(initiator.SessionKey[:])
+	h.Write(responder.SessionKey[:])
+	return h.Sum(nil)
+}
+
+// replayStream hands back bytes read while looking for a handshake
+type replayStream struct {
+	io.Reader
+	io.Writer
+}
+
+// secureStream is an io.ReadWriter sending AES-GCM sealed frames: a 4 byte length,
+// then the ciphertext.  Each direction has its own key, and the nonce is a message
+// counter, so a frame that is dropped, replayed or reordered fails to open.
+type secureStream struct {
+	conn      io.ReadWriter
+	sealer    cipher.AEAD
+	opener    cipher.AEAD
+	sendCount uint64
+	recvCount uint64
+	pending   []byte // opened plaintext not yet read
+}
+
+func newSecureStream(conn io.ReadWriter, sendKey, recvKey []byte) (*secureStream, error) {
+	s := new(secureStream)
+	s.conn = conn
+	var err error
+	if s.sealer, err = newAEAD(sendKey); err != nil {
+		return nil, err
+	}
+	if s.opener, err = newAEAD(recvKey); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func (s *secureStream) nonce(count uint64) []byte {
+	nonce := make([]byte, s.sealer.NonceSize())
+	binary.BigEndian.PutUint64(nonce[len(nonce)-8:], count)
+	return nonce
+}
+
+func (s *secureStream) Write(p []byte) (int, error) {
+	written := 0
+	for len(p) > 0 {
+		chunk := p
+		if len(chunk) > maxSecureFrame {
+			chunk = chunk[:maxSecureFrame]
+		}
+		sealed := s.sealer.Seal(nil, s.nonce(s.sendCount), chunk, nil)
+		s.sendCount++
+		frame := make([]byte, 4, 4+len(sealed))
+		binary.BigEndian.PutUint32(frame, uint32(len(sealed)))
+		if _, err := s.conn.Write(append(frame, sealed...)); err != nil {
+			return written, err
+		}
+		written += len(chunk)
+		p = p[len(chunk):]
+	}
+	return written, nil
+}
+
+func (s *secureStream) Read(p []byte) (int, error) {
+	for len(s.pending) == 0 {
+		var size [4]byte
+		if _, err := io.ReadFull(s.conn, size[:]); err != nil {
+			return 0, err
+		}
+		length := binary.BigEndian.Uint32(size[:])
+		if length > uint32(maxSecureFrame+s.opener.Overhead()) {
+			return 0, errFrameTooLarge
+		}
+		sealed := make([]byte, length)
+		if _, err := io.ReadFull(s.conn, sealed); err != nil {
+			return 0, err
+		}
+		opened, err := s.opener.Open(sealed[:0], s.nonce(s.recvCount), sealed, nil)
+		if err != nil {
+			return 0, err
+		}
+		s.recvCount++
+		s.pending = opened
+	}
+	n := copy(p, s.pending)
+	s.pending = s.pending[n:]
+	return n, nil
+}
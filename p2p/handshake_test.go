@@ -0,0 +1,200 @@
+package p2p_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "github.com/FactomProject/factomd/p2p"
+)
+
+type handshakeResult struct {
+	stream io.ReadWriter
+	remote *Handshake
+	err    error
+}
+
+// handshakePair runs both sides of the handshake over a pipe
+func handshakePair() (dialer, listener handshakeResult, dialKey, listenKey *NodeKey) {
+	dialKey, _ = NewNodeKey()
+	listenKey, _ = NewNodeKey()
+	a, b := net.Pipe()
+	done := make(chan handshakeResult)
+	go func() {
+		var r handshakeResult
+		r.stream, r.remote, r.err = DoHandshake(b, listenKey, false)
+		done <- r
+	}()
+	dialer.stream, dialer.remote, dialer.err = DoHandshake(a, dialKey, true)
+	listener = <-done
+	return
+}
+
+func sendHandshake(w io.Writer, h *Handshake) {
+	data, _ := h.MarshalBinary()
+	var size [2]byte
+	binary.BigEndian.PutUint16(size[:], uint16(len(data)))
+	w.Write(append(size[:], data...))
+}
+
+func TestHandshakeMarshal(t *testing.T) {
+	key, err := NewNodeKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := new(Handshake)
+	h.Version = ProtocolVersion
+	h.Network = TestNet
+	h.NodeID = 42
+	h.ListenPort = "8108"
+	h.SessionKey[0] = 1
+	h.Sign(key)
+	if !h.Verify() {
+		t.Error("Signed handshake does not verify")
+	}
+
+	data, err := h.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	h2 := new(Handshake)
+	err = h2.UnmarshalBinary(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *h != *h2 {
+		t.Errorf("Handshake changed in a round trip: %v vs %v", h, h2)
+	}
+	if !h2.Verify() {
+		t.Error("Unmarshaled handshake does not verify")
+	}
+
+	h2.ListenPort = "8109"
+	if h2.Verify() {
+		t.Error("Altered handshake still verifies")
+	}
+	if h2.UnmarshalBinary(data[:len(data)-1]) == nil {
+		t.Error("Short handshake unmarshaled")
+	}
+}
+
+func TestHandshakeEncrypted(t *testing.T) {
+	dialer, listener, dialKey, listenKey := handshakePair()
+	if dialer.err != nil || listener.err != nil {
+		t.Fatalf("Handshake failed: %v %v", dialer.err, listener.err)
+	}
+	if dialer.remote.NodeKey != *listenKey.Public || listener.remote.NodeKey != *dialKey.Public {
+		t.Error("Handshake returned the wrong node keys")
+	}
+
+	// Bigger than one frame, to check the chunking
+	msg := make([]byte, 200000)
+	for i := range msg {
+		msg[i] = byte(i)
+	}
+	go func() {
+		dialer.stream.Write(msg)
+		dialer.stream.Write([]byte("reply"))
+	}()
+	got := make([]byte, len(msg))
+	if _, err := io.ReadFull(listener.stream, got); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, msg) {
+		t.Error("Message changed going through the secure stream")
+	}
+	reply := make([]byte, 5)
+	if _, err := io.ReadFull(listener.stream, reply); err != nil || string(reply) != "reply" {
+		t.Errorf("Got %q, %v", reply, err)
+	}
+}
+
+func TestHandshakeRejected(t *testing.T) {
+	key, _ := NewNodeKey()
+	listenKey, _ := NewNodeKey()
+
+	wrongNetwork := new(Handshake)
+	wrongNetwork.Version = ProtocolVersion
+	wrongNetwork.Network = CurrentNetwork + 1
+	wrongNetwork.NodeID = NodeID + 1
+	wrongNetwork.Sign(key)
+
+	badSig := new(Handshake)
+	badSig.Version = ProtocolVersion
+	badSig.Network = CurrentNetwork
+	badSig.NodeID = NodeID + 1
+	badSig.Sign(key)
+	badSig.ListenPort = "1"
+
+	oldVersion := new(Handshake)
+	oldVersion.Network = CurrentNetwork
+	oldVersion.NodeID = NodeID + 1
+	oldVersion.Sign(key)
+
+	ourselves := new(Handshake)
+	ourselves.Version = ProtocolVersion
+	ourselves.Network = CurrentNetwork
+	ourselves.Sign(listenKey)
+
+	for i, h := range []*Handshake{wrongNetwork, badSig, oldVersion, ourselves} {
+		a, b := net.Pipe()
+		go sendHandshake(a, h)
+		stream, _, err := DoHandshake(b, listenKey, false)
+		if err == nil || stream != nil {
+			t.Errorf("Handshake %d was accepted", i)
+		}
+		a.Close()
+		b.Close()
+	}
+}
+
+func TestHandshakePlaintextPeer(t *testing.T) {
+	key, _ := NewNodeKey()
+	a, b := net.Pipe()
+	go a.Write([]byte("gob stream"))
+	stream, remote, err := DoHandshake(b, key, false)
+	if err == nil || remote != nil {
+		t.Fatal("Plaintext peer passed the handshake")
+	}
+	if stream == nil {
+		t.Fatal("No stream to replay the plaintext peer")
+	}
+	got := make([]byte, 10)
+	if _, err = io.ReadFull(stream, got); err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != "gob stream" {
+		t.Errorf("Replayed %q", got)
+	}
+}
+
+func TestLoadNodeKey(t *testing.T) {
+	dir, err := ioutil.TempDir("", "nodekey")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "peers.nodekey")
+
+	key, err := LoadNodeKey(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	again, err := LoadNodeKey(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *key.Private != *again.Private || key.String() != again.String() {
+		t.Error("Node key changed after reloading it")
+	}
+
+	ioutil.WriteFile(path, []byte("not a key"), 0600)
+	if _, err = LoadNodeKey(path); err == nil {
+		t.Error("Loaded an invalid node key")
+	}
+}
@@ -3,7 +3,6 @@ package p2p
 import (
 	"fmt"
 	"net"
-	"strings"
 	"time"
 )
 
@@ -29,10 +28,15 @@ func (l *limitListenerSources) Accept() (net.Conn, error) {
 		return nil, err
 	}
 
-	// Grab the address, check for last connection
-	addr := strings.Split(c.RemoteAddr().String(), ":")
-	if v, ok := l.accepted[addr[0]]; !ok || time.Since(v) > time.Second {
-		l.accepted[addr[0]] = time.Now()
+	// Grab the address, check for last connection. IPv6 addresses have colons
+	// too, so split off the port rather than splitting on colons
+	addr, _, err := net.SplitHostPort(c.RemoteAddr().String())
+	if err != nil {
+		addr = c.RemoteAddr().String()
+	}
+	addr = NormalizeAddress(addr)
+	if v, ok := l.accepted[addr]; !ok || time.Since(v) > time.Second {
+		l.accepted[addr] = time.Now()
 		return c, nil
 	}
 	c.Close()
@@ -8,6 +8,7 @@ import (
 	"fmt"
 	"math/rand"
 	"net"
+	"strings"
 	"time"
 
 	log "github.com/sirupsen/logrus"
@@ -19,7 +20,7 @@ var peerLogger = packageLogger.WithField("subpack", "peer")
 
 type Peer struct {
 	QualityScore int32     // 0 is neutral quality, negative is a bad peer.
-	Address      string    // Must be in form of x.x.x.x
+	Address      string    // IPv4 (x.x.x.x) or IPv6 address without brackets, see NormalizeAddress
 	Port         string    // Must be in form of xxxx
 	NodeID       uint64    // a nonce to distinguish multiple nodes behind one IP address
 	Hash         string    // This is more of a connection ID than hash right now.
@@ -47,6 +48,7 @@ func (p *Peer) Init(address string, port string, quality int32, peerType uint8,
 		"port":     port,
 		"peerType": peerType,
 	})
+	address = strings.Trim(address, "[]")
 	if net.ParseIP(address) == nil {
 		ipAddress, err := net.LookupHost(address)
 		if err != nil {
@@ -57,7 +59,7 @@ func (p *Peer) Init(address string, port string, quality int32, peerType uint8,
 		}
 	}
 
-	p.Address = address
+	p.Address = NormalizeAddress(address)
 	p.Port = port
 	p.QualityScore = quality
 	p.generatePeerHash()
@@ -69,20 +71,33 @@ func (p *Peer) Init(address string, port string, quality int32, peerType uint8,
 }
 
 func (p *Peer) generatePeerHash() {
-	p.Hash = fmt.Sprintf("%s:%s %x", p.Address, p.Port, rand.Int63())
+	p.Hash = fmt.Sprintf("%s %x", p.AddressPort(), rand.Int63())
 }
 
+// NormalizeAddress returns the canonical form of an IP address, so the same
+// address is always the same string: without brackets, IPv4 mapped IPv6
+// addresses as IPv4, and IPv6 addresses in their shortest form.  Host names
+// are returned as they are.
+func NormalizeAddress(address string) string {
+	address = strings.Trim(address, "[]")
+	if ip := net.ParseIP(address); ip != nil {
+		return ip.String()
+	}
+	return address
+}
+
+// AddressPort returns the host:port to dial, with IPv6 addresses in brackets
 func (p *Peer) AddressPort() string {
-	return p.Address + ":" + p.Port
+	return net.JoinHostPort(p.Address, p.Port)
 }
 
 func (p *Peer) PeerIdent() string {
-	return p.Hash[0:12] + "-" + p.Address + ":" + p.Port
+	return p.Hash[0:12] + "-" + p.AddressPort()
 }
 
 func (p *Peer) PeerFixedIdent() string {
-	address := fmt.Sprintf("%16s", p.Address)
-	return p.Hash[0:12] + "-" + address + ":" + p.Port
+	address := fmt.Sprintf("%16s", p.AddressPort())
+	return p.Hash[0:12] + "-" + address
 }
 
 func (p *Peer) PeerLogFields() log.Fields {
@@ -107,21 +122,10 @@ func (p *Peer) LastSource() (result string) {
 	return
 }
 
-// TODO Hadn't considered IPV6 address support.
-// TODO Need to audit all the net code to check IPv6 addresses
-// Here's an IPv6 conversion:
-// Ref: http://stackoverflow.com/questions/23297141/golang-net-ip-to-ipv6-from-mysql-as-decimal39-0-conversion
-// func ipv6ToInt(IPv6Addr net.IP) *big.Int {
-//     IPv6Int := big.NewInt(0)
-//     IPv6Int.SetBytes(IPv6Addr)
-//     return IPv6Int
-// }
-// Problem is we're working with string addresses, may never have made a connection.
-// TODO - we might have a DNS address, not iP address and need to resolve it!
 // locationFromAddress converts the peers address into a uint32 "location" numeric
+// TODO - we might have a DNS address, not iP address and need to resolve it!
 func (p *Peer) LocationFromAddress() (location uint32) {
 	location = 0
-	// Split the IPv4 octets
 	ip := net.ParseIP(p.Address)
 	if ip == nil {
 		ipAddress, err := net.LookupHost(p.Address)
@@ -131,18 +135,28 @@ func (p *Peer) LocationFromAddress() (location uint32) {
 			p.logger.Debugf("Peer: %s has Location: %d", p.Hash, location)
 			return 0 // We use location on 0 to say invalid
 		}
-		p.Address = ipAddress[0]
+		p.Address = NormalizeAddress(ipAddress[0])
 		ip = net.ParseIP(p.Address)
 	}
-	if len(ip) == 16 { // If we got back an IP6 (16 byte) address, use the last 4 byte
-		ip = ip[12:]
+	location = locationFromIP(ip)
+	p.logger.Debugf("peer", "Peer: %s has Location: %d", p.Hash, location)
+	return location
+}
+
+// locationFromIP turns the IPv4 address, or the first 32 bits (the routing
+// prefix) of an IPv6 address, into a uint32.  Peers close together on the
+// network have locations close together, which is what the location is used for.
+func locationFromIP(ip net.IP) (location uint32) {
+	if ip4 := ip.To4(); ip4 != nil {
+		ip = ip4
+	}
+	if len(ip) < 4 {
+		return 0
 	}
-	// Turn into uint32
 	location += uint32(ip[0]) << 24
 	location += uint32(ip[1]) << 16
 	location += uint32(ip[2]) << 8
 	location += uint32(ip[3])
-	p.logger.Debugf("peer", "Peer: %s has Location: %d", p.Hash, location)
 	return location
 }
 
@@ -151,7 +165,7 @@ func (p *Peer) IsSamePeerAs(netAddress net.Addr) bool {
 	if err != nil {
 		return false
 	}
-	return address == p.Address
+	return NormalizeAddress(address) == p.Address
 }
 
 // merit increases a peers reputation
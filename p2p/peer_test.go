@@ -0,0 +1,147 @@
+package p2p
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestNormalizeAddress(t *testing.T) {
+	for address, expected := range map[string]string{
+		"1.2.3.4":                  "1.2.3.4",
+		"[2001:db8::1]":            "2001:db8::1",
+		"2001:0DB8:0000::0001":     "2001:db8::1",
+		"[::ffff:1.2.3.4]":         "1.2.3.4",
+		"::1":                      "::1",
+		"seed.factom.com":          "seed.factom.com",
+		"[fe80::1:2:3:4]":          "fe80::1:2:3:4",
+		"2001:db8:0:0:0:0:0:00ff":  "2001:db8::ff",
+		"0000:0000:0000::0:0:0001": "::1",
+	} {
+		if NormalizeAddress(address) != expected {
+			t.Errorf("NormalizeAddress(%s) is %s, expected %s", address, NormalizeAddress(address), expected)
+		}
+	}
+}
+
+func TestIPv6Peer(t *testing.T) {
+	peer := new(Peer).Init("[2001:DB8::1]", "8108", 0, RegularPeer, 0)
+	if peer.Address != "2001:db8::1" {
+		t.Errorf("Wrong address %s", peer.Address)
+	}
+	if peer.AddressPort() != "[2001:db8::1]:8108" {
+		t.Errorf("Wrong address and port %s", peer.AddressPort())
+	}
+	if peer.Location != 0x20010db8 {
+		t.Errorf("Wrong location %x", peer.Location)
+	}
+	if !peer.IsSamePeerAs(&net.TCPAddr{IP: net.ParseIP("2001:db8::1"), Port: 5555}) {
+		t.Error("Not the same peer as its address")
+	}
+	if peer.IsSamePeerAs(&net.TCPAddr{IP: net.ParseIP("2001:db8::2"), Port: 8108}) {
+		t.Error("The same peer as another address")
+	}
+
+	mapped := new(Peer).Init("::ffff:10.0.0.1", "8108", 0, RegularPeer, 0)
+	if mapped.Address != "10.0.0.1" || mapped.AddressPort() != "10.0.0.1:8108" || mapped.Location != 0x0a000001 {
+		t.Errorf("Wrong IPv4 mapped peer %s %x", mapped.AddressPort(), mapped.Location)
+	}
+}
+
+func TestParseIPv6SpecialPeers(t *testing.T) {
+	c := new(Controller)
+	c.logger = controllerLogger
+	peers := c.parseSpecialPeers("[2001:db8::1]:8108 1.2.3.4:8109 [::1]:8110 2001:db8::2", SpecialPeerConfig)
+	if len(peers) != 3 {
+		t.Fatalf("Expected 3 special peers, got %d", len(peers))
+	}
+	for i, expected := range []string{"[2001:db8::1]:8108", "1.2.3.4:8109", "[::1]:8110"} {
+		if peers[i].AddressPort() != expected {
+			t.Errorf("Special peer %d is %s, expected %s", i, peers[i].AddressPort(), expected)
+		}
+	}
+}
+
+func TestLearnAndShareIPv6Peers(t *testing.T) {
+	dir, err := ioutil.TempDir("", "peers")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	d := new(Discovery).Init(filepath.Join(dir, "peers.json"), "")
+	shared := []Peer{
+		{Address: "[2001:DB8::1]", Port: "8108", Network: CurrentNetwork},
+		{Address: "2001:db8:0::1", Port: "8108", Network: CurrentNetwork},
+		{Address: "::ffff:1.2.3.4", Port: "8108", Network: CurrentNetwork},
+		{Address: "1.2.3.4", Port: "8108", Network: CurrentNetwork},
+		{Address: "not an address", Port: "8108", Network: CurrentNetwork},
+	}
+	payload, err := json.Marshal(shared)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parcel := NewParcel(CurrentNetwork, payload)
+	parcel.Header.PeerAddress = "2001:db8::99"
+	d.LearnPeers(*parcel)
+
+	peers := d.GetOutgoingPeers()
+	if len(peers) != 2 {
+		t.Fatalf("Expected 2 unique peers, got %+v", peers)
+	}
+	for _, peer := range peers {
+		if peer.Address != "2001:db8::1" && peer.Address != "1.2.3.4" {
+			t.Errorf("Unexpected peer %s", peer.Address)
+		}
+		if peer.Address == "2001:db8::1" && peer.Location != 0x20010db8 {
+			t.Errorf("Wrong location %x of %s", peer.Location, peer.Address)
+		}
+	}
+
+	unique := d.filterForUniqueIPAdresses([]Peer{
+		{Address: "2001:db8::1"}, {Address: "[2001:db8::1]"}, {Address: "::ffff:1.2.3.4"}, {Address: "1.2.3.4"},
+	})
+	if len(unique) != 2 {
+		t.Errorf("Expected 2 unique addresses, got %+v", unique)
+	}
+}
+
+func TestLimitIPv6ListenerSources(t *testing.T) {
+	listener, err := net.Listen("tcp", "[::1]:0")
+	if err != nil {
+		t.Skipf("No IPv6 loopback: %v", err)
+	}
+	limited := LimitListenerSources(listener)
+	defer limited.Close()
+
+	accepted := make(chan error, 2)
+	go func() {
+		for i := 0; i < 2; i++ {
+			conn, err := limited.Accept()
+			if err == nil {
+				conn.Close()
+			}
+			accepted <- err
+		}
+	}()
+
+	for i := 0; i < 2; i++ {
+		conn, err := net.Dial("tcp", listener.Addr().String())
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer conn.Close()
+	}
+	if err := <-accepted; err != nil {
+		t.Errorf("First connection from [::1] was rejected: %v", err)
+	}
+	if err := <-accepted; err == nil {
+		t.Error("Second connection from [::1] within a second was not rate limited")
+	}
+	if len(limited.(*limitListenerSources).accepted) != 1 {
+		t.Errorf("Expected a single source, got %v", limited.(*limitListenerSources).accepted)
+	}
+}
@@ -55,6 +55,9 @@ var (
 	MinumumSharingQualityScore   int32  = 20          // if a peer's score is less than this we don't share them.
 	OnlySpecialPeers                    = false       // dial out to special peers only
 	AllowUnknownIncomingPeers           = true        // allow incoming connections from peers that are not in the special peer list
+	EncryptedTransport                  = false       // handshake with peers and encrypt the connection (see handshake.go)
+	AllowPlaintextPeers                 = true        // with EncryptedTransport, still talk in the clear to peers that don't handshake
+	HandshakeTimeout                    = time.Second * 10
 	NetworkDeadline                     = time.Duration(30) * time.Second
 	NumberPeersToConnect                = 32
 	NumberPeersToBroadcast              = 8 // This gets overwritten by command line flag!
@@ -68,6 +71,9 @@ var (
 	PeerSaveInterval                    = time.Second * 30
 	PeerRequestInterval                 = time.Second * 180
 	PeerDiscoveryInterval               = time.Hour * 4
+	SeedTimeout                         = time.Second * 10   // How long to wait for a seed source that does not set its own timeout
+	AutoBanDuration                     = time.Duration(0)   // How long a peer is banned for after misbehaving below MinumumQualityScore, 0 to not ban it
+	ApplicationBanDuration              = time.Hour * 24 * 7 // How long a peer the application bans is banned for
 
 	// Testing metrics
 	TotalMessagesReceived       uint64
@@ -0,0 +1,178 @@
+// Copyright 2017 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package p2p
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// Kinds of seed sources
+const (
+	SeedURL  = "url"  // A http(s) URL serving a list of host:port lines
+	SeedDNS  = "dns"  // A DNS name, all its A and AAAA records are peers
+	SeedFile = "file" // A local file with a list of host:port lines
+)
+
+// SeedSource is a place we get peers from when we start out, or when it has
+// been a while (see PeerDiscoveryInterval).  The sources in the config are
+// separated by commas and tried in order, until one of them gives us peers:
+//
+//	https://example.com/seed.txt	a URL
+//	dns://seed.example.com:8108	a DNS seed, the port defaults to the network port
+//	file:///home/factom/seed.txt	a local file
+//
+// Every source can have its own timeout, eg dns://seed.example.com#timeout=3s
+type SeedSource struct {
+	Kind     string
+	Location string        // The URL, DNS name or path
+	Port     string        // Port of the peers of a DNS seed
+	Timeout  time.Duration // How long to wait for the source
+}
+
+// ParseSeedSources parses the comma separated seed sources of the config, and
+// returns the ones it could parse together with an error for the others
+func ParseSeedSources(config string) ([]SeedSource, error) {
+	var sources []SeedSource
+	var bad []string
+	for _, field := range strings.Split(config, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		source, err := ParseSeedSource(field)
+		if err != nil {
+			bad = append(bad, err.Error())
+			continue
+		}
+		sources = append(sources, source)
+	}
+	if len(bad) > 0 {
+		return sources, fmt.Errorf("invalid seed sources: %s", strings.Join(bad, "; "))
+	}
+	return sources, nil
+}
+
+// ParseSeedSource parses a single seed source, see SeedSource
+func ParseSeedSource(source string) (SeedSource, error) {
+	s := SeedSource{Timeout: SeedTimeout}
+	u, err := url.Parse(source)
+	if err != nil {
+		return s, fmt.Errorf("%s: %v", source, err)
+	}
+	if u.Fragment != "" {
+		options, err := url.ParseQuery(u.Fragment)
+		if err != nil {
+			return s, fmt.Errorf("%s: %v", source, err)
+		}
+		if timeout := options.Get("timeout"); timeout != "" {
+			s.Timeout, err = time.ParseDuration(timeout)
+			if err != nil || s.Timeout <= 0 {
+				return s, fmt.Errorf("%s: invalid timeout %s", source, timeout)
+			}
+		}
+		u.Fragment = ""
+	}
+
+	switch strings.ToLower(u.Scheme) {
+	case "http", "https":
+		s.Kind = SeedURL
+		s.Location = u.String()
+	case "dns":
+		s.Kind = SeedDNS
+		s.Location = u.Hostname()
+		s.Port = u.Port()
+	case "file":
+		s.Kind = SeedFile
+		s.Location = u.Host + u.Path // file://seed.txt is relative
+		if s.Location == "" {
+			s.Location = u.Opaque // file:seed.txt
+		}
+	default:
+		return s, fmt.Errorf("%s: unknown kind of seed source, use http(s)://, dns:// or file://", source)
+	}
+	if s.Location == "" {
+		return s, fmt.Errorf("%s: no location", source)
+	}
+	return s, nil
+}
+
+// String is what the peers of the source get as their source
+func (s SeedSource) String() string {
+	switch s.Kind {
+	case SeedDNS:
+		if s.Port != "" {
+			return "dns://" + net.JoinHostPort(s.Location, s.Port)
+		}
+		return "dns://" + s.Location
+	case SeedFile:
+		return "file://" + s.Location
+	default:
+		return s.Location
+	}
+}
+
+// Fetch returns the host:port addresses of the peers of the source
+func (s SeedSource) Fetch() ([]string, error) {
+	switch s.Kind {
+	case SeedURL:
+		client := http.Client{Timeout: s.Timeout}
+		resp, err := client.Get(s.Location)
+		if err != nil {
+			return nil, err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			return nil, fmt.Errorf("%s returned %s", s.Location, resp.Status)
+		}
+		return readSeedLines(resp.Body)
+	case SeedDNS:
+		ctx, cancel := context.WithTimeout(context.Background(), s.Timeout)
+		defer cancel()
+		addresses, err := net.DefaultResolver.LookupIPAddr(ctx, s.Location)
+		if err != nil {
+			return nil, err
+		}
+		port := s.Port
+		if port == "" {
+			port = NetworkListenPort
+		}
+		var lines []string
+		for _, address := range addresses {
+			lines = append(lines, net.JoinHostPort(address.IP.String(), port))
+		}
+		return lines, nil
+	case SeedFile:
+		file, err := os.Open(s.Location)
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+		return readSeedLines(file)
+	}
+	return nil, fmt.Errorf("unknown kind of seed source %s", s.Kind)
+}
+
+// readSeedLines reads the lines of a seed list, skipping empty lines and # comments
+func readSeedLines(reader io.Reader) ([]string, error) {
+	var lines []string
+	scanner := bufio.NewScanner(reader)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return lines, scanner.Err()
+}
@@ -0,0 +1,108 @@
+package p2p_test
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/FactomProject/factomd/p2p"
+)
+
+func TestParseSeedSources(t *testing.T) {
+	sources, err := p2p.ParseSeedSources("https://example.com/seed.txt, dns://seed.example.com:8108#timeout=3s,file:///tmp/seed.txt,,file:seed.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []p2p.SeedSource{
+		{Kind: p2p.SeedURL, Location: "https://example.com/seed.txt", Timeout: p2p.SeedTimeout},
+		{Kind: p2p.SeedDNS, Location: "seed.example.com", Port: "8108", Timeout: 3 * time.Second},
+		{Kind: p2p.SeedFile, Location: "/tmp/seed.txt", Timeout: p2p.SeedTimeout},
+		{Kind: p2p.SeedFile, Location: "seed.txt", Timeout: p2p.SeedTimeout},
+	}
+	if len(sources) != len(expected) {
+		t.Fatalf("Expected %d sources, got %+v", len(expected), sources)
+	}
+	for i := range expected {
+		if sources[i] != expected[i] {
+			t.Errorf("Source %d is %+v, expected %+v", i, sources[i], expected[i])
+		}
+	}
+
+	sources, err = p2p.ParseSeedSources("ftp://example.com/seed.txt,https://example.com/seed.txt#timeout=soon,dns://seed.example.com")
+	if err == nil {
+		t.Error("Parsed invalid seed sources")
+	}
+	if len(sources) != 1 || sources[0].String() != "dns://seed.example.com" {
+		t.Errorf("Expected only the valid source, got %+v", sources)
+	}
+}
+
+func TestSeedSourceFetch(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "# seed\n1.2.3.4:8108\n\n5.6.7.8:8108\n")
+	}))
+	defer server.Close()
+
+	source, err := p2p.ParseSeedSource(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines, err := source.Fetch()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(lines) != 2 || lines[0] != "1.2.3.4:8108" || lines[1] != "5.6.7.8:8108" {
+		t.Errorf("Wrong peers from the URL %v", lines)
+	}
+
+	source, err = p2p.ParseSeedSource("dns://localhost:8110#timeout=2s")
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines, err = source.Fetch()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(lines) == 0 {
+		t.Error("No peers from the DNS seed")
+	}
+	for _, line := range lines {
+		if line != "127.0.0.1:8110" && line != "[::1]:8110" {
+			t.Errorf("Wrong peer %s from the DNS seed", line)
+		}
+	}
+}
+
+func TestDiscoverPeersFallback(t *testing.T) {
+	dir, err := ioutil.TempDir("", "seeds")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "seed.txt")
+	err = ioutil.WriteFile(path, []byte("10.0.0.1:8108\n10.0.0.2:8108\n"), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The unreachable URL and the missing file fall back to the seed file
+	server := httptest.NewServer(http.NotFoundHandler())
+	defer server.Close()
+	seeds := server.URL + "/missing," + "file://" + filepath.Join(dir, "missing.txt") + ",file://" + path
+	d := new(p2p.Discovery).Init(filepath.Join(dir, "peers.json"), seeds)
+
+	peers := d.GetOutgoingPeers()
+	if len(peers) != 2 {
+		t.Fatalf("Expected the 2 peers of the seed file, got %+v", peers)
+	}
+	for _, peer := range peers {
+		if _, ok := peer.Source["Seed file://"+path]; !ok {
+			t.Errorf("Peer %s does not have the seed file as its source: %v", peer.Address, peer.Source)
+		}
+	}
+}
@@ -0,0 +1,118 @@
+// Copyright 2017 Factom Foundation
+// Use of this source code is governed by the MIT license
+// that can be found in the LICENSE file.
+
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/FactomProject/factomd/anchor"
+	"github.com/FactomProject/factomd/receipts"
+	"github.com/FactomProject/factomd/receipts/receiptVerifier"
+)
+
+func main() {
+	keys := flag.String("keys", strings.Join(anchor.AnchorSigKeys, ","), "Comma separated public keys the anchor records are signed with")
+	flag.Usage = func() {
+		fmt.Println("Usage:")
+		fmt.Println("VerifyReceipt [-keys key1,key2] receipt.json [anchors.json]")
+		fmt.Println("Verifies an entry or transaction receipt without a database.  The receipt can be")
+		fmt.Println("the result of the V2 receipt or transaction-receipt method.  The anchors are a JSON")
+		fmt.Println("array of the anchor chain entries, as {\"content\":\"...\",\"extids\":[\"...\"]} in hex.")
+		fmt.Println("Leave out the anchors to only verify the receipt up to the directory block KeyMR")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	if flag.NArg() < 1 {
+		flag.Usage()
+		fmt.Println("\nNot enough arguments passed")
+		os.Exit(1)
+	}
+	if flag.NArg() > 2 {
+		flag.Usage()
+		fmt.Println("\nToo many arguments passed")
+		os.Exit(1)
+	}
+
+	data, err := ioutil.ReadFile(flag.Arg(0))
+	if err != nil {
+		fmt.Printf("ERROR: %v\n", err)
+		os.Exit(1)
+	}
+	// Take the receipt out of a V2 response
+	var wrapped struct {
+		Receipt json.RawMessage `json:"receipt"`
+	}
+	if json.Unmarshal(data, &wrapped) == nil && len(wrapped.Receipt) > 0 {
+		data = wrapped.Receipt
+	}
+
+	verifier := receiptVerifier.NewVerifier(nil)
+	verifier.Keys, err = anchor.ParseAnchorSigKeys(strings.Split(*keys, ","))
+	if err != nil {
+		fmt.Printf("ERROR: Invalid key - %v\n", err)
+		os.Exit(1)
+	}
+	if flag.NArg() == 2 {
+		anchors, err := ioutil.ReadFile(flag.Arg(1))
+		if err != nil {
+			fmt.Printf("ERROR: %v\n", err)
+			os.Exit(1)
+		}
+		err = json.Unmarshal(anchors, &verifier.Records)
+		if err != nil {
+			fmt.Printf("ERROR: Invalid anchors - %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	err = verify(verifier, data, flag.NArg() == 2)
+	if err != nil {
+		fmt.Printf("INVALID: %v\n", err)
+		os.Exit(1)
+	}
+	if flag.NArg() == 2 {
+		fmt.Println("VALID: the receipt is anchored by a signed anchor record")
+	} else {
+		fmt.Println("VALID: the receipt leads to the directory block KeyMR, the anchors were not checked")
+	}
+}
+
+// verify checks the receipt as a transaction receipt if it has a type, and as an
+// entry receipt otherwise
+func verify(verifier *receiptVerifier.Verifier, data []byte, withAnchors bool) error {
+	var kind struct {
+		Type string `json:"type"`
+	}
+	err := json.Unmarshal(data, &kind)
+	if err != nil {
+		return err
+	}
+
+	if kind.Type != "" {
+		receipt, err := receipts.DecodeTransactionReceiptString(string(data))
+		if err != nil {
+			return err
+		}
+		if withAnchors {
+			return verifier.VerifyTransactionReceipt(receipt)
+		}
+		return receiptVerifier.VerifyTransactionReceiptPath(receipt)
+	}
+
+	receipt, err := receipts.DecodeReceiptString(string(data))
+	if err != nil {
+		return err
+	}
+	if withAnchors {
+		return verifier.VerifyReceipt(receipt)
+	}
+	return receiptVerifier.VerifyReceiptPath(receipt)
+}
@@ -0,0 +1,324 @@
+// Copyright 2017 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+// Package receiptVerifier checks receipts without a factomd database.  A receipt
+// is followed link by link, from the entry or transaction up its Merkle branch
+// to the directory block KeyMR, and from there to a signed record of the anchor
+// chain that puts the directory block in Bitcoin or Ethereum.  When a check fails
+// the error is a *LinkError naming the link.
+package receiptVerifier
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/FactomProject/factomd/anchor"
+	"github.com/FactomProject/factomd/common/entryBlock"
+	"github.com/FactomProject/factomd/common/interfaces"
+	"github.com/FactomProject/factomd/common/primitives"
+	"github.com/FactomProject/factomd/receipts"
+)
+
+// Link is a part of the chain from an entry or transaction to its anchor
+type Link string
+
+const (
+	LinkEntry           Link = "entry"            // the entry hashes to the entry hash
+	LinkTransaction     Link = "transaction"      // the transaction is the one with the ID
+	LinkMerkleNode      Link = "merkle node"      // a node of the Merkle branch
+	LinkBlock           Link = "block"            // the branch passes through the entry, factoid or EC block
+	LinkDirectoryBlock  Link = "directory block"  // the branch ends at the directory block KeyMR
+	LinkAnchorSignature Link = "anchor signature" // a record for the directory block is signed by an anchor key
+	LinkBitcoinAnchor   Link = "bitcoin anchor"   // a signed record puts the directory block in Bitcoin
+	LinkEthereumAnchor  Link = "ethereum anchor"  // a signed record puts the directory block window in Ethereum
+	LinkAnchor          Link = "anchor"           // a signed record anchors the directory block at all
+)
+
+// LinkError tells which link of the chain failed.  Index is the node of a
+// Merkle branch or the anchor record the error is about, or -1.
+type LinkError struct {
+	Link  Link
+	Index int
+	Err   error
+}
+
+func (e *LinkError) Error() string {
+	if e.Index < 0 {
+		return fmt.Sprintf("%v: %v", e.Link, e.Err)
+	}
+	return fmt.Sprintf("%v %v: %v", e.Link, e.Index, e.Err)
+}
+
+func linkError(link Link, index int, format string, a ...interface{}) *LinkError {
+	return &LinkError{Link: link, Index: index, Err: fmt.Errorf(format, a...)}
+}
+
+// SignedAnchorRecord is a record as it is in an entry of the anchor chain, in hex
+// like the V2 entry method returns it.  A version 1 record has the signature
+// appended to the content, a version 2 record has it as the only external ID.
+type SignedAnchorRecord struct {
+	Content     string   `json:"content"`
+	ExternalIDs []string `json:"extids,omitempty"`
+}
+
+// NewSignedAnchorRecord returns the record in an entry of the anchor chain
+func NewSignedAnchorRecord(entry interfaces.IEBEntry) *SignedAnchorRecord {
+	record := new(SignedAnchorRecord)
+	record.Content = hex.EncodeToString(entry.GetContent())
+	for _, extID := range entry.ExternalIDs() {
+		record.ExternalIDs = append(record.ExternalIDs, hex.EncodeToString(extID))
+	}
+	return record
+}
+
+// Verifier checks receipts against a set of signed anchor records
+type Verifier struct {
+	Records []*SignedAnchorRecord
+	Keys    []interfaces.Verifier
+}
+
+// NewVerifier returns a Verifier that trusts the records signed by the mainnet
+// anchor keys
+func NewVerifier(records []*SignedAnchorRecord) *Verifier {
+	v := new(Verifier)
+	v.Records = records
+	keys, err := anchor.ParseAnchorSigKeys(anchor.AnchorSigKeys)
+	if err != nil {
+		panic(err)
+	}
+	v.Keys = keys
+	return v
+}
+
+// VerifyReceipt checks the whole chain of an entry receipt
+func (v *Verifier) VerifyReceipt(receipt *receipts.Receipt) error {
+	err := VerifyReceiptPath(receipt)
+	if err != nil {
+		return err
+	}
+	return v.VerifyAnchor(receipt.DirectoryBlockKeyMR, receipt.BitcoinTransactionHash, receipt.BitcoinBlockHash, receipt.EthereumAnchor)
+}
+
+// VerifyTransactionReceipt checks the whole chain of a factoid or entry credit
+// transaction receipt
+func (v *Verifier) VerifyTransactionReceipt(receipt *receipts.TransactionReceipt) error {
+	err := VerifyTransactionReceiptPath(receipt)
+	if err != nil {
+		return err
+	}
+	return v.VerifyAnchor(receipt.DirectoryBlockKeyMR, receipt.BitcoinTransactionHash, receipt.BitcoinBlockHash, receipt.EthereumAnchor)
+}
+
+// VerifyReceiptPath checks an entry receipt up to the directory block KeyMR
+func VerifyReceiptPath(receipt *receipts.Receipt) error {
+	if receipt == nil || receipt.Entry == nil {
+		return linkError(LinkEntry, -1, "Receipt has no entry")
+	}
+	if receipt.EntryBlockKeyMR == nil {
+		return linkError(LinkBlock, -1, "Receipt has no EntryBlockKeyMR")
+	}
+	if receipt.DirectoryBlockKeyMR == nil {
+		return linkError(LinkDirectoryBlock, -1, "Receipt has no DirectoryBlockKeyMR")
+	}
+
+	entryHash, err := primitives.NewShaHashFromStr(receipt.Entry.EntryHash)
+	if err != nil {
+		return &LinkError{Link: LinkEntry, Index: -1, Err: err}
+	}
+	if receipt.Entry.Raw != "" {
+		raw, err := hex.DecodeString(receipt.Entry.Raw)
+		if err != nil {
+			return &LinkError{Link: LinkEntry, Index: -1, Err: err}
+		}
+		entry, err := entryBlock.UnmarshalEntry(raw)
+		if err != nil {
+			return &LinkError{Link: LinkEntry, Index: -1, Err: err}
+		}
+		if entry.GetHash().IsSameAs(entryHash) == false {
+			return linkError(LinkEntry, -1, "Entry hashes to %v, not %v", entry.GetHash(), entryHash)
+		}
+	}
+
+	if len(receipt.MerkleBranch) == 0 {
+		return linkError(LinkMerkleNode, -1, "Receipt has no Merkle branch")
+	}
+	err = receipts.WalkMerkleBranch(entryHash, receipt.MerkleBranch, receipt.EntryBlockKeyMR, receipt.DirectoryBlockKeyMR)
+	return branchLinkError(err, LinkMerkleNode, 2)
+}
+
+// VerifyTransactionReceiptPath checks a transaction receipt up to the directory
+// block KeyMR
+func VerifyTransactionReceiptPath(receipt *receipts.TransactionReceipt) error {
+	if receipt == nil {
+		return linkError(LinkTransaction, -1, "No receipt provided")
+	}
+	err := receipt.ValidateBranch()
+	if err == nil {
+		return nil
+	}
+	if _, ok := err.(*receipts.MerkleBranchError); ok == false {
+		return &LinkError{Link: LinkTransaction, Index: -1, Err: err}
+	}
+	// An entry credit transaction is checked against its block, so the branch
+	// starts at the block
+	checkpoints := 2
+	if receipt.Type == receipts.ECTransactionReceipt {
+		checkpoints = 1
+	}
+	return branchLinkError(err, LinkMerkleNode, checkpoints)
+}
+
+// branchLinkError names the link of a receipts.WalkMerkleBranch error over a
+// branch with the given number of checkpoints: a node of the branch, a block the
+// branch misses, or the directory block the branch does not end at
+func branchLinkError(err error, nodeLink Link, checkpoints int) error {
+	if err == nil {
+		return nil
+	}
+	be, ok := err.(*receipts.MerkleBranchError)
+	if ok == false {
+		return &LinkError{Link: nodeLink, Index: -1, Err: err}
+	}
+	switch {
+	case be.Node >= 0:
+		return &LinkError{Link: nodeLink, Index: be.Node, Err: be.Err}
+	case be.Checkpoint < checkpoints-1:
+		return &LinkError{Link: LinkBlock, Index: -1, Err: be.Err}
+	}
+	return &LinkError{Link: LinkDirectoryBlock, Index: -1, Err: be.Err}
+}
+
+// VerifyAnchor checks that a record signed by one of the keys anchors the
+// directory block.  If the receipt names a Bitcoin transaction, or an Ethereum
+// anchor, a signed record has to agree with it.
+func (v *Verifier) VerifyAnchor(dBlockKeyMR interfaces.IHash, btcTxHash, btcBlockHash *primitives.Hash, eth *receipts.EthereumAnchor) error {
+	if dBlockKeyMR == nil {
+		return linkError(LinkDirectoryBlock, -1, "Receipt has no DirectoryBlockKeyMR")
+	}
+	if eth != nil {
+		err := verifyWindowBranch(dBlockKeyMR, eth)
+		if err != nil {
+			return err
+		}
+	}
+
+	var btcFound, ethFound bool
+	var badRecord error
+	for i, record := range v.Records {
+		ar, err := v.validRecordFor(i, record, dBlockKeyMR, eth)
+		if err != nil {
+			// Another record may still anchor the block
+			if badRecord == nil {
+				badRecord = err
+			}
+			continue
+		}
+		if ar == nil {
+			continue
+		}
+		if ar.Bitcoin != nil && ar.IsWindow() == false && bitcoinMatches(ar.Bitcoin, btcTxHash, btcBlockHash) {
+			btcFound = true
+		}
+		if ar.Ethereum != nil && ethereumMatches(ar.Ethereum, eth) {
+			ethFound = true
+		}
+	}
+
+	var err error
+	switch {
+	case isSet(btcTxHash) && btcFound == false:
+		err = linkError(LinkBitcoinAnchor, -1, "No signed record puts %v in Bitcoin transaction %v", dBlockKeyMR, btcTxHash)
+	case eth != nil && ethFound == false:
+		err = linkError(LinkEthereumAnchor, -1, "No signed record puts window %v in Ethereum transaction %v", eth.WindowMR, eth.TransactionHash)
+	case btcFound == false && ethFound == false:
+		err = linkError(LinkAnchor, -1, "No signed record anchors %v", dBlockKeyMR)
+	}
+	if err != nil && badRecord != nil {
+		// The record that would anchor it is not signed
+		return badRecord
+	}
+	return err
+}
+
+// validRecordFor returns the record if it is about the directory block, or its
+// Ethereum window, and is signed by one of the keys.  Records about other blocks
+// are skipped with nil, records about it that are not signed are an error.
+func (v *Verifier) validRecordFor(i int, record *SignedAnchorRecord, dBlockKeyMR interfaces.IHash, eth *receipts.EthereumAnchor) (*anchor.AnchorRecord, error) {
+	if record == nil {
+		return nil, nil
+	}
+	content, err := hex.DecodeString(record.Content)
+	if err != nil {
+		return nil, &LinkError{Link: LinkAnchorSignature, Index: i, Err: err}
+	}
+	ar, err := anchor.UnmarshalAnchorRecord(content)
+	if err != nil {
+		// Not a record, like the first entry of the anchor chain
+		return nil, nil
+	}
+	if ar.IsWindow() {
+		if eth == nil || eth.WindowMR == nil || eth.WindowMR.String() != ar.WindowMR {
+			return nil, nil
+		}
+	} else if ar.KeyMR != dBlockKeyMR.String() {
+		return nil, nil
+	}
+
+	ar, valid, err := anchor.UnmarshalAndValidateAnchorRecord(content, v.Keys)
+	if ar == nil && len(record.ExternalIDs) > 0 {
+		var sig []byte
+		sig, err = hex.DecodeString(record.ExternalIDs[0])
+		if err == nil {
+			ar, valid, err = anchor.UnmarshalAndValidateAnchorRecordV2(content, [][]byte{sig}, v.Keys)
+		}
+	}
+	if err != nil {
+		return nil, &LinkError{Link: LinkAnchorSignature, Index: i, Err: err}
+	}
+	if valid == false || ar == nil {
+		return nil, linkError(LinkAnchorSignature, i, "Record for %v is not signed by an anchor key", dBlockKeyMR)
+	}
+	return ar, nil
+}
+
+// verifyWindowBranch checks the branch from the directory block KeyMR to the
+// WindowMR of the Ethereum anchor
+func verifyWindowBranch(dBlockKeyMR interfaces.IHash, eth *receipts.EthereumAnchor) error {
+	err := eth.Validate(dBlockKeyMR)
+	if err == nil {
+		return nil
+	}
+	if be, ok := err.(*receipts.MerkleBranchError); ok && be.Node >= 0 {
+		return &LinkError{Link: LinkEthereumAnchor, Index: be.Node, Err: be.Err}
+	}
+	return &LinkError{Link: LinkEthereumAnchor, Index: -1, Err: err}
+}
+
+func bitcoinMatches(btc *anchor.BitcoinStruct, txHash, blockHash *primitives.Hash) bool {
+	if isSet(txHash) && btc.TXID != txHash.String() {
+		return false
+	}
+	if isSet(blockHash) && btc.BlockHash != blockHash.String() {
+		return false
+	}
+	return true
+}
+
+func ethereumMatches(e *anchor.EthereumStruct, eth *receipts.EthereumAnchor) bool {
+	if eth == nil {
+		return true
+	}
+	if isSet(eth.TransactionHash) && strings.TrimPrefix(e.TXID, "0x") != eth.TransactionHash.String() {
+		return false
+	}
+	if isSet(eth.BlockHash) && strings.TrimPrefix(e.BlockHash, "0x") != eth.BlockHash.String() {
+		return false
+	}
+	return true
+}
+
+func isSet(h *primitives.Hash) bool {
+	return h != nil && h.IsZero() == false
+}
@@ -0,0 +1,225 @@
+// Copyright 2017 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package receiptVerifier_test
+
+import (
+	"encoding/hex"
+	"testing"
+
+	"github.com/FactomProject/factomd/anchor"
+	"github.com/FactomProject/factomd/common/interfaces"
+	"github.com/FactomProject/factomd/common/primitives"
+	"github.com/FactomProject/factomd/receipts"
+	. "github.com/FactomProject/factomd/receipts/receiptVerifier"
+	. "github.com/FactomProject/factomd/testHelper"
+)
+
+// testVerifier trusts the key the test anchor records are signed with, and has
+// the records of all the test blocks
+func testVerifier(blocks []*BlockSet) *Verifier {
+	v := NewVerifier(nil)
+	v.Keys = []interfaces.Verifier{NewPrimitivesPrivateKey(0).Pub}
+	for _, block := range blocks {
+		for _, entry := range block.Entries {
+			if entry.GetChainID().IsSameAs(GetAnchorChainID()) {
+				v.Records = append(v.Records, NewSignedAnchorRecord(entry))
+			}
+		}
+	}
+	return v
+}
+
+func expectLink(t *testing.T, err error, link Link, index int) {
+	le, ok := err.(*LinkError)
+	if ok == false {
+		t.Errorf("Expected a %v error, got %v", link, err)
+		return
+	}
+	if le.Link != link || le.Index != index {
+		t.Errorf("Expected a %v %v error, got %v", link, index, le)
+	}
+}
+
+func TestVerifyReceipts(t *testing.T) {
+	dbo := CreateAndPopulateTestDatabaseOverlay()
+	blocks := CreateFullTestBlockSet()
+	v := testVerifier(blocks)
+
+	for _, block := range blocks[:len(blocks)-2] {
+		for _, entry := range block.Entries {
+			receipt, err := receipts.CreateFullReceipt(dbo, entry.DatabasePrimaryIndex())
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = v.VerifyReceipt(receipt)
+			if err != nil {
+				t.Errorf("%v", err)
+			}
+
+			receipt.TrimReceipt()
+			err = v.VerifyReceipt(receipt)
+			if err != nil {
+				t.Errorf("Trimmed receipt - %v", err)
+			}
+		}
+
+		for _, tx := range block.FBlock.GetTransactions() {
+			receipt, err := receipts.CreateTransactionReceipt(dbo, tx.GetSigHash())
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = v.VerifyTransactionReceipt(receipt)
+			if err != nil {
+				t.Errorf("%v", err)
+			}
+		}
+	}
+}
+
+func TestVerifyReceiptFailedLinks(t *testing.T) {
+	dbo := CreateAndPopulateTestDatabaseOverlay()
+	blocks := CreateFullTestBlockSet()
+	v := testVerifier(blocks)
+	entry := blocks[2].Entries[0]
+
+	receipt, err := receipts.CreateFullReceipt(dbo, entry.DatabasePrimaryIndex())
+	if err != nil {
+		t.Fatal(err)
+	}
+	raw, err := entry.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	receipt.Entry.Raw = hex.EncodeToString(raw)
+	if err = v.VerifyReceipt(receipt); err != nil {
+		t.Errorf("%v", err)
+	}
+	receipt.Entry.Raw = hex.EncodeToString(append(raw, 0))
+	expectLink(t, v.VerifyReceipt(receipt), LinkEntry, -1)
+	receipt.Entry.Raw = ""
+
+	saved := receipt.MerkleBranch[1].Top
+	receipt.MerkleBranch[1].Top = primitives.NewZeroHash().(*primitives.Hash)
+	expectLink(t, v.VerifyReceipt(receipt), LinkMerkleNode, 1)
+	receipt.MerkleBranch[1].Top = saved
+
+	saved = receipt.EntryBlockKeyMR
+	receipt.EntryBlockKeyMR = primitives.NewZeroHash().(*primitives.Hash)
+	expectLink(t, v.VerifyReceipt(receipt), LinkBlock, -1)
+	receipt.EntryBlockKeyMR = saved
+
+	saved = receipt.DirectoryBlockKeyMR
+	receipt.DirectoryBlockKeyMR = primitives.NewZeroHash().(*primitives.Hash)
+	expectLink(t, v.VerifyReceipt(receipt), LinkDirectoryBlock, -1)
+	receipt.DirectoryBlockKeyMR = saved
+
+	saved = receipt.BitcoinTransactionHash
+	receipt.BitcoinTransactionHash = primitives.Sha([]byte("not the transaction")).(*primitives.Hash)
+	expectLink(t, v.VerifyReceipt(receipt), LinkBitcoinAnchor, -1)
+	receipt.BitcoinTransactionHash = saved
+
+	if err = v.VerifyReceipt(receipt); err != nil {
+		t.Errorf("%v", err)
+	}
+
+	// Without the records no anchor is proven
+	empty := NewVerifier(nil)
+	empty.Keys = v.Keys
+	expectLink(t, empty.VerifyReceipt(receipt), LinkBitcoinAnchor, -1)
+	if err = VerifyReceiptPath(receipt); err != nil {
+		t.Errorf("%v", err)
+	}
+
+	// With the mainnet keys the test records are not signed
+	mainnet := NewVerifier(v.Records)
+	err = mainnet.VerifyReceipt(receipt)
+	if le, ok := err.(*LinkError); ok == false || le.Link != LinkAnchorSignature {
+		t.Errorf("Expected an anchor signature error, got %v", err)
+	}
+}
+
+func TestVerifySkipsBadRecords(t *testing.T) {
+	dbo := CreateAndPopulateTestDatabaseOverlay()
+	blocks := CreateFullTestBlockSet()
+	v := testVerifier(blocks)
+
+	receipt, err := receipts.CreateFullReceipt(dbo, blocks[2].Entries[0].DatabasePrimaryIndex())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A record for the same directory block signed with a key that is not trusted
+	var forged *SignedAnchorRecord
+	for _, record := range v.Records {
+		content, _ := hex.DecodeString(record.Content)
+		ar, err := anchor.UnmarshalAnchorRecord(content)
+		if err != nil || ar.KeyMR != receipt.DirectoryBlockKeyMR.String() {
+			continue
+		}
+		data, sig, err := ar.MarshalAndSignV2(NewPrimitivesPrivateKey(1))
+		if err != nil {
+			t.Fatal(err)
+		}
+		forged = &SignedAnchorRecord{Content: hex.EncodeToString(data), ExternalIDs: []string{hex.EncodeToString(sig)}}
+	}
+	if forged == nil {
+		t.Fatalf("No record for %v", receipt.DirectoryBlockKeyMR)
+	}
+
+	good := v.Records
+	v.Records = append([]*SignedAnchorRecord{forged}, good...)
+	if err = v.VerifyReceipt(receipt); err != nil {
+		t.Errorf("%v", err)
+	}
+
+	// Alone it proves nothing
+	v.Records = []*SignedAnchorRecord{forged}
+	err = v.VerifyReceipt(receipt)
+	if le, ok := err.(*LinkError); ok == false || le.Link != LinkAnchorSignature || le.Index != 0 {
+		t.Errorf("Expected an anchor signature error, got %v", err)
+	}
+}
+
+func TestVerifyEthereumAnchor(t *testing.T) {
+	dbo := CreateAndPopulateTestDatabaseOverlay()
+	blocks := CreateFullTestBlockSet()
+	v := testVerifier(blocks)
+
+	ar := CreateTestEthereumAnchorRecord(dbo, 1, 5)
+	err := dbo.SaveAnchorInfoAsDirBlockInfo([]*anchor.AnchorRecord{ar})
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, sig, err := ar.MarshalAndSignV2(NewPrimitivesPrivateKey(0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	record := &SignedAnchorRecord{Content: hex.EncodeToString(data), ExternalIDs: []string{hex.EncodeToString(sig)}}
+
+	receipt, err := receipts.CreateFullReceipt(dbo, blocks[3].Entries[0].DatabasePrimaryIndex())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if receipt.EthereumAnchor == nil {
+		t.Fatalf("No Ethereum anchor in receipt")
+	}
+	expectLink(t, v.VerifyReceipt(receipt), LinkEthereumAnchor, -1)
+
+	v.Records = append(v.Records, record)
+	if err = v.VerifyReceipt(receipt); err != nil {
+		t.Errorf("%v", err)
+	}
+
+	// Only the Ethereum anchor
+	receipt.BitcoinTransactionHash = nil
+	receipt.BitcoinBlockHash = nil
+	if err = v.VerifyReceipt(receipt); err != nil {
+		t.Errorf("%v", err)
+	}
+
+	receipt.EthereumAnchor.MerkleBranch[0].Top = primitives.NewZeroHash().(*primitives.Hash)
+	expectLink(t, v.VerifyReceipt(receipt), LinkEthereumAnchor, 0)
+}
@@ -20,6 +20,61 @@ type Receipt struct {
 	DirectoryBlockKeyMR    *primitives.Hash         `json:"directoryblockkeymr,omitempty"`
 	BitcoinTransactionHash *primitives.Hash         `json:"bitcointransactionhash,omitempty"`
 	BitcoinBlockHash       *primitives.Hash         `json:"bitcoinblockhash,omitempty"`
+	EthereumAnchor         *EthereumAnchor          `json:"ethereumanchor,omitempty"`
+}
+
+// EthereumAnchor is where a directory block is anchored in Ethereum.  The anchor
+// contract holds the WindowMR, the Merkle root of the KeyMRs of the directory
+// blocks from DBHeightMin to DBHeightMax, and the Merkle branch leads from the
+// KeyMR of the directory block to the WindowMR.
+type EthereumAnchor struct {
+	TransactionHash  *primitives.Hash         `json:"transactionhash"`
+	TransactionIndex int64                    `json:"transactionindex"`
+	BlockHeight      int64                    `json:"blockheight"`
+	BlockHash        *primitives.Hash         `json:"blockhash"`
+	ContractAddress  string                   `json:"contractaddress"`
+	DBHeightMin      uint32                   `json:"dbheightmin"`
+	DBHeightMax      uint32                   `json:"dbheightmax"`
+	WindowMR         *primitives.Hash         `json:"windowmr"`
+	MerkleBranch     []*primitives.MerkleNode `json:"merklebranch,omitempty"`
+}
+
+// Validate checks that the Merkle branch leads from the directory block KeyMR to
+// the WindowMR.  A window of one block has no branch, its KeyMR is the WindowMR.
+func (e *EthereumAnchor) Validate(dBlockKeyMR interfaces.IHash) error {
+	if e.WindowMR == nil {
+		return fmt.Errorf("Ethereum anchor has no WindowMR")
+	}
+	if e.DBHeightMin > e.DBHeightMax {
+		return fmt.Errorf("Ethereum anchor window %v-%v is empty", e.DBHeightMin, e.DBHeightMax)
+	}
+	if len(e.MerkleBranch) == 0 {
+		if dBlockKeyMR.IsSameAs(e.WindowMR) == false {
+			return fmt.Errorf("DirectoryBlockKeyMR is not the WindowMR of the Ethereum anchor")
+		}
+		return nil
+	}
+	return WalkMerkleBranch(dBlockKeyMR, e.MerkleBranch, e.WindowMR)
+}
+
+func (e *EthereumAnchor) IsSameAs(r *EthereumAnchor) bool {
+	if e == nil || r == nil {
+		return e == nil && r == nil
+	}
+	return e.String() == r.String()
+}
+
+func (e *EthereumAnchor) JSONByte() ([]byte, error) {
+	return primitives.EncodeJSON(e)
+}
+
+func (e *EthereumAnchor) JSONString() (string, error) {
+	return primitives.EncodeJSONString(e)
+}
+
+func (e *EthereumAnchor) String() string {
+	str, _ := e.JSONString()
+	return str
 }
 
 func (e *Receipt) TrimReceipt() {
@@ -109,6 +164,10 @@ func (e *Receipt) Validate() error {
 		return fmt.Errorf("DirectoryBlockKeyMR not found in branch")
 	}
 
+	if e.EthereumAnchor != nil {
+		return e.EthereumAnchor.Validate(e.DirectoryBlockKeyMR)
+	}
+
 	return nil
 }
 
@@ -202,6 +261,10 @@ func (e *Receipt) IsSameAs(r *Receipt) bool {
 		}
 	}
 
+	if e.EthereumAnchor.IsSameAs(r.EthereumAnchor) == false {
+		return false
+	}
+
 	return true
 }
 
@@ -334,64 +397,127 @@ func CreateReceipt(dbo interfaces.DBOverlaySimple, entryID interfaces.IHash) (*R
 
 	//DBlock
 
-	hash, err = dbo.FetchIncludedIn(hash)
+	branch, dBlock, err := directoryBlockBranch(dbo, hash)
 	if err != nil {
 		return nil, err
 	}
+	receipt.MerkleBranch = append(receipt.MerkleBranch, branch...)
 
-	if hash == nil {
-		return nil, fmt.Errorf("Block containing EBlock not found")
-	}
+	//DirBlockInfo
 
-	dBlock, err := dbo.FetchDBlock(hash)
+	hash = dBlock.DatabasePrimaryIndex()
+	receipt.DirectoryBlockKeyMR = hash.(*primitives.Hash)
+
+	receipt.BitcoinTransactionHash, receipt.BitcoinBlockHash, receipt.EthereumAnchor, err = anchors(dbo, dBlock)
 	if err != nil {
 		return nil, err
 	}
 
-	if dBlock == nil {
-		return nil, fmt.Errorf("DBlock not found")
+	return receipt, nil
+}
+
+// directoryBlockBranch returns the Merkle branch from the KeyMR of a block up to
+// the KeyMR of the directory block it is in, and that directory block
+func directoryBlockBranch(dbo interfaces.DBOverlaySimple, blockKeyMR interfaces.IHash) ([]*primitives.MerkleNode, interfaces.IDirectoryBlock, error) {
+	hash, err := dbo.FetchIncludedIn(blockKeyMR)
+	if err != nil {
+		return nil, nil, err
 	}
 
-	//str, _ = dBlock.JSONString()
-	//fmt.Printf("dBlock - %v\n\n", str)
+	if hash == nil {
+		return nil, nil, fmt.Errorf("DBlock containing block %v not found", blockKeyMR)
+	}
 
-	entries = dBlock.GetEntryHashesForBranch()
-	//fmt.Printf("dBlock entries - %v\n\n", entries)
+	dBlock, err := dbo.FetchDBlock(hash)
+	if err != nil {
+		return nil, nil, err
+	}
 
-	//merkleTree := primitives.BuildMerkleTreeStore(entries)
-	//fmt.Printf("dBlock merkleTree - %v\n\n", merkleTree)
+	if dBlock == nil {
+		return nil, nil, fmt.Errorf("DBlock not found")
+	}
 
-	branch = primitives.BuildMerkleBranchForEntryHash(entries, receipt.EntryBlockKeyMR, true)
-	blockNode = new(primitives.MerkleNode)
-	left, err = dBlock.GetHeaderHash()
+	branch := primitives.BuildMerkleBranchForEntryHash(dBlock.GetEntryHashesForBranch(), blockKeyMR, true)
+	if branch == nil {
+		return nil, nil, fmt.Errorf("Block %v not found in DBlock %v", blockKeyMR, hash)
+	}
+	blockNode := new(primitives.MerkleNode)
+	left, err := dBlock.GetHeaderHash()
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	blockNode.Left = left.(*primitives.Hash)
 	blockNode.Right = dBlock.BodyKeyMR().(*primitives.Hash)
 	blockNode.Top = hash.(*primitives.Hash)
-	//fmt.Printf("dBlock blockNode - %v\n\n", blockNode)
 	branch = append(branch, blockNode)
-	receipt.MerkleBranch = append(receipt.MerkleBranch, branch...)
 
-	//DirBlockInfo
-
-	hash = dBlock.DatabasePrimaryIndex()
-	receipt.DirectoryBlockKeyMR = hash.(*primitives.Hash)
+	return branch, dBlock, nil
+}
 
-	dirBlockInfo, err := dbo.FetchDirBlockInfoByKeyMR(hash)
+// anchors returns the Bitcoin transaction and block anchoring the directory block,
+// and its Ethereum anchor, or nils for the ones it does not have yet
+func anchors(dbo interfaces.DBOverlaySimple, dBlock interfaces.IDirectoryBlock) (*primitives.Hash, *primitives.Hash, *EthereumAnchor, error) {
+	dirBlockInfo, err := dbo.FetchDirBlockInfoByKeyMR(dBlock.DatabasePrimaryIndex())
 	if err != nil {
-		return nil, err
+		return nil, nil, nil, err
 	}
 
-	if dirBlockInfo != nil {
-		dbi := dirBlockInfo.(*dbInfo.DirBlockInfo)
+	if dirBlockInfo == nil {
+		return nil, nil, nil, nil
+	}
 
-		receipt.BitcoinTransactionHash = dbi.BTCTxHash.(*primitives.Hash)
-		receipt.BitcoinBlockHash = dbi.BTCBlockHash.(*primitives.Hash)
+	dbi := dirBlockInfo.(*dbInfo.DirBlockInfo)
+	var btcTxHash, btcBlockHash *primitives.Hash
+	if dbi.GetBTCTxHash().IsZero() == false {
+		btcTxHash = dbi.BTCTxHash.(*primitives.Hash)
+		btcBlockHash = dbi.BTCBlockHash.(*primitives.Hash)
 	}
 
-	return receipt, nil
+	if dbi.GetEthConfirmed() == false {
+		return btcTxHash, btcBlockHash, nil, nil
+	}
+	eth, err := ethereumAnchor(dbo, dbi, dBlock)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return btcTxHash, btcBlockHash, eth, nil
+}
+
+// ethereumAnchor returns the Ethereum anchor of the directory block, with the
+// Merkle branch from its KeyMR to the WindowMR the contract holds
+func ethereumAnchor(dbo interfaces.DBOverlaySimple, dbi *dbInfo.DirBlockInfo, dBlock interfaces.IDirectoryBlock) (*EthereumAnchor, error) {
+	eth := new(EthereumAnchor)
+	eth.TransactionHash = dbi.EthTxHash.(*primitives.Hash)
+	eth.TransactionIndex = dbi.EthTxOffset
+	eth.BlockHeight = dbi.EthBlockHeight
+	eth.BlockHash = dbi.EthBlockHash.(*primitives.Hash)
+	eth.ContractAddress = dbi.EthContractAddress
+	eth.DBHeightMin = dbi.EthDBHeightMin
+	eth.DBHeightMax = dbi.EthDBHeightMax
+	eth.WindowMR = dbi.EthWindowMR.(*primitives.Hash)
+
+	height := dBlock.GetDatabaseHeight()
+	if height < eth.DBHeightMin || height > eth.DBHeightMax {
+		return nil, fmt.Errorf("DBlock %v is not in the anchor window %v-%v", height, eth.DBHeightMin, eth.DBHeightMax)
+	}
+	if eth.DBHeightMin == eth.DBHeightMax {
+		return eth, nil
+	}
+
+	keyMRs := make([]interfaces.IHash, 0, int(eth.DBHeightMax-eth.DBHeightMin)+1)
+	for h := eth.DBHeightMin; h <= eth.DBHeightMax; h++ {
+		keyMR, err := dbo.FetchDBKeyMRByHeight(h)
+		if err != nil {
+			return nil, err
+		}
+		if keyMR == nil {
+			return nil, fmt.Errorf("DBlock %v of the anchor window not found", h)
+		}
+		keyMRs = append(keyMRs, keyMR)
+	}
+	eth.MerkleBranch = primitives.BuildMerkleBranch(keyMRs, int(height-eth.DBHeightMin), true)
+
+	return eth, nil
 }
 
 func VerifyFullReceipt(dbo interfaces.DBOverlaySimple, receiptStr string) error {
@@ -7,6 +7,7 @@ package receipts_test
 import (
 	"testing"
 
+	"github.com/FactomProject/factomd/anchor"
 	"github.com/FactomProject/factomd/common/primitives"
 	. "github.com/FactomProject/factomd/receipts"
 	. "github.com/FactomProject/factomd/testHelper"
@@ -97,3 +98,47 @@ func TestDecodeReceiptString(t *testing.T) {
 		t.Error(err)
 	}
 }
+
+func TestEthereumAnchorInReceipt(t *testing.T) {
+	dbo := CreateAndPopulateTestDatabaseOverlay()
+	blocks := CreateFullTestBlockSet()
+	ar := CreateTestEthereumAnchorRecord(dbo, 1, 5)
+	err := dbo.SaveAnchorInfoAsDirBlockInfo([]*anchor.AnchorRecord{ar})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, block := range blocks[1:6] {
+		for _, entry := range block.Entries {
+			receipt, err := CreateFullReceipt(dbo, entry.DatabasePrimaryIndex())
+			if err != nil {
+				t.Fatal(err)
+			}
+			eth := receipt.EthereumAnchor
+			if eth == nil {
+				t.Fatalf("No Ethereum anchor in receipt of DBlock %v", block.Height)
+			}
+			if eth.WindowMR.String() != ar.WindowMR || len(eth.MerkleBranch) == 0 {
+				t.Errorf("Wrong Ethereum anchor %v", eth)
+			}
+
+			err = VerifyFullReceipt(dbo, receipt.CustomMarshalString())
+			if err != nil {
+				t.Error(err)
+			}
+
+			eth.WindowMR = primitives.NewZeroHash().(*primitives.Hash)
+			if receipt.Validate() == nil {
+				t.Errorf("Receipt validated with the wrong WindowMR")
+			}
+		}
+	}
+
+	receipt, err := CreateFullReceipt(dbo, blocks[6].Entries[0].DatabasePrimaryIndex())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if receipt.EthereumAnchor != nil {
+		t.Errorf("Ethereum anchor in receipt of a DBlock outside the window")
+	}
+}
@@ -0,0 +1,600 @@
+// Copyright 2017 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package receipts
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/FactomProject/factomd/common/constants"
+	"github.com/FactomProject/factomd/common/entryCreditBlock"
+	"github.com/FactomProject/factomd/common/factoid"
+	"github.com/FactomProject/factomd/common/interfaces"
+	"github.com/FactomProject/factomd/common/primitives"
+)
+
+// The types of transaction receipts
+const (
+	FactoidTransactionReceipt = "factoid"
+	ECTransactionReceipt      = "entrycredit"
+)
+
+// TransactionReceipt proves a factoid transaction is in an FBlock, or an entry
+// credit transaction (a commit or a balance increase) is in an ECBlock, and that
+// the block is in a directory block.  It carries everything needed to check it
+// without a node.
+//
+// A factoid transaction is proven with the Merkle branch from its hash through the
+// body of the FBlock to the FBlock KeyMR.  The body of an ECBlock is hashed as a
+// whole rather than as a Merkle tree, so an entry credit receipt carries the whole
+// ECBlock, which hashes to its header hash (the key the directory block uses).
+// From the block the Merkle branch continues through the directory block to its
+// KeyMR, which the Bitcoin transaction anchors, and on to the WindowMR the
+// Ethereum anchor contract holds.
+type TransactionReceipt struct {
+	Type                   string                   `json:"type"`
+	TransactionID          *primitives.Hash         `json:"transactionid"`
+	RawTransaction         string                   `json:"rawtransaction"`
+	ECBlock                string                   `json:"ecblock,omitempty"`
+	BlockKeyMR             *primitives.Hash         `json:"blockkeymr"`
+	MerkleBranch           []*primitives.MerkleNode `json:"merklebranch"`
+	DirectoryBlockKeyMR    *primitives.Hash         `json:"directoryblockkeymr"`
+	BitcoinTransactionHash *primitives.Hash         `json:"bitcointransactionhash,omitempty"`
+	BitcoinBlockHash       *primitives.Hash         `json:"bitcoinblockhash,omitempty"`
+	EthereumAnchor         *EthereumAnchor          `json:"ethereumanchor,omitempty"`
+}
+
+var _ interfaces.BinaryMarshallable = (*TransactionReceipt)(nil)
+
+// CreateTransactionReceipt builds the receipt of a saved factoid or entry credit
+// transaction, found by its transaction ID or full hash
+func CreateTransactionReceipt(dbo interfaces.DBOverlaySimple, txID interfaces.IHash) (*TransactionReceipt, error) {
+	hash, err := dbo.FetchIncludedIn(txID)
+	if err != nil {
+		return nil, err
+	}
+
+	if hash == nil {
+		return nil, fmt.Errorf("Block containing transaction not found")
+	}
+
+	fBlock, err := dbo.FetchFBlock(hash)
+	if err != nil {
+		return nil, err
+	}
+
+	var receipt *TransactionReceipt
+	if fBlock != nil {
+		receipt, err = factoidTransactionReceipt(fBlock, txID)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		ecBlock, err := dbo.FetchECBlock(hash)
+		if err != nil {
+			return nil, err
+		}
+		if ecBlock == nil {
+			return nil, fmt.Errorf("Transaction is not in an FBlock or ECBlock")
+		}
+		receipt, err = ecTransactionReceipt(ecBlock, txID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	branch, dBlock, err := directoryBlockBranch(dbo, receipt.BlockKeyMR)
+	if err != nil {
+		return nil, err
+	}
+	receipt.MerkleBranch = append(receipt.MerkleBranch, branch...)
+
+	hash = dBlock.DatabasePrimaryIndex()
+	receipt.DirectoryBlockKeyMR = hash.(*primitives.Hash)
+
+	receipt.BitcoinTransactionHash, receipt.BitcoinBlockHash, receipt.EthereumAnchor, err = anchors(dbo, dBlock)
+	if err != nil {
+		return nil, err
+	}
+
+	return receipt, nil
+}
+
+// factoidTransactionReceipt starts the receipt of a transaction in the FBlock,
+// with the branch up to the FBlock KeyMR
+func factoidTransactionReceipt(fBlock interfaces.IFBlock, txID interfaces.IHash) (*TransactionReceipt, error) {
+	var tx interfaces.ITransaction
+	for _, t := range fBlock.GetTransactions() {
+		if txID.IsSameAs(t.GetSigHash()) || txID.IsSameAs(t.GetHash()) {
+			tx = t
+			break
+		}
+	}
+
+	if tx == nil {
+		return nil, fmt.Errorf("Transaction not found in FBlock")
+	}
+
+	raw, err := tx.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+
+	receipt := new(TransactionReceipt)
+	receipt.Type = FactoidTransactionReceipt
+	receipt.TransactionID = primitives.NewHash(txID.Bytes()).(*primitives.Hash)
+	receipt.RawTransaction = hex.EncodeToString(raw)
+
+	keyMR := fBlock.GetKeyMR()
+	receipt.BlockKeyMR = keyMR.(*primitives.Hash)
+
+	branch := primitives.BuildMerkleBranchForEntryHash(fBlock.GetBodyHashes(), tx.GetHash(), true)
+	header, err := fBlock.MarshalHeader()
+	if err != nil {
+		return nil, err
+	}
+	blockNode := new(primitives.MerkleNode)
+	blockNode.Left = primitives.Sha(header).(*primitives.Hash)
+	blockNode.Right = fBlock.GetBodyMR().(*primitives.Hash)
+	blockNode.Top = keyMR.(*primitives.Hash)
+	receipt.MerkleBranch = append(branch, blockNode)
+
+	return receipt, nil
+}
+
+// ecTransactionReceipt starts the receipt of a transaction in the ECBlock
+func ecTransactionReceipt(ecBlock interfaces.IEntryCreditBlock, txID interfaces.IHash) (*TransactionReceipt, error) {
+	tx := ecBlock.GetEntryByHash(txID)
+	if tx == nil {
+		return nil, fmt.Errorf("Transaction not found in ECBlock")
+	}
+
+	raw, err := tx.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+
+	block, err := ecBlock.MarshalBinary()
+	if err != nil {
+		return nil, err
+	}
+
+	keyMR, err := ecBlock.HeaderHash()
+	if err != nil {
+		return nil, err
+	}
+
+	receipt := new(TransactionReceipt)
+	receipt.Type = ECTransactionReceipt
+	receipt.TransactionID = primitives.NewHash(txID.Bytes()).(*primitives.Hash)
+	receipt.RawTransaction = hex.EncodeToString(raw)
+	receipt.ECBlock = hex.EncodeToString(block)
+	receipt.BlockKeyMR = keyMR.(*primitives.Hash)
+
+	return receipt, nil
+}
+
+// Validate checks the receipt on its own: that the transaction is the one with
+// the transaction ID, that it is in the block, and that the Merkle branch leads
+// from the block to the directory block KeyMR, and on to the WindowMR of the
+// Ethereum anchor if there is one.  Whether the directory block is anchored in
+// Bitcoin, or the WindowMR in Ethereum, is left to the caller.
+func (e *TransactionReceipt) Validate() error {
+	err := e.ValidateBranch()
+	if err != nil {
+		return err
+	}
+	if e.EthereumAnchor != nil {
+		return e.EthereumAnchor.Validate(e.DirectoryBlockKeyMR)
+	}
+	return nil
+}
+
+// ValidateBranch is Validate up to the directory block KeyMR
+func (e *TransactionReceipt) ValidateBranch() error {
+	if e == nil {
+		return fmt.Errorf("No receipt provided")
+	}
+	if e.TransactionID == nil {
+		return fmt.Errorf("Receipt has no TransactionID")
+	}
+	if e.BlockKeyMR == nil {
+		return fmt.Errorf("Receipt has no BlockKeyMR")
+	}
+	if e.DirectoryBlockKeyMR == nil {
+		return fmt.Errorf("Receipt has no DirectoryBlockKeyMR")
+	}
+	raw, err := hex.DecodeString(e.RawTransaction)
+	if err != nil {
+		return err
+	}
+
+	switch e.Type {
+	case FactoidTransactionReceipt:
+		tx := new(factoid.Transaction)
+		err = tx.UnmarshalBinary(raw)
+		if err != nil {
+			return err
+		}
+		if e.TransactionID.IsSameAs(tx.GetSigHash()) == false && e.TransactionID.IsSameAs(tx.GetHash()) == false {
+			return fmt.Errorf("Transaction is not %v", e.TransactionID)
+		}
+		return WalkMerkleBranch(tx.GetHash(), e.MerkleBranch, e.BlockKeyMR, e.DirectoryBlockKeyMR)
+
+	case ECTransactionReceipt:
+		data, err := hex.DecodeString(e.ECBlock)
+		if err != nil {
+			return err
+		}
+		ecBlock, err := entryCreditBlock.UnmarshalECBlock(data)
+		if err != nil {
+			return err
+		}
+		tx := ecBlock.GetEntryByHash(e.TransactionID)
+		if tx == nil {
+			return fmt.Errorf("Transaction %v not found in ECBlock", e.TransactionID)
+		}
+		data, err = tx.MarshalBinary()
+		if err != nil {
+			return err
+		}
+		if bytes.Equal(data, raw) == false {
+			return fmt.Errorf("Transaction is not the one in ECBlock")
+		}
+		keyMR, err := ecBlock.HeaderHash()
+		if err != nil {
+			return err
+		}
+		if keyMR.IsSameAs(e.BlockKeyMR) == false {
+			return fmt.Errorf("ECBlock hashes to %v, not %v", keyMR, e.BlockKeyMR)
+		}
+		return WalkMerkleBranch(keyMR, e.MerkleBranch, e.DirectoryBlockKeyMR)
+	}
+	return fmt.Errorf("Unknown receipt type %q", e.Type)
+}
+
+// MerkleBranchError is an error of WalkMerkleBranch.  Node is the node of the
+// branch it is about, and Checkpoint the checkpoint it is about, or -1.
+type MerkleBranchError struct {
+	Node       int
+	Checkpoint int
+	Err        error
+}
+
+func (e *MerkleBranchError) Error() string {
+	return e.Err.Error()
+}
+
+func nodeError(node int, format string, a ...interface{}) *MerkleBranchError {
+	return &MerkleBranchError{Node: node, Checkpoint: -1, Err: fmt.Errorf(format, a...)}
+}
+
+// WalkMerkleBranch hashes the leaf up the branch, which has to pass through each
+// of the checkpoints in order and end at the last one.  Nodes may be trimmed to
+// one side and no top.  Errors are *MerkleBranchError.
+func WalkMerkleBranch(leaf interfaces.IHash, branch []*primitives.MerkleNode, checkpoints ...interfaces.IHash) error {
+	current := leaf
+	next := 0
+	for i, node := range branch {
+		if node == nil {
+			return nodeError(i, "Node %v/%v is missing", i, len(branch))
+		}
+		var left, right interfaces.IHash
+		switch {
+		case node.Left == nil && node.Right == nil:
+			return nodeError(i, "Node %v/%v has two nil sides", i, len(branch))
+		case node.Left == nil:
+			left, right = current, node.Right
+		case node.Right == nil:
+			left, right = node.Left, current
+		default:
+			if current.IsSameAs(node.Left) == false && current.IsSameAs(node.Right) == false {
+				return nodeError(i, "%v not found in node %v/%v", current, i, len(branch))
+			}
+			left, right = node.Left, node.Right
+		}
+		top := primitives.HashMerkleBranches(left, right)
+		if node.Top != nil && top.IsSameAs(node.Top) == false {
+			return nodeError(i, "Derived top %v is not the same as saved top in node %v/%v", top, i, len(branch))
+		}
+		if next < len(checkpoints) && top.IsSameAs(checkpoints[next]) {
+			next++
+		}
+		current = top
+	}
+
+	if next < len(checkpoints) {
+		return &MerkleBranchError{Node: -1, Checkpoint: next, Err: fmt.Errorf("%v not found in branch", checkpoints[next])}
+	}
+	last := len(checkpoints) - 1
+	if current.IsSameAs(checkpoints[last]) == false {
+		return &MerkleBranchError{Node: -1, Checkpoint: last, Err: fmt.Errorf("Branch does not end at %v", checkpoints[last])}
+	}
+	return nil
+}
+
+func (e *TransactionReceipt) MarshalBinary() (rval []byte, err error) {
+	defer func(pe *error) {
+		if *pe != nil {
+			fmt.Fprintf(os.Stderr, "TransactionReceipt.MarshalBinary err:%v", *pe)
+		}
+	}(&err)
+	buf := primitives.NewBuffer(nil)
+
+	err = buf.PushString(e.Type)
+	if err != nil {
+		return nil, err
+	}
+	err = pushHash(buf, e.TransactionID)
+	if err != nil {
+		return nil, err
+	}
+	for _, h := range []string{e.RawTransaction, e.ECBlock} {
+		data, err := hex.DecodeString(h)
+		if err != nil {
+			return nil, err
+		}
+		err = buf.PushBytes(data)
+		if err != nil {
+			return nil, err
+		}
+	}
+	err = pushHash(buf, e.BlockKeyMR)
+	if err != nil {
+		return nil, err
+	}
+
+	err = pushMerkleBranch(buf, e.MerkleBranch)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, h := range []*primitives.Hash{e.DirectoryBlockKeyMR, e.BitcoinTransactionHash, e.BitcoinBlockHash} {
+		err = pushHash(buf, h)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err = pushEthereumAnchor(buf, e.EthereumAnchor)
+	if err != nil {
+		return nil, err
+	}
+
+	return buf.DeepCopyBytes(), nil
+}
+
+func (e *TransactionReceipt) UnmarshalBinaryData(data []byte) (newData []byte, err error) {
+	buf := primitives.NewBuffer(data)
+
+	e.Type, err = buf.PopString()
+	if err != nil {
+		return nil, err
+	}
+	e.TransactionID, err = popHash(buf)
+	if err != nil {
+		return nil, err
+	}
+	raw, err := buf.PopBytes()
+	if err != nil {
+		return nil, err
+	}
+	e.RawTransaction = hex.EncodeToString(raw)
+	raw, err = buf.PopBytes()
+	if err != nil {
+		return nil, err
+	}
+	e.ECBlock = hex.EncodeToString(raw)
+	e.BlockKeyMR, err = popHash(buf)
+	if err != nil {
+		return nil, err
+	}
+
+	e.MerkleBranch, err = popMerkleBranch(buf)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, h := range []**primitives.Hash{&e.DirectoryBlockKeyMR, &e.BitcoinTransactionHash, &e.BitcoinBlockHash} {
+		*h, err = popHash(buf)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Receipts encoded before Ethereum anchors were indexed end here
+	if buf.Len() > 0 {
+		e.EthereumAnchor, err = popEthereumAnchor(buf)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return buf.DeepCopyBytes(), nil
+}
+
+func (e *TransactionReceipt) UnmarshalBinary(data []byte) (err error) {
+	_, err = e.UnmarshalBinaryData(data)
+	return
+}
+
+func pushMerkleBranch(buf *primitives.Buffer, branch []*primitives.MerkleNode) error {
+	err := buf.PushVarInt(uint64(len(branch)))
+	if err != nil {
+		return err
+	}
+	for _, node := range branch {
+		if node == nil {
+			node = new(primitives.MerkleNode)
+		}
+		for _, h := range []*primitives.Hash{node.Left, node.Right, node.Top} {
+			err = pushHash(buf, h)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func popMerkleBranch(buf *primitives.Buffer) ([]*primitives.MerkleNode, error) {
+	l, err := buf.PopVarInt()
+	if err != nil {
+		return nil, err
+	}
+	if l > uint64(buf.Len()) {
+		return nil, fmt.Errorf("Merkle branch of %v nodes is longer than the data", l)
+	}
+	branch := make([]*primitives.MerkleNode, int(l))
+	for i := range branch {
+		node := new(primitives.MerkleNode)
+		for _, h := range []**primitives.Hash{&node.Left, &node.Right, &node.Top} {
+			*h, err = popHash(buf)
+			if err != nil {
+				return nil, err
+			}
+		}
+		branch[i] = node
+	}
+	return branch, nil
+}
+
+// pushEthereumAnchor writes an anchor that may be nil
+func pushEthereumAnchor(buf *primitives.Buffer, eth *EthereumAnchor) error {
+	err := buf.PushBool(eth != nil)
+	if err != nil || eth == nil {
+		return err
+	}
+	err = pushHash(buf, eth.TransactionHash)
+	if err != nil {
+		return err
+	}
+	err = buf.PushInt64(eth.TransactionIndex)
+	if err != nil {
+		return err
+	}
+	err = buf.PushInt64(eth.BlockHeight)
+	if err != nil {
+		return err
+	}
+	err = pushHash(buf, eth.BlockHash)
+	if err != nil {
+		return err
+	}
+	err = buf.PushString(eth.ContractAddress)
+	if err != nil {
+		return err
+	}
+	err = buf.PushUInt32(eth.DBHeightMin)
+	if err != nil {
+		return err
+	}
+	err = buf.PushUInt32(eth.DBHeightMax)
+	if err != nil {
+		return err
+	}
+	err = pushHash(buf, eth.WindowMR)
+	if err != nil {
+		return err
+	}
+	return pushMerkleBranch(buf, eth.MerkleBranch)
+}
+
+func popEthereumAnchor(buf *primitives.Buffer) (*EthereumAnchor, error) {
+	present, err := buf.PopBool()
+	if err != nil || present == false {
+		return nil, err
+	}
+	eth := new(EthereumAnchor)
+	eth.TransactionHash, err = popHash(buf)
+	if err != nil {
+		return nil, err
+	}
+	eth.TransactionIndex, err = buf.PopInt64()
+	if err != nil {
+		return nil, err
+	}
+	eth.BlockHeight, err = buf.PopInt64()
+	if err != nil {
+		return nil, err
+	}
+	eth.BlockHash, err = popHash(buf)
+	if err != nil {
+		return nil, err
+	}
+	eth.ContractAddress, err = buf.PopString()
+	if err != nil {
+		return nil, err
+	}
+	eth.DBHeightMin, err = buf.PopUInt32()
+	if err != nil {
+		return nil, err
+	}
+	eth.DBHeightMax, err = buf.PopUInt32()
+	if err != nil {
+		return nil, err
+	}
+	eth.WindowMR, err = popHash(buf)
+	if err != nil {
+		return nil, err
+	}
+	eth.MerkleBranch, err = popMerkleBranch(buf)
+	if err != nil {
+		return nil, err
+	}
+	if len(eth.MerkleBranch) == 0 {
+		eth.MerkleBranch = nil
+	}
+	return eth, nil
+}
+
+// pushHash writes a hash that may be nil
+func pushHash(buf *primitives.Buffer, h *primitives.Hash) error {
+	err := buf.PushBool(h != nil)
+	if err != nil || h == nil {
+		return err
+	}
+	return buf.Push(h.Bytes())
+}
+
+func popHash(buf *primitives.Buffer) (*primitives.Hash, error) {
+	present, err := buf.PopBool()
+	if err != nil || present == false {
+		return nil, err
+	}
+	if buf.Len() < constants.HASH_LENGTH {
+		return nil, fmt.Errorf("End of Buffer Looking for a hash")
+	}
+	data, err := buf.PopLen(constants.HASH_LENGTH)
+	if err != nil {
+		return nil, err
+	}
+	return primitives.NewHash(data).(*primitives.Hash), nil
+}
+
+func (e *TransactionReceipt) JSONByte() ([]byte, error) {
+	return primitives.EncodeJSON(e)
+}
+
+func (e *TransactionReceipt) JSONString() (string, error) {
+	return primitives.EncodeJSONString(e)
+}
+
+func (e *TransactionReceipt) String() string {
+	str, _ := e.JSONString()
+	return str
+}
+
+func DecodeTransactionReceiptString(str string) (*TransactionReceipt, error) {
+	receipt := new(TransactionReceipt)
+	err := json.Unmarshal([]byte(str), receipt)
+	if err != nil {
+		return nil, err
+	}
+	return receipt, nil
+}
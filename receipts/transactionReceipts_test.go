@@ -0,0 +1,130 @@
+// Copyright 2017 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package receipts_test
+
+import (
+	"testing"
+
+	"github.com/FactomProject/factomd/common/primitives"
+	. "github.com/FactomProject/factomd/receipts"
+	. "github.com/FactomProject/factomd/testHelper"
+)
+
+func TestFactoidTransactionReceipts(t *testing.T) {
+	dbo := CreateAndPopulateTestDatabaseOverlay()
+	blocks := CreateFullTestBlockSet()
+	for _, block := range blocks[:len(blocks)-2] {
+		for _, tx := range block.FBlock.GetTransactions() {
+			receipt, err := CreateTransactionReceipt(dbo, tx.GetSigHash())
+			if err != nil {
+				t.Fatal(err)
+			}
+			if receipt.Type != FactoidTransactionReceipt {
+				t.Errorf("Wrong receipt type %v", receipt.Type)
+			}
+			if receipt.BlockKeyMR.IsSameAs(block.FBlock.GetKeyMR()) == false {
+				t.Errorf("Receipt is for FBlock %v, not %v", receipt.BlockKeyMR, block.FBlock.GetKeyMR())
+			}
+			if receipt.DirectoryBlockKeyMR.IsSameAs(block.DBlock.GetKeyMR()) == false {
+				t.Errorf("Receipt is for DBlock %v, not %v", receipt.DirectoryBlockKeyMR, block.DBlock.GetKeyMR())
+			}
+			testTransactionReceiptEncodings(t, receipt)
+		}
+	}
+}
+
+func TestECTransactionReceipts(t *testing.T) {
+	dbo := CreateAndPopulateTestDatabaseOverlay()
+	blocks := CreateFullTestBlockSet()
+	for _, block := range blocks[:len(blocks)-2] {
+		for _, hash := range block.ECBlock.GetEntryHashes() {
+			receipt, err := CreateTransactionReceipt(dbo, hash)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if receipt.Type != ECTransactionReceipt {
+				t.Errorf("Wrong receipt type %v", receipt.Type)
+			}
+			if receipt.DirectoryBlockKeyMR.IsSameAs(block.DBlock.GetKeyMR()) == false {
+				t.Errorf("Receipt is for DBlock %v, not %v", receipt.DirectoryBlockKeyMR, block.DBlock.GetKeyMR())
+			}
+			testTransactionReceiptEncodings(t, receipt)
+		}
+	}
+}
+
+func TestTransactionReceiptTampering(t *testing.T) {
+	dbo := CreateAndPopulateTestDatabaseOverlay()
+	blocks := CreateFullTestBlockSet()
+	tx := blocks[1].FBlock.GetTransactions()[0]
+
+	receipt, err := CreateTransactionReceipt(dbo, tx.GetHash())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	other := *receipt
+	other.TransactionID = primitives.NewZeroHash().(*primitives.Hash)
+	if other.Validate() == nil {
+		t.Errorf("Receipt validated for the wrong transaction")
+	}
+
+	other = *receipt
+	other.DirectoryBlockKeyMR = primitives.NewZeroHash().(*primitives.Hash)
+	if other.Validate() == nil {
+		t.Errorf("Receipt validated for the wrong DBlock")
+	}
+
+	other = *receipt
+	other.MerkleBranch = receipt.MerkleBranch[1:]
+	if other.Validate() == nil {
+		t.Errorf("Receipt validated with a node missing")
+	}
+
+	_, err = CreateTransactionReceipt(dbo, primitives.NewZeroHash())
+	if err == nil {
+		t.Errorf("Created a receipt for a transaction that does not exist")
+	}
+}
+
+func testTransactionReceiptEncodings(t *testing.T, receipt *TransactionReceipt) {
+	err := receipt.Validate()
+	if err != nil {
+		t.Errorf("%v", err)
+	}
+
+	str, err := receipt.JSONString()
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := DecodeTransactionReceiptString(str)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = decoded.Validate()
+	if err != nil {
+		t.Errorf("JSON decoded receipt - %v", err)
+	}
+
+	data, err := receipt.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	unmarshalled := new(TransactionReceipt)
+	rest, err := unmarshalled.UnmarshalBinaryData(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rest) != 0 {
+		t.Errorf("%v bytes left over", len(rest))
+	}
+	err = unmarshalled.Validate()
+	if err != nil {
+		t.Errorf("Binary decoded receipt - %v", err)
+	}
+	if unmarshalled.String() != receipt.String() {
+		t.Errorf("Receipts are not the same after binary encoding:\n%v\n%v", unmarshalled, receipt)
+	}
+}
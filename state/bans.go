@@ -0,0 +1,38 @@
+// Copyright 2017 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package state
+
+import (
+	"fmt"
+	"time"
+)
+
+// The peer ban list lives in the p2p controller, these pass the debug API calls on
+
+func (s *State) GetPeerBans() (interface{}, error) {
+	if s.NetworkController == nil {
+		return nil, fmt.Errorf("The p2p network is not running")
+	}
+	return s.NetworkController.ListBans(), nil
+}
+
+// AddPeerBan bans an IP address or CIDR subnet for the number of seconds, or forever
+// if seconds is 0
+func (s *State) AddPeerBan(address string, reason string, seconds int64) (interface{}, error) {
+	if s.NetworkController == nil {
+		return nil, fmt.Errorf("The p2p network is not running")
+	}
+	if seconds < 0 {
+		return nil, fmt.Errorf("The duration of a ban can not be negative")
+	}
+	return s.NetworkController.AddBan(address, reason, time.Duration(seconds)*time.Second)
+}
+
+func (s *State) RemovePeerBan(address string) (bool, error) {
+	if s.NetworkController == nil {
+		return false, fmt.Errorf("The p2p network is not running")
+	}
+	return s.NetworkController.RemoveBan(address), nil
+}
@@ -1571,6 +1571,9 @@ func (list *DBStateList) SaveDBStateToDB(d *DBState) (progress bool) {
 	progress = true
 	d.ReadyToSave = false
 	d.Saved = true
+	list.State.publishSaved(d)
+	list.State.indexSaved(d.DirectoryBlock.GetDatabaseHeight())
+	list.State.PruneEntries(uint32(dbheight))
 
 	// Now that we have saved the perm balances, we can clear the api hashmaps that held the differences
 	// between the actual saved block prior, and this saved block.  If you are looking for balances of
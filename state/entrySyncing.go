@@ -59,11 +59,14 @@ func (s *State) MakeMissingEntryRequests() {
 		avg := 0
 		highest := 0
 
-		// Look through our map, and remove any entries we now have in our database.
+		// Look through our map, and remove any entries we now have in our database,
+		// or that fell out of what a pruned node keeps.
 		for k := range MissingEntryMap {
 			if has(s, MissingEntryMap[k].EntryHash) {
 				found++
 				delete(MissingEntryMap, k)
+			} else if et := MissingEntryMap[k]; et.ChainID != nil && !s.KeepEntryContent(et.ChainID, et.DBHeight) {
+				delete(MissingEntryMap, k)
 			} else {
 				cnt++
 				sum += MissingEntryMap[k].Cnt
@@ -244,6 +247,11 @@ func (s *State) GoSyncEntries() {
 					eBlock, _ = s.DB.FetchEBlock(ebKeyMR)
 				}
 
+				// A pruned node does not want the entries it would delete again
+				if !s.KeepEntryContent(eBlock.GetChainID(), scan) {
+					continue
+				}
+
 				// Go through all the entry hashes.
 				for _, entryhash := range eBlock.GetEntryHashes() {
 					if entryhash.IsMinuteMarker() {
@@ -284,6 +292,7 @@ func (s *State) GoSyncEntries() {
 						v.DBHeight = eBlock.GetHeader().GetDBHeight()
 						v.EntryHash = entryhash
 						v.EBHash = ebKeyMR
+						v.ChainID = eBlock.GetChainID()
 						entryMissing++
 						missingMap[entryhash.Fixed()] = entryhash
 						s.MissingEntries <- &v
@@ -193,6 +193,11 @@ func (fs *FactoidState) AddTransactionBlock(blk interfaces.IFBlock) error {
 	}
 
 	transactions := blk.GetTransactions()
+	for _, trans := range transactions {
+		if err := fs.validateRCDTypes(blk.GetDatabaseHeight(), trans); err != nil {
+			return err
+		}
+	}
 	for _, trans := range transactions {
 		err := fs.UpdateTransaction(false, trans)
 		if err != nil {
@@ -390,6 +395,10 @@ func (fs *FactoidState) ProcessEndOfBlock(state interfaces.IState) {
 // Returns an error message about what is wrong with the transaction if it is
 // invalid, otherwise you are good to go.
 func (fs *FactoidState) Validate(index int, trans interfaces.ITransaction) error {
+	if err := fs.validateRCDTypes(fs.DBHeight, trans); err != nil {
+		return err
+	}
+
 	var sums = make(map[[32]byte]uint64, 10)  // Look at the sum of an address's inputs
 	for _, input := range trans.GetInputs() { //    to a transaction.
 		bal, err := factoid.ValidateAmounts(sums[input.GetAddress().Fixed()], input.GetAmount())
@@ -409,6 +418,18 @@ func (fs *FactoidState) Validate(index int, trans interfaces.ITransaction) error
 	return nil
 }
 
+// validateRCDTypes rejects multisig (RCD_2) inputs in blocks below the
+// RCD2_MULTISIG activation height, so blocks from before then validate as they
+// always have, however far along the node is.
+func (fs *FactoidState) validateRCDTypes(dbheight uint32, trans interfaces.ITransaction) error {
+	for i, rcd := range trans.GetRCDs() {
+		if _, ok := rcd.(*factoid.RCD_2); ok && !activations.IsActive(activations.RCD2_MULTISIG, int(dbheight)) {
+			return fmt.Errorf("RCD %d is a multisig RCD, which is not active at height %d", i, dbheight)
+		}
+	}
+	return nil
+}
+
 func (fs *FactoidState) GetCoinbaseTransaction(dbheight uint32, ftime interfaces.Timestamp) interfaces.ITransaction {
 	coinbase := new(factoid.Transaction)
 	coinbase.SetTimestamp(ftime)
@@ -10,6 +10,7 @@ import (
 	"math/rand"
 	"testing"
 
+	"github.com/FactomProject/factomd/activations"
 	"github.com/FactomProject/factomd/common/constants"
 	"github.com/FactomProject/factomd/common/entryCreditBlock"
 	"github.com/FactomProject/factomd/common/factoid"
@@ -545,3 +546,60 @@ func Test_updating_balances_FactoidState(test *testing.T) {
 
 }
 */
+
+func TestValidateMultisigActivation(t *testing.T) {
+	s := testHelper.CreateAndPopulateTestStateAndStartValidator()
+	fs := s.FactoidState
+
+	addresses := make([]interfaces.IAddress, 2)
+	for i := range addresses {
+		addresses[i], _ = testHelper.NewFactoidRCDAddress(uint64(i)).GetAddress()
+	}
+	rcd, err := factoid.NewRCD_2(1, 2, addresses)
+	if err != nil {
+		t.Fatal(err)
+	}
+	multisigAddress, _ := rcd.GetAddress()
+	s.PutF(true, multisigAddress.Fixed(), 1000)
+
+	ft := new(factoid.Transaction)
+	ft.AddInput(multisigAddress, 10)
+	ft.AddAuthorization(rcd)
+
+	heights := activations.ActivationMap[activations.RCD2_MULTISIG].ActivationHeight
+	s.IsActive(activations.RCD2_MULTISIG) // make sure this network has an entry
+	saved := map[string]int{}
+	for net, h := range heights {
+		saved[net] = h
+		heights[net] = math.MaxInt32
+	}
+	defer func() {
+		for net, h := range saved {
+			heights[net] = h
+		}
+	}()
+
+	if err := fs.Validate(1, ft); err == nil {
+		t.Error("Multisig input accepted before activation")
+	}
+
+	for net := range heights {
+		heights[net] = 0
+	}
+	if err := fs.Validate(1, ft); err != nil {
+		t.Errorf("Multisig input rejected after activation - %v", err)
+	}
+
+	// The height of the block decides, not how far along the node is
+	for net := range heights {
+		heights[net] = int(fs.DBHeight) + 1
+	}
+	if err := fs.Validate(1, ft); err == nil {
+		t.Error("Multisig input accepted in a block before the activation height")
+	}
+	fs.DBHeight++
+	defer func() { fs.DBHeight-- }()
+	if err := fs.Validate(1, ft); err != nil {
+		t.Errorf("Multisig input rejected in the block at the activation height - %v", err)
+	}
+}
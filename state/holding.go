@@ -0,0 +1,312 @@
+// Copyright 2017 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package state
+
+import (
+	"container/list"
+	"sort"
+	"time"
+
+	"github.com/FactomProject/factomd/common/constants"
+	"github.com/FactomProject/factomd/common/interfaces"
+	"github.com/FactomProject/factomd/common/messages"
+)
+
+// Default limits of the holding queue, see State.HoldingMaxPerType etc.
+const (
+	HoldingMaxPerType   = 20000            // Messages of one type
+	HoldingMaxPerPeer   = 5000             // Messages from one peer, our own are not limited
+	HoldingMaxAge       = time.Hour        // Commits are only valid for an hour either way
+	HoldingMaxOrphanAge = 10 * time.Minute // Messages still waiting for the message they depend on
+)
+
+// HoldingEvictLookahead is how many of the oldest messages of a full type or peer
+// are looked at for one waiting on a message we may never get, to evict it first
+const HoldingEvictLookahead = 16
+
+// Reasons a message waits in holding, and why it was evicted
+const (
+	HoldCommitWaitingForReveal = "commit-waiting-for-reveal"
+	HoldRevealWaitingForCommit = "reveal-waiting-for-commit"
+	HoldAckWaitingForMessage   = "ack-waiting-for-message"
+	HoldWaitingForAck          = "waiting-for-ack"
+	HoldWaitingForProcessList  = "waiting-for-process-list"
+	HoldFutureBlock            = "future-block"
+	HoldFutureMinute           = "future-minute"
+
+	EvictTypeCap   = "type-cap"
+	EvictPeerCap   = "peer-cap"
+	EvictAge       = "age"
+	EvictOrphanAge = "orphan-age"
+)
+
+// isOrphanReason is true for the reasons that depend on a message we may never get
+func isOrphanReason(reason string) bool {
+	switch reason {
+	case HoldCommitWaitingForReveal, HoldRevealWaitingForCommit, HoldAckWaitingForMessage:
+		return true
+	}
+	return false
+}
+
+// heldMessage is what we know about a message in holding, besides the message
+type heldMessage struct {
+	Added  time.Time
+	Type   byte
+	Peer   string        // NetworkOrigin, empty for our own messages
+	byType *list.Element // Place in the queue of the type, oldest first
+	byPeer *list.Element // Place in the queue of the peer, nil for our own messages
+}
+
+// HeldMessage explains why a message is in holding, for the debug API
+type HeldMessage struct {
+	Hash    string  `json:"hash"`
+	Type    string  `json:"type"`
+	Peer    string  `json:"peer"`
+	VMIndex int     `json:"vmindex"`
+	Age     float64 `json:"age"` // Seconds
+	Reason  string  `json:"reason"`
+}
+
+// AddToHolding puts the message in holding under the hash.  If holding has as
+// many messages of its type, or from its peer, as it may have, the oldest of
+// them is evicted first, preferring messages that wait for a message we may
+// never get.
+func (s *State) AddToHolding(hash [32]byte, msg interfaces.IMsg) {
+	s.reconcileHolding()
+	if _, present := s.Holding[hash]; present {
+		s.Holding[hash] = msg
+		return
+	}
+
+	typ, peer := msg.Type(), msg.GetNetworkOrigin()
+	if queue := s.holdingByType[typ]; queue != nil && queue.Len() >= s.holdingMaxPerType() {
+		s.evictFromHolding(EvictTypeCap, queue)
+	}
+	if queue := s.holdingByPeer[peer]; peer != "" && queue != nil && queue.Len() >= s.holdingMaxPerPeer() {
+		s.evictFromHolding(EvictPeerCap, queue)
+	}
+
+	s.Holding[hash] = msg
+	s.rememberHeld(hash, msg, time.Now())
+}
+
+// DeleteFromHolding takes the message with the hash out of holding
+func (s *State) DeleteFromHolding(hash [32]byte) {
+	delete(s.Holding, hash)
+	s.forgetHeld(hash)
+}
+
+// rememberHeld adds the message at the back of the queues of its type and peer
+func (s *State) rememberHeld(hash [32]byte, msg interfaces.IMsg, added time.Time) {
+	held := &heldMessage{Added: added, Type: msg.Type(), Peer: msg.GetNetworkOrigin()}
+	if s.holdingByType[held.Type] == nil {
+		s.holdingByType[held.Type] = list.New()
+	}
+	held.byType = s.holdingByType[held.Type].PushBack(hash)
+	if held.Peer != "" {
+		if s.holdingByPeer[held.Peer] == nil {
+			s.holdingByPeer[held.Peer] = list.New()
+		}
+		held.byPeer = s.holdingByPeer[held.Peer].PushBack(hash)
+	}
+	s.holdingMeta[hash] = held
+}
+
+// forgetHeld takes the message out of the queues of its type and peer
+func (s *State) forgetHeld(hash [32]byte) {
+	held, present := s.holdingMeta[hash]
+	if !present {
+		return
+	}
+	delete(s.holdingMeta, hash)
+	queue := s.holdingByType[held.Type]
+	if queue.Remove(held.byType); queue.Len() == 0 {
+		delete(s.holdingByType, held.Type)
+	}
+	if held.byPeer != nil {
+		queue = s.holdingByPeer[held.Peer]
+		if queue.Remove(held.byPeer); queue.Len() == 0 {
+			delete(s.holdingByPeer, held.Peer)
+		}
+	}
+}
+
+// evictFromHolding evicts the oldest message of the queue, or an orphan if one of
+// the HoldingEvictLookahead oldest is one.  Only looking at the front of the queue
+// keeps the work per message the same however large holding grows.
+func (s *State) evictFromHolding(why string, queue *list.List) {
+	if queue.Len() == 0 {
+		return
+	}
+	victim := queue.Front().Value.([32]byte)
+	for e, i := queue.Front(), 0; e != nil && i < HoldingEvictLookahead; e, i = e.Next(), i+1 {
+		if hash := e.Value.([32]byte); isOrphanReason(s.holdingReason(s.Holding[hash])) {
+			victim = hash
+			break
+		}
+	}
+	s.LogMessage("executeMsg", "evict from holding, "+why, s.Holding[victim])
+	HoldingQueueEvictions.WithLabelValues(why).Inc()
+	TotalHoldingQueueOutputs.Inc()
+	s.DeleteFromHolding(victim)
+}
+
+// expireHolding evicts the messages held too long, where messages waiting for a
+// message that never came are not held as long as others
+func (s *State) expireHolding() {
+	s.reconcileHolding()
+	now := time.Now()
+	maxAge, maxOrphanAge := s.holdingMaxAge(), s.holdingMaxOrphanAge()
+	for hash, held := range s.holdingMeta {
+		age := now.Sub(held.Added)
+		if age <= maxOrphanAge {
+			continue
+		}
+		msg := s.Holding[hash]
+		why := ""
+		if age > maxAge {
+			why = EvictAge
+		} else if isOrphanReason(s.holdingReason(msg)) {
+			why = EvictOrphanAge
+		} else {
+			continue
+		}
+		s.LogMessage("executeMsg", "evict from holding, "+why, msg)
+		HoldingQueueEvictions.WithLabelValues(why).Inc()
+		TotalHoldingQueueOutputs.Inc()
+		s.DeleteFromHolding(hash)
+	}
+}
+
+// reconcileHolding catches up with messages that were put in, or taken out of,
+// the Holding map directly
+func (s *State) reconcileHolding() {
+	if s.holdingMeta != nil && len(s.holdingMeta) == len(s.Holding) {
+		return
+	}
+	if s.Holding == nil {
+		s.Holding = make(map[[32]byte]interfaces.IMsg)
+	}
+	if s.holdingMeta == nil {
+		s.holdingMeta = make(map[[32]byte]*heldMessage)
+		s.holdingByType = make(map[byte]*list.List)
+		s.holdingByPeer = make(map[string]*list.List)
+	}
+	for hash := range s.holdingMeta {
+		if _, present := s.Holding[hash]; !present {
+			s.forgetHeld(hash)
+		}
+	}
+	now := time.Now()
+	for hash, msg := range s.Holding {
+		if _, present := s.holdingMeta[hash]; !present {
+			s.rememberHeld(hash, msg, now)
+		}
+	}
+}
+
+// holdingReason is why the message waits in holding
+func (s *State) holdingReason(msg interfaces.IMsg) string {
+	switch m := msg.(type) {
+	case *messages.Ack:
+		if s.Holding[m.GetHash().Fixed()] == nil {
+			return HoldAckWaitingForMessage
+		}
+		return HoldWaitingForProcessList
+	case *messages.CommitEntryMsg:
+		if s.Holding[m.CommitEntry.EntryHash.Fixed()] == nil {
+			return HoldCommitWaitingForReveal
+		}
+	case *messages.CommitChainMsg:
+		if s.Holding[m.CommitChain.EntryHash.Fixed()] == nil {
+			return HoldCommitWaitingForReveal
+		}
+	case *messages.RevealEntryMsg:
+		if s.Commits.Get(m.GetHash().Fixed()) == nil {
+			return HoldRevealWaitingForCommit
+		}
+	case *messages.EOM:
+		if m.DBHeight > s.LLeaderHeight {
+			return HoldFutureBlock
+		}
+		if m.DBHeight == s.LLeaderHeight && int(m.Minute) > s.CurrentMinute {
+			return HoldFutureMinute
+		}
+	case *messages.DirectoryBlockSignature:
+		if m.DBHeight > s.LLeaderHeight {
+			return HoldFutureBlock
+		}
+	}
+	if msg != nil && s.Acks[msg.GetMsgHash().Fixed()] != nil {
+		return HoldWaitingForProcessList
+	}
+	return HoldWaitingForAck
+}
+
+// explainHolding explains every message in holding, oldest first, and sets the
+// gauges of the reasons
+func (s *State) explainHolding() []HeldMessage {
+	s.reconcileHolding()
+	now := time.Now()
+	reasons := map[string]int{}
+	explained := make([]HeldMessage, 0, len(s.Holding))
+	for hash, msg := range s.Holding {
+		held := s.holdingMeta[hash]
+		reason := s.holdingReason(msg)
+		reasons[reason]++
+		explained = append(explained, HeldMessage{
+			Hash:    msg.GetMsgHash().String(),
+			Type:    constants.MessageName(msg.Type()),
+			Peer:    held.Peer,
+			VMIndex: msg.GetVMIndex(),
+			Age:     now.Sub(held.Added).Seconds(),
+			Reason:  reason,
+		})
+	}
+	sort.Slice(explained, func(i, j int) bool { return explained[i].Age > explained[j].Age })
+
+	for _, reason := range []string{HoldCommitWaitingForReveal, HoldRevealWaitingForCommit, HoldAckWaitingForMessage,
+		HoldWaitingForAck, HoldWaitingForProcessList, HoldFutureBlock, HoldFutureMinute} {
+		HoldingQueueWaiting.WithLabelValues(reason).Set(float64(reasons[reason]))
+	}
+	return explained
+}
+
+// ExplainHolding returns why each message in holding waits ([]HeldMessage), as of
+// the last time the state looked (about a second)
+func (s *State) ExplainHolding() interface{} {
+	s.HoldingMutex.RLock()
+	defer s.HoldingMutex.RUnlock()
+	return s.HoldingExplained
+}
+
+func (s *State) holdingMaxPerType() int {
+	if s.HoldingMaxPerType > 0 {
+		return s.HoldingMaxPerType
+	}
+	return HoldingMaxPerType
+}
+
+func (s *State) holdingMaxPerPeer() int {
+	if s.HoldingMaxPerPeer > 0 {
+		return s.HoldingMaxPerPeer
+	}
+	return HoldingMaxPerPeer
+}
+
+func (s *State) holdingMaxAge() time.Duration {
+	if s.HoldingMaxAge > 0 {
+		return s.HoldingMaxAge
+	}
+	return HoldingMaxAge
+}
+
+func (s *State) holdingMaxOrphanAge() time.Duration {
+	if s.HoldingMaxOrphanAge > 0 {
+		return s.HoldingMaxOrphanAge
+	}
+	return HoldingMaxOrphanAge
+}
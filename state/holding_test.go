@@ -0,0 +1,189 @@
+// Copyright 2017 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package state
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/FactomProject/factomd/common/entryBlock"
+	"github.com/FactomProject/factomd/common/entryCreditBlock"
+	"github.com/FactomProject/factomd/common/interfaces"
+	"github.com/FactomProject/factomd/common/messages"
+	"github.com/FactomProject/factomd/common/primitives"
+)
+
+func newHoldingTestState() *State {
+	s := new(State)
+	s.Holding = make(map[[32]byte]interfaces.IMsg)
+	s.Acks = make(map[[32]byte]interfaces.IMsg)
+	s.Commits = NewSafeMsgMap("commits", s)
+	return s
+}
+
+func newHeldBounce(name string, peer string) *messages.Bounce {
+	msg := new(messages.Bounce)
+	msg.Name = name
+	msg.Timestamp = primitives.NewTimestampNow()
+	msg.SetNetworkOrigin(peer)
+	return msg
+}
+
+func newHeldCommit(peer string) *messages.CommitEntryMsg {
+	commit := messages.NewCommitEntryMsg()
+	commit.CommitEntry = entryCreditBlock.NewCommitEntry()
+	commit.CommitEntry.Credits = 1
+	commit.CommitEntry.Init()
+	commit.CommitEntry.EntryHash = primitives.RandomHash()
+	commit.SetNetworkOrigin(peer)
+	return commit
+}
+
+func TestHoldingCaps(t *testing.T) {
+	s := newHoldingTestState()
+	s.HoldingMaxPerPeer = 3
+	s.HoldingMaxPerType = 5
+
+	var first [32]byte
+	for i := 0; i < 5; i++ {
+		msg := newHeldBounce(fmt.Sprintf("peer-a %d", i), "peer-a")
+		if i == 0 {
+			first = msg.GetMsgHash().Fixed()
+		}
+		s.AddToHolding(msg.GetMsgHash().Fixed(), msg)
+	}
+	if len(s.Holding) != 3 {
+		t.Errorf("Holding has %d messages from peer-a, the cap is 3", len(s.Holding))
+	}
+	if s.Holding[first] != nil {
+		t.Error("The oldest message of peer-a was not evicted")
+	}
+
+	// Our own messages are only limited by type
+	for i := 0; i < 5; i++ {
+		msg := newHeldBounce(fmt.Sprintf("local %d", i), "")
+		s.AddToHolding(msg.GetMsgHash().Fixed(), msg)
+	}
+	if len(s.Holding) != 5 {
+		t.Errorf("Holding has %d bounces, the cap is 5", len(s.Holding))
+	}
+
+	for hash := range s.Holding {
+		s.DeleteFromHolding(hash)
+	}
+	if len(s.holdingMeta) != 0 || len(s.holdingByPeer) != 0 || len(s.holdingByType) != 0 {
+		t.Errorf("Holding still counts messages after deleting them all: %v %v", s.holdingByType, s.holdingByPeer)
+	}
+}
+
+func TestHoldingEvictsOrphansFirst(t *testing.T) {
+	s := newHoldingTestState()
+	s.HoldingMaxPerPeer = 2
+
+	bounce := newHeldBounce("old", "peer-a")
+	s.AddToHolding(bounce.GetMsgHash().Fixed(), bounce)
+	commit := newHeldCommit("peer-a")
+	s.AddToHolding(commit.GetMsgHash().Fixed(), commit)
+	if reason := s.holdingReason(commit); reason != HoldCommitWaitingForReveal {
+		t.Errorf("Commit without its reveal is %s", reason)
+	}
+
+	newer := newHeldBounce("new", "peer-a")
+	s.AddToHolding(newer.GetMsgHash().Fixed(), newer)
+	if s.Holding[commit.GetMsgHash().Fixed()] != nil {
+		t.Error("The commit waiting for its reveal was not evicted first")
+	}
+	if s.Holding[bounce.GetMsgHash().Fixed()] == nil || s.Holding[newer.GetMsgHash().Fixed()] == nil {
+		t.Error("A message not waiting on another was evicted")
+	}
+}
+
+func TestHoldingEvictsOnlyLooksAtTheOldest(t *testing.T) {
+	s := newHoldingTestState()
+	s.HoldingMaxPerPeer = HoldingEvictLookahead + 1
+
+	var oldest [32]byte
+	for i := 0; i < HoldingEvictLookahead; i++ {
+		msg := newHeldBounce(fmt.Sprintf("bounce %d", i), "peer-a")
+		if i == 0 {
+			oldest = msg.GetMsgHash().Fixed()
+		}
+		s.AddToHolding(msg.GetMsgHash().Fixed(), msg)
+	}
+	commit := newHeldCommit("peer-a")
+	s.AddToHolding(commit.GetMsgHash().Fixed(), commit)
+
+	newer := newHeldBounce("new", "peer-a")
+	s.AddToHolding(newer.GetMsgHash().Fixed(), newer)
+	if s.Holding[oldest] != nil {
+		t.Error("The oldest message was not evicted")
+	}
+	if s.Holding[commit.GetMsgHash().Fixed()] == nil {
+		t.Error("A commit newer than the messages looked at was evicted")
+	}
+	if queue := s.holdingByPeer["peer-a"]; queue.Len() != HoldingEvictLookahead+1 || queue.Back().Value.([32]byte) != newer.GetMsgHash().Fixed() {
+		t.Error("The queue of peer-a is not oldest first")
+	}
+}
+
+func TestHoldingExpire(t *testing.T) {
+	s := newHoldingTestState()
+	s.HoldingMaxOrphanAge = time.Minute
+	s.HoldingMaxAge = time.Hour
+
+	bounce := newHeldBounce("bounce", "peer-a")
+	s.AddToHolding(bounce.GetMsgHash().Fixed(), bounce)
+	commit := newHeldCommit("peer-a")
+	s.AddToHolding(commit.GetMsgHash().Fixed(), commit)
+
+	age := func(hash [32]byte, by time.Duration) {
+		held := s.holdingMeta[hash]
+		held.Added = held.Added.Add(-by)
+		s.holdingMeta[hash] = held
+	}
+	age(bounce.GetMsgHash().Fixed(), 2*time.Minute)
+	age(commit.GetMsgHash().Fixed(), 2*time.Minute)
+	s.expireHolding()
+	if s.Holding[commit.GetMsgHash().Fixed()] != nil {
+		t.Error("Commit waiting for its reveal was not evicted after the orphan age")
+	}
+	if s.Holding[bounce.GetMsgHash().Fixed()] == nil {
+		t.Error("Message was evicted before the max age")
+	}
+
+	age(bounce.GetMsgHash().Fixed(), 2*time.Hour)
+	s.expireHolding()
+	if len(s.Holding) != 0 {
+		t.Error("Message was not evicted after the max age")
+	}
+}
+
+func TestExplainHolding(t *testing.T) {
+	s := newHoldingTestState()
+
+	commit := newHeldCommit("peer-a")
+	s.AddToHolding(commit.GetMsgHash().Fixed(), commit)
+	reveal := messages.NewRevealEntryMsg()
+	entry := entryBlock.NewEntry()
+	entry.ChainID = primitives.RandomHash()
+	entry.Content = primitives.ByteSlice{Bytes: []byte("held")}
+	reveal.Entry = entry
+	s.AddToHolding(reveal.GetMsgHash().Fixed(), reveal)
+	ack := new(messages.Ack)
+	ack.MsgHash = primitives.RandomHash()
+	ack.MessageHash = primitives.RandomHash()
+	s.AddToHolding(ack.GetMsgHash().Fixed(), ack)
+
+	reasons := map[string]int{}
+	for _, held := range s.explainHolding() {
+		reasons[held.Reason]++
+	}
+	for _, reason := range []string{HoldCommitWaitingForReveal, HoldRevealWaitingForCommit, HoldAckWaitingForMessage} {
+		if reasons[reason] != 1 {
+			t.Errorf("%d messages are %s, expected 1 (%v)", reasons[reason], reason, reasons)
+		}
+	}
+}
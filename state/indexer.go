@@ -0,0 +1,176 @@
+package state
+
+import (
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/FactomProject/factomd/common/interfaces"
+	"github.com/FactomProject/factomd/common/messages"
+)
+
+// How long to wait before retrying a height the indexer did not take
+var INDEX_RETRY time.Duration = 5 * time.Second
+
+/**********************
+ *      Indexers      *
+ **********************/
+
+// IndexController pushes the saved DBStates to an indexer plugin in height
+// order. The plugin is the one that remembers how far it got, so after a restart
+// of either side we carry on from the height after its acknowledged height.
+type IndexController struct {
+	Indexer interfaces.IIndexerController
+
+	acked int64       // Highest height the indexer acknowledged, -1 if none
+	saved chan uint32 // Wakes the controller up when a block is saved
+
+	quit chan int
+}
+
+func NewIndexController(indexer interfaces.IIndexerController) *IndexController {
+	i := new(IndexController)
+	i.Indexer = indexer
+	i.acked = -1
+	i.saved = make(chan uint32, 1)
+	i.quit = make(chan int, 10)
+
+	return i
+}
+
+func (i *IndexController) Close() {
+	i.quit <- 0
+}
+
+// AckedHeight returns the highest height the indexer acknowledged, or -1
+func (i *IndexController) AckedHeight() int64 {
+	return atomic.LoadInt64(&i.acked)
+}
+
+// Saved is called when a DBState is saved, and never blocks
+func (i *IndexController) Saved(height uint32) {
+	select {
+	case i.saved <- height:
+	default:
+	}
+}
+
+// indexSaved lets the indexer know there is a new block, if there is one
+func (s *State) indexSaved(height uint32) {
+	if s.Indexer != nil {
+		s.Indexer.Saved(height)
+	}
+}
+
+// RunIndexController feeds the indexer until it is closed
+func (s *State) RunIndexController() {
+	fmt.Println("Starting index controller")
+	i := s.Indexer
+	for {
+		wait := INDEX_RETRY
+		if err := s.indexBlocks(); err != nil {
+			fmt.Printf("ERROR: Indexer plugin: %v\n", err)
+		} else {
+			// Nothing left to do, the next saved block wakes us up
+			wait = time.Minute
+		}
+
+		select {
+		case <-i.quit:
+			i.quit <- 0
+			return
+		case <-i.saved:
+		case <-time.After(wait):
+		}
+	}
+}
+
+// indexBlocks pushes all the blocks the indexer has not acknowledged yet
+func (s *State) indexBlocks() error {
+	i := s.Indexer
+	if err := i.Indexer.Alive(); err != nil {
+		return err
+	}
+
+	acked, err := i.Indexer.AckedHeight()
+	if err != nil {
+		return err
+	}
+	atomic.StoreInt64(&i.acked, acked)
+
+	// Only send heights we have all the entries for
+	top := s.GetHighestSavedBlk()
+	if complete := s.GetEntryDBHeightComplete(); complete < top {
+		top = complete
+	}
+
+	for height := acked + 1; height <= int64(top); height++ {
+		select {
+		case <-i.quit:
+			i.quit <- 0
+			return nil
+		default:
+		}
+
+		block, err := s.loadIndexBlock(uint32(height))
+		if err != nil {
+			return err
+		}
+		data, err := block.MarshalBinary()
+		if err != nil {
+			return err
+		}
+		err = i.Indexer.IndexDBState(uint32(height), data)
+		if err != nil {
+			return fmt.Errorf("height %d was not indexed: %v", height, err)
+		}
+		atomic.StoreInt64(&i.acked, height)
+	}
+	return nil
+}
+
+// loadIndexBlock packages the saved DBState at the height with its entries.  The
+// entries a pruned node no longer has are left out, their entry blocks still list
+// them; any other missing entry is an error, so the height is tried again.
+func (s *State) loadIndexBlock(height uint32) (*WholeBlock, error) {
+	msg, err := s.LoadDBState(height)
+	if err != nil {
+		return nil, err
+	}
+	if msg == nil {
+		return nil, fmt.Errorf("no DBState at height %d", height)
+	}
+	d := msg.(*messages.DBStateMsg)
+	block := NewWholeBlock()
+	block.DBlock = d.DirectoryBlock
+	block.ABlock = d.AdminBlock
+	block.FBlock = d.FactoidBlock
+	block.ECBlock = d.EntryCreditBlock
+	block.SigList = d.SignatureList.List
+
+	pruned := 0
+	for _, eblock := range d.EBlocks {
+		block.AddEblock(eblock)
+		for _, hash := range eblock.GetEntryHashes() {
+			if hash.IsMinuteMarker() {
+				continue
+			}
+			entry, err := s.DB.FetchEntry(hash)
+			if err != nil {
+				return nil, fmt.Errorf("loading entry %x at height %d: %v", hash.Bytes(), height, err)
+			}
+			if entry == nil {
+				if s.IsEntryPruned(hash) {
+					pruned++
+					continue
+				}
+				return nil, fmt.Errorf("entry %x at height %d is not in the database", hash.Bytes(), height)
+			}
+			block.AddIEBEntry(entry)
+		}
+	}
+	if pruned > 0 {
+		s.LogPrintf("indexer", "Left out %d pruned entries at height %d", pruned, height)
+	}
+	return block, nil
+}
@@ -169,6 +169,14 @@ var (
 		Name: "factomd_state_holding_queue_revealentry_outputs",
 		Help: "Tally of RevealEntry messages drained out of Holding",
 	})
+	HoldingQueueWaiting = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "factomd_state_holding_queue_waiting",
+		Help: "Messages in Holding by what they are waiting for",
+	}, []string{"reason"})
+	HoldingQueueEvictions = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "factomd_state_holding_queue_evictions",
+		Help: "Tally of messages evicted from Holding by why",
+	}, []string{"reason"})
 
 	// Acks Queue
 	TotalAcksInputs = prometheus.NewCounter(prometheus.CounterOpts{
@@ -321,6 +329,8 @@ func RegisterPrometheus() {
 	prometheus.MustRegister(HoldingQueueCommitChainOutputs)
 	prometheus.MustRegister(HoldingQueueRevealEntryInputs)
 	prometheus.MustRegister(HoldingQueueRevealEntryOutputs)
+	prometheus.MustRegister(HoldingQueueWaiting)
+	prometheus.MustRegister(HoldingQueueEvictions)
 
 	// Acks
 	prometheus.MustRegister(TotalAcksInputs)
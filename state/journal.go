@@ -0,0 +1,249 @@
+// Copyright 2017 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package state
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+// JournalMagic starts every binary journal file
+const JournalMagic = "FCTJRNL1"
+
+// JournalRecord is a message as it was received, with where and when from
+type JournalRecord struct {
+	Received time.Time
+	Peer     string // NetworkOrigin of the message, empty if it is our own
+	VMIndex  int
+	Message  []byte // The marshaled message
+}
+
+// The record on disk is
+//
+//	length	uint32	of the payload
+//	crc	uint32	IEEE CRC32 of the payload
+//	payload	received int64 (unix nanoseconds), vm index int32,
+//		peer length uint16, peer, message
+const journalRecordHeader = 8
+const journalMaxRecord = 64 * 1024 * 1024
+
+func (r *JournalRecord) marshal() []byte {
+	peer := r.Peer
+	if len(peer) > 0xFFFF {
+		peer = peer[:0xFFFF]
+	}
+	payload := make([]byte, 8+4+2+len(peer)+len(r.Message))
+	binary.BigEndian.PutUint64(payload[0:], uint64(r.Received.UnixNano()))
+	binary.BigEndian.PutUint32(payload[8:], uint32(int32(r.VMIndex)))
+	binary.BigEndian.PutUint16(payload[12:], uint16(len(peer)))
+	copy(payload[14:], peer)
+	copy(payload[14+len(peer):], r.Message)
+
+	data := make([]byte, journalRecordHeader+len(payload))
+	binary.BigEndian.PutUint32(data[0:], uint32(len(payload)))
+	binary.BigEndian.PutUint32(data[4:], crc32.ChecksumIEEE(payload))
+	copy(data[journalRecordHeader:], payload)
+	return data
+}
+
+func (r *JournalRecord) unmarshal(payload []byte) error {
+	if len(payload) < 14 {
+		return errors.New("journal record too short")
+	}
+	r.Received = time.Unix(0, int64(binary.BigEndian.Uint64(payload[0:])))
+	r.VMIndex = int(int32(binary.BigEndian.Uint32(payload[8:])))
+	peerLen := int(binary.BigEndian.Uint16(payload[12:]))
+	if len(payload) < 14+peerLen {
+		return errors.New("journal record peer too long")
+	}
+	r.Peer = string(payload[14 : 14+peerLen])
+	r.Message = append([]byte(nil), payload[14+peerLen:]...)
+	return nil
+}
+
+// Journal writes the messages a node receives to a binary file, which is
+// rotated once it grows past MaxSize.  The rotated files are the path with .1
+// (the newest) up to .MaxFiles-1 appended.
+type Journal struct {
+	lock     sync.Mutex
+	path     string
+	maxSize  int64 // 0 for no limit
+	maxFiles int   // Files kept, with the current one; 1 or less keeps none
+	file     *os.File
+	size     int64
+}
+
+// NewJournal starts a journal at the path.  The journal of an earlier run is
+// rotated out of the way first.
+func NewJournal(path string, maxSize int64, maxFiles int) (*Journal, error) {
+	j := new(Journal)
+	j.path = path
+	j.maxSize = maxSize
+	j.maxFiles = maxFiles
+	if info, err := os.Stat(path); err == nil && info.Size() > 0 {
+		j.rotateFiles()
+	}
+	if err := j.open(); err != nil {
+		return nil, err
+	}
+	return j, nil
+}
+
+func (j *Journal) open() error {
+	f, err := os.OpenFile(j.path, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0666)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write([]byte(JournalMagic)); err != nil {
+		f.Close()
+		return err
+	}
+	j.file = f
+	j.size = int64(len(JournalMagic))
+	return nil
+}
+
+// rotateFiles shifts path.N to path.N+1, dropping the oldest, and moves path to path.1
+func (j *Journal) rotateFiles() {
+	if j.maxFiles <= 1 {
+		os.Remove(j.path)
+		return
+	}
+	os.Remove(fmt.Sprintf("%s.%d", j.path, j.maxFiles-1))
+	for i := j.maxFiles - 2; i >= 1; i-- {
+		os.Rename(fmt.Sprintf("%s.%d", j.path, i), fmt.Sprintf("%s.%d", j.path, i+1))
+	}
+	os.Rename(j.path, j.path+".1")
+}
+
+// Write appends the record, rotating the journal first if it would grow too large
+func (j *Journal) Write(record *JournalRecord) error {
+	data := record.marshal()
+
+	j.lock.Lock()
+	defer j.lock.Unlock()
+	if j.file == nil {
+		return errors.New("journal is closed")
+	}
+	if j.maxSize > 0 && j.size > int64(len(JournalMagic)) && j.size+int64(len(data)) > j.maxSize {
+		j.file.Close()
+		j.file = nil
+		j.rotateFiles()
+		if err := j.open(); err != nil {
+			return err
+		}
+	}
+	n, err := j.file.Write(data)
+	j.size += int64(n)
+	return err
+}
+
+func (j *Journal) Path() string {
+	return j.path
+}
+
+func (j *Journal) Close() error {
+	j.lock.Lock()
+	defer j.lock.Unlock()
+	if j.file == nil {
+		return nil
+	}
+	err := j.file.Close()
+	j.file = nil
+	return err
+}
+
+// JournalFiles returns the files of the journal at the path, the oldest rotated
+// file first and the path itself last
+func JournalFiles(path string) []string {
+	var files []string
+	for i := 1; ; i++ {
+		rotated := fmt.Sprintf("%s.%d", path, i)
+		if _, err := os.Stat(rotated); err != nil {
+			break
+		}
+		files = append([]string{rotated}, files...)
+	}
+	return append(files, path)
+}
+
+// JournalReader reads the records of a binary journal
+type JournalReader struct {
+	r *bufio.Reader
+}
+
+// IsBinaryJournal tells a binary journal from an old text journal, without
+// consuming anything of the reader
+func IsBinaryJournal(r *bufio.Reader) bool {
+	magic, err := r.Peek(len(JournalMagic))
+	return err == nil && bytes.Equal(magic, []byte(JournalMagic))
+}
+
+// NewJournalReader checks the reader is a binary journal
+func NewJournalReader(r io.Reader) (*JournalReader, error) {
+	br := bufio.NewReader(r)
+	if !IsBinaryJournal(br) {
+		return nil, errors.New("not a binary journal")
+	}
+	br.Discard(len(JournalMagic))
+	return &JournalReader{r: br}, nil
+}
+
+// Next returns the next record, or io.EOF at the end of the journal.  A record
+// cut short, as the last one is when the node dies while writing it, is the end.
+func (jr *JournalReader) Next() (*JournalRecord, error) {
+	header := make([]byte, journalRecordHeader)
+	if _, err := io.ReadFull(jr.r, header); err != nil {
+		return nil, io.EOF
+	}
+	length := binary.BigEndian.Uint32(header[0:])
+	if length > journalMaxRecord {
+		return nil, fmt.Errorf("journal record of %d bytes is too long", length)
+	}
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(jr.r, payload); err != nil {
+		return nil, io.EOF
+	}
+	if crc32.ChecksumIEEE(payload) != binary.BigEndian.Uint32(header[4:]) {
+		return nil, errors.New("journal record failed its checksum")
+	}
+	record := new(JournalRecord)
+	if err := record.unmarshal(payload); err != nil {
+		return nil, err
+	}
+	return record, nil
+}
+
+// ReadJournalFile returns all the records of a binary journal file
+func ReadJournalFile(path string) ([]*JournalRecord, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	jr, err := NewJournalReader(f)
+	if err != nil {
+		return nil, err
+	}
+	var records []*JournalRecord
+	for {
+		record, err := jr.Next()
+		if err == io.EOF {
+			return records, nil
+		}
+		if err != nil {
+			return records, err
+		}
+		records = append(records, record)
+	}
+}
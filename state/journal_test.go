@@ -5,11 +5,16 @@
 package state_test
 
 import (
+	"bytes"
+	"io/ioutil"
 	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/FactomProject/factomd/common/messages"
 	"github.com/FactomProject/factomd/common/primitives"
+	. "github.com/FactomProject/factomd/state"
 	. "github.com/FactomProject/factomd/testHelper"
 )
 
@@ -37,3 +42,82 @@ func TestJournal(t *testing.T) {
 		t.Error("No messages returned from journal")
 	}
 }
+
+func TestJournalRotation(t *testing.T) {
+	dir, err := ioutil.TempDir("", "journal")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "journal0.log")
+
+	// Each record is 8 + 14 + 4 + 100 bytes, so 3 fit in a file of 400
+	journal, err := NewJournal(path, 400, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Now()
+	var written []*JournalRecord
+	for i := 0; i < 10; i++ {
+		record := &JournalRecord{
+			Received: start.Add(time.Duration(i) * time.Millisecond),
+			Peer:     "peer",
+			VMIndex:  i % 3,
+			Message:  bytes.Repeat([]byte{byte(i)}, 100),
+		}
+		if err := journal.Write(record); err != nil {
+			t.Fatal(err)
+		}
+		written = append(written, record)
+	}
+	journal.Close()
+
+	files := JournalFiles(path)
+	if len(files) != 3 || files[0] != path+".2" || files[1] != path+".1" || files[2] != path {
+		t.Fatalf("Wrong journal files %v", files)
+	}
+	var read []*JournalRecord
+	for _, file := range files {
+		records, err := ReadJournalFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		read = append(read, records...)
+	}
+	// The oldest file was dropped, the last 7 records are left
+	if len(read) != 7 {
+		t.Fatalf("Read %d records, expected 7", len(read))
+	}
+	for i, record := range read {
+		expected := written[i+3]
+		if !record.Received.Equal(expected.Received) || record.Peer != expected.Peer || record.VMIndex != expected.VMIndex || !bytes.Equal(record.Message, expected.Message) {
+			t.Errorf("Record %d is %+v, expected %+v", i, record, expected)
+		}
+	}
+
+	// A record cut short is the end of the journal
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ioutil.WriteFile(path, data[:len(data)-10], 0600); err != nil {
+		t.Fatal(err)
+	}
+	records, err := ReadJournalFile(path)
+	if err != nil || len(records) != 0 {
+		t.Errorf("Read %d records of a cut journal: %v", len(records), err)
+	}
+
+	// A new journal keeps the one before it
+	journal, err = NewJournal(path, 400, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	journal.Close()
+	if records, _ := ReadJournalFile(path + ".1"); len(records) != 0 {
+		t.Errorf("The journal before has %d records", len(records))
+	}
+	if len(JournalFiles(path)) != 3 {
+		t.Errorf("Wrong journal files %v", JournalFiles(path))
+	}
+}
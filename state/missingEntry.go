@@ -86,6 +86,9 @@ type MissingEntry struct {
 	EBHash    interfaces.IHash
 	EntryHash interfaces.IHash
 	DBHeight  uint32
+
+	// Not marshalled
+	ChainID interfaces.IHash
 }
 
 var _ interfaces.BinaryMarshallable = (*MissingEntry)(nil)
@@ -1007,7 +1007,7 @@ func (p *ProcessList) Process(state *State) (progress bool) {
 				if msg.Process(p.DBHeight, state) { // Try and Process this entry
 
 					if msg.Type() == constants.REVEAL_ENTRY_MSG {
-						delete(p.State.Holding, msg.GetMsgHash().Fixed()) // We successfully executed the message, so take it out of holding if it is there.
+						p.State.DeleteFromHolding(msg.GetMsgHash().Fixed()) // We successfully executed the message, so take it out of holding if it is there.
 						p.State.Commits.Delete(msg.GetMsgHash().Fixed())
 					}
 
@@ -1015,6 +1015,7 @@ func (p *ProcessList) Process(state *State) (progress bool) {
 					vm.heartBeat = 0
 					vm.Height = j + 1 // Don't process it again if the process worked.
 					p.State.LogMessage("process", fmt.Sprintf("done %v/%v/%v", p.DBHeight, i, j), msg)
+					p.State.publishProcessed(p.DBHeight, msg)
 
 					progress = true
 
@@ -1025,7 +1026,7 @@ func (p *ProcessList) Process(state *State) (progress bool) {
 					p.State.Replay.IsTSValidAndUpdateState(constants.INTERNAL_REPLAY, msgHashFixed, msg.GetTimestamp(), now)
 
 					delete(p.State.Acks, msgHashFixed)
-					delete(p.State.Holding, msgHashFixed)
+					p.State.DeleteFromHolding(msgHashFixed)
 
 				} else {
 					p.State.LogMessage("process", fmt.Sprintf("retry %v/%v/%v", p.DBHeight, i, j), msg)
@@ -1128,7 +1129,7 @@ func (p *ProcessList) AddToProcessList(ack *messages.Ack, m interfaces.IMsg) {
 		p.State.LogPrintf("processList", "Drop "+hint)
 		TotalHoldingQueueOutputs.Inc()
 		TotalAcksOutputs.Inc()
-		delete(p.State.Holding, msgHash.Fixed())
+		p.State.DeleteFromHolding(msgHash.Fixed())
 		delete(p.State.Acks, msgHash.Fixed())
 	}
 
@@ -1192,7 +1193,7 @@ func (p *ProcessList) AddToProcessList(ack *messages.Ack, m interfaces.IMsg) {
 	p.State.LogPrintf("executeMsg", "remove from holding M-%v|R-%v", m.GetMsgHash().String()[:6], m.GetRepeatHash().String()[:6])
 	TotalHoldingQueueOutputs.Inc()
 	TotalAcksOutputs.Inc()
-	delete(p.State.Holding, msgHash.Fixed())
+	p.State.DeleteFromHolding(msgHash.Fixed())
 	delete(p.State.Acks, msgHash.Fixed())
 	p.VMs[ack.VMIndex].List[ack.Height] = m
 	p.VMs[ack.VMIndex].ListAck[ack.Height] = ack
@@ -0,0 +1,189 @@
+// Copyright 2017 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package state
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/FactomProject/factomd/common/interfaces"
+	"github.com/FactomProject/factomd/common/primitives"
+	"github.com/FactomProject/factomd/database/databaseOverlay"
+)
+
+// A PRUNED node follows the network like a FULL node and keeps every directory,
+// admin, factoid, entry credit and entry block, but only keeps the content of the
+// entries of the last PruneKeepBlocks blocks and of the chains in PruneKeepChains.
+// Entries are still written when their block is saved, so whatever processes them
+// then (identities, the exchange rate, anchors) finds them, and are deleted once
+// their block falls out of the window.  The chains the node needs to follow the
+// network are always kept.  An entry revealed again in a block still inside the
+// window is kept too, since both blocks share its content.  Receipts still work for
+// pruned entries, since they only need the blocks.  A PruneKeepBlocks of 0 turns
+// pruning off.
+
+// MaxPrunedBlocksPerSave bounds the work done each time a block is saved, so turning
+// on pruning for a large database does not stall the node
+var MaxPrunedBlocksPerSave uint32 = 100
+
+func parsePruneKeepChains(list string) map[[32]byte]bool {
+	chains := make(map[[32]byte]bool)
+	for _, c := range strings.Split(list, ",") {
+		c = strings.TrimSpace(c)
+		if c == "" {
+			continue
+		}
+		h, err := primitives.HexToHash(c)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Ignoring bad chain ID %q in PruneKeepChains: %v\n", c, err)
+			continue
+		}
+		chains[h.Fixed()] = true
+	}
+	return chains
+}
+
+func (s *State) IsPruned() bool {
+	return s.NodeMode == "PRUNED" && s.PruneKeepBlocks > 0
+}
+
+// KeepEntryContent is true if the node keeps the content of the entries of the
+// chain in the block at the given height
+func (s *State) KeepEntryContent(chainID interfaces.IHash, dbheight uint32) bool {
+	if !s.IsPruned() || s.keepChain(chainID) {
+		return true
+	}
+	top := s.GetHighestSavedBlk()
+	if known := s.GetHighestKnownBlock(); known > top {
+		top = known
+	}
+	return dbheight+s.PruneKeepBlocks > top
+}
+
+func (s *State) keepChain(chainID interfaces.IHash) bool {
+	if s.PruneKeepChains[chainID.Fixed()] {
+		return true
+	}
+	switch chainID.String() {
+	case s.FERChainId, databaseOverlay.AnchorBlockID, MAIN_FACTOM_IDENTITY_LIST:
+		return true
+	}
+	// Identity and management chains of the authorities
+	return s.IdentityControl != nil && s.IdentityControl.GetIdentity(chainID) != nil
+}
+
+// IsEntryPruned is true if the entry is in a saved block but its content was pruned
+func (s *State) IsEntryPruned(hash interfaces.IHash) bool {
+	if !s.IsPruned() || s.DB == nil {
+		return false
+	}
+	if entry, err := s.DB.FetchEntry(hash); err != nil || entry != nil {
+		return false
+	}
+	included, err := s.DB.FetchIncludedIn(hash)
+	return err == nil && included != nil
+}
+
+// PruneEntries deletes the content of the entries that fell out of the window now
+// that the block at dbheight is saved
+func (s *State) PruneEntries(dbheight uint32) {
+	if !s.IsPruned() || dbheight < s.PruneKeepBlocks {
+		return
+	}
+	if !s.entryPrunedLoaded {
+		s.EntryDBHeightPruned, _ = s.DB.FetchDatabasePrunedHeight()
+		s.entryPrunedLoaded = true
+	}
+
+	end := dbheight - s.PruneKeepBlocks + 1
+	if s.EntryDBHeightPruned >= end {
+		// Nothing new fell out of the window, eg as PruneKeepBlocks was raised
+		return
+	}
+	if end-s.EntryDBHeightPruned > MaxPrunedBlocksPerSave {
+		end = s.EntryDBHeightPruned + MaxPrunedBlocksPerSave
+	}
+	kept := make(map[[32]byte]map[[32]byte]bool)
+	for s.EntryDBHeightPruned < end {
+		if err := s.pruneBlockEntries(s.EntryDBHeightPruned, kept); err != nil {
+			s.LogPrintf("pruning", "Pruning entries at height %d failed: %v", s.EntryDBHeightPruned, err)
+			break
+		}
+		s.EntryDBHeightPruned++
+	}
+	if err := s.DB.SaveDatabasePrunedHeight(s.EntryDBHeightPruned); err != nil {
+		s.LogPrintf("pruning", "Saving the pruned height failed: %v", err)
+	}
+}
+
+// pruneBlockEntries prunes the entries of the block at dbheight.  kept holds the
+// entries in the window of the chains looked at so far, so each chain is only
+// walked once however many blocks are pruned.
+func (s *State) pruneBlockEntries(dbheight uint32, kept map[[32]byte]map[[32]byte]bool) error {
+	dblock, err := s.DB.FetchDBlockByHeight(dbheight)
+	if err != nil {
+		return err
+	}
+	if dblock == nil {
+		return fmt.Errorf("no directory block")
+	}
+	pruned := 0
+	for _, dbe := range dblock.GetEBlockDBEntries() {
+		if s.keepChain(dbe.GetChainID()) {
+			continue
+		}
+		eblock, err := s.DB.FetchEBlock(dbe.GetKeyMR())
+		if err != nil {
+			return err
+		}
+		if eblock == nil {
+			continue
+		}
+		chainID := dbe.GetChainID().Fixed()
+		if kept[chainID] == nil {
+			kept[chainID], err = s.entriesInWindow(dbe.GetChainID())
+			if err != nil {
+				return err
+			}
+		}
+		for _, hash := range eblock.GetEntryHashes() {
+			if hash.IsMinuteMarker() || kept[chainID][hash.Fixed()] {
+				continue
+			}
+			if err := s.DB.PruneEntry(hash); err != nil {
+				return err
+			}
+			pruned++
+		}
+	}
+	s.LogPrintf("pruning", "Pruned %d entries at height %d", pruned, dbheight)
+	return nil
+}
+
+// entriesInWindow returns the entries of the chain in its blocks still inside the
+// window.  An entry hash covers the chain ID, so only the same chain can reveal it
+// again.
+func (s *State) entriesInWindow(chainID interfaces.IHash) (map[[32]byte]bool, error) {
+	entries := make(map[[32]byte]bool)
+	keyMR, err := s.DB.FetchHeadIndexByChainID(chainID)
+	if err != nil {
+		return nil, err
+	}
+	for keyMR != nil && !keyMR.IsZero() {
+		eblock, err := s.DB.FetchEBlock(keyMR)
+		if err != nil {
+			return nil, err
+		}
+		if eblock == nil || !s.KeepEntryContent(chainID, eblock.GetDatabaseHeight()) {
+			break
+		}
+		for _, hash := range eblock.GetEntryHashes() {
+			entries[hash.Fixed()] = true
+		}
+		keyMR = eblock.GetHeader().GetPrevKeyMR()
+	}
+	return entries, nil
+}
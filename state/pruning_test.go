@@ -0,0 +1,180 @@
+// Copyright 2017 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package state_test
+
+import (
+	"testing"
+
+	"github.com/FactomProject/factomd/common/interfaces"
+	. "github.com/FactomProject/factomd/state"
+	. "github.com/FactomProject/factomd/testHelper"
+)
+
+// entriesAt returns the chain and entries of the first entry block at the height
+func entriesAt(t *testing.T, s *State, height uint32) (interfaces.IHash, []interfaces.IHash) {
+	dblock, err := s.DB.FetchDBlockByHeight(height)
+	if err != nil || dblock == nil {
+		t.Fatalf("no dblock at %d: %v", height, err)
+	}
+	dbe := dblock.GetEBlockDBEntries()[0]
+	eblock, err := s.DB.FetchEBlock(dbe.GetKeyMR())
+	if err != nil || eblock == nil {
+		t.Fatalf("no eblock at %d: %v", height, err)
+	}
+	var hashes []interfaces.IHash
+	for _, h := range eblock.GetEntryHashes() {
+		if !h.IsMinuteMarker() {
+			hashes = append(hashes, h)
+		}
+	}
+	return dbe.GetChainID(), hashes
+}
+
+func TestPruneEntries(t *testing.T) {
+	s := CreateAndPopulateTestState()
+	top := uint32(BlockCount - 1)
+
+	// Not pruned, nothing happens
+	s.PruneEntries(top)
+	chainID, old := entriesAt(t, s, 1)
+	if e, _ := s.DB.FetchEntry(old[0]); e == nil {
+		t.Fatalf("entry pruned on a full node")
+	}
+
+	s.NodeMode = "PRUNED"
+	s.PruneKeepBlocks = 3
+	s.HighestKnown = top
+	if s.KeepEntryContent(chainID, 1) {
+		t.Errorf("keeping entries out of the window")
+	}
+	if !s.KeepEntryContent(chainID, top) {
+		t.Errorf("not keeping entries in the window")
+	}
+
+	s.PruneEntries(top)
+	for height := uint32(1); height <= top; height++ {
+		_, hashes := entriesAt(t, s, height)
+		for _, h := range hashes {
+			e, err := s.DB.FetchEntry(h)
+			if err != nil {
+				t.Fatal(err)
+			}
+			kept := height+s.PruneKeepBlocks > top
+			if kept != (e != nil) {
+				t.Errorf("entry at height %d: expected kept %v", height, kept)
+			}
+			if s.IsEntryPruned(h) == kept {
+				t.Errorf("entry at height %d: expected pruned %v", height, !kept)
+			}
+		}
+	}
+	if s.EntryDBHeightPruned != top-s.PruneKeepBlocks+1 {
+		t.Errorf("pruned height %d", s.EntryDBHeightPruned)
+	}
+	if h, _ := s.DB.FetchDatabasePrunedHeight(); h != s.EntryDBHeightPruned {
+		t.Errorf("saved pruned height %d", h)
+	}
+
+	// The entry blocks stay, so the entries can still be located
+	if included, _ := s.DB.FetchIncludedIn(old[0]); included == nil {
+		t.Errorf("lost the entry block of a pruned entry")
+	}
+}
+
+func TestPruneKeepChains(t *testing.T) {
+	s := CreateAndPopulateTestState()
+	top := uint32(BlockCount - 1)
+	chainID, hashes := entriesAt(t, s, 1)
+
+	s.NodeMode = "PRUNED"
+	s.PruneKeepBlocks = 3
+	s.PruneKeepChains = map[[32]byte]bool{chainID.Fixed(): true}
+	if !s.KeepEntryContent(chainID, 1) {
+		t.Errorf("not keeping an allowed chain")
+	}
+
+	s.PruneEntries(top)
+	if e, _ := s.DB.FetchEntry(hashes[0]); e == nil {
+		t.Errorf("pruned an allowed chain")
+	}
+	if s.IsEntryPruned(hashes[0]) {
+		t.Errorf("allowed entry reported pruned")
+	}
+}
+
+func TestPruneEntryRevealedAgain(t *testing.T) {
+	s := CreateAndPopulateTestState()
+	top := uint32(BlockCount - 1)
+	chainID, old := entriesAt(t, s, 1)
+	_, other := entriesAt(t, s, 2)
+	entry, err := s.DB.FetchEntry(old[0])
+	if err != nil || entry == nil {
+		t.Fatalf("no entry: %v", err)
+	}
+
+	// The entry of height 1 revealed again in a block inside the window
+	head, err := s.DB.FetchEBlockHead(chainID)
+	if err != nil || head == nil {
+		t.Fatalf("no chain head: %v", err)
+	}
+	again, _ := CreateTestEntryBlock(head)
+	again.Header.SetChainID(chainID)
+	again.Header.SetDBHeight(top + 1)
+	again.AddEBEntry(entry)
+	if err := s.DB.ProcessEBlockBatch(again, false); err != nil {
+		t.Fatal(err)
+	}
+
+	s.NodeMode = "PRUNED"
+	s.PruneKeepBlocks = 3
+	s.HighestKnown = top + 1
+	s.PruneEntries(top + 1)
+	if e, _ := s.DB.FetchEntry(old[0]); e == nil {
+		t.Errorf("pruned an entry revealed again inside the window")
+	}
+	if e, _ := s.DB.FetchEntry(other[0]); e != nil {
+		t.Errorf("kept an entry out of the window")
+	}
+}
+
+func TestPruneKeepBlocksZero(t *testing.T) {
+	s := CreateAndPopulateTestState()
+	top := uint32(BlockCount - 1)
+	chainID, hashes := entriesAt(t, s, 1)
+
+	s.NodeMode = "PRUNED"
+	s.PruneKeepBlocks = 0
+	if s.IsPruned() || !s.KeepEntryContent(chainID, 1) {
+		t.Errorf("pruning with PruneKeepBlocks 0")
+	}
+	s.PruneEntries(top)
+	if e, _ := s.DB.FetchEntry(hashes[0]); e == nil {
+		t.Errorf("entry pruned with PruneKeepBlocks 0")
+	}
+}
+
+func TestPruneKeepBlocksRaised(t *testing.T) {
+	s := CreateAndPopulateTestState()
+	top := uint32(BlockCount - 1)
+
+	s.NodeMode = "PRUNED"
+	s.PruneKeepBlocks = 3
+	s.HighestKnown = top
+	s.PruneEntries(top)
+	pruned := s.EntryDBHeightPruned
+	if pruned != top-3+1 {
+		t.Fatalf("pruned up to %d, expected %d", pruned, top-3+1)
+	}
+
+	// The window now starts below the pruned height, nothing is left to prune
+	s.PruneKeepBlocks = 8
+	s.PruneEntries(top)
+	if s.EntryDBHeightPruned != pruned {
+		t.Errorf("pruned height moved from %d to %d after raising PruneKeepBlocks", pruned, s.EntryDBHeightPruned)
+	}
+	if saved, _ := s.DB.FetchDatabasePrunedHeight(); saved != pruned {
+		t.Errorf("saved pruned height is %d, expected %d", saved, pruned)
+	}
+}
@@ -5,10 +5,11 @@
 package state
 
 import (
-	"bufio"
 	"bytes"
+	"container/list"
 	"crypto/rand"
 	"encoding/binary"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -28,8 +29,10 @@ import (
 	"github.com/FactomProject/factomd/database/boltdb"
 	"github.com/FactomProject/factomd/database/databaseOverlay"
 	"github.com/FactomProject/factomd/database/leveldb"
+	"github.com/FactomProject/factomd/database/lsmdb"
 	"github.com/FactomProject/factomd/database/mapdb"
 	"github.com/FactomProject/factomd/p2p"
+	"github.com/FactomProject/factomd/subscriptions"
 	"github.com/FactomProject/factomd/util"
 	"github.com/FactomProject/factomd/util/atomic"
 	"github.com/FactomProject/factomd/wsapi"
@@ -64,6 +67,8 @@ type State struct {
 	LogLevel        string
 	ConsoleLogLevel string
 	NodeMode        string
+	PruneKeepBlocks uint32            // A PRUNED node keeps the entries of this many blocks
+	PruneKeepChains map[[32]byte]bool // and of these chains (see pruning.go)
 	DBType          string
 	CheckChainHeads struct {
 		CheckChainHeads bool
@@ -94,6 +99,9 @@ type State struct {
 	ControlPanelChannel     chan DisplayState
 	ControlPanelDataRequest bool // If true, update Display state
 
+	// Feeds block, entry, ack and minute events to API subscribers
+	Subscriptions *subscriptions.Hub
+
 	// Network Configuration
 	Network                 string
 	MainNetworkPort         string
@@ -109,6 +117,8 @@ type State struct {
 	CustomNetworkPort       string
 	CustomSeedURL           string
 	CustomSpecialPeers      string
+	PeerBanDuration         string // How long misbehaving peers are banned for, see the p2p package
+	PeerPenalties           string // Quality score penalties for misbehaving peers
 	CustomNetworkID         []byte
 	CustomBootstrapIdentity string
 	CustomBootstrapKey      string
@@ -136,6 +146,8 @@ type State struct {
 	HoldingMutex sync.RWMutex
 	HoldingLast  int64
 	HoldingMap   map[[32]byte]interfaces.IMsg
+	// Why each message in the snapshot is waiting, for the debug API
+	HoldingExplained []HeldMessage
 
 	// Elections are managed through the Elections Structure
 	EFactory  interfaces.IElectionsFactory
@@ -177,9 +189,12 @@ type State struct {
 	ackQueue               chan interfaces.IMsg
 	msgQueue               chan interfaces.IMsg
 
-	ShutdownChan chan int // For gracefully halting Factom
-	JournalFile  string
-	Journaling   bool
+	ShutdownChan    chan int // For gracefully halting Factom
+	JournalFile     string
+	Journaling      bool
+	JournalMaxSize  int64 // Bytes before the journal is rotated, 0 for no limit
+	JournalMaxFiles int   // Journal files kept, with the current one
+	journal         *Journal
 
 	serverPrivKey         *primitives.PrivateKey
 	serverPubKey          *primitives.PublicKey
@@ -187,9 +202,25 @@ type State struct {
 	serverPendingPubKeys  []*primitives.PublicKey
 
 	// RPC connection config
-	RpcUser     string
-	RpcPass     string
-	RpcAuthHash []byte
+	RpcUser       string
+	RpcPass       string
+	RpcAuthHash   []byte
+	RpcTokensFile string // JSON file of the named API tokens and their roles
+
+	// API rate limits, 0 is no limit
+	ApiRateLimitPerIP     int
+	ApiRateLimitPerToken  int
+	ApiExpensiveRateLimit int
+	ApiMaxBatchSize       int
+
+	// Holding limits, 0 is the default (see holding.go)
+	HoldingMaxPerType   int
+	HoldingMaxPerPeer   int
+	HoldingMaxAge       time.Duration
+	HoldingMaxOrphanAge time.Duration
+	holdingMeta         map[[32]byte]*heldMessage
+	holdingByType       map[byte]*list.List   // Held messages of each type, oldest first
+	holdingByPeer       map[string]*list.List // Held messages from each peer, oldest first
 
 	FactomdTLSEnable   bool
 	factomdTLSKeyFile  string
@@ -262,7 +293,7 @@ type State struct {
 	// ====
 	// For Follower
 	ResendHolding interfaces.Timestamp         // Timestamp to gate resending holding to neighbors
-	Holding       map[[32]byte]interfaces.IMsg // Hold Messages
+	Holding       map[[32]byte]interfaces.IMsg // Hold Messages, add and delete with AddToHolding and DeleteFromHolding
 	XReview       []interfaces.IMsg            // After the EOM, we must review the messages in Holding
 	Acks          map[[32]byte]interfaces.IMsg // Hold Acknowledgements
 	Commits       *SafeMsgMap                  //  map[[32]byte]interfaces.IMsg // Commit Messages
@@ -338,6 +369,9 @@ type State struct {
 	EntryDBHeightComplete uint32
 	// DBlock Height at which we have started asking for or have all entries
 	EntryDBHeightProcessing uint32
+	// DBlock Height below which a pruned node deleted the entries it does not keep
+	EntryDBHeightPruned uint32
+	entryPrunedLoaded   bool
 	// Height in the Directory Block where we have
 	// Entries we don't have that we are asking our neighbors for
 	MissingEntries chan *MissingEntry
@@ -377,6 +411,7 @@ type State struct {
 	useTorrents             bool
 	torrentUploader         bool
 	Uploader                *UploadController // Controls the uploads of torrents. Prevents backups
+	Indexer                 *IndexController  // Feeds the saved blocks to an indexer plugin, if there is one
 	DBStateManager          interfaces.IManagerController
 	HighestCompletedTorrent uint32
 	FastBoot                bool
@@ -447,6 +482,8 @@ func (s *State) Clone(cloneNumber int) interfaces.IState {
 	newState.LdbPath = s.LdbPath + "/Sim" + number
 	newState.JournalFile = s.LogPath + "/journal" + number + ".log"
 	newState.Journaling = s.Journaling
+	newState.JournalMaxSize = s.JournalMaxSize
+	newState.JournalMaxFiles = s.JournalMaxFiles
 	newState.BoltDBPath = s.BoltDBPath + "/Sim" + number
 	newState.LogLevel = s.LogLevel
 	newState.ConsoleLogLevel = s.ConsoleLogLevel
@@ -459,6 +496,8 @@ func (s *State) Clone(cloneNumber int) interfaces.IState {
 	newState.Network = s.Network
 	newState.MainNetworkPort = s.MainNetworkPort
 	newState.PeersFile = s.PeersFile
+	newState.PeerBanDuration = s.PeerBanDuration
+	newState.PeerPenalties = s.PeerPenalties
 	newState.MainSeedURL = s.MainSeedURL
 	newState.MainSpecialPeers = s.MainSpecialPeers
 	newState.TestNetworkPort = s.TestNetworkPort
@@ -512,6 +551,15 @@ func (s *State) Clone(cloneNumber int) interfaces.IState {
 	newState.RpcUser = s.RpcUser
 	newState.RpcPass = s.RpcPass
 	newState.RpcAuthHash = s.RpcAuthHash
+	newState.RpcTokensFile = s.RpcTokensFile
+	newState.ApiRateLimitPerIP = s.ApiRateLimitPerIP
+	newState.ApiRateLimitPerToken = s.ApiRateLimitPerToken
+	newState.ApiExpensiveRateLimit = s.ApiExpensiveRateLimit
+	newState.ApiMaxBatchSize = s.ApiMaxBatchSize
+	newState.HoldingMaxPerType = s.HoldingMaxPerType
+	newState.HoldingMaxPerPeer = s.HoldingMaxPerPeer
+	newState.HoldingMaxAge = s.HoldingMaxAge
+	newState.HoldingMaxOrphanAge = s.HoldingMaxOrphanAge
 
 	newState.FactomdTLSEnable = s.FactomdTLSEnable
 	newState.factomdTLSKeyFile = s.factomdTLSKeyFile
@@ -519,7 +567,7 @@ func (s *State) Clone(cloneNumber int) interfaces.IState {
 	newState.FactomdLocations = s.FactomdLocations
 
 	switch newState.DBType {
-	case "LDB":
+	case "LDB", "LSM":
 		newState.StateSaverStruct.FastBoot = s.StateSaverStruct.FastBoot
 		newState.StateSaverStruct.FastBootLocation = newState.LdbPath
 		break
@@ -618,6 +666,16 @@ func (s *State) GetRpcAuthHash() []byte {
 	return s.RpcAuthHash
 }
 
+func (s *State) GetRpcTokensFile() string {
+	return s.RpcTokensFile
+}
+
+// GetApiLimits returns the requests per second per IP address and per token,
+// the expensive budget per second and the max batch size of the API
+func (s *State) GetApiLimits() (int, int, int, int) {
+	return s.ApiRateLimitPerIP, s.ApiRateLimitPerToken, s.ApiExpensiveRateLimit, s.ApiMaxBatchSize
+}
+
 func (s *State) GetTlsInfo() (bool, string, string) {
 	return s.FactomdTLSEnable, s.factomdTLSKeyFile, s.factomdTLSCertFile
 }
@@ -702,6 +760,8 @@ func (s *State) LoadConfig(filename string, networkFlag string) {
 		s.LogLevel = cfg.Log.LogLevel
 		s.ConsoleLogLevel = cfg.Log.ConsoleLogLevel
 		s.NodeMode = cfg.App.NodeMode
+		s.PruneKeepBlocks = uint32(cfg.App.PruneKeepBlocks)
+		s.PruneKeepChains = parsePruneKeepChains(cfg.App.PruneKeepChains)
 		s.DBType = cfg.App.DBType
 		s.ExportData = cfg.App.ExportData // bool
 		s.ExportDataSubpath = cfg.App.ExportDataSubpath
@@ -721,12 +781,23 @@ func (s *State) LoadConfig(filename string, networkFlag string) {
 		s.CustomNetworkPort = cfg.App.CustomNetworkPort
 		s.CustomSeedURL = cfg.App.CustomSeedURL
 		s.CustomSpecialPeers = cfg.App.CustomSpecialPeers
+		s.PeerBanDuration = cfg.App.PeerBanDuration
+		s.PeerPenalties = cfg.App.PeerPenalties
 		s.FactoshisPerEC = cfg.App.ExchangeRate
 		s.DirectoryBlockInSeconds = cfg.App.DirectoryBlockInSeconds
 		s.PortNumber = cfg.App.PortNumber
 		s.ControlPanelPort = cfg.App.ControlPanelPort
 		s.RpcUser = cfg.App.FactomdRpcUser
 		s.RpcPass = cfg.App.FactomdRpcPass
+		s.RpcTokensFile = cfg.App.FactomdRpcTokensFile
+		s.ApiRateLimitPerIP = cfg.App.ApiRateLimitPerIP
+		s.ApiRateLimitPerToken = cfg.App.ApiRateLimitPerToken
+		s.ApiExpensiveRateLimit = cfg.App.ApiExpensiveRateLimit
+		s.ApiMaxBatchSize = cfg.App.ApiMaxBatchSize
+		s.HoldingMaxPerType = cfg.App.HoldingMaxPerType
+		s.HoldingMaxPerPeer = cfg.App.HoldingMaxPerPeer
+		s.HoldingMaxAge = time.Duration(cfg.App.HoldingMaxAge) * time.Second
+		s.HoldingMaxOrphanAge = time.Duration(cfg.App.HoldingMaxOrphanAge) * time.Second
 		s.StateSaverStruct.FastBoot = cfg.App.FastBoot
 		s.StateSaverStruct.FastBootLocation = cfg.App.FastBootLocation
 		s.FastBoot = cfg.App.FastBoot
@@ -769,12 +840,14 @@ func (s *State) LoadConfig(filename string, networkFlag string) {
 		s.LogLevel = "none"
 		s.ConsoleLogLevel = "standard"
 		s.NodeMode = "SERVER"
+		s.PruneKeepBlocks = 1000
 		s.DBType = "Map"
 		s.ExportData = false
 		s.ExportDataSubpath = "data/export"
 		s.Network = "TEST"
 		s.MainNetworkPort = "8108"
 		s.PeersFile = "peers.json"
+		s.PeerBanDuration = "0s"
 		s.MainSeedURL = "https://raw.githubusercontent.com/FactomProject/factomproject.github.io/master/seed/mainseed.txt"
 		s.MainSpecialPeers = ""
 		s.TestNetworkPort = "8109"
@@ -853,6 +926,7 @@ func (s *State) Init() {
 	}
 
 	s.ControlPanelChannel = make(chan DisplayState, 20)
+	s.Subscriptions = subscriptions.NewHub()
 	s.tickerQueue = make(chan int, 100)                        //ticks from a clock
 	s.timerMsgQueue = make(chan interfaces.IMsg, 100)          //incoming eom notifications, used by leaders
 	s.TimeOffset = new(primitives.Timestamp)                   //interfaces.Timestamp(int64(rand.Int63() % int64(time.Microsecond*10)))
@@ -871,12 +945,12 @@ func (s *State) Init() {
 	s.WriteEntry = make(chan interfaces.IEBEntry, 3000)            //Entries to be written to the database
 
 	if s.Journaling {
-		f, err := os.Create(s.JournalFile)
+		journal, err := NewJournal(s.JournalFile, s.JournalMaxSize, s.JournalMaxFiles)
 		if err != nil {
 			fmt.Println("Could not create the journal file:", s.JournalFile)
 			s.JournalFile = ""
 		}
-		f.Close()
+		s.journal = journal
 	}
 	// Set up struct to stop replay attacks
 	s.Replay = new(Replay)
@@ -920,8 +994,18 @@ func (s *State) Init() {
 		s.Println("\n   +-------------------------+")
 		s.Println("   |       Leader Node       |")
 		s.Print("   +-------------------------+\n\n")
+	case "PRUNED":
+		s.Leader = false
+		s.Println("\n   +---------------------------+")
+		s.Println("   +------- Pruned Node -------+")
+		s.Print("   +---------------------------+\n\n")
+		if s.PruneKeepBlocks == 0 {
+			s.Print("   PruneKeepBlocks is 0, so no entries are pruned\n\n")
+		} else {
+			s.Print(fmt.Sprintf("   Keeping the entries of the last %d blocks and of %d chains\n\n", s.PruneKeepBlocks, len(s.PruneKeepChains)))
+		}
 	default:
-		panic("Bad Node Mode (must be FULL or SERVER)")
+		panic("Bad Node Mode (must be FULL, SERVER or PRUNED)")
 	}
 
 	//Database
@@ -930,6 +1014,10 @@ func (s *State) Init() {
 		if err := s.InitLevelDB(); err != nil {
 			panic(fmt.Sprintf("Error initializing the database: %v", err))
 		}
+	case "LSM":
+		if err := s.InitLSMDB(); err != nil {
+			panic(fmt.Sprintf("Error initializing the database: %v", err))
+		}
 	case "Bolt":
 		if err := s.InitBoltDB(); err != nil {
 			panic(fmt.Sprintf("Error initializing the database: %v", err))
@@ -1376,10 +1464,12 @@ func (s *State) fillHoldingMap() {
 		for i, msg := range s.Holding {
 			localMap[i] = msg
 		}
+		explained := s.explainHolding()
 		s.HoldingLast = time.Now().Unix()
 		s.HoldingMutex.Lock()
 		defer s.HoldingMutex.Unlock()
 		s.HoldingMap = localMap
+		s.HoldingExplained = explained
 
 	}
 }
@@ -1746,52 +1836,69 @@ func (s *State) DatabaseContains(hash interfaces.IHash) bool {
 	return false
 }
 
-// JournalMessage writes the message to the message journal for debugging
+// JournalMessage writes the message to the message journal for debugging, with
+// when it was received, the peer it came from and its VM index, so it can be
+// replayed (see engine.ReplayJournal)
 func (s *State) JournalMessage(msg interfaces.IMsg) {
-	type journalentry struct {
-		Type    byte
-		Message interfaces.IMsg
+	if !s.Journaling || len(s.JournalFile) == 0 {
+		return
 	}
-
-	if s.Journaling && len(s.JournalFile) != 0 {
-		f, err := os.OpenFile(s.JournalFile, os.O_APPEND+os.O_WRONLY, 0666)
+	if s.journal == nil || s.journal.Path() != s.JournalFile {
+		if s.journal != nil {
+			s.journal.Close()
+		}
+		journal, err := NewJournal(s.JournalFile, s.JournalMaxSize, s.JournalMaxFiles)
 		if err != nil {
 			s.JournalFile = ""
 			return
 		}
-		defer f.Close()
-
-		e := new(journalentry)
-		e.Type = msg.Type()
-		e.Message = msg
+		s.journal = journal
+	}
 
-		p, err := json.Marshal(e)
-		if err != nil {
-			return
-		}
-		fmt.Fprintln(f, string(p))
+	data, err := msg.MarshalBinary()
+	if err != nil {
+		return
+	}
+	record := &JournalRecord{
+		Received: time.Now(),
+		Peer:     msg.GetNetworkOrigin(),
+		VMIndex:  msg.GetVMIndex(),
+		Message:  data,
+	}
+	if err := s.journal.Write(record); err != nil {
+		fmt.Println("Could not write to the journal file:", s.JournalFile, err)
 	}
 }
 
-// GetJournalMessages gets all messages from the message journal
+// GetJournalMessages gets all messages from the current message journal file,
+// as JSON with the message in hex
 func (s *State) GetJournalMessages() [][]byte {
+	type journalentry struct {
+		Type     byte
+		Received time.Time
+		Peer     string
+		VMIndex  int
+		Message  string
+	}
+
 	ret := make([][]byte, 0)
 	if !s.Journaling || len(s.JournalFile) == 0 {
 		return nil
 	}
 
-	f, err := os.Open(s.JournalFile)
-	if err != nil {
-		s.JournalFile = ""
+	records, err := ReadJournalFile(s.JournalFile)
+	if err != nil && records == nil {
 		return nil
 	}
-	defer f.Close()
-
-	r := bufio.NewReader(f)
-	for {
-		p, err := r.ReadBytes('\n')
+	for _, record := range records {
+		e := journalentry{Received: record.Received, Peer: record.Peer, VMIndex: record.VMIndex}
+		if len(record.Message) > 0 {
+			e.Type = record.Message[0]
+		}
+		e.Message = hex.EncodeToString(record.Message)
+		p, err := json.Marshal(e)
 		if err != nil {
-			break
+			continue
 		}
 		ret = append(ret, p)
 	}
@@ -2315,6 +2422,25 @@ func (s *State) InitLevelDB() error {
 	return nil
 }
 
+func (s *State) InitLSMDB() error {
+	if s.DB != nil {
+		return nil
+	}
+
+	path := s.LdbPath + "/" + s.Network + "/" + "factoid_lsm.db"
+
+	s.Println("Database:", path)
+	fmt.Fprint(os.Stderr, "Database:", path)
+
+	dbase, err := lsmdb.NewLSMDB(path, true)
+	if err != nil {
+		return err
+	}
+
+	s.DB = databaseOverlay.NewOverlay(dbase)
+	return nil
+}
+
 func (s *State) InitBoltDB() error {
 	if s.DB != nil {
 		return nil
@@ -161,11 +161,11 @@ func (s *State) executeMsg(vm *VM, msg interfaces.IMsg) (ret bool) {
 		switch msg.Type() {
 		case constants.REVEAL_ENTRY_MSG, constants.COMMIT_ENTRY_MSG, constants.COMMIT_CHAIN_MSG:
 			if !s.NoEntryYet(msg.GetHash(), nil) {
-				delete(s.Holding, msg.GetHash().Fixed())
+				s.DeleteFromHolding(msg.GetHash().Fixed())
 				s.Commits.Delete(msg.GetHash().Fixed())
 				return true
 			}
-			s.Holding[msg.GetMsgHash().Fixed()] = msg
+			s.AddToHolding(msg.GetMsgHash().Fixed(), msg)
 		}
 
 		var vml int
@@ -206,7 +206,7 @@ func (s *State) executeMsg(vm *VM, msg interfaces.IMsg) (ret bool) {
 		if _, valid := s.Replay.Valid(constants.INTERNAL_REPLAY, msg.GetRepeatHash().Fixed(), msg.GetTimestamp(), s.GetTimestamp()); valid {
 			TotalHoldingQueueInputs.Inc()
 			TotalHoldingQueueRecycles.Inc()
-			s.Holding[msg.GetMsgHash().Fixed()] = msg
+			s.AddToHolding(msg.GetMsgHash().Fixed(), msg)
 		} else {
 			s.LogMessage("executeMsg", "drop, IReplay", msg)
 		}
@@ -301,7 +301,7 @@ ackLoop:
 				// toss the ack into holding and we will try again in a bit...
 				TotalHoldingQueueInputs.Inc()
 				TotalHoldingQueueRecycles.Inc()
-				s.Holding[ack.GetMsgHash().Fixed()] = ack
+				s.AddToHolding(ack.GetMsgHash().Fixed(), ack)
 				continue
 			}
 
@@ -419,6 +419,7 @@ func (s *State) ReviewHolding() {
 
 	s.Commits.Cleanup(s)
 	s.DB.Trim()
+	s.expireHolding()
 
 	// Set the resend time at the END of the function. This prevents the time it takes to execute this function
 	// from reducing the time we allow before another review
@@ -447,7 +448,7 @@ func (s *State) ReviewHolding() {
 			s.LogMessage("executeMsg", "expire from holding", v)
 			s.ExpireCnt++
 			TotalHoldingQueueOutputs.Inc()
-			delete(s.Holding, k)
+			s.DeleteFromHolding(k)
 			continue
 		}
 
@@ -455,7 +456,7 @@ func (s *State) ReviewHolding() {
 		case -1:
 			s.LogMessage("executeMsg", "invalid from holding", v)
 			TotalHoldingQueueOutputs.Inc()
-			delete(s.Holding, k)
+			s.DeleteFromHolding(k)
 			continue
 		case 0:
 			continue
@@ -471,27 +472,27 @@ func (s *State) ReviewHolding() {
 
 		if int(highest)-int(saved) > 1000 {
 			TotalHoldingQueueOutputs.Inc()
-			delete(s.Holding, k)
+			s.DeleteFromHolding(k)
 		}
 
 		eom, ok := v.(*messages.EOM)
 		if ok && ((eom.DBHeight <= saved && saved > 0) || int(eom.Minute) < s.CurrentMinute) {
 			TotalHoldingQueueOutputs.Inc()
-			delete(s.Holding, k)
+			s.DeleteFromHolding(k)
 			continue
 		}
 
 		dbsmsg, ok := v.(*messages.DBStateMsg)
 		if ok && (dbsmsg.DirectoryBlock.GetHeader().GetDBHeight() < saved-1 && saved > 0) {
 			TotalHoldingQueueOutputs.Inc()
-			delete(s.Holding, k)
+			s.DeleteFromHolding(k)
 			continue
 		}
 
 		dbsigmsg, ok := v.(*messages.DirectoryBlockSignature)
 		if ok && ((dbsigmsg.DBHeight <= saved && saved > 0) || (dbsigmsg.DBHeight < highest-3 && highest > 2)) {
 			TotalHoldingQueueOutputs.Inc()
-			delete(s.Holding, k)
+			s.DeleteFromHolding(k)
 			continue
 		}
 
@@ -499,7 +500,7 @@ func (s *State) ReviewHolding() {
 		ok2 := s.FReplay.IsHashUnique(constants.BLOCK_REPLAY, v.GetRepeatHash().Fixed())
 		if !ok || !ok2 {
 			TotalHoldingQueueOutputs.Inc()
-			delete(s.Holding, k)
+			s.DeleteFromHolding(k)
 			continue
 		}
 
@@ -510,7 +511,7 @@ func (s *State) ReviewHolding() {
 			x := s.NoEntryYet(ce.CommitEntry.EntryHash, ce.CommitEntry.GetTimestamp())
 			if !x {
 				TotalHoldingQueueOutputs.Inc()
-				delete(s.Holding, k) // Drop commits with the same entry hash from holding because they are blocked by a previous entry
+				s.DeleteFromHolding(k) // Drop commits with the same entry hash from holding because they are blocked by a previous entry
 				continue
 			}
 		}
@@ -522,7 +523,7 @@ func (s *State) ReviewHolding() {
 			x := s.NoEntryYet(cc.CommitChain.EntryHash, cc.CommitChain.GetTimestamp())
 			if !x {
 				TotalHoldingQueueOutputs.Inc()
-				delete(s.Holding, k) // Drop commits with the same entry hash from holding because they are blocked by a previous entry
+				s.DeleteFromHolding(k) // Drop commits with the same entry hash from holding because they are blocked by a previous entry
 				continue
 			}
 		}
@@ -530,7 +531,7 @@ func (s *State) ReviewHolding() {
 		// If a Reveal Entry has a commit available, then process the Reveal Entry and send it out.
 		if re, ok := v.(*messages.RevealEntryMsg); ok {
 			if !s.NoEntryYet(re.GetHash(), s.GetLeaderTimestamp()) {
-				delete(s.Holding, re.GetHash().Fixed())
+				s.DeleteFromHolding(re.GetHash().Fixed())
 				s.Commits.Delete(re.GetHash().Fixed())
 				continue
 			}
@@ -627,7 +628,7 @@ func (s *State) FollowerExecuteMsg(m interfaces.IMsg) {
 	// add it to the holding queue in case AddToProcessList may remove it
 	TotalHoldingQueueInputs.Inc()
 
-	s.Holding[m.GetMsgHash().Fixed()] = m
+	s.AddToHolding(m.GetMsgHash().Fixed(), m)
 	ack, _ := s.Acks[m.GetMsgHash().Fixed()].(*messages.Ack)
 
 	if ack != nil {
@@ -664,7 +665,7 @@ func (s *State) FollowerExecuteEOM(m interfaces.IMsg) {
 	FollowerEOMExecutions.Inc()
 	// add it to the holding queue in case AddToProcessList may remove it
 	TotalHoldingQueueInputs.Inc()
-	s.Holding[m.GetMsgHash().Fixed()] = m // FollowerExecuteEOM
+	s.AddToHolding(m.GetMsgHash().Fixed(), m) // FollowerExecuteEOM
 
 	ack, _ := s.Acks[m.GetMsgHash().Fixed()].(*messages.Ack)
 	if ack != nil {
@@ -1130,7 +1131,7 @@ func (s *State) FollowerExecuteRevealEntry(m interfaces.IMsg) {
 	FollowerExecutions.Inc()
 	TotalHoldingQueueInputs.Inc()
 
-	s.Holding[m.GetMsgHash().Fixed()] = m // hold in  FollowerExecuteRevealEntry
+	s.AddToHolding(m.GetMsgHash().Fixed(), m) // hold in  FollowerExecuteRevealEntry
 
 	ack, _ := s.Acks[m.GetMsgHash().Fixed()].(*messages.Ack)
 
@@ -1175,7 +1176,7 @@ func (s *State) LeaderExecute(m interfaces.IMsg) {
 	_, ok := s.Replay.Valid(constants.INTERNAL_REPLAY, m.GetRepeatHash().Fixed(), m.GetTimestamp(), s.GetTimestamp())
 	if !ok {
 		TotalHoldingQueueOutputs.Inc()
-		delete(s.Holding, m.GetMsgHash().Fixed())
+		s.DeleteFromHolding(m.GetMsgHash().Fixed())
 		if s.DebugExec() {
 			s.LogMessage("executeMsg", "Drop replay", m)
 		}
@@ -1262,7 +1263,7 @@ func (s *State) LeaderExecuteEOM(m interfaces.IMsg) {
 	s.FollowerExecuteEOM(m)
 	s.UpdateState()
 	delete(s.Acks, ack.GetHash().Fixed())
-	delete(s.Holding, m.GetMsgHash().Fixed())
+	s.DeleteFromHolding(m.GetMsgHash().Fixed())
 }
 
 func (s *State) LeaderExecuteDBSig(m interfaces.IMsg) {
@@ -1300,7 +1301,7 @@ func (s *State) LeaderExecuteDBSig(m interfaces.IMsg) {
 	if !ok {
 		TotalHoldingQueueOutputs.Inc()
 		HoldingQueueDBSigOutputs.Inc()
-		delete(s.Holding, m.GetMsgHash().Fixed())
+		s.DeleteFromHolding(m.GetMsgHash().Fixed())
 		s.LogMessage("executeMsg", "drop INTERNAL_REPLAY", m)
 		return
 	}
@@ -1791,6 +1792,7 @@ func (s *State) ProcessEOM(dbheight uint32, msg interfaces.IMsg) bool {
 
 		s.setCurrentMinute(s.CurrentMinute + 1)
 		s.CurrentMinuteStartTime = time.Now().UnixNano()
+		s.publishMinute(e.DBHeight, s.CurrentMinute)
 		// If an election took place, our lists will be unsorted. Fix that
 		pl.SortAuditServers()
 		pl.SortFedServers()
@@ -2292,6 +2294,18 @@ func (s *State) PutCommit(hash interfaces.IHash, msg interfaces.IMsg) {
 	}
 }
 
+// PendingCommit returns the commit waiting for the reveal of the entry, when the
+// commit times out of the replay window, and whether the entry was revealed already
+func (s *State) PendingCommit(hash interfaces.IHash) (commit interfaces.IMsg, expires interfaces.Timestamp, revealed bool) {
+	commit = s.NextCommit(hash)
+	revealed = !s.NoEntryYet(hash, s.GetTimestamp())
+	if commit == nil {
+		return nil, nil, revealed
+	}
+	expires = primitives.NewTimestampFromMilliseconds(uint64(commit.GetTimestamp().GetTimeMilli() + Range*60*1000))
+	return commit, expires, revealed
+}
+
 func (s *State) GetHighestAck() uint32 {
 	return s.HighestAck
 }
@@ -0,0 +1,73 @@
+// Copyright 2017 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package state
+
+import (
+	"github.com/FactomProject/factomd/common/constants"
+	"github.com/FactomProject/factomd/common/interfaces"
+	"github.com/FactomProject/factomd/common/messages"
+	"github.com/FactomProject/factomd/subscriptions"
+)
+
+// Feeds the subscription hub (see the subscriptions package).  Events are only built
+// if someone subscribed to the topic, so none of this costs anything on nodes
+// without subscribers.
+
+func (s *State) GetSubscriptions() interfaces.ISubscriptionHub {
+	return s.Subscriptions
+}
+
+// publishProcessed is called when the process list processed a message, i.e. it
+// was acknowledged by the leaders
+func (s *State) publishProcessed(dbheight uint32, msg interfaces.IMsg) {
+	hub := s.Subscriptions
+	acked := constants.AckStatusString(constants.AckStatusACK)
+
+	switch m := msg.(type) {
+	case *messages.CommitChainMsg:
+		if hub.HasSubscribers(subscriptions.TopicStatus) {
+			hub.Publish(subscriptions.NewStatusEvent("commit", m.CommitChain.EntryHash, m.CommitChain.GetSigHash(), nil, dbheight, acked))
+		}
+	case *messages.CommitEntryMsg:
+		if hub.HasSubscribers(subscriptions.TopicStatus) {
+			hub.Publish(subscriptions.NewStatusEvent("commit", m.CommitEntry.EntryHash, m.CommitEntry.GetSigHash(), nil, dbheight, acked))
+		}
+	case *messages.RevealEntryMsg:
+		if hub.HasSubscribers(subscriptions.TopicStatus) {
+			hub.Publish(subscriptions.NewStatusEvent("reveal", m.Entry.GetHash(), nil, m.Entry.GetChainID(), dbheight, acked))
+		}
+		if hub.HasSubscribers(subscriptions.TopicEntry) {
+			hub.Publish(subscriptions.NewEntryEvent(m.Entry.GetChainID(), m.Entry.GetHash(), dbheight, acked))
+		}
+	case *messages.FactoidTransaction:
+		if hub.HasSubscribers(subscriptions.TopicFactoid) {
+			hub.Publish(subscriptions.NewFactoidEvent(m.Transaction, dbheight, acked))
+		}
+	}
+}
+
+// publishMinute is called when the node moves on to the next minute
+func (s *State) publishMinute(dbheight uint32, minute int) {
+	hub := s.Subscriptions
+	if !hub.HasSubscribers(subscriptions.TopicMinute) {
+		return
+	}
+	hub.Publish(&subscriptions.Event{
+		Topic: subscriptions.TopicMinute,
+		Data:  &subscriptions.MinuteEvent{Height: dbheight, Minute: minute},
+	})
+}
+
+// publishSaved is called once a DBState is written to the database
+func (s *State) publishSaved(d *DBState) {
+	hub := s.Subscriptions
+	if hub.Subscribers() == 0 {
+		return
+	}
+	// The entry blocks of the DBState are gone once saved, so they are read back
+	for _, e := range subscriptions.BlockEvents(s.DB, d.DirectoryBlock, d.FactoidBlock, d.EntryCreditBlock, hub.HasSubscribers) {
+		hub.Publish(e)
+	}
+}
@@ -0,0 +1,151 @@
+// Copyright 2017 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package subscriptions
+
+import (
+	"fmt"
+
+	"github.com/FactomProject/factomd/common/constants"
+	"github.com/FactomProject/factomd/common/entryCreditBlock"
+	"github.com/FactomProject/factomd/common/interfaces"
+	"github.com/FactomProject/factomd/common/primitives"
+)
+
+// NewStatusEvent is the event of a commit or reveal ("commit" or "reveal") whose status changed
+func NewStatusEvent(kind string, entryHash, txid, chainID interfaces.IHash, height uint32, status string) *Event {
+	e := &StatusEvent{
+		Type:      kind,
+		EntryHash: entryHash.String(),
+		Height:    height,
+		Status:    status,
+	}
+	keys := []string{e.EntryHash}
+	if txid != nil {
+		e.TxID = txid.String()
+		keys = append(keys, e.TxID)
+	}
+	if chainID != nil {
+		e.ChainID = chainID.String()
+		keys = append(keys, e.ChainID)
+	}
+	return &Event{Topic: TopicStatus, Keys: keys, Data: e}
+}
+
+func NewEntryEvent(chainID, entryHash interfaces.IHash, height uint32, status string) *Event {
+	e := &EntryEvent{
+		ChainID:   chainID.String(),
+		EntryHash: entryHash.String(),
+		Height:    height,
+		Status:    status,
+	}
+	return &Event{Topic: TopicEntry, Keys: []string{e.ChainID}, Data: e}
+}
+
+func NewFactoidEvent(tx interfaces.ITransaction, height uint32, status string) *Event {
+	e := &FactoidEvent{
+		TxID:      tx.GetSigHash().String(),
+		Addresses: []string{},
+		Height:    height,
+		Status:    status,
+	}
+	for _, adrs := range [][]interfaces.ITransAddress{tx.GetInputs(), tx.GetOutputs()} {
+		for _, adr := range adrs {
+			e.Addresses = append(e.Addresses, primitives.ConvertFctAddressToUserStr(adr.GetAddress()))
+		}
+	}
+	for _, adr := range tx.GetECOutputs() {
+		e.Addresses = append(e.Addresses, primitives.ConvertECAddressToUserStr(adr.GetAddress()))
+	}
+	keys := append([]string{e.TxID}, e.Addresses...)
+	return &Event{Topic: TopicFactoid, Keys: keys, Data: e}
+}
+
+// BlockEvents builds the events of a saved directory block for the topics wanted.
+// The entry blocks are read back from the database.
+func BlockEvents(db interfaces.DBOverlaySimple, dblock interfaces.IDirectoryBlock, fblock interfaces.IFBlock,
+	ecblock interfaces.IEntryCreditBlock, wanted func(topic string) bool) []*Event {
+	var events []*Event
+	height := dblock.GetDatabaseHeight()
+	confirmed := constants.AckStatusString(constants.AckStatusDBlockConfirmed)
+
+	if wanted(TopicDBlock) {
+		events = append(events, &Event{
+			Topic: TopicDBlock,
+			Data: &DBlockEvent{
+				Height:    height,
+				KeyMR:     dblock.GetKeyMR().String(),
+				Timestamp: dblock.GetTimestamp().GetTimeSeconds(),
+			},
+		})
+	}
+
+	if wanted(TopicFactoid) && fblock != nil {
+		for _, tx := range fblock.GetTransactions() {
+			events = append(events, NewFactoidEvent(tx, height, confirmed))
+		}
+	}
+
+	if wanted(TopicStatus) && ecblock != nil {
+		for _, entry := range ecblock.GetEntries() {
+			switch e := entry.(type) {
+			case *entryCreditBlock.CommitChain:
+				events = append(events, NewStatusEvent("commit", e.EntryHash, e.GetSigHash(), nil, height, confirmed))
+			case *entryCreditBlock.CommitEntry:
+				events = append(events, NewStatusEvent("commit", e.EntryHash, e.GetSigHash(), nil, height, confirmed))
+			}
+		}
+	}
+
+	entries := wanted(TopicEntry)
+	reveals := wanted(TopicStatus)
+	if !entries && !reveals {
+		return events
+	}
+	for _, dbe := range dblock.GetEBlockDBEntries() {
+		eblock, err := db.FetchEBlock(dbe.GetKeyMR())
+		if err != nil || eblock == nil {
+			continue
+		}
+		chainID := eblock.GetChainID()
+		for _, hash := range eblock.GetEntryHashes() {
+			if hash.IsMinuteMarker() {
+				continue
+			}
+			if entries {
+				events = append(events, NewEntryEvent(chainID, hash, height, confirmed))
+			}
+			if reveals {
+				events = append(events, NewStatusEvent("reveal", hash, nil, chainID, height, confirmed))
+			}
+		}
+	}
+	return events
+}
+
+// SavedEvents builds the events of the topic for the blocks saved at the height,
+// for subscribers catching up on what they missed.  Minutes are not saved, so
+// there are none of those.
+func SavedEvents(db interfaces.DBOverlaySimple, height uint32, topic string) ([]*Event, error) {
+	dblock, err := db.FetchDBlockByHeight(height)
+	if err != nil {
+		return nil, err
+	}
+	if dblock == nil {
+		return nil, fmt.Errorf("no directory block at height %d", height)
+	}
+	var fblock interfaces.IFBlock
+	var ecblock interfaces.IEntryCreditBlock
+	if topic == TopicFactoid {
+		if fblock, err = db.FetchFBlockByHeight(height); err != nil {
+			return nil, err
+		}
+	}
+	if topic == TopicStatus {
+		if ecblock, err = db.FetchECBlockByHeight(height); err != nil {
+			return nil, err
+		}
+	}
+	return BlockEvents(db, dblock, fblock, ecblock, func(t string) bool { return t == topic }), nil
+}
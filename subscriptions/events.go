@@ -0,0 +1,60 @@
+// Copyright 2017 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package subscriptions
+
+// The Data of the events of each topic.  Hashes, chain IDs and keys are hex, addresses
+// are in their human readable form, and Status is one of the ack status strings
+// ("TransactionACK", "DBlockConfirmed").
+
+type DBlockEvent struct {
+	Height    uint32 `json:"height"`
+	KeyMR     string `json:"keymr"`
+	Timestamp int64  `json:"timestamp"` // seconds
+}
+
+type EntryEvent struct {
+	ChainID   string `json:"chainid"`
+	EntryHash string `json:"entryhash"`
+	Height    uint32 `json:"height"`
+	Status    string `json:"status"`
+}
+
+type StatusEvent struct {
+	Type      string `json:"type"` // "commit" or "reveal"
+	EntryHash string `json:"entryhash"`
+	TxID      string `json:"txid,omitempty"` // the commit, if known
+	ChainID   string `json:"chainid,omitempty"`
+	Height    uint32 `json:"height"`
+	Status    string `json:"status"`
+}
+
+type FactoidEvent struct {
+	TxID      string   `json:"txid"`
+	Addresses []string `json:"addresses"` // inputs and outputs, FA and EC
+	Height    uint32   `json:"height"`
+	Status    string   `json:"status"`
+}
+
+type MinuteEvent struct {
+	Height uint32 `json:"height"`
+	Minute int    `json:"minute"`
+}
+
+// height is the block height the event is about, 0 if it has none
+func (e *Event) height() uint32 {
+	switch d := e.Data.(type) {
+	case *DBlockEvent:
+		return d.Height
+	case *EntryEvent:
+		return d.Height
+	case *StatusEvent:
+		return d.Height
+	case *FactoidEvent:
+		return d.Height
+	case *MinuteEvent:
+		return d.Height
+	}
+	return 0
+}
@@ -0,0 +1,249 @@
+// Copyright 2017 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+// Package subscriptions fans out what happens in the state (blocks saved, entries,
+// commit and reveal status changes, factoid transactions, minutes) to subscribers
+// such as the websocket API.  Publishing never blocks the state: every subscriber
+// has a bounded queue, and events that do not fit are dropped for that subscriber
+// and counted, so it can be told what it missed and catch up from a height (see
+// SavedEvents).
+package subscriptions
+
+import (
+	"fmt"
+	"sync"
+)
+
+const (
+	TopicDBlock  = "dblock"  // a directory block was saved
+	TopicEntry   = "entry"   // an entry was acknowledged or saved, filtered by chain ID
+	TopicStatus  = "status"  // the status of a commit or reveal changed, filtered by entry hash
+	TopicFactoid = "factoid" // a factoid transaction was acknowledged or saved, filtered by txid or address
+	TopicMinute  = "minute"  // the node moved on to the next minute
+)
+
+var Topics = []string{TopicDBlock, TopicEntry, TopicStatus, TopicFactoid, TopicMinute}
+
+// DefaultQueueSize is how many events a subscriber may fall behind before it misses events
+const DefaultQueueSize = 1000
+
+// Event is one thing that happened.  Keys are what subscription filters are matched
+// against (chain IDs, entry hashes, txids, addresses); an event with no keys only
+// goes to subscribers without filters.
+type Event struct {
+	Topic string      `json:"topic"`
+	Keys  []string    `json:"-"`
+	Data  interface{} `json:"data"`
+}
+
+// Hub keeps the subscribers of one state
+type Hub struct {
+	mutex       sync.RWMutex
+	subscribers map[*Subscriber]struct{}
+	topics      map[string]int // number of subscribers to each topic
+}
+
+func NewHub() *Hub {
+	h := new(Hub)
+	h.subscribers = make(map[*Subscriber]struct{})
+	h.topics = make(map[string]int)
+	return h
+}
+
+// NewSubscriber adds a subscriber with room for queueSize events.  It gets nothing
+// until it subscribes to a topic.
+func (h *Hub) NewSubscriber(queueSize int) *Subscriber {
+	if queueSize <= 0 {
+		queueSize = DefaultQueueSize
+	}
+	s := new(Subscriber)
+	s.hub = h
+	s.events = make(chan *Event, queueSize)
+	s.done = make(chan struct{})
+	s.filters = make(map[string]map[string]bool)
+
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	h.subscribers[s] = struct{}{}
+	return s
+}
+
+// Remove drops the subscriber, closing its Done channel
+func (h *Hub) Remove(s *Subscriber) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	h.remove(s)
+}
+
+func (h *Hub) remove(s *Subscriber) {
+	if _, ok := h.subscribers[s]; !ok {
+		return
+	}
+	delete(h.subscribers, s)
+	for topic := range s.filters {
+		h.topics[topic]--
+	}
+	close(s.done)
+}
+
+// Subscribers returns how many subscribers are connected
+func (h *Hub) Subscribers() int {
+	if h == nil {
+		return 0
+	}
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+	return len(h.subscribers)
+}
+
+// HasSubscribers lets the publisher skip building events nobody listens to
+func (h *Hub) HasSubscribers(topic string) bool {
+	if h == nil {
+		return false
+	}
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+	return h.topics[topic] > 0
+}
+
+// Publish queues the event for every subscriber it matches.  It never blocks; a
+// subscriber whose queue is full misses the event, which it learns through
+// TakeDropped().
+func (h *Hub) Publish(e *Event) {
+	if h == nil || e == nil {
+		return
+	}
+	h.mutex.RLock()
+	defer h.mutex.RUnlock()
+	for s := range h.subscribers {
+		if !s.matches(e) {
+			continue
+		}
+		select {
+		case s.events <- e:
+		default:
+			s.drop(e)
+		}
+	}
+}
+
+// Dropped is what a subscriber missed since it was last told: the number of
+// events of each topic, and the lowest height they were at.  Subscribing again
+// from that height (see SavedEvents) gets the saved ones back.
+type Dropped struct {
+	Topics map[string]int `json:"topics"`
+	From   uint32         `json:"from"`
+}
+
+// Subscriber receives the events of the topics it subscribed to
+type Subscriber struct {
+	hub     *Hub
+	events  chan *Event
+	done    chan struct{}
+	filters map[string]map[string]bool // topic -> keys.  An empty set takes every event of the topic
+
+	droppedMutex sync.Mutex // Publish only holds the read lock of the hub
+	dropped      *Dropped
+}
+
+// Subscribe adds the topic, or replaces its filters if already subscribed.  With no
+// keys every event of the topic is delivered.
+func (s *Subscriber) Subscribe(topic string, keys ...string) error {
+	if !validTopic(topic) {
+		return fmt.Errorf("unknown topic %q", topic)
+	}
+	filter := make(map[string]bool)
+	for _, k := range keys {
+		filter[k] = true
+	}
+
+	s.hub.mutex.Lock()
+	defer s.hub.mutex.Unlock()
+	if _, ok := s.hub.subscribers[s]; !ok {
+		return fmt.Errorf("subscriber was removed")
+	}
+	if _, ok := s.filters[topic]; !ok {
+		s.hub.topics[topic]++
+	}
+	s.filters[topic] = filter
+	return nil
+}
+
+// Unsubscribe stops the events of the topic
+func (s *Subscriber) Unsubscribe(topic string) {
+	s.hub.mutex.Lock()
+	defer s.hub.mutex.Unlock()
+	if _, ok := s.filters[topic]; !ok {
+		return
+	}
+	delete(s.filters, topic)
+	if _, ok := s.hub.subscribers[s]; ok {
+		s.hub.topics[topic]--
+	}
+}
+
+// Events is the queue of events for the subscriber
+func (s *Subscriber) Events() <-chan *Event {
+	return s.events
+}
+
+// Done is closed once the subscriber is removed from the hub
+func (s *Subscriber) Done() <-chan struct{} {
+	return s.done
+}
+
+// TakeDropped returns what the subscriber missed since the last call, nil if nothing
+func (s *Subscriber) TakeDropped() *Dropped {
+	s.droppedMutex.Lock()
+	defer s.droppedMutex.Unlock()
+	d := s.dropped
+	s.dropped = nil
+	return d
+}
+
+func (s *Subscriber) drop(e *Event) {
+	s.droppedMutex.Lock()
+	defer s.droppedMutex.Unlock()
+	height := e.height()
+	if s.dropped == nil {
+		s.dropped = &Dropped{Topics: map[string]int{}, From: height}
+	}
+	s.dropped.Topics[e.Topic]++
+	if height < s.dropped.From {
+		s.dropped.From = height
+	}
+}
+
+// Wants is true if the event matches the topics and filters of the subscriber
+func (s *Subscriber) Wants(e *Event) bool {
+	s.hub.mutex.RLock()
+	defer s.hub.mutex.RUnlock()
+	return s.matches(e)
+}
+
+// matches is called with the hub mutex held
+func (s *Subscriber) matches(e *Event) bool {
+	filter, ok := s.filters[e.Topic]
+	if !ok {
+		return false
+	}
+	if len(filter) == 0 {
+		return true
+	}
+	for _, k := range e.Keys {
+		if filter[k] {
+			return true
+		}
+	}
+	return false
+}
+
+func validTopic(topic string) bool {
+	for _, t := range Topics {
+		if t == topic {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,99 @@
+package subscriptions_test
+
+import (
+	"testing"
+
+	. "github.com/FactomProject/factomd/subscriptions"
+)
+
+func TestHubFilters(t *testing.T) {
+	hub := NewHub()
+	all := hub.NewSubscriber(10)
+	one := hub.NewSubscriber(10)
+
+	if err := all.Subscribe(TopicEntry); err != nil {
+		t.Fatal(err)
+	}
+	if err := one.Subscribe(TopicEntry, "aa"); err != nil {
+		t.Fatal(err)
+	}
+	if err := one.Subscribe("nope"); err == nil {
+		t.Error("Subscribed to an unknown topic")
+	}
+	if !hub.HasSubscribers(TopicEntry) || hub.HasSubscribers(TopicDBlock) {
+		t.Error("Wrong topics subscribed")
+	}
+
+	hub.Publish(&Event{Topic: TopicEntry, Keys: []string{"aa"}})
+	hub.Publish(&Event{Topic: TopicEntry, Keys: []string{"bb"}})
+	hub.Publish(&Event{Topic: TopicDBlock})
+
+	if len(all.Events()) != 2 {
+		t.Errorf("Unfiltered subscriber got %d events, expected 2", len(all.Events()))
+	}
+	if len(one.Events()) != 1 {
+		t.Errorf("Filtered subscriber got %d events, expected 1", len(one.Events()))
+	}
+	if e := <-one.Events(); e.Keys[0] != "aa" {
+		t.Errorf("Filtered subscriber got %v", e.Keys)
+	}
+
+	one.Unsubscribe(TopicEntry)
+	all.Unsubscribe(TopicEntry)
+	if hub.HasSubscribers(TopicEntry) {
+		t.Error("Topic still has subscribers")
+	}
+	hub.Publish(&Event{Topic: TopicEntry, Keys: []string{"aa"}})
+	if len(one.Events()) != 0 {
+		t.Error("Got an event after unsubscribing")
+	}
+}
+
+func TestHubSlowConsumer(t *testing.T) {
+	hub := NewHub()
+	slow := hub.NewSubscriber(2)
+	fast := hub.NewSubscriber(10)
+	slow.Subscribe(TopicMinute)
+	fast.Subscribe(TopicMinute)
+
+	for i := 0; i < 4; i++ {
+		hub.Publish(&Event{Topic: TopicMinute, Data: &MinuteEvent{Height: uint32(10 + i)}})
+	}
+
+	select {
+	case <-slow.Done():
+		t.Fatal("Slow subscriber was removed")
+	default:
+	}
+	if hub.Subscribers() != 2 || len(fast.Events()) != 4 || len(slow.Events()) != 2 {
+		t.Errorf("Wrong events queued: %d subscribers, %d fast, %d slow", hub.Subscribers(), len(fast.Events()), len(slow.Events()))
+	}
+	if fast.TakeDropped() != nil {
+		t.Error("Fast subscriber dropped events")
+	}
+	dropped := slow.TakeDropped()
+	if dropped == nil || dropped.Topics[TopicMinute] != 2 || dropped.From != 12 {
+		t.Errorf("Wrong events dropped: %v", dropped)
+	}
+	if slow.TakeDropped() != nil {
+		t.Error("Dropped events reported twice")
+	}
+
+	hub.Remove(fast)
+	hub.Remove(fast)
+	hub.Remove(slow)
+	if hub.HasSubscribers(TopicMinute) {
+		t.Error("Removed subscribers still counted")
+	}
+	if err := slow.Subscribe(TopicMinute); err == nil {
+		t.Error("Removed subscriber could subscribe again")
+	}
+}
+
+func TestNilHub(t *testing.T) {
+	var hub *Hub
+	hub.Publish(&Event{Topic: TopicMinute})
+	if hub.HasSubscribers(TopicMinute) || hub.Subscribers() != 0 {
+		t.Error("Nil hub has subscribers")
+	}
+}
@@ -21,6 +21,17 @@ func CreateTestDirBlockInfo(prev *dbInfo.DirBlockInfo) *dbInfo.DirBlockInfo {
 	dbi.BTCBlockHash.UnmarshalBinary(IntToByteSlice(255 - int(height)))
 	dbi.DBMerkleRoot.UnmarshalBinary(IntToByteSlice(255 - int(height)))
 	dbi.BTCConfirmed = height%2 == 1
+	if height%3 == 0 {
+		dbi.EthTxHash.UnmarshalBinary(IntToByteSlice(int(height) + 1))
+		dbi.EthTxOffset = int64(height % 10)
+		dbi.EthBlockHeight = int64(height)
+		dbi.EthBlockHash.UnmarshalBinary(IntToByteSlice(254 - int(height)))
+		dbi.EthContractAddress = "0x30aa981f6d2fce81083e584c8ee2f822b548752f"
+		dbi.EthWindowMR.UnmarshalBinary(IntToByteSlice(int(height) + 2))
+		dbi.EthDBHeightMin = height
+		dbi.EthDBHeightMax = height
+		dbi.EthConfirmed = true
+	}
 
 	return dbi
 }
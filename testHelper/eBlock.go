@@ -223,3 +223,30 @@ func CreateTestAnchorEnry(dBlock *directoryBlock.DirectoryBlock) *entryBlock.Ent
 
 	return answer
 }
+
+// CreateTestEthereumAnchorRecord creates an Ethereum anchor record for the window
+// of saved directory blocks from min to max
+func CreateTestEthereumAnchorRecord(dbo interfaces.DBOverlaySimple, min, max uint32) *anchor.AnchorRecord {
+	keyMRs := []interfaces.IHash{}
+	for h := min; h <= max; h++ {
+		keyMR, err := dbo.FetchDBKeyMRByHeight(h)
+		if err != nil {
+			panic(err)
+		}
+		keyMRs = append(keyMRs, keyMR)
+	}
+
+	ar := new(anchor.AnchorRecord)
+	ar.AnchorRecordVer = 2
+	ar.DBHeightMin = min
+	ar.DBHeightMax = max
+	ar.WindowMR = primitives.ComputeMerkleRoot(keyMRs).String()
+	ar.RecordHeight = max + 1
+	ar.Ethereum = new(anchor.EthereumStruct)
+	ar.Ethereum.Address = "0x30aa981f6d2fce81083e584c8ee2f822b548752f"
+	ar.Ethereum.TXID = fmt.Sprintf("0x%x", IntToByteSlice(int(max)))
+	ar.Ethereum.BlockHeight = int64(max)
+	ar.Ethereum.BlockHash = fmt.Sprintf("0x%x", IntToByteSlice(255-int(max)))
+	ar.Ethereum.Offset = int64(max % 10)
+	return ar
+}
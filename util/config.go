@@ -31,6 +31,8 @@ type FactomdConfig struct {
 		FastBoot                               bool
 		FastBootLocation                       string
 		NodeMode                               string
+		PruneKeepBlocks                        int
+		PruneKeepChains                        string
 		IdentityChainID                        string
 		LocalServerPrivKey                     string
 		LocalServerPublicKey                   string
@@ -56,6 +58,8 @@ type FactomdConfig struct {
 		CustomNetworkPort       string
 		CustomSeedURL           string
 		CustomSpecialPeers      string
+		PeerBanDuration         string
+		PeerPenalties           string
 		CustomBootstrapIdentity string
 		CustomBootstrapKey      string
 		FactomdTlsEnabled       bool
@@ -63,6 +67,15 @@ type FactomdConfig struct {
 		FactomdTlsPublicCert    string
 		FactomdRpcUser          string
 		FactomdRpcPass          string
+		FactomdRpcTokensFile    string
+		ApiRateLimitPerIP       int
+		ApiRateLimitPerToken    int
+		ApiExpensiveRateLimit   int
+		ApiMaxBatchSize         int
+		HoldingMaxPerType       int
+		HoldingMaxPerPeer       int
+		HoldingMaxAge           int
+		HoldingMaxOrphanAge     int
 
 		ChangeAcksHeight uint32
 	}
@@ -111,7 +124,7 @@ HomeDir                               = ""
 ; --------------- ControlPanel disabled | readonly | readwrite
 ControlPanelSetting                   = readonly
 ControlPanelPort                      = 8090
-; --------------- DBType: LDB | Bolt | Map
+; --------------- DBType: LDB | Bolt | Map | LSM
 DBType                                = "LDB"
 LdbPath                               = "database/ldb"
 BoltDBPath                            = "database/bolt"
@@ -136,10 +149,16 @@ LocalSpecialPeers    = ""
 CustomNetworkPort    = 8110
 CustomSeedURL        = ""
 CustomSpecialPeers   = ""
+PeerBanDuration      = "0s"
+PeerPenalties        = ""
 CustomBootstrapIdentity     = 38bab1455b7bd7e5efd15c53c777c79d0c988e9210f1da49a99d95b3a6417be9
 CustomBootstrapKey          = cc1985cdfae4e32b5a454dfda8ce5e1361558482684f3367649c3ad852c8e31a
-; --------------- NodeMode: FULL | SERVER ----------------
+; --------------- NodeMode: FULL | SERVER | PRUNED ----------------
 NodeMode                                = FULL
+; A PRUNED node only keeps the content of the entries of the last PruneKeepBlocks blocks,
+; and of the chains in PruneKeepChains (chain IDs separated by commas).  0 keeps everything.
+PruneKeepBlocks                         = 1000
+PruneKeepChains                         = ""
 LocalServerPrivKey                      = 4c38c72fc5cdad68f13b74674d3ffb1f3d63a112710868c9b08946553448d26d
 LocalServerPublicKey                    = cc1985cdfae4e32b5a454dfda8ce5e1361558482684f3367649c3ad852c8e31a
 ExchangeRateChainId                     = 111111118d918a8be684e0dac725493a75862ef96d2d3f43f84b26969329bf03
@@ -159,6 +178,15 @@ FactomdTlsPublicCert                  = "/full/path/to/factomdAPIpub.cert"
 ; This file is also used by factom-cli and factom-walletd to determine what login to use
 FactomdRpcUser                        = ""
 FactomdRpcPass                        = ""
+FactomdRpcTokensFile                  = ""
+ApiRateLimitPerIP                     = 0
+ApiRateLimitPerToken                  = 0
+ApiExpensiveRateLimit                 = 0
+ApiMaxBatchSize                       = 0
+HoldingMaxPerType                     = 0
+HoldingMaxPerPeer                     = 0
+HoldingMaxAge                         = 0
+HoldingMaxOrphanAge                   = 0
 
 ; Specifying when to change ACKs for switching leader servers
 ChangeAcksHeight                      = 0
@@ -228,9 +256,13 @@ func (s *FactomdConfig) String() string {
 	out.WriteString(fmt.Sprintf("\n    CustomNetworkPort       %v", s.App.CustomNetworkPort))
 	out.WriteString(fmt.Sprintf("\n    CustomSeedURL           %v", s.App.CustomSeedURL))
 	out.WriteString(fmt.Sprintf("\n    CustomSpecialPeers      %v", s.App.CustomSpecialPeers))
+	out.WriteString(fmt.Sprintf("\n    PeerBanDuration         %v", s.App.PeerBanDuration))
+	out.WriteString(fmt.Sprintf("\n    PeerPenalties           %v", s.App.PeerPenalties))
 	out.WriteString(fmt.Sprintf("\n    CustomBootstrapIdentity %v", s.App.CustomBootstrapIdentity))
 	out.WriteString(fmt.Sprintf("\n    CustomBootstrapKey      %v", s.App.CustomBootstrapKey))
 	out.WriteString(fmt.Sprintf("\n    NodeMode                %v", s.App.NodeMode))
+	out.WriteString(fmt.Sprintf("\n    PruneKeepBlocks         %v", s.App.PruneKeepBlocks))
+	out.WriteString(fmt.Sprintf("\n    PruneKeepChains         %v", s.App.PruneKeepChains))
 	out.WriteString(fmt.Sprintf("\n    IdentityChainID         %v", s.App.IdentityChainID))
 	out.WriteString(fmt.Sprintf("\n    LocalServerPrivKey      %v", s.App.LocalServerPrivKey))
 	out.WriteString(fmt.Sprintf("\n    LocalServerPublicKey    %v", s.App.LocalServerPublicKey))
@@ -242,6 +274,15 @@ func (s *FactomdConfig) String() string {
 	out.WriteString(fmt.Sprintf("\n    FactomdTlsPublicCert     %v", s.App.FactomdTlsPublicCert))
 	out.WriteString(fmt.Sprintf("\n    FactomdRpcUser          	%v", s.App.FactomdRpcUser))
 	out.WriteString(fmt.Sprintf("\n    FactomdRpcPass          	%v", s.App.FactomdRpcPass))
+	out.WriteString(fmt.Sprintf("\n    FactomdRpcTokensFile     %v", s.App.FactomdRpcTokensFile))
+	out.WriteString(fmt.Sprintf("\n    ApiRateLimitPerIP        %v", s.App.ApiRateLimitPerIP))
+	out.WriteString(fmt.Sprintf("\n    ApiRateLimitPerToken     %v", s.App.ApiRateLimitPerToken))
+	out.WriteString(fmt.Sprintf("\n    ApiExpensiveRateLimit    %v", s.App.ApiExpensiveRateLimit))
+	out.WriteString(fmt.Sprintf("\n    ApiMaxBatchSize          %v", s.App.ApiMaxBatchSize))
+	out.WriteString(fmt.Sprintf("\n    HoldingMaxPerType        %v", s.App.HoldingMaxPerType))
+	out.WriteString(fmt.Sprintf("\n    HoldingMaxPerPeer        %v", s.App.HoldingMaxPerPeer))
+	out.WriteString(fmt.Sprintf("\n    HoldingMaxAge            %v", s.App.HoldingMaxAge))
+	out.WriteString(fmt.Sprintf("\n    HoldingMaxOrphanAge      %v", s.App.HoldingMaxOrphanAge))
 	out.WriteString(fmt.Sprintf("\n    ChangeAcksHeight         %v", s.App.ChangeAcksHeight))
 
 	out.WriteString(fmt.Sprintf("\n  Log"))
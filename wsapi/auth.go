@@ -0,0 +1,287 @@
+// Copyright 2017 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package wsapi
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/FactomProject/factomd/common/interfaces"
+	"github.com/FactomProject/factomd/common/primitives"
+)
+
+// Role is what an API client is allowed to do.  Every role can call the
+// methods of the roles below it.
+type Role int
+
+const (
+	RoleNone     Role = iota
+	RoleReadOnly      // Query the blockchain and the state of the node
+	RoleSubmit        // Also commit and reveal entries and chains, and submit transactions
+	RoleAdmin         // Also change the node through the debug API
+)
+
+var roleNames = map[Role]string{
+	RoleNone:     "none",
+	RoleReadOnly: "read-only",
+	RoleSubmit:   "submit",
+	RoleAdmin:    "admin",
+}
+
+func (r Role) String() string {
+	name, ok := roleNames[r]
+	if !ok {
+		return fmt.Sprintf("role(%d)", int(r))
+	}
+	return name
+}
+
+// ParseRole returns the role with the name, as used in the tokens file
+func ParseRole(name string) (Role, error) {
+	for role, n := range roleNames {
+		if role != RoleNone && n == strings.ToLower(strings.TrimSpace(name)) {
+			return role, nil
+		}
+	}
+	return RoleNone, fmt.Errorf("%s is not a role, use read-only, submit or admin", name)
+}
+
+// v2MethodRoles are the V2 (and V1) methods that need more than RoleReadOnly
+var v2MethodRoles = map[string]Role{
+	"commit-chain":     RoleSubmit,
+	"commit-entry":     RoleSubmit,
+	"reveal-chain":     RoleSubmit,
+	"reveal-entry":     RoleSubmit,
+	"factoid-submit":   RoleSubmit,
+	"send-raw-message": RoleSubmit,
+}
+
+// debugMethodRoles are the debug methods that only read the state of the node.
+// All the other debug methods need RoleAdmin.
+var debugMethodRoles = map[string]Role{
+	"audit-servers":     RoleReadOnly,
+	"authorities":       RoleReadOnly,
+	"configuration":     RoleAdmin, // the config has the rpc password and the server private key
+	"current-minute":    RoleReadOnly,
+	"delay":             RoleReadOnly,
+	"drop-rate":         RoleReadOnly,
+	"federated-servers": RoleReadOnly,
+	"holding-queue":     RoleReadOnly,
+	"holding-explain":   RoleReadOnly,
+	"messages":          RoleReadOnly,
+	"network-info":      RoleReadOnly,
+	"summary":           RoleReadOnly,
+	"predictive-fer":    RoleReadOnly,
+	"process-list":      RoleReadOnly,
+	"peer-bans":         RoleReadOnly,
+}
+
+// API names for MethodRole
+const (
+	ApiV1    = "v1"
+	ApiV2    = "v2"
+	ApiDebug = "debug"
+)
+
+// MethodRole returns the role needed to call the method of the api
+func MethodRole(api string, method string) Role {
+	switch api {
+	case ApiV1, ApiV2:
+		if role, ok := v2MethodRoles[method]; ok {
+			return role
+		}
+		return RoleReadOnly
+	case ApiDebug:
+		if role, ok := debugMethodRoles[method]; ok {
+			return role
+		}
+		return RoleAdmin
+	}
+	return RoleAdmin
+}
+
+// ApiToken is an entry of the tokens file, which is a JSON list like
+//
+//	[{"name": "explorer", "role": "read-only", "token": "e4c1..."}]
+//
+// Clients send the token as "Authorization: Bearer <token>", or as the password
+// of HTTP basic auth with the name of the token as the user.
+type ApiToken struct {
+	Name  string `json:"name"`
+	Role  string `json:"role"`
+	Token string `json:"token"`
+}
+
+type apiClient struct {
+	Name string
+	Role Role
+}
+
+// ApiTokens are the tokens of the tokens file, keyed by the hash of the token
+type ApiTokens struct {
+	clients map[[sha256.Size]byte]apiClient
+}
+
+// LoadApiTokens reads the tokens file at the path
+func LoadApiTokens(path string) (*ApiTokens, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var tokens []ApiToken
+	if err := json.Unmarshal(data, &tokens); err != nil {
+		return nil, fmt.Errorf("could not decode the API tokens in %s: %v", path, err)
+	}
+
+	t := new(ApiTokens)
+	t.clients = map[[sha256.Size]byte]apiClient{}
+	for i, token := range tokens {
+		if token.Name == "" || token.Token == "" {
+			return nil, fmt.Errorf("API token %d in %s needs a name and a token", i, path)
+		}
+		role, err := ParseRole(token.Role)
+		if err != nil {
+			return nil, fmt.Errorf("API token %s in %s: %v", token.Name, path, err)
+		}
+		hash := sha256.Sum256([]byte(token.Token))
+		if _, exists := t.clients[hash]; exists {
+			return nil, fmt.Errorf("API token %s in %s is not unique", token.Name, path)
+		}
+		t.clients[hash] = apiClient{Name: token.Name, Role: role}
+	}
+	return t, nil
+}
+
+// Lookup returns the name and role of the token
+func (t *ApiTokens) Lookup(token string) (string, Role, bool) {
+	if t == nil {
+		return "", RoleNone, false
+	}
+	client, ok := t.clients[sha256.Sum256([]byte(token))]
+	return client.Name, client.Role, ok
+}
+
+var apiTokens *ApiTokens
+var apiTokensMutex sync.RWMutex
+
+// SetApiTokens sets the tokens clients can authenticate with.  With no tokens
+// and no FactomdRpcUser the API is open to everyone.
+func SetApiTokens(tokens *ApiTokens) {
+	apiTokensMutex.Lock()
+	defer apiTokensMutex.Unlock()
+	apiTokens = tokens
+}
+
+func getApiTokens() *ApiTokens {
+	apiTokensMutex.RLock()
+	defer apiTokensMutex.RUnlock()
+	return apiTokens
+}
+
+// reloadApiTokens loads the tokens file of the config, if there is one
+func reloadApiTokens(state interfaces.IState) error {
+	path := state.GetRpcTokensFile()
+	if path == "" {
+		SetApiTokens(nil)
+		return nil
+	}
+	tokens, err := LoadApiTokens(path)
+	if err != nil {
+		return err
+	}
+	SetApiTokens(tokens)
+	return nil
+}
+
+// authenticate returns the client of the request.  The user and password of the
+// config are an admin, like before there were tokens.
+func authenticate(state interfaces.IState, r *http.Request) (apiClient, error) {
+	tokens := getApiTokens()
+	rpcUser := state.GetRpcUser()
+	if rpcUser == "" && tokens == nil {
+		//no username or tokens were specified in the config file or command line, meaning factomd API is open access
+		return apiClient{Role: RoleAdmin}, nil
+	}
+
+	authhdr := r.Header["Authorization"]
+	if len(authhdr) == 0 {
+		return apiClient{}, errors.New("no auth")
+	}
+
+	if rpcUser != "" {
+		h := sha256.New()
+		h.Write([]byte(authhdr[0]))
+		presentedPassHash := h.Sum(nil)
+		//compare hashes because ConstantTimeCompare takes a constant time based on the slice size.  hashing gives a constant slice size.
+		if subtle.ConstantTimeCompare(presentedPassHash, state.GetRpcAuthHash()) == 1 {
+			return apiClient{Name: rpcUser, Role: RoleAdmin}, nil
+		}
+	}
+
+	scheme, credentials := authhdr[0], ""
+	if i := strings.IndexByte(authhdr[0], ' '); i >= 0 {
+		scheme, credentials = authhdr[0][:i], strings.TrimSpace(authhdr[0][i+1:])
+	}
+	switch strings.ToLower(scheme) {
+	case "bearer":
+		if name, role, ok := tokens.Lookup(credentials); ok {
+			return apiClient{Name: name, Role: role}, nil
+		}
+	case "basic":
+		decoded, err := base64.StdEncoding.DecodeString(credentials)
+		if err != nil {
+			break
+		}
+		parts := strings.SplitN(string(decoded), ":", 2)
+		if len(parts) != 2 {
+			break
+		}
+		if name, role, ok := tokens.Lookup(parts[1]); ok && name == parts[0] {
+			return apiClient{Name: name, Role: role}, nil
+		}
+	}
+	return apiClient{}, errors.New("bad auth")
+}
+
+// authorize checks the client of the request may call the method of the api,
+// and audit logs it when it may not
+func authorize(state interfaces.IState, r *http.Request, api string, method string) (apiClient, *primitives.JSONError) {
+	client, err := authenticate(state, r)
+	if err != nil {
+		APIRejectedRequests.WithLabelValues(api, RejectUnauthorized).Inc()
+		auditDenied(r, api, method, "", err.Error())
+		return client, NewUnauthorizedError()
+	}
+	if needed := MethodRole(api, method); client.Role < needed {
+		APIRejectedRequests.WithLabelValues(api, RejectForbidden).Inc()
+		auditDenied(r, api, method, client.Name, fmt.Sprintf("role %s, needs %s", client.Role, needed))
+		return client, NewForbiddenError(method, needed)
+	}
+	return client, nil
+}
+
+func auditDenied(r *http.Request, api string, method string, client string, reason string) {
+	remoteIP := r.RemoteAddr
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		remoteIP = host
+	}
+	if client == "" {
+		client = "-"
+	}
+	msg := fmt.Sprintf("API access denied: ip=%s client=%s api=%s method=%s reason=%s", remoteIP, client, api, method, reason)
+	fmt.Println(msg)
+	if rpcLog != nil {
+		rpcLog.Warning(msg)
+	}
+}
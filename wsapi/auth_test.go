@@ -0,0 +1,161 @@
+package wsapi_test
+
+import (
+	"bytes"
+	"encoding/base64"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/FactomProject/factomd/state"
+	"github.com/FactomProject/factomd/testHelper"
+	"github.com/FactomProject/factomd/util"
+	. "github.com/FactomProject/factomd/wsapi"
+)
+
+func writeTokens(t *testing.T, dir string, tokens string) string {
+	path := filepath.Join(dir, "tokens.json")
+	if err := ioutil.WriteFile(path, []byte(tokens), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadApiTokens(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tokens")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	tokens, err := LoadApiTokens(writeTokens(t, dir, `[
+		{"name": "explorer", "role": "read-only", "token": "read"},
+		{"name": "wallet", "role": "submit", "token": "submit"},
+		{"name": "ops", "role": "Admin", "token": "admin"}
+	]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for token, expected := range map[string]Role{"read": RoleReadOnly, "submit": RoleSubmit, "admin": RoleAdmin} {
+		if _, role, ok := tokens.Lookup(token); !ok || role != expected {
+			t.Errorf("Token %s has role %s, expected %s", token, role, expected)
+		}
+	}
+	if _, _, ok := tokens.Lookup("unknown"); ok {
+		t.Error("Found an unknown token")
+	}
+
+	for _, bad := range []string{
+		`{"name": "explorer"}`,
+		`[{"name": "explorer", "role": "reader", "token": "read"}]`,
+		`[{"name": "explorer", "role": "read-only"}]`,
+		`[{"name": "a", "role": "admin", "token": "same"}, {"name": "b", "role": "submit", "token": "same"}]`,
+	} {
+		if _, err := LoadApiTokens(writeTokens(t, dir, bad)); err == nil {
+			t.Errorf("Loaded invalid tokens %s", bad)
+		}
+	}
+}
+
+func TestMethodRole(t *testing.T) {
+	for _, c := range []struct {
+		api, method string
+		role        Role
+	}{
+		{ApiV2, "heights", RoleReadOnly},
+		{ApiV2, "commit-entry", RoleSubmit},
+		{ApiV2, "send-raw-message", RoleSubmit},
+		{ApiV1, "factoid-submit", RoleSubmit},
+		{ApiDebug, "holding-queue", RoleReadOnly},
+		{ApiDebug, "configuration", RoleAdmin},
+		{ApiDebug, "set-delay", RoleAdmin},
+		{ApiDebug, "reload-configuration", RoleAdmin},
+		{ApiDebug, "some-new-method", RoleAdmin},
+	} {
+		if role := MethodRole(c.api, c.method); role != c.role {
+			t.Errorf("%s %s needs %s, expected %s", c.api, c.method, role, c.role)
+		}
+	}
+}
+
+func TestHandleV2Roles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tokens")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	tokens, err := LoadApiTokens(writeTokens(t, dir, `[{"name": "explorer", "role": "read-only", "token": "read"}]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	SetApiTokens(tokens)
+	defer SetApiTokens(nil)
+
+	call := func(auth string, method string) *testHelper.TestResponseWriter {
+		context := testHelper.CreateWebContext()
+		body := `{"jsonrpc": "2.0", "id": 0, "method": "` + method + `", "params": {}}`
+		context.Request, _ = http.NewRequest("POST", "/v2", bytes.NewBufferString(body))
+		context.Request.RemoteAddr = "10.0.0.1:5555"
+		if auth != "" {
+			context.Request.Header.Set("Authorization", auth)
+		}
+		HandleV2(context)
+		return context.ResponseWriter.(*testHelper.TestResponseWriter)
+	}
+
+	if resp := call("", "heights"); resp.HeaderCode != http.StatusUnauthorized {
+		t.Errorf("No token gave %d", resp.HeaderCode)
+	}
+	if resp := call("Bearer wrong", "heights"); resp.HeaderCode != http.StatusUnauthorized {
+		t.Errorf("Wrong token gave %d", resp.HeaderCode)
+	}
+	if resp := call("Bearer read", "heights"); strings.Contains(resp.Body, `"error"`) {
+		t.Errorf("Read-only token could not read: %s", resp.Body)
+	}
+	basic := "Basic " + base64.StdEncoding.EncodeToString([]byte("explorer:read"))
+	if resp := call(basic, "heights"); strings.Contains(resp.Body, `"error"`) {
+		t.Errorf("Read-only token as basic auth could not read: %s", resp.Body)
+	}
+	if resp := call("Bearer read", "commit-entry"); !strings.Contains(resp.Body, "-32014") {
+		t.Errorf("Read-only token could commit: %s", resp.Body)
+	}
+}
+
+func TestHandleDebugConfigSecrets(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tokens")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	tokens, err := LoadApiTokens(writeTokens(t, dir, `[{"name": "explorer", "role": "read-only", "token": "read"}]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	SetApiTokens(tokens)
+	defer SetApiTokens(nil)
+
+	context := testHelper.CreateWebContext()
+	cfg := new(util.FactomdConfig)
+	cfg.App.FactomdRpcPass = "rpc-secret"
+	cfg.App.LocalServerPrivKey = "4c38c72fc5cdad68f13b74674d3ffb1f3d63a112710868c9b08946553448d26d"
+	context.Server.Env["state"].(*state.State).Cfg = cfg
+
+	body := `{"jsonrpc": "2.0", "id": 0, "method": "configuration", "params": {}}`
+	context.Request, _ = http.NewRequest("POST", "/debug", bytes.NewBufferString(body))
+	context.Request.RemoteAddr = "10.0.0.1:5555"
+	context.Request.Header.Set("Authorization", "Bearer read")
+	HandleDebug(context)
+
+	resp := context.ResponseWriter.(*testHelper.TestResponseWriter)
+	if !strings.Contains(resp.Body, "-32014") {
+		t.Errorf("Read-only token could read the configuration: %s", resp.Body)
+	}
+	for _, secret := range []string{cfg.App.FactomdRpcPass, cfg.App.LocalServerPrivKey} {
+		if strings.Contains(resp.Body, secret) {
+			t.Errorf("Read-only token could see %s", secret)
+		}
+	}
+}
@@ -53,6 +53,15 @@ func HandleDebug(ctx *web.Context) {
 		return
 	}
 
+	client, jsonError := authorize(state, ctx.Request, ApiDebug, j.Method)
+	if jsonError == nil {
+		jsonError = limitRequest(ctx.Request, ApiDebug, client, j.Method, j.Params)
+	}
+	if jsonError != nil {
+		HandleV2Error(ctx, j, jsonError)
+		return
+	}
+
 	jsonResp, jsonError := HandleDebugRequest(state, j)
 
 	if jsonError != nil {
@@ -104,6 +113,9 @@ func HandleDebugRequest(
 	case "holding-queue":
 		resp, jsonError = HandleHoldingQueue(state, params)
 		break
+	case "holding-explain":
+		resp, jsonError = HandleHoldingExplain(state, params)
+		break
 	case "messages":
 		resp, jsonError = HandleMessages(state, params)
 		break
@@ -122,6 +134,15 @@ func HandleDebugRequest(
 	case "reload-configuration":
 		resp, jsonError = HandleReloadConfig(state, params)
 		break
+	case "peer-bans":
+		resp, jsonError = HandlePeerBans(state, params)
+		break
+	case "add-peer-ban":
+		resp, jsonError = HandleAddPeerBan(state, params)
+		break
+	case "remove-peer-ban":
+		resp, jsonError = HandleRemovePeerBan(state, params)
+		break
 	default:
 		jsonError = NewMethodNotFoundError()
 		break
@@ -309,6 +330,22 @@ func HandleHoldingQueue(
 	return r, nil
 }
 
+// HandleHoldingExplain returns why each message in holding is waiting, oldest first
+func HandleHoldingExplain(
+	state interfaces.IState,
+	params interface{},
+) (
+	interface{},
+	*primitives.JSONError,
+) {
+	type ret struct {
+		Messages interface{}
+	}
+	r := new(ret)
+	r.Messages = state.ExplainHolding()
+	return r, nil
+}
+
 func HandleMessages(
 	state interfaces.IState,
 	params interface{},
@@ -400,10 +437,87 @@ func HandleReloadConfig(
 ) {
 	// LoacConfig with "" strings should load the default location
 	state.LoadConfig(state.GetConfigPath(), state.GetNetworkName())
+	if err := reloadApiTokens(state); err != nil {
+		return nil, NewCustomInternalError(err.Error())
+	}
+	reloadApiLimits(state)
 
 	return state.GetCfg(), nil
 }
 
+func HandlePeerBans(
+	state interfaces.IState,
+	params interface{},
+) (
+	interface{},
+	*primitives.JSONError,
+) {
+	type ret struct {
+		Bans interface{} `json:"bans"`
+	}
+	r := new(ret)
+
+	bans, err := state.GetPeerBans()
+	if err != nil {
+		return nil, NewCustomInternalError(err.Error())
+	}
+	r.Bans = bans
+	return r, nil
+}
+
+func HandleAddPeerBan(
+	state interfaces.IState,
+	params interface{},
+) (
+	interface{},
+	*primitives.JSONError,
+) {
+	type ret struct {
+		Ban interface{} `json:"ban"`
+	}
+	r := new(ret)
+
+	ban := new(AddPeerBanRequest)
+	err := MapToObject(params, ban)
+	if err != nil || ban.Address == "" {
+		return nil, NewInvalidParamsError()
+	}
+	if ban.Reason == "" {
+		ban.Reason = "banned through the debug API"
+	}
+
+	r.Ban, err = state.AddPeerBan(ban.Address, ban.Reason, ban.Duration)
+	if err != nil {
+		return nil, NewCustomInvalidParamsError(err.Error())
+	}
+	return r, nil
+}
+
+func HandleRemovePeerBan(
+	state interfaces.IState,
+	params interface{},
+) (
+	interface{},
+	*primitives.JSONError,
+) {
+	type ret struct {
+		Removed bool `json:"removed"`
+	}
+	r := new(ret)
+
+	ban := new(RemovePeerBanRequest)
+	err := MapToObject(params, ban)
+	if err != nil || ban.Address == "" {
+		return nil, NewInvalidParamsError()
+	}
+
+	r.Removed, err = state.RemovePeerBan(ban.Address)
+	if err != nil {
+		return nil, NewCustomInternalError(err.Error())
+	}
+	return r, nil
+}
+
 type SetDelayRequest struct {
 	Delay int64 `json:"delay"`
 }
@@ -411,3 +525,13 @@ type SetDelayRequest struct {
 type SetDropRateRequest struct {
 	DropRate int `json:"droprate"`
 }
+
+type AddPeerBanRequest struct {
+	Address  string `json:"address"`  // IP address or CIDR subnet
+	Reason   string `json:"reason"`   // Optional
+	Duration int64  `json:"duration"` // In seconds, 0 bans forever
+}
+
+type RemovePeerBanRequest struct {
+	Address string `json:"address"`
+}
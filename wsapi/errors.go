@@ -5,6 +5,9 @@
 package wsapi
 
 import (
+	"fmt"
+	"time"
+
 	"github.com/FactomProject/factomd/common/primitives"
 )
 
@@ -97,3 +100,18 @@ func NewReceiptError() *primitives.JSONError {
 func NewRepeatCommitError(data interface{}) *primitives.JSONError {
 	return primitives.NewJSONError(-32011, "Repeated Commit", data)
 }
+func NewEntryPrunedError() *primitives.JSONError {
+	return primitives.NewJSONError(-32012, "Entry pruned", "this node does not keep the content of the entry")
+}
+func NewUnauthorizedError() *primitives.JSONError {
+	return primitives.NewJSONError(-32013, "Unauthorized", nil)
+}
+func NewForbiddenError(method string, role Role) *primitives.JSONError {
+	return primitives.NewJSONError(-32014, "Forbidden", fmt.Sprintf("%s needs the %s role", method, role))
+}
+func NewRateLimitError(wait time.Duration) *primitives.JSONError {
+	return primitives.NewJSONError(-32015, "Too many requests", fmt.Sprintf("retry in %v", wait.Round(time.Millisecond)))
+}
+func NewBatchTooLargeError(size int, max int) *primitives.JSONError {
+	return primitives.NewJSONError(-32016, "Batch too large", fmt.Sprintf("%d is more than the maximum of %d", size, max))
+}
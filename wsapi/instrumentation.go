@@ -5,6 +5,21 @@ import (
 )
 
 var (
+	WebsocketSubscribers = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "factomd_wsapi_websocket_subscribers",
+		Help: "Number of clients connected to the websocket API",
+	})
+
+	WebsocketSlowConsumers = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "factomd_wsapi_websocket_slow_consumers_total",
+		Help: "Number of times websocket clients fell behind and missed events",
+	})
+
+	APIRejectedRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "factomd_wsapi_rejected_requests_total",
+		Help: "Number of API requests rejected, by API and reason",
+	}, []string{"api", "reason"})
+
 	GensisFblockCall = prometheus.NewCounter(prometheus.CounterOpts{
 		Name: "factomd_wsapi_v2_gensis_fblock_count",
 		Help: "Number of times the gensis Fblock is asked for",
@@ -100,6 +115,11 @@ var (
 		Help: "Time it takes to compelete a ",
 	})
 
+	HandleV2APICallTxReceipt = prometheus.NewSummary(prometheus.SummaryOpts{
+		Name: "factomd_wsapi_v2_api_call_txreceipt_ns",
+		Help: "Time it takes to compelete a transaction-receipt",
+	})
+
 	HandleV2APICallRevealEntry = prometheus.NewSummary(prometheus.SummaryOpts{
 		Name: "factomd_wsapi_v2_api_call_reventry_ns",
 		Help: "Time it takes to compelete a revealentry",
@@ -179,6 +199,31 @@ var (
 		Name: "factomd_wsapi_v2_api_call_tpsrate_ns",
 		Help: "Time it takes to compelete a tpsrate",
 	})
+
+	HandleV2APICallDBlockFilter = prometheus.NewSummary(prometheus.SummaryOpts{
+		Name: "factomd_wsapi_v2_api_call_dblockfilter_ns",
+		Help: "Time it takes to compelete a dblock-filter or dblock-filters",
+	})
+
+	HandleV2APICallAddressTxs = prometheus.NewSummary(prometheus.SummaryOpts{
+		Name: "factomd_wsapi_v2_api_call_addresstxs_ns",
+		Help: "Time it takes to compelete an address-transactions",
+	})
+
+	HandleV2APICallBalancesAtHeight = prometheus.NewSummary(prometheus.SummaryOpts{
+		Name: "factomd_wsapi_v2_api_call_balancesatheight_ns",
+		Help: "Time it takes to compelete a balances-at-height",
+	})
+
+	HandleV2APICallSimulateTx = prometheus.NewSummary(prometheus.SummaryOpts{
+		Name: "factomd_wsapi_v2_api_call_simulatetx_ns",
+		Help: "Time it takes to compelete a simulate-transaction or estimate-fee",
+	})
+
+	HandleV2APICallEntryCost = prometheus.NewSummary(prometheus.SummaryOpts{
+		Name: "factomd_wsapi_v2_api_call_entrycost_ns",
+		Help: "Time it takes to compelete an entry-cost, chain-cost or pending-commit",
+	})
 )
 
 var registered = false
@@ -192,6 +237,9 @@ func RegisterPrometheus() {
 	registered = true
 
 	prometheus.MustRegister(GensisFblockCall)
+	prometheus.MustRegister(WebsocketSubscribers)
+	prometheus.MustRegister(WebsocketSlowConsumers)
+	prometheus.MustRegister(APIRejectedRequests)
 	prometheus.MustRegister(HandleV2APICallGeneral)
 	prometheus.MustRegister(HandleV2APICallChainHead)
 	prometheus.MustRegister(HandleV2APICallCommitChain)
@@ -208,6 +256,7 @@ func RegisterPrometheus() {
 	prometheus.MustRegister(HandleV2APICallProp)
 	prometheus.MustRegister(HandleV2APICallRawData)
 	prometheus.MustRegister(HandleV2APICallReceipt)
+	prometheus.MustRegister(HandleV2APICallTxReceipt)
 	prometheus.MustRegister(HandleV2APICallRevealEntry)
 	prometheus.MustRegister(HandleV2APICallFctAck)
 	prometheus.MustRegister(HandleV2APICallEntryAck)
@@ -223,6 +272,11 @@ func RegisterPrometheus() {
 	prometheus.MustRegister(HandleV2APICallABlockByHeight)
 	prometheus.MustRegister(HandleV2APICallAuthorities)
 	prometheus.MustRegister(HandleV2APICallTpsRate)
+	prometheus.MustRegister(HandleV2APICallAddressTxs)
+	prometheus.MustRegister(HandleV2APICallBalancesAtHeight)
+	prometheus.MustRegister(HandleV2APICallSimulateTx)
+	prometheus.MustRegister(HandleV2APICallEntryCost)
+	prometheus.MustRegister(HandleV2APICallDBlockFilter)
 	prometheus.MustRegister(HandleV2APICallAblock)
 	prometheus.MustRegister(HandleV2APICallFblock)
 }
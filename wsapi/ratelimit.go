@@ -0,0 +1,195 @@
+// Copyright 2017 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package wsapi
+
+import (
+	"math"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/FactomProject/factomd/common/interfaces"
+	"github.com/FactomProject/factomd/common/primitives"
+)
+
+// expensiveMethods are the methods that also draw on the expensive budget of a
+// client, as they read many accounts or blocks, or flood the network
+var expensiveMethods = map[string]bool{
+	"multiple-fct-balances": true,
+	"multiple-ec-balances":  true,
+	"balances-at-height":    true,
+	"address-transactions":  true,
+	"factoid-accounts":      true,
+	"dblock-filters":        true,
+	"pending-entries":       true,
+	"pending-transactions":  true,
+	"send-raw-message":      true,
+}
+
+// Reasons a request is rejected, the labels of APIRejectedRequests
+const (
+	RejectUnauthorized  = "unauthorized"
+	RejectForbidden     = "forbidden"
+	RejectIPRate        = "ip_rate"
+	RejectTokenRate     = "token_rate"
+	RejectExpensiveRate = "expensive_rate"
+	RejectBatchSize     = "batch_size"
+)
+
+// ApiLimits are the limits of the config, 0 means no limit
+type ApiLimits struct {
+	PerIP     int // Requests per second from one IP address, without a token
+	PerToken  int // Requests per second with one API token
+	Expensive int // Cost per second of the expensive methods of one client, a request costs its batch size
+	MaxBatch  int // Most addresses (or requests of a batch) in one request
+}
+
+// bucket is a token bucket, that holds up to a second worth of its rate
+type bucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// take takes the cost out of the bucket, or returns how long until it can
+func (b *bucket) take(rate float64, cost float64, now time.Time) (time.Duration, bool) {
+	b.tokens = math.Min(rate, b.tokens+now.Sub(b.last).Seconds()*rate)
+	b.last = now
+	cost = math.Min(cost, rate) // a batch bigger than the budget takes all of it
+	if b.tokens < cost {
+		return time.Duration((cost - b.tokens) / rate * float64(time.Second)), false
+	}
+	b.tokens -= cost
+	return 0, true
+}
+
+// RateLimiter keeps the request rates of the API clients, by IP address or
+// token name
+type RateLimiter struct {
+	lock      sync.Mutex
+	limits    ApiLimits
+	requests  map[string]*bucket
+	expensive map[string]*bucket
+	pruned    time.Time
+}
+
+func NewRateLimiter(limits ApiLimits) *RateLimiter {
+	l := new(RateLimiter)
+	l.limits = limits
+	l.requests = map[string]*bucket{}
+	l.expensive = map[string]*bucket{}
+	return l
+}
+
+// Check returns an error if the client (its token name, or IP address if it has
+// none) can not call the method with a batch of the size now
+func (l *RateLimiter) Check(api string, ip string, token string, method string, batch int, now time.Time) *primitives.JSONError {
+	if err := l.CheckBatch(api, batch); err != nil {
+		return err
+	}
+
+	client, rate, reason := "ip "+ip, l.limits.PerIP, RejectIPRate
+	if token != "" {
+		client, rate, reason = "token "+token, l.limits.PerToken, RejectTokenRate
+	}
+
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	l.prune(now)
+	if rate > 0 {
+		if wait, ok := l.bucket(l.requests, client, rate, now).take(float64(rate), 1, now); !ok {
+			APIRejectedRequests.WithLabelValues(api, reason).Inc()
+			return NewRateLimitError(wait)
+		}
+	}
+	if l.limits.Expensive > 0 && expensiveMethods[method] {
+		cost := float64(batch)
+		if cost < 1 {
+			cost = 1
+		}
+		if wait, ok := l.bucket(l.expensive, client, l.limits.Expensive, now).take(float64(l.limits.Expensive), cost, now); !ok {
+			APIRejectedRequests.WithLabelValues(api, RejectExpensiveRate).Inc()
+			return NewRateLimitError(wait)
+		}
+	}
+	return nil
+}
+
+// CheckBatch returns an error if the batch, of addresses or of requests, is too large
+func (l *RateLimiter) CheckBatch(api string, batch int) *primitives.JSONError {
+	if l.limits.MaxBatch > 0 && batch > l.limits.MaxBatch {
+		APIRejectedRequests.WithLabelValues(api, RejectBatchSize).Inc()
+		return NewBatchTooLargeError(batch, l.limits.MaxBatch)
+	}
+	return nil
+}
+
+func (l *RateLimiter) bucket(buckets map[string]*bucket, client string, rate int, now time.Time) *bucket {
+	b, ok := buckets[client]
+	if !ok {
+		b = &bucket{tokens: float64(rate), last: now}
+		buckets[client] = b
+	}
+	return b
+}
+
+// prune drops the buckets of the clients that have been quiet for a while, as
+// their buckets are full again
+func (l *RateLimiter) prune(now time.Time) {
+	if now.Sub(l.pruned) < time.Minute {
+		return
+	}
+	l.pruned = now
+	for _, buckets := range []map[string]*bucket{l.requests, l.expensive} {
+		for client, b := range buckets {
+			if now.Sub(b.last) > time.Second {
+				delete(buckets, client)
+			}
+		}
+	}
+}
+
+var apiLimiter = NewRateLimiter(ApiLimits{})
+var apiLimiterMutex sync.RWMutex
+
+// SetApiLimits replaces the rate limiter, and with it all the request rates so far
+func SetApiLimits(limits ApiLimits) {
+	apiLimiterMutex.Lock()
+	defer apiLimiterMutex.Unlock()
+	apiLimiter = NewRateLimiter(limits)
+}
+
+func getApiLimiter() *RateLimiter {
+	apiLimiterMutex.RLock()
+	defer apiLimiterMutex.RUnlock()
+	return apiLimiter
+}
+
+func reloadApiLimits(state interfaces.IState) {
+	perIP, perToken, expensive, maxBatch := state.GetApiLimits()
+	SetApiLimits(ApiLimits{PerIP: perIP, PerToken: perToken, Expensive: expensive, MaxBatch: maxBatch})
+}
+
+// limitRequest checks the rate limits of the client of the request
+func limitRequest(r *http.Request, api string, client apiClient, method string, params interface{}) *primitives.JSONError {
+	ip := ""
+	if r != nil {
+		ip = r.RemoteAddr
+		if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+			ip = host
+		}
+	}
+	return getApiLimiter().Check(api, ip, client.Name, method, batchSize(params), time.Now())
+}
+
+// batchSize is the number of addresses in the params of a request
+func batchSize(params interface{}) int {
+	if x, ok := params.(map[string]interface{}); ok {
+		if addresses, ok := x["addresses"].([]interface{}); ok {
+			return len(addresses)
+		}
+	}
+	return 0
+}
@@ -0,0 +1,62 @@
+package wsapi_test
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/FactomProject/factomd/wsapi"
+)
+
+func TestRateLimiter(t *testing.T) {
+	RegisterPrometheus()
+	l := NewRateLimiter(ApiLimits{PerIP: 2, PerToken: 5, Expensive: 10, MaxBatch: 20})
+	now := time.Now()
+
+	// Two requests a second from an IP address
+	for i := 0; i < 2; i++ {
+		if err := l.Check(ApiV2, "10.0.0.1", "", "heights", 0, now); err != nil {
+			t.Fatalf("Request %d was limited: %v", i, err)
+		}
+	}
+	if err := l.Check(ApiV2, "10.0.0.1", "", "heights", 0, now); err == nil || err.Code != -32015 {
+		t.Errorf("Third request was not limited: %v", err)
+	}
+	if err := l.Check(ApiV2, "10.0.0.2", "", "heights", 0, now); err != nil {
+		t.Errorf("Another IP address was limited: %v", err)
+	}
+	if err := l.Check(ApiV2, "10.0.0.1", "", "heights", 0, now.Add(500*time.Millisecond)); err != nil {
+		t.Errorf("Request half a second later was limited: %v", err)
+	}
+
+	// A token has its own, higher limit
+	for i := 0; i < 5; i++ {
+		if err := l.Check(ApiV2, "10.0.0.1", "explorer", "heights", 0, now); err != nil {
+			t.Fatalf("Token request %d was limited: %v", i, err)
+		}
+	}
+	if err := l.Check(ApiV2, "10.0.0.1", "explorer", "heights", 0, now); err == nil {
+		t.Error("Token request over the limit was not limited")
+	}
+
+	// Expensive requests cost their batch size
+	if err := l.Check(ApiV2, "10.0.0.3", "", "multiple-fct-balances", 21, now); err == nil || err.Code != -32016 {
+		t.Errorf("Batch over the max was not rejected: %v", err)
+	}
+	if err := l.Check(ApiV2, "10.0.0.3", "", "multiple-fct-balances", 8, now); err != nil {
+		t.Errorf("Expensive request was limited: %v", err)
+	}
+	if err := l.Check(ApiV2, "10.0.0.3", "", "multiple-ec-balances", 8, now); err == nil || err.Code != -32015 {
+		t.Errorf("Expensive request over the budget was not limited: %v", err)
+	}
+	if err := l.Check(ApiV2, "10.0.0.3", "", "multiple-ec-balances", 8, now.Add(time.Second)); err != nil {
+		t.Errorf("Expensive request a second later was limited: %v", err)
+	}
+
+	// No limits
+	l = NewRateLimiter(ApiLimits{})
+	for i := 0; i < 1000; i++ {
+		if err := l.Check(ApiV2, "10.0.0.1", "", "send-raw-message", 1000, now); err != nil {
+			t.Fatalf("Request %d was limited without limits: %v", i, err)
+		}
+	}
+}
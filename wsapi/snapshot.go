@@ -0,0 +1,59 @@
+// Copyright 2017 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package wsapi
+
+import (
+	"github.com/FactomProject/factomd/common/interfaces"
+)
+
+// snapshotState is the state a V2 request is served with.  Its database is a
+// snapshot taken when the request came in, so every block the request reads is
+// from the same saved height, even while the node saves the next one.
+type snapshotState struct {
+	interfaces.IState
+	db   interfaces.DBOverlaySimple
+	head interfaces.IDirectoryBlock // the highest saved directory block in the snapshot
+}
+
+// newSnapshotState takes a snapshot of the state's database, which must be
+// released once the request is served
+func newSnapshotState(state interfaces.IState) (*snapshotState, error) {
+	db, err := state.GetDB().Snapshot()
+	if err != nil {
+		return nil, err
+	}
+	head, err := db.FetchDBlockHead()
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &snapshotState{IState: state, db: db, head: head}, nil
+}
+
+func (s *snapshotState) GetDB() interfaces.DBOverlaySimple {
+	return s.db
+}
+
+// GetHighestSavedBlk is the height of the snapshot, so the heights a request
+// reports match the blocks it reads
+func (s *snapshotState) GetHighestSavedBlk() uint32 {
+	if s.head == nil {
+		return s.IState.GetHighestSavedBlk()
+	}
+	return s.head.GetDatabaseHeight()
+}
+
+// Height is the height the request was served at, or nil for an empty database
+func (s *snapshotState) Height() *uint32 {
+	if s.head == nil {
+		return nil
+	}
+	height := s.head.GetDatabaseHeight()
+	return &height
+}
+
+func (s *snapshotState) Release() {
+	s.db.Close()
+}
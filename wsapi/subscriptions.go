@@ -0,0 +1,230 @@
+// Copyright 2017 Factom Foundation
+// Use of this source code is governed by the MIT
+// license that can be found in the LICENSE file.
+
+package wsapi
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/FactomProject/factomd/common/interfaces"
+	"github.com/FactomProject/factomd/common/primitives"
+	"github.com/FactomProject/factomd/subscriptions"
+	"github.com/FactomProject/web"
+	"golang.org/x/net/websocket"
+)
+
+// The websocket API at /ws pushes events instead of having clients poll.  Requests
+// are JSON-RPC 2.0 like on /v2:
+//
+//   {"jsonrpc": "2.0", "id": 1, "method": "subscribe", "params": {"topic": "entry", "filters": ["<chainid>"]}}
+//   {"jsonrpc": "2.0", "id": 2, "method": "unsubscribe", "params": {"topic": "entry"}}
+//
+// Events come as notifications (no id):
+//
+//   {"jsonrpc": "2.0", "method": "event", "params": {"topic": "entry", "data": {...}}}
+//
+// The topics and their data are in the subscriptions package.  Events are not held
+// up for a client reading slower than they are published: the ones that do not fit
+// its queue are dropped, and it is sent a notice of what it missed and the lowest
+// height of it:
+//
+//   {"jsonrpc": "2.0", "method": "dropped", "params": {"topics": {"entry": 12}, "from": 1234}}
+//
+// Subscribing again with "from": 1234 replays the events of the saved blocks from
+// that height on (up to SubscriberMaxReplay blocks), mixed in with the live ones.
+// Events of the blocks saved meanwhile may come twice.
+
+var (
+	SubscriberQueueSize    = subscriptions.DefaultQueueSize
+	SubscriberWriteTimeout = 10 * time.Second
+	SubscriberMaxReplay    = 1000 // Blocks a subscriber can catch up on with "from"
+)
+
+type SubscribeRequest struct {
+	Topic   string   `json:"topic"`
+	Filters []string `json:"filters,omitempty"`
+	From    *uint32  `json:"from,omitempty"` // Replay the saved blocks from this height on
+}
+
+type SubscribeResponse struct {
+	Topic   string   `json:"topic"`
+	Filters []string `json:"filters"`
+}
+
+// EventNotification is a JSON-RPC notification, so it has no id
+type EventNotification struct {
+	JSONRPC string      `json:"jsonrpc"`
+	Method  string      `json:"method"`
+	Params  interface{} `json:"params,omitempty"`
+}
+
+// subscriptionServer answers websocket connections for whichever state the server
+// is currently set to (see SetState)
+func subscriptionServer(server *web.Server) http.Handler {
+	return websocket.Server{
+		Handshake: func(config *websocket.Config, r *http.Request) error {
+			ServersMutex.Lock()
+			state := server.Env["state"].(interfaces.IState)
+			ServersMutex.Unlock()
+			if err := checkAuthHeader(state, r); err != nil {
+				remoteIP := r.RemoteAddr
+				if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+					remoteIP = host
+				}
+				fmt.Printf("Unauthorized websocket API client connection attempt from %s\n", remoteIP)
+				return err
+			}
+			return nil
+		},
+		Handler: func(ws *websocket.Conn) {
+			ServersMutex.Lock()
+			state := server.Env["state"].(interfaces.IState)
+			ServersMutex.Unlock()
+			hub, _ := state.GetSubscriptions().(*subscriptions.Hub)
+			ServeSubscriptions(hub, state.GetDB(), ws)
+		},
+	}
+}
+
+// ServeSubscriptions runs one websocket client until it hangs up.  Missed events
+// are replayed from the database.
+func ServeSubscriptions(hub *subscriptions.Hub, db interfaces.DBOverlaySimple, ws *websocket.Conn) {
+	defer ws.Close()
+	if hub == nil {
+		return
+	}
+	sub := hub.NewSubscriber(SubscriberQueueSize)
+	defer hub.Remove(sub)
+	WebsocketSubscribers.Inc()
+	defer WebsocketSubscribers.Dec()
+
+	// Only this goroutine writes, the reader hands its responses (and replayed
+	// events) over
+	responses := make(chan interface{}, 10)
+	readerDone := make(chan struct{})
+	go func() {
+		defer close(readerDone)
+		for {
+			req := new(primitives.JSON2Request)
+			if err := websocket.JSON.Receive(ws, req); err != nil {
+				return
+			}
+			resp, replay := handleSubscriptionRequest(sub, db, req)
+			for _, out := range append([]interface{}{resp}, replay...) {
+				select {
+				case responses <- out:
+				case <-sub.Done():
+					return
+				}
+			}
+		}
+	}()
+
+	send := func(v interface{}) bool {
+		ws.SetWriteDeadline(time.Now().Add(SubscriberWriteTimeout))
+		return websocket.JSON.Send(ws, v) == nil
+	}
+	for {
+		select {
+		case resp := <-responses:
+			if !send(resp) {
+				return
+			}
+		case e := <-sub.Events():
+			if !send(newEventNotification("event", e)) {
+				return
+			}
+			if dropped := sub.TakeDropped(); dropped != nil {
+				WebsocketSlowConsumers.Inc()
+				if !send(newEventNotification("dropped", dropped)) {
+					return
+				}
+			}
+		case <-sub.Done():
+			return
+		case <-readerDone:
+			return
+		}
+	}
+}
+
+func newEventNotification(method string, params interface{}) *EventNotification {
+	n := new(EventNotification)
+	n.JSONRPC = "2.0"
+	n.Method = method
+	n.Params = params
+	return n
+}
+
+// handleSubscriptionRequest answers the request, and returns the events to replay
+// for a subscribe "from" a height
+func handleSubscriptionRequest(sub *subscriptions.Subscriber, db interfaces.DBOverlaySimple, j *primitives.JSON2Request) (*primitives.JSON2Response, []interface{}) {
+	resp := primitives.NewJSON2Response()
+	resp.ID = j.ID
+
+	req := new(SubscribeRequest)
+	if err := MapToObject(j.Params, req); err != nil {
+		resp.Error = NewInvalidParamsError()
+		return resp, nil
+	}
+	var replay []interface{}
+	switch j.Method {
+	case "subscribe":
+		if err := sub.Subscribe(req.Topic, req.Filters...); err != nil {
+			resp.Error = NewCustomInvalidParamsError(err.Error())
+			return resp, nil
+		}
+		if req.From != nil {
+			var err error
+			if replay, err = replayEvents(sub, db, req.Topic, *req.From); err != nil {
+				resp.Error = NewCustomInvalidParamsError(err.Error())
+				return resp, nil
+			}
+		}
+	case "unsubscribe":
+		sub.Unsubscribe(req.Topic)
+	default:
+		resp.Error = NewMethodNotFoundError()
+		return resp, nil
+	}
+	if req.Filters == nil {
+		req.Filters = []string{}
+	}
+	resp.Result = &SubscribeResponse{Topic: req.Topic, Filters: req.Filters}
+	return resp, replay
+}
+
+// replayEvents builds the events of the topic in the blocks saved from the height on
+func replayEvents(sub *subscriptions.Subscriber, db interfaces.DBOverlaySimple, topic string, from uint32) ([]interface{}, error) {
+	if db == nil {
+		return nil, fmt.Errorf("no database to replay from")
+	}
+	head, err := db.FetchDBlockHead()
+	if err != nil || head == nil {
+		return nil, fmt.Errorf("no blocks to replay")
+	}
+	top := head.GetDatabaseHeight()
+	if from > top {
+		return nil, nil
+	}
+	if top-from >= uint32(SubscriberMaxReplay) {
+		return nil, fmt.Errorf("can replay at most %d blocks, catch up over /v2 first", SubscriberMaxReplay)
+	}
+	var replay []interface{}
+	for height := from; height <= top; height++ {
+		events, err := subscriptions.SavedEvents(db, height, topic)
+		if err != nil {
+			return nil, err
+		}
+		for _, e := range events {
+			if sub.Wants(e) {
+				replay = append(replay, newEventNotification("event", e))
+			}
+		}
+	}
+	return replay, nil
+}
@@ -0,0 +1,150 @@
+package wsapi_test
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/FactomProject/factomd/common/interfaces"
+	"github.com/FactomProject/factomd/subscriptions"
+	"github.com/FactomProject/factomd/testHelper"
+	. "github.com/FactomProject/factomd/wsapi"
+	"golang.org/x/net/websocket"
+)
+
+type wsMessage struct {
+	ID     interface{}            `json:"id"`
+	Method string                 `json:"method"`
+	Result map[string]interface{} `json:"result"`
+	Error  map[string]interface{} `json:"error"`
+	Params map[string]interface{} `json:"params"`
+}
+
+func dialSubscriptions(t *testing.T, hub *subscriptions.Hub, db interfaces.DBOverlaySimple) *websocket.Conn {
+	server := httptest.NewServer(websocket.Server{Handler: func(ws *websocket.Conn) {
+		ServeSubscriptions(hub, db, ws)
+	}})
+	url := "ws" + strings.TrimPrefix(server.URL, "http")
+	ws, err := websocket.Dial(url, "", server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ws.SetDeadline(time.Now().Add(5 * time.Second))
+	return ws
+}
+
+func TestServeSubscriptions(t *testing.T) {
+	hub := subscriptions.NewHub()
+	ws := dialSubscriptions(t, hub, nil)
+	defer ws.Close()
+
+	send := func(req string) *wsMessage {
+		if err := websocket.Message.Send(ws, req); err != nil {
+			t.Fatal(err)
+		}
+		resp := new(wsMessage)
+		if err := websocket.JSON.Receive(ws, resp); err != nil {
+			t.Fatal(err)
+		}
+		return resp
+	}
+
+	resp := send(`{"jsonrpc": "2.0", "id": 1, "method": "subscribe", "params": {"topic": "entry", "filters": ["aa"]}}`)
+	if resp.Error != nil || resp.Result["topic"] != "entry" {
+		t.Fatalf("Subscribe failed: %v", resp)
+	}
+	resp = send(`{"jsonrpc": "2.0", "id": 2, "method": "subscribe", "params": {"topic": "blocks"}}`)
+	if resp.Error == nil {
+		t.Error("Subscribed to an unknown topic")
+	}
+	resp = send(`{"jsonrpc": "2.0", "id": 3, "method": "listen", "params": {"topic": "entry"}}`)
+	if resp.Error == nil {
+		t.Error("Unknown method accepted")
+	}
+
+	hub.Publish(&subscriptions.Event{Topic: subscriptions.TopicEntry, Keys: []string{"bb"}, Data: "skipped"})
+	hub.Publish(&subscriptions.Event{Topic: subscriptions.TopicEntry, Keys: []string{"aa"}, Data: "wanted"})
+	event := new(wsMessage)
+	if err := websocket.JSON.Receive(ws, event); err != nil {
+		t.Fatal(err)
+	}
+	if event.Method != "event" || event.ID != nil || event.Params["topic"] != "entry" || event.Params["data"] != "wanted" {
+		t.Errorf("Got the wrong event: %v", event)
+	}
+
+	resp = send(`{"jsonrpc": "2.0", "id": 4, "method": "unsubscribe", "params": {"topic": "entry"}}`)
+	if resp.Error != nil || hub.HasSubscribers(subscriptions.TopicEntry) {
+		t.Errorf("Unsubscribe failed: %v", resp)
+	}
+}
+
+func TestServeSubscriptionsSlowConsumer(t *testing.T) {
+	hub := subscriptions.NewHub()
+	ws := dialSubscriptions(t, hub, nil)
+	defer ws.Close()
+
+	if err := websocket.Message.Send(ws, `{"jsonrpc": "2.0", "id": 1, "method": "subscribe", "params": {"topic": "minute"}}`); err != nil {
+		t.Fatal(err)
+	}
+	resp := new(wsMessage)
+	if err := websocket.JSON.Receive(ws, resp); err != nil {
+		t.Fatal(err)
+	}
+
+	// Publish far more than the queue holds without reading any of it
+	for i := 0; i < SubscriberQueueSize*10; i++ {
+		hub.Publish(&subscriptions.Event{Topic: subscriptions.TopicMinute, Data: &subscriptions.MinuteEvent{Height: uint32(i)}})
+	}
+	if hub.Subscribers() != 1 {
+		t.Fatal("Slow consumer was removed")
+	}
+
+	for {
+		msg := new(wsMessage)
+		if err := websocket.JSON.Receive(ws, msg); err != nil {
+			t.Fatal("No notice of the dropped events")
+		}
+		if msg.Method == "dropped" {
+			topics, _ := msg.Params["topics"].(map[string]interface{})
+			if topics["minute"] == nil || msg.Params["from"] == nil {
+				t.Errorf("Wrong dropped notice: %v", msg.Params)
+			}
+			break
+		}
+	}
+}
+
+func TestServeSubscriptionsReplay(t *testing.T) {
+	state := testHelper.CreateAndPopulateTestState()
+	hub := subscriptions.NewHub()
+	ws := dialSubscriptions(t, hub, state.GetDB())
+	defer ws.Close()
+
+	head, err := state.GetDB().FetchDBlockHead()
+	if err != nil || head == nil {
+		t.Fatal("No blocks in the test database", err)
+	}
+	top := head.GetDatabaseHeight()
+
+	if err := websocket.Message.Send(ws, `{"jsonrpc": "2.0", "id": 1, "method": "subscribe", "params": {"topic": "dblock", "from": 1}}`); err != nil {
+		t.Fatal(err)
+	}
+	resp := new(wsMessage)
+	if err := websocket.JSON.Receive(ws, resp); err != nil {
+		t.Fatal(err)
+	}
+	if resp.Error != nil {
+		t.Fatalf("Subscribe failed: %v", resp.Error)
+	}
+	for height := uint32(1); height <= top; height++ {
+		event := new(wsMessage)
+		if err := websocket.JSON.Receive(ws, event); err != nil {
+			t.Fatal(err)
+		}
+		data, _ := event.Params["data"].(map[string]interface{})
+		if event.Method != "event" || data["height"] != float64(height) {
+			t.Fatalf("Expected the block at height %d, got %v", height, event)
+		}
+	}
+}
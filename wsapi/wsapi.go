@@ -6,11 +6,9 @@ package wsapi
 
 import (
 	"crypto/sha256"
-	"crypto/subtle"
 	"crypto/tls"
 	"encoding/base64"
 	"encoding/json"
-	"errors"
 	"fmt"
 	"io/ioutil"
 	"net/http"
@@ -50,6 +48,10 @@ func Start(state interfaces.IState) {
 	h := sha256.New()
 	h.Write(httpBasicAuth(rpcUser, rpcPass))
 	state.SetRpcAuthHash(h.Sum(nil)) //set this in the beginning to prevent timing attacks
+	if err := reloadApiTokens(state); err != nil {
+		panic(fmt.Sprintf("could not load the API tokens with error: %v", err))
+	}
+	reloadApiLimits(state)
 
 	if Servers[state.GetPort()] == nil {
 		server = web.NewServer()
@@ -85,6 +87,7 @@ func Start(state interfaces.IState) {
 
 		server.Post("/v2", HandleV2)
 		server.Get("/v2", HandleV2)
+		server.Handle("/ws", "GET", subscriptionServer(server))
 
 		// start the debugging api if we are not on the main network
 		if state.GetNetworkName() != "MAIN" {
@@ -860,27 +863,8 @@ func httpBasicAuth(username, password string) []byte {
 }
 
 func checkAuthHeader(state interfaces.IState, r *http.Request) error {
-	if "" == state.GetRpcUser() {
-		//no username was specified in the config file or command line, meaning factomd API is open access
-		return nil
-	}
-
-	authhdr := r.Header["Authorization"]
-	if len(authhdr) == 0 {
-		return errors.New("no auth")
-	}
-
-	correctAuth := state.GetRpcAuthHash()
-
-	h := sha256.New()
-	h.Write([]byte(authhdr[0]))
-	presentedPassHash := h.Sum(nil)
-
-	cmp := subtle.ConstantTimeCompare(presentedPassHash, correctAuth) //compare hashes because ConstantTimeCompare takes a constant time based on the slice size.  hashing gives a constant slice size.
-	if cmp != 1 {
-		return errors.New("bad auth")
-	}
-	return nil
+	_, err := authenticate(state, r)
+	return err
 }
 
 func checkHttpPasswordOkV1(state interfaces.IState, ctx *web.Context) bool {
@@ -892,6 +876,20 @@ func checkHttpPasswordOkV1(state interfaces.IState, ctx *web.Context) bool {
 		http.Error(ctx.ResponseWriter, "401 Unauthorized.", http.StatusUnauthorized)
 		return false
 	}
+	// The V1 endpoints are named like the V2 methods, eg /v1/factoid-submit/
+	method := ""
+	if ctx.Request != nil && ctx.Request.URL != nil {
+		method = strings.Split(strings.TrimPrefix(ctx.Request.URL.Path, "/v1/"), "/")[0]
+	}
+	client, err := authorize(state, ctx.Request, ApiV1, method)
+	if err != nil {
+		http.Error(ctx.ResponseWriter, "403 Forbidden.", http.StatusForbidden)
+		return false
+	}
+	if err := limitRequest(ctx.Request, ApiV1, client, method, nil); err != nil {
+		http.Error(ctx.ResponseWriter, "429 Too Many Requests.", http.StatusTooManyRequests)
+		return false
+	}
 	return true
 }
 
@@ -85,6 +85,11 @@ type ReceiptResponse struct {
 	Receipt *receipts.Receipt `json:"receipt"`
 }
 
+type TransactionReceiptResponse struct {
+	Receipt *receipts.TransactionReceipt `json:"receipt"`
+	RawData string                       `json:"rawdata"`
+}
+
 type EntryBlockResponse struct {
 	Header struct {
 		BlockSequenceNumber int64  `json:"blocksequencenumber"`
@@ -138,6 +143,113 @@ type SendRawMessageResponse struct {
 	Message string `json:"message"`
 }
 
+type AddressTransactionsResponse struct {
+	Address      string                           `json:"address"`
+	StartHeight  int64                            `json:"startheight"`
+	EndHeight    int64                            `json:"endheight"`
+	NextHeight   int64                            `json:"nextheight,omitempty"`
+	Transactions []*interfaces.AddressTransaction `json:"transactions"`
+}
+
+// DBlockFilterResponse holds the compact filter of one directory block.  Filter is
+// the serialized filter (N as a CompactSize, then the Golomb-Rice coded set), and
+// the SipHash key is the first 16 bytes of the KeyMR.
+type DBlockFilterResponse struct {
+	Height int64  `json:"height"`
+	KeyMR  string `json:"keymr"`
+	P      int    `json:"p"`
+	M      int    `json:"m"`
+	N      uint32 `json:"n"`
+	Filter string `json:"filter"`
+}
+
+type BalancesAtHeightResponse struct {
+	Height   int64                      `json:"height"`
+	Balances []*BalanceAtHeightResponse `json:"balances"`
+}
+
+type BalanceAtHeightResponse struct {
+	Address string `json:"address"`
+	Balance int64  `json:"balance"`
+	Error   string `json:"error,omitempty"`
+}
+
+type SimulateTransactionResponse struct {
+	TxID           string                `json:"txid"`
+	Valid          bool                  `json:"valid"`
+	Error          string                `json:"error,omitempty"`
+	FactoshisPerEC uint64                `json:"factoshisperec"`
+	Fee            uint64                `json:"fee"`
+	PaidFee        int64                 `json:"paidfee"`
+	TotalInputs    uint64                `json:"totalinputs"`
+	TotalOutputs   uint64                `json:"totaloutputs"`
+	TotalECOutputs uint64                `json:"totalecoutputs"`
+	Inputs         []*SimulatedInput     `json:"inputs"`
+	Outputs        []*SimulatedOutput    `json:"outputs"`
+	ECOutputs      []*SimulatedOutput    `json:"ecoutputs"`
+	Signatures     []*SimulatedSignature `json:"signatures"`
+}
+
+type SimulatedInput struct {
+	Address    string `json:"address"`
+	Amount     uint64 `json:"amount"`
+	Balance    int64  `json:"balance"`
+	Sufficient bool   `json:"sufficient"`
+}
+
+type SimulatedOutput struct {
+	Address string `json:"address"`
+	Amount  uint64 `json:"amount"`
+	Balance int64  `json:"balance"`
+}
+
+type SimulatedSignature struct {
+	Input      int    `json:"input"`
+	RCDType    int    `json:"rcdtype"`
+	Signatures int    `json:"signatures"`
+	Valid      bool   `json:"valid"`
+	Error      string `json:"error,omitempty"`
+}
+
+type EntryCostResponse struct {
+	EntryHash    string `json:"entryhash"`
+	ChainID      string `json:"chainid"`
+	Size         int    `json:"size"`
+	EntryCredits int    `json:"entrycredits"`
+}
+
+type PendingCommitResponse struct {
+	EntryHash      string `json:"entryhash"`
+	Pending        bool   `json:"pending"`
+	Type           string `json:"type,omitempty"`
+	TxID           string `json:"txid,omitempty"`
+	ECPubKey       string `json:"ecpubkey,omitempty"`
+	Credits        int    `json:"credits"`
+	Timestamp      int64  `json:"timestamp,omitempty"`
+	Expires        int64  `json:"expires,omitempty"`
+	Required       int    `json:"required,omitempty"`
+	Supersedes     bool   `json:"supersedes"`
+	Revealed       bool   `json:"revealed"`
+	RevealPossible bool   `json:"revealpossible"`
+	Reason         string `json:"reason,omitempty"`
+}
+
+type EstimateFeeResponse struct {
+	FactoshisPerEC uint64 `json:"factoshisperec"`
+	Fee            uint64 `json:"fee"`
+	EntryCredits   uint64 `json:"entrycredits"`
+	Size           int    `json:"size"`
+	Signatures     int    `json:"signatures"`
+	AssumedRCDs    int    `json:"assumedrcds"`
+}
+
+type DBlockFiltersResponse struct {
+	StartHeight int64                   `json:"startheight"`
+	EndHeight   int64                   `json:"endheight"`
+	NextHeight  int64                   `json:"nextheight,omitempty"`
+	Filters     []*DBlockFilterResponse `json:"filters"`
+}
+
 type TransactionRateResponse struct {
 	TotalTransactionRate   float64 `json:"totaltxrate"`
 	InstantTransactionRate float64 `json:"instanttxrate"`
@@ -253,6 +365,12 @@ type HashRequest struct {
 	Hash string `json:"hash"`
 }
 
+type PendingCommitRequest struct {
+	Hash   string `json:"hash"`
+	Entry  string `json:"entry"`
+	Commit string `json:"commit"`
+}
+
 type KeyMRRequest struct {
 	KeyMR string `json:"keymr"`
 }
@@ -276,6 +394,23 @@ type PendingTransaction struct {
 	Status        string           `json:"status"`
 }
 
+type AddressTransactionsRequest struct {
+	Address     string `json:"address"`
+	StartHeight int64  `json:"startheight"`
+	EndHeight   int64  `json:"endheight"`
+	Limit       int    `json:"limit"`
+}
+
+type BalancesAtHeightRequest struct {
+	Addresses []string `json:"addresses"`
+	Height    int64    `json:"height"`
+}
+
+type DBlockFiltersRequest struct {
+	StartHeight int64 `json:"startheight"`
+	EndHeight   int64 `json:"endheight"`
+}
+
 type TransactionRequest struct {
 	Transaction string `json:"transaction"`
 }
@@ -15,6 +15,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/FactomProject/factomd/common/compactFilter"
 	"github.com/FactomProject/factomd/common/constants"
 	"github.com/FactomProject/factomd/common/entryBlock"
 	"github.com/FactomProject/factomd/common/entryCreditBlock"
@@ -23,6 +24,7 @@ import (
 	"github.com/FactomProject/factomd/common/messages"
 	"github.com/FactomProject/factomd/common/primitives"
 	"github.com/FactomProject/factomd/receipts"
+	"github.com/FactomProject/factomd/util"
 	"github.com/FactomProject/web"
 )
 
@@ -51,12 +53,26 @@ func HandleV2(ctx *web.Context) {
 		return
 	}
 
+	if trimmed := strings.TrimSpace(string(body)); strings.HasPrefix(trimmed, "[") {
+		HandleV2Batch(ctx, state, []byte(trimmed))
+		return
+	}
+
 	j, err := primitives.ParseJSON2Request(string(body))
 	if err != nil {
 		HandleV2Error(ctx, nil, NewInvalidRequestError())
 		return
 	}
 
+	client, jsonError := authorize(state, ctx.Request, ApiV2, j.Method)
+	if jsonError == nil {
+		jsonError = limitRequest(ctx.Request, ApiV2, client, j.Method, j.Params)
+	}
+	if jsonError != nil {
+		HandleV2Error(ctx, j, jsonError)
+		return
+	}
+
 	jsonResp, jsonError := HandleV2Request(state, j)
 
 	if jsonError != nil {
@@ -67,7 +83,99 @@ func HandleV2(ctx *web.Context) {
 	ctx.Write([]byte(jsonResp.String()))
 }
 
+// HandleV2Batch serves a JSON-RPC 2.0 batch, an array of requests.  It answers
+// with an array of their responses in the same order, each with its result or
+// error, unless the batch itself is invalid.  Notifications, requests without an
+// id, are served but get no response, and a batch of only notifications gets
+// nothing back at all.
+func HandleV2Batch(ctx *web.Context, state interfaces.IState, body []byte) {
+	var batch []json.RawMessage
+	if err := json.Unmarshal(body, &batch); err != nil {
+		HandleV2Error(ctx, nil, NewParseError())
+		return
+	}
+	if len(batch) == 0 {
+		HandleV2Error(ctx, nil, NewInvalidRequestError())
+		return
+	}
+	if jsonError := getApiLimiter().CheckBatch(ApiV2, len(batch)); jsonError != nil {
+		HandleV2Error(ctx, nil, jsonError)
+		return
+	}
+
+	responses := make([]*primitives.JSON2Response, 0, len(batch))
+	for _, raw := range batch {
+		if resp := handleV2BatchElement(ctx.Request, state, raw); resp != nil {
+			responses = append(responses, resp)
+		}
+	}
+	if len(responses) == 0 {
+		return
+	}
+
+	data, err := json.Marshal(responses)
+	if err != nil {
+		HandleV2Error(ctx, nil, NewInternalError())
+		return
+	}
+	ctx.Write(data)
+}
+
+// handleV2BatchElement serves one request of a batch, which is authorized and
+// rate limited like a request on its own.  It returns nil for a notification.
+func handleV2BatchElement(r *http.Request, state interfaces.IState, raw json.RawMessage) *primitives.JSON2Response {
+	j, err := primitives.ParseJSON2Request(string(raw))
+	if err != nil {
+		resp := primitives.NewJSON2Response()
+		resp.Error = NewInvalidRequestError()
+		return resp
+	}
+	notification := isNotification(raw)
+
+	client, jsonError := authorize(state, r, ApiV2, j.Method)
+	if jsonError == nil {
+		jsonError = limitRequest(r, ApiV2, client, j.Method, j.Params)
+	}
+	var jsonResp *primitives.JSON2Response
+	if jsonError == nil {
+		jsonResp, jsonError = HandleV2Request(state, j)
+	}
+	if jsonError != nil {
+		jsonResp = primitives.NewJSON2Response()
+		jsonResp.ID = j.ID
+		jsonResp.Error = jsonError
+	}
+	if notification {
+		return nil
+	}
+	return jsonResp
+}
+
+// isNotification returns true if the request has no id member.  A request with
+// an id of null is not a notification, and gets a response.
+func isNotification(raw json.RawMessage) bool {
+	var members map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &members); err != nil {
+		return false
+	}
+	_, ok := members["id"]
+	return !ok
+}
+
 func HandleV2Request(state interfaces.IState, j *primitives.JSON2Request) (*primitives.JSON2Response, *primitives.JSONError) {
+	// Serve the whole request from one snapshot of the database, so it does not
+	// see a block that is only partly saved
+	var snap *snapshotState
+	if state.GetDB() != nil {
+		var err error
+		snap, err = newSnapshotState(state)
+		if err != nil {
+			return nil, NewInternalDatabaseError()
+		}
+		defer snap.Release()
+		state = snap
+	}
+
 	var resp interface{}
 	var jsonError *primitives.JSONError
 	params := j.Params
@@ -129,6 +237,9 @@ func HandleV2Request(state interfaces.IState, j *primitives.JSON2Request) (*prim
 	case "receipt":
 		resp, jsonError = HandleV2Receipt(state, params)
 		break
+	case "transaction-receipt":
+		resp, jsonError = HandleV2TransactionReceipt(state, params)
+		break
 	case "reveal-chain":
 		resp, jsonError = HandleV2RevealChain(state, params)
 		break
@@ -175,6 +286,24 @@ func HandleV2Request(state interfaces.IState, j *primitives.JSON2Request) (*prim
 		resp, jsonError = HandleV2MultipleFCTBalances(state, params)
 	case "multiple-ec-balances":
 		resp, jsonError = HandleV2MultipleECBalances(state, params)
+	case "address-transactions":
+		resp, jsonError = HandleV2AddressTransactions(state, params)
+	case "balances-at-height":
+		resp, jsonError = HandleV2BalancesAtHeight(state, params)
+	case "dblock-filter":
+		resp, jsonError = HandleV2DBlockFilter(state, params)
+	case "dblock-filters":
+		resp, jsonError = HandleV2DBlockFilters(state, params)
+	case "simulate-transaction":
+		resp, jsonError = HandleV2SimulateTransaction(state, params)
+	case "estimate-fee":
+		resp, jsonError = HandleV2EstimateFee(state, params)
+	case "entry-cost":
+		resp, jsonError = HandleV2EntryCost(state, params)
+	case "chain-cost":
+		resp, jsonError = HandleV2ChainCost(state, params)
+	case "pending-commit":
+		resp, jsonError = HandleV2PendingCommit(state, params)
 		//case "factoid-accounts":
 		// resp, jsonError = HandleV2Accounts(state, params)
 	default:
@@ -188,6 +317,9 @@ func HandleV2Request(state interfaces.IState, j *primitives.JSON2Request) (*prim
 	jsonResp := primitives.NewJSON2Response()
 	jsonResp.ID = j.ID
 	jsonResp.Result = resp
+	if snap != nil {
+		jsonResp.Height = snap.Height()
+	}
 
 	return jsonResp, nil
 }
@@ -720,6 +852,8 @@ func HandleV2RawData(state interfaces.IState, params interface{}) (interface{},
 			b, _ = block.MarshalBinary()
 		} else if block, _ = dbase.FetchEntry(h); block != nil {
 			b, _ = block.MarshalBinary()
+		} else if state.IsEntryPruned(h) {
+			return nil, NewEntryPrunedError()
 		} else {
 			return nil, NewObjectNotFoundError()
 		}
@@ -757,6 +891,38 @@ func HandleV2Receipt(state interfaces.IState, params interface{}) (interface{},
 	return resp, nil
 }
 
+func HandleV2TransactionReceipt(state interfaces.IState, params interface{}) (interface{}, *primitives.JSONError) {
+	n := time.Now()
+	defer HandleV2APICallTxReceipt.Observe(float64(time.Since(n).Nanoseconds()))
+
+	hashkey := new(HashRequest)
+	err := MapToObject(params, hashkey)
+	if err != nil {
+		return nil, NewInvalidParamsError()
+	}
+
+	h, err := primitives.HexToHash(hashkey.Hash)
+	if err != nil {
+		return nil, NewInvalidHashError()
+	}
+
+	dbase := state.GetDB()
+
+	receipt, err := receipts.CreateTransactionReceipt(dbase, h)
+	if err != nil {
+		return nil, NewReceiptError()
+	}
+	raw, err := receipt.MarshalBinary()
+	if err != nil {
+		return nil, NewInternalError()
+	}
+	resp := new(TransactionReceiptResponse)
+	resp.Receipt = receipt
+	resp.RawData = hex.EncodeToString(raw)
+
+	return resp, nil
+}
+
 func HandleV2DirectoryBlock(state interfaces.IState, params interface{}) (interface{}, *primitives.JSONError) {
 	n := time.Now()
 	defer HandleV2APICallDBlock.Observe(float64(time.Since(n).Nanoseconds()))
@@ -895,6 +1061,9 @@ func HandleV2Entry(state interfaces.IState, params interface{}) (interface{}, *p
 			return nil, NewInvalidHashError()
 		}
 		if entry == nil {
+			if state.IsEntryPruned(h) {
+				return nil, NewEntryPrunedError()
+			}
 			return nil, NewEntryNotFoundError()
 		}
 	}
@@ -1380,6 +1549,558 @@ func HandleV2MultipleFCTBalances(state interfaces.IState, params interface{}) (i
 	return h, nil
 }
 
+// Most transactions returned by one address-transactions call.  A page is only
+// cut between heights, so a single busy height can go over the limit.
+const MaxAddressTransactionsPerPage = 1000
+
+func HandleV2AddressTransactions(state interfaces.IState, params interface{}) (interface{}, *primitives.JSONError) {
+	n := time.Now()
+	defer HandleV2APICallAddressTxs.Observe(float64(time.Since(n).Nanoseconds()))
+
+	req := new(AddressTransactionsRequest)
+	err := MapToObject(params, req)
+	if err != nil {
+		return nil, NewInvalidParamsError()
+	}
+
+	dbase := state.GetDB()
+
+	head, err := dbase.FetchDBlockHead()
+	if err != nil {
+		return nil, NewInternalDatabaseError()
+	}
+	if head == nil {
+		return nil, NewBlockNotFoundError()
+	}
+	headHeight := int64(head.GetDatabaseHeight())
+	if req.EndHeight == 0 || req.EndHeight > headHeight {
+		req.EndHeight = headHeight
+	}
+	if req.StartHeight < 0 || req.StartHeight > req.EndHeight {
+		return nil, NewCustomInvalidParamsError("Invalid height range")
+	}
+	if req.Limit <= 0 || req.Limit > MaxAddressTransactionsPerPage {
+		req.Limit = MaxAddressTransactionsPerPage
+	}
+
+	var txs []*interfaces.AddressTransaction
+	switch {
+	case primitives.ValidateFUserStr(req.Address):
+		adr := primitives.NewHash(primitives.ConvertUserStrToAddress(req.Address))
+		txs, err = dbase.FetchFactoidAddressTransactions(adr, uint32(req.StartHeight), uint32(req.EndHeight), req.Limit)
+	case primitives.ValidateECUserStr(req.Address):
+		adr := primitives.NewHash(primitives.ConvertUserStrToAddress(req.Address))
+		txs, err = dbase.FetchECAddressTransactions(adr, uint32(req.StartHeight), uint32(req.EndHeight), req.Limit)
+	default:
+		return nil, NewInvalidAddressError()
+	}
+	if err != nil {
+		return nil, NewInternalDatabaseError()
+	}
+
+	resp := new(AddressTransactionsResponse)
+	resp.Address = req.Address
+	resp.StartHeight = req.StartHeight
+	resp.EndHeight = req.EndHeight
+
+	if len(txs) > req.Limit {
+		last := txs[req.Limit-1].Height
+		cut := req.Limit
+		for cut < len(txs) && txs[cut].Height == last {
+			cut++
+		}
+		if cut < len(txs) {
+			txs = txs[:cut]
+			resp.EndHeight = int64(last)
+			resp.NextHeight = int64(last) + 1
+		}
+	}
+	resp.Transactions = txs
+
+	return resp, nil
+}
+
+// Most addresses one balances-at-height call answers for
+const MaxBalancesAtHeightAddresses = 1000
+
+// HandleV2BalancesAtHeight returns the balances of factoid and entry credit
+// addresses once the block at the given height was saved
+func HandleV2BalancesAtHeight(state interfaces.IState, params interface{}) (interface{}, *primitives.JSONError) {
+	n := time.Now()
+	defer HandleV2APICallBalancesAtHeight.Observe(float64(time.Since(n).Nanoseconds()))
+
+	req := new(BalancesAtHeightRequest)
+	err := MapToObject(params, req)
+	if err != nil {
+		return nil, NewInvalidParamsError()
+	}
+	if len(req.Addresses) == 0 || len(req.Addresses) > MaxBalancesAtHeightAddresses {
+		return nil, NewCustomInvalidParamsError(fmt.Sprintf("Expected 1 to %d addresses", MaxBalancesAtHeightAddresses))
+	}
+
+	dbase := state.GetDB()
+
+	head, err := dbase.FetchDBlockHead()
+	if err != nil {
+		return nil, NewInternalDatabaseError()
+	}
+	if head == nil {
+		return nil, NewBlockNotFoundError()
+	}
+	if req.Height < 0 || req.Height > int64(head.GetDatabaseHeight()) {
+		return nil, NewCustomInvalidParamsError("Height is not saved yet")
+	}
+
+	resp := new(BalancesAtHeightResponse)
+	resp.Height = req.Height
+	for _, a := range req.Addresses {
+		b := new(BalanceAtHeightResponse)
+		b.Address = a
+		switch {
+		case primitives.ValidateFUserStr(a):
+			adr := primitives.NewHash(primitives.ConvertUserStrToAddress(a))
+			b.Balance, err = dbase.FetchFactoidBalanceAtHeight(adr, uint32(req.Height))
+		case primitives.ValidateECUserStr(a):
+			adr := primitives.NewHash(primitives.ConvertUserStrToAddress(a))
+			b.Balance, err = dbase.FetchECBalanceAtHeight(adr, uint32(req.Height))
+		default:
+			b.Error = "Error decoding address"
+		}
+		if err != nil {
+			return nil, NewInternalDatabaseError()
+		}
+		resp.Balances = append(resp.Balances, b)
+	}
+
+	return resp, nil
+}
+
+// HandleV2SimulateTransaction checks a signed transaction the way the network
+// would, without submitting it, and reports the fee, the balances of its
+// addresses, the signatures of each RCD and the first validation error
+func HandleV2SimulateTransaction(state interfaces.IState, params interface{}) (interface{}, *primitives.JSONError) {
+	n := time.Now()
+	defer HandleV2APICallSimulateTx.Observe(float64(time.Since(n).Nanoseconds()))
+
+	t := new(TransactionRequest)
+	err := MapToObject(params, t)
+	if err != nil {
+		return nil, NewInvalidParamsError()
+	}
+	p, err := hex.DecodeString(t.Transaction)
+	if err != nil {
+		return nil, NewUnableToDecodeTransactionError()
+	}
+	tx := new(factoid.Transaction)
+	if err := tx.UnmarshalBinary(p); err != nil {
+		return nil, NewUnableToDecodeTransactionError()
+	}
+
+	fs := state.GetFactoidState()
+	resp := new(SimulateTransactionResponse)
+	resp.TxID = tx.GetSigHash().String()
+	resp.FactoshisPerEC = state.GetFactoshisPerEC()
+
+	// Balances, where an address used for more than one input has to cover them all
+	sums := map[[32]byte]uint64{}
+	for _, input := range tx.GetInputs() {
+		in := new(SimulatedInput)
+		in.Address = primitives.ConvertFctAddressToUserStr(input.GetAddress())
+		in.Amount = input.GetAmount()
+		in.Balance = fs.GetFactoidBalance(input.GetAddress().Fixed())
+		sums[input.GetAddress().Fixed()] += input.GetAmount()
+		in.Sufficient = int64(sums[input.GetAddress().Fixed()]) <= in.Balance
+		resp.Inputs = append(resp.Inputs, in)
+	}
+	for _, output := range tx.GetOutputs() {
+		resp.Outputs = append(resp.Outputs, &SimulatedOutput{
+			Address: primitives.ConvertFctAddressToUserStr(output.GetAddress()),
+			Amount:  output.GetAmount(),
+			Balance: fs.GetFactoidBalance(output.GetAddress().Fixed()),
+		})
+	}
+	for _, output := range tx.GetECOutputs() {
+		resp.ECOutputs = append(resp.ECOutputs, &SimulatedOutput{
+			Address: primitives.ConvertECAddressToUserStr(output.GetAddress()),
+			Amount:  output.GetAmount(),
+			Balance: fs.GetECBalance(output.GetAddress().Fixed()),
+		})
+	}
+
+	sigBlocks := tx.GetSignatureBlocks()
+	for i, rcd := range tx.GetRCDs() {
+		sig := new(SimulatedSignature)
+		sig.Input = i
+		sig.Signatures = rcd.NumberOfSignatures()
+		switch rcd.(type) {
+		case *factoid.RCD_1:
+			sig.RCDType = 1
+		case *factoid.RCD_2:
+			sig.RCDType = 2
+		}
+		if address, err := rcd.GetAddress(); err != nil || !address.IsSameAs(tx.GetInputs()[i].GetAddress()) {
+			sig.Error = "RCD does not match the address of the input"
+		} else {
+			sig.Valid = rcd.CheckSig(tx, sigBlocks[i])
+		}
+		resp.Signatures = append(resp.Signatures, sig)
+	}
+
+	// The same checks, in the same order, as the network
+	resp.TotalInputs, _ = tx.TotalInputs()
+	resp.TotalOutputs, _ = tx.TotalOutputs()
+	resp.TotalECOutputs, _ = tx.TotalECs()
+	resp.PaidFee = int64(resp.TotalInputs) - int64(resp.TotalOutputs) - int64(resp.TotalECOutputs)
+	resp.Fee, err = tx.CalculateFee(resp.FactoshisPerEC)
+	if err == nil {
+		err = tx.Validate(1)
+	}
+	if err == nil {
+		err = tx.ValidateSignatures()
+	}
+	if err == nil {
+		err = fs.Validate(1, tx)
+	}
+	if err == nil && resp.PaidFee < int64(resp.Fee) {
+		err = fmt.Errorf("The inputs %s do not cover the outputs %s, the Entry Credit outputs %s, and the required fee %s",
+			primitives.ConvertDecimalToString(resp.TotalInputs),
+			primitives.ConvertDecimalToString(resp.TotalOutputs),
+			primitives.ConvertDecimalToString(resp.TotalECOutputs),
+			primitives.ConvertDecimalToString(resp.Fee))
+	}
+	if err == nil {
+		err = fs.ValidateTransactionAge(tx)
+	}
+	if err != nil {
+		resp.Error = strings.TrimSpace(err.Error())
+	}
+	resp.Valid = err == nil
+
+	return resp, nil
+}
+
+// HandleV2EstimateFee returns the fee a transaction that is not signed yet will
+// need once it is.  The transaction is the ledger that gets signed, optionally
+// followed by the RCDs of the inputs; inputs without an RCD are taken to be
+// single signature addresses.
+func HandleV2EstimateFee(state interfaces.IState, params interface{}) (interface{}, *primitives.JSONError) {
+	n := time.Now()
+	defer HandleV2APICallSimulateTx.Observe(float64(time.Since(n).Nanoseconds()))
+
+	t := new(TransactionRequest)
+	err := MapToObject(params, t)
+	if err != nil {
+		return nil, NewInvalidParamsError()
+	}
+	p, err := hex.DecodeString(t.Transaction)
+	if err != nil {
+		return nil, NewUnableToDecodeTransactionError()
+	}
+	tx := new(factoid.Transaction)
+	rest, assumed, err := tx.UnmarshalUnsignedBinaryData(p)
+	if err != nil || len(rest) > 0 {
+		return nil, NewUnableToDecodeTransactionError()
+	}
+	data, err := tx.MarshalBinary()
+	if err != nil {
+		return nil, NewUnableToDecodeTransactionError()
+	}
+
+	resp := new(EstimateFeeResponse)
+	resp.FactoshisPerEC = state.GetFactoshisPerEC()
+	resp.Fee, err = tx.CalculateFee(resp.FactoshisPerEC)
+	if err != nil {
+		return nil, NewCustomInvalidParamsError(err.Error())
+	}
+	if resp.FactoshisPerEC > 0 {
+		resp.EntryCredits = resp.Fee / resp.FactoshisPerEC
+	}
+	resp.Size = len(data)
+	for _, rcd := range tx.GetRCDs() {
+		resp.Signatures += rcd.NumberOfSignatures()
+	}
+	resp.AssumedRCDs = assumed
+
+	return resp, nil
+}
+
+// Entry credits a chain costs, on top of its first entry
+const ChainCreationCost = 10
+
+// HandleV2EntryCost returns the entry credits a commit must pay to reveal the entry
+func HandleV2EntryCost(state interfaces.IState, params interface{}) (interface{}, *primitives.JSONError) {
+	n := time.Now()
+	defer HandleV2APICallEntryCost.Observe(float64(time.Since(n).Nanoseconds()))
+
+	entry, jsonError := entryFromRequest(params)
+	if jsonError != nil {
+		return nil, jsonError
+	}
+	return entryCost(entry, false)
+}
+
+// HandleV2ChainCost returns the entry credits a commit must pay to create a chain
+// with the entry as its first entry.  Without a chain ID, the chain ID of the
+// external IDs of the entry is used.
+func HandleV2ChainCost(state interfaces.IState, params interface{}) (interface{}, *primitives.JSONError) {
+	n := time.Now()
+	defer HandleV2APICallEntryCost.Observe(float64(time.Since(n).Nanoseconds()))
+
+	entry, jsonError := entryFromRequest(params)
+	if jsonError != nil {
+		return nil, jsonError
+	}
+	chainID := entryBlock.ExternalIDsToChainID(entry.ExternalIDs())
+	if entry.ChainID == nil || entry.ChainID.IsZero() {
+		entry.ChainID = chainID
+	} else if !entry.ChainID.IsSameAs(chainID) {
+		return nil, NewCustomInvalidParamsError("ChainID is not the hash of the external IDs")
+	}
+	return entryCost(entry, true)
+}
+
+func entryFromRequest(params interface{}) (*entryBlock.Entry, *primitives.JSONError) {
+	e := new(EntryRequest)
+	err := MapToObject(params, e)
+	if err != nil {
+		return nil, NewInvalidParamsError()
+	}
+	p, err := hex.DecodeString(e.Entry)
+	if err != nil {
+		return nil, NewInvalidEntryError()
+	}
+	entry := entryBlock.NewEntry()
+	if _, err := entry.UnmarshalBinaryData(p); err != nil {
+		return nil, NewInvalidEntryError()
+	}
+	return entry, nil
+}
+
+func entryCost(entry *entryBlock.Entry, chain bool) (*EntryCostResponse, *primitives.JSONError) {
+	data, err := entry.MarshalBinary()
+	if err != nil {
+		return nil, NewInvalidEntryError()
+	}
+	cost, err := util.EntryCost(data)
+	if err != nil {
+		return nil, NewCustomInvalidParamsError(err.Error())
+	}
+
+	resp := new(EntryCostResponse)
+	resp.EntryHash = entry.GetHash().String()
+	resp.ChainID = entry.GetChainID().String()
+	resp.Size = len(data)
+	resp.EntryCredits = int(cost)
+	if chain {
+		resp.EntryCredits += ChainCreationCost
+	}
+	return resp, nil
+}
+
+// HandleV2PendingCommit returns the commit this node holds for an entry, until
+// the entry is revealed or the commit times out.  Given the entry, it also says
+// whether the commit paid enough to reveal it, and given another commit, whether
+// that commit would replace the one held.
+func HandleV2PendingCommit(state interfaces.IState, params interface{}) (interface{}, *primitives.JSONError) {
+	n := time.Now()
+	defer HandleV2APICallEntryCost.Observe(float64(time.Since(n).Nanoseconds()))
+
+	req := new(PendingCommitRequest)
+	err := MapToObject(params, req)
+	if err != nil {
+		return nil, NewInvalidParamsError()
+	}
+
+	var entry *entryBlock.Entry
+	var hash interfaces.IHash
+	if req.Entry != "" {
+		var jsonError *primitives.JSONError
+		entry, jsonError = entryFromRequest(&EntryRequest{Entry: req.Entry})
+		if jsonError != nil {
+			return nil, jsonError
+		}
+		hash = entry.GetHash()
+	} else {
+		hash, err = primitives.HexToHash(req.Hash)
+		if err != nil {
+			return nil, NewInvalidHashError()
+		}
+	}
+
+	var other interfaces.IMsg
+	if req.Commit != "" {
+		p, err := hex.DecodeString(req.Commit)
+		if err != nil {
+			return nil, NewInvalidCommitEntryError()
+		}
+		if len(p) == entryCreditBlock.CommitChainSize {
+			chainCommit := entryCreditBlock.NewCommitChain()
+			if _, err := chainCommit.UnmarshalBinaryData(p); err != nil {
+				return nil, NewInvalidCommitChainError()
+			}
+			other = &messages.CommitChainMsg{CommitChain: chainCommit}
+		} else {
+			entryCommit := entryCreditBlock.NewCommitEntry()
+			if _, err := entryCommit.UnmarshalBinaryData(p); err != nil {
+				return nil, NewInvalidCommitEntryError()
+			}
+			other = &messages.CommitEntryMsg{CommitEntry: entryCommit}
+		}
+	}
+
+	commit, expires, revealed := state.PendingCommit(hash)
+
+	resp := new(PendingCommitResponse)
+	resp.EntryHash = hash.String()
+	resp.Revealed = revealed
+	chain := false
+	switch c := commit.(type) {
+	case *messages.CommitEntryMsg:
+		resp.Type = "commit-entry"
+		resp.TxID = c.CommitEntry.GetSigHash().String()
+		resp.ECPubKey = c.CommitEntry.ECPubKey.String()
+		resp.Credits = int(c.CommitEntry.Credits)
+	case *messages.CommitChainMsg:
+		chain = true
+		resp.Type = "commit-chain"
+		resp.TxID = c.CommitChain.GetSigHash().String()
+		resp.ECPubKey = c.CommitChain.ECPubKey.String()
+		resp.Credits = int(c.CommitChain.Credits)
+	}
+	if commit != nil {
+		resp.Pending = true
+		resp.Timestamp = commit.GetTimestamp().GetTimeMilli()
+		resp.Expires = expires.GetTimeMilli()
+	}
+	if entry != nil {
+		cost, jsonError := entryCost(entry, chain)
+		if jsonError != nil {
+			return nil, jsonError
+		}
+		resp.Required = cost.EntryCredits
+	}
+	if other != nil {
+		resp.Supersedes = state.IsHighestCommit(hash, other)
+	}
+
+	switch {
+	case revealed:
+		resp.Reason = "The entry was revealed already"
+	case commit == nil:
+		resp.Reason = "No commit is waiting for the entry"
+	case time.Now().UnixNano()/1e6 > resp.Expires:
+		resp.Reason = "The commit has expired"
+	case resp.Required > resp.Credits:
+		resp.Reason = fmt.Sprintf("The commit paid %d entry credits, the entry needs %d", resp.Credits, resp.Required)
+	default:
+		resp.RevealPossible = true
+	}
+	return resp, nil
+}
+
+func HandleV2DBlockFilter(state interfaces.IState, params interface{}) (interface{}, *primitives.JSONError) {
+	n := time.Now()
+	defer HandleV2APICallDBlockFilter.Observe(float64(time.Since(n).Nanoseconds()))
+
+	req := new(HeightRequest)
+	err := MapToObject(params, req)
+	if err != nil {
+		return nil, NewInvalidParamsError()
+	}
+	if req.Height < 0 {
+		return nil, NewBlockNotFoundError()
+	}
+
+	resp, jsonError := dblockFilter(state.GetDB(), uint32(req.Height))
+	if jsonError != nil {
+		return nil, jsonError
+	}
+	if resp == nil {
+		return nil, NewBlockNotFoundError()
+	}
+	return resp, nil
+}
+
+// Most filters returned by one dblock-filters call
+const MaxDBlockFiltersPerCall = 1000
+
+func HandleV2DBlockFilters(state interfaces.IState, params interface{}) (interface{}, *primitives.JSONError) {
+	n := time.Now()
+	defer HandleV2APICallDBlockFilter.Observe(float64(time.Since(n).Nanoseconds()))
+
+	req := new(DBlockFiltersRequest)
+	err := MapToObject(params, req)
+	if err != nil {
+		return nil, NewInvalidParamsError()
+	}
+
+	dbase := state.GetDB()
+	head, err := dbase.FetchDBlockHead()
+	if err != nil {
+		return nil, NewInternalDatabaseError()
+	}
+	if head == nil {
+		return nil, NewBlockNotFoundError()
+	}
+	headHeight := int64(head.GetDatabaseHeight())
+	if req.EndHeight == 0 || req.EndHeight > headHeight {
+		req.EndHeight = headHeight
+	}
+	if req.StartHeight < 0 || req.StartHeight > req.EndHeight {
+		return nil, NewCustomInvalidParamsError("Invalid height range")
+	}
+
+	resp := new(DBlockFiltersResponse)
+	resp.StartHeight = req.StartHeight
+	resp.EndHeight = req.EndHeight
+	if resp.EndHeight-resp.StartHeight >= MaxDBlockFiltersPerCall {
+		resp.EndHeight = resp.StartHeight + MaxDBlockFiltersPerCall - 1
+		resp.NextHeight = resp.EndHeight + 1
+	}
+	resp.Filters = []*DBlockFilterResponse{}
+	for height := resp.StartHeight; height <= resp.EndHeight; height++ {
+		filter, jsonError := dblockFilter(dbase, uint32(height))
+		if jsonError != nil {
+			return nil, jsonError
+		}
+		if filter == nil {
+			return nil, NewBlockNotFoundError()
+		}
+		resp.Filters = append(resp.Filters, filter)
+	}
+	return resp, nil
+}
+
+func dblockFilter(dbase interfaces.DBOverlaySimple, height uint32) (*DBlockFilterResponse, *primitives.JSONError) {
+	keyMR, err := dbase.FetchDBKeyMRByHeight(height)
+	if err != nil {
+		return nil, NewInternalDatabaseError()
+	}
+	if keyMR == nil {
+		return nil, nil
+	}
+	data, err := dbase.FetchDBlockFilter(keyMR)
+	if err != nil {
+		return nil, NewInternalDatabaseError()
+	}
+	if data == nil {
+		return nil, nil
+	}
+	filter := new(compactFilter.Filter)
+	if err := filter.UnmarshalBinary(data); err != nil {
+		return nil, NewInternalError()
+	}
+
+	resp := new(DBlockFilterResponse)
+	resp.Height = int64(height)
+	resp.KeyMR = keyMR.String()
+	resp.P = compactFilter.P
+	resp.M = compactFilter.M
+	resp.N = filter.N
+	resp.Filter = hex.EncodeToString(data)
+	return resp, nil
+}
+
 //func HandleV2Accounts(state interfaces.IState, params interface{}) (interface{}, *primitives.JSONError) {
 // height, acc, returnedLen, totalLen := state.GetFactoidState().GetFactiodAccounts(params)
 // h := new(FactiodAccounts)
@@ -2,7 +2,9 @@ package wsapi_test
 
 import (
 	"bytes"
+	"encoding/hex"
 	"encoding/json"
+	"fmt"
 	"io/ioutil"
 	"net/http"
 	"reflect"
@@ -11,8 +13,14 @@ import (
 
 	"time"
 
+	"github.com/FactomProject/factomd/common/compactFilter"
+	"github.com/FactomProject/factomd/common/entryBlock"
+	"github.com/FactomProject/factomd/common/entryCreditBlock"
+	"github.com/FactomProject/factomd/common/factoid"
 	"github.com/FactomProject/factomd/common/interfaces"
+	"github.com/FactomProject/factomd/common/messages"
 	"github.com/FactomProject/factomd/common/primitives"
+	"github.com/FactomProject/factomd/database/databaseOverlay"
 	"github.com/FactomProject/factomd/receipts"
 	"github.com/FactomProject/factomd/testHelper"
 	. "github.com/FactomProject/factomd/wsapi"
@@ -274,6 +282,44 @@ func TestHandleV2GetReceipt(t *testing.T) {
 	}
 }
 
+func TestHandleV2TransactionReceipt(t *testing.T) {
+	state := testHelper.CreateAndPopulateTestStateAndStartValidator()
+	blocks := testHelper.CreateFullTestBlockSet()
+
+	hashkey := new(HashRequest)
+	hashkey.Hash = blocks[1].FBlock.GetTransactions()[0].GetSigHash().String()
+
+	resp, jErr := HandleV2TransactionReceipt(state, hashkey)
+	if jErr != nil {
+		t.Fatalf("%v", jErr)
+	}
+
+	receipt := resp.(*TransactionReceiptResponse).Receipt
+	err := receipt.Validate()
+	if err != nil {
+		t.Error(err)
+	}
+
+	raw, err := hex.DecodeString(resp.(*TransactionReceiptResponse).RawData)
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded := new(receipts.TransactionReceipt)
+	err = decoded.UnmarshalBinary(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if decoded.String() != receipt.String() {
+		t.Errorf("Raw receipt does not match the JSON receipt")
+	}
+
+	hashkey.Hash = primitives.NewZeroHash().String()
+	_, jErr = HandleV2TransactionReceipt(state, hashkey)
+	if jErr == nil {
+		t.Errorf("Got a receipt for a transaction that does not exist")
+	}
+}
+
 func TestHandleV2GetTranasction(t *testing.T) {
 	state := testHelper.CreateAndPopulateTestStateAndStartValidator()
 	blocks := testHelper.CreateFullTestBlockSet()
@@ -411,6 +457,75 @@ func TestHandleV2GetTranasction(t *testing.T) {
 	}
 }
 
+func TestHandleV2AddressTransactions(t *testing.T) {
+	state := testHelper.CreateAndPopulateTestStateAndStartValidator()
+	blocks := testHelper.CreateFullTestBlockSet()
+
+	// Count the transactions of every factoid address in the test blocks
+	counts := map[string]int{}
+	for _, block := range blocks {
+		for _, tx := range block.FBlock.GetTransactions() {
+			seen := map[string]bool{}
+			for _, adrs := range [][]interfaces.ITransAddress{tx.GetInputs(), tx.GetOutputs()} {
+				for _, adr := range adrs {
+					user := primitives.ConvertFctAddressToUserStr(adr.GetAddress())
+					if seen[user] == false {
+						counts[user]++
+					}
+					seen[user] = true
+				}
+			}
+		}
+	}
+	if len(counts) == 0 {
+		t.Fatalf("No factoid transactions in the test blocks")
+	}
+
+	for user, count := range counts {
+		req := new(AddressTransactionsRequest)
+		req.Address = user
+		resp, jErr := HandleV2AddressTransactions(state, req)
+		if jErr != nil {
+			t.Fatalf("%v", jErr)
+		}
+		all := resp.(*AddressTransactionsResponse)
+		if len(all.Transactions) != count {
+			t.Errorf("Got %v transactions for %v, expected %v", len(all.Transactions), user, count)
+		}
+
+		// Page through one height at a time and check nothing is lost
+		paged := 0
+		req.Limit = 1
+		for {
+			resp, jErr = HandleV2AddressTransactions(state, req)
+			if jErr != nil {
+				t.Fatalf("%v", jErr)
+			}
+			page := resp.(*AddressTransactionsResponse)
+			for _, tx := range page.Transactions {
+				if int64(tx.Height) < req.StartHeight || int64(tx.Height) > page.EndHeight {
+					t.Errorf("Transaction at height %v outside of page %v-%v", tx.Height, req.StartHeight, page.EndHeight)
+				}
+			}
+			paged += len(page.Transactions)
+			if page.NextHeight == 0 {
+				break
+			}
+			req.StartHeight = page.NextHeight
+		}
+		if paged != count {
+			t.Errorf("Paging returned %v transactions for %v, expected %v", paged, user, count)
+		}
+	}
+
+	req := new(AddressTransactionsRequest)
+	req.Address = "not an address"
+	_, jErr := HandleV2AddressTransactions(state, req)
+	if jErr == nil {
+		t.Errorf("Expected an error for an invalid address")
+	}
+}
+
 func TestJSONString(t *testing.T) {
 	eblock := new(EBlock)
 	eblock.Header.BlockSequenceNumber = 5
@@ -481,3 +596,454 @@ func Test_ecBlockToResp(t *testing.T) {
 		})
 	}
 }
+
+func TestHandleV2DBlockFilters(t *testing.T) {
+	state := testHelper.CreateAndPopulateTestStateAndStartValidator()
+	blocks := testHelper.CreateFullTestBlockSet()
+
+	req := new(DBlockFiltersRequest)
+	resp, jErr := HandleV2DBlockFilters(state, req)
+	if jErr != nil {
+		t.Fatalf("%v", jErr)
+	}
+	filters := resp.(*DBlockFiltersResponse)
+	if len(filters.Filters) != len(blocks) || filters.NextHeight != 0 {
+		t.Fatalf("Got %v filters, expected %v", len(filters.Filters), len(blocks))
+	}
+
+	for i, block := range blocks {
+		f := filters.Filters[i]
+		if f.Height != int64(block.DBlock.GetDatabaseHeight()) || f.KeyMR != block.DBlock.GetKeyMR().String() {
+			t.Errorf("Filter %v is for %v %v", i, f.Height, f.KeyMR)
+		}
+
+		resp, jErr = HandleV2DBlockFilter(state, &HeightRequest{Height: f.Height})
+		if jErr != nil {
+			t.Fatalf("%v", jErr)
+		}
+		single := resp.(*DBlockFilterResponse)
+		if single.Filter != f.Filter {
+			t.Errorf("dblock-filter and dblock-filters differ at height %v", f.Height)
+		}
+
+		data, err := hex.DecodeString(f.Filter)
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+		filter := new(compactFilter.Filter)
+		if err = filter.UnmarshalBinary(data); err != nil {
+			t.Fatalf("%v", err)
+		}
+		key := compactFilter.Key(block.DBlock.GetKeyMR().Bytes())
+		if !filter.Match(key, block.EBlock.GetChainID().Bytes()) {
+			t.Errorf("Filter at height %v does not match its EBlock chain", f.Height)
+		}
+		for _, tx := range block.FBlock.GetTransactions() {
+			for _, adr := range tx.GetOutputs() {
+				if !filter.Match(key, adr.GetAddress().Bytes()) {
+					t.Errorf("Filter at height %v does not match output %v", f.Height, adr.GetAddress())
+				}
+			}
+		}
+	}
+
+	_, jErr = HandleV2DBlockFilter(state, &HeightRequest{Height: int64(len(blocks)) + 10})
+	if jErr == nil {
+		t.Error("Got a filter for a missing block")
+	}
+	_, jErr = HandleV2DBlockFilters(state, &DBlockFiltersRequest{StartHeight: 3, EndHeight: 2})
+	if jErr == nil {
+		t.Error("Inverted height range accepted")
+	}
+}
+
+func TestHandleV2EntryPruned(t *testing.T) {
+	state := testHelper.CreateAndPopulateTestState()
+	blocks := testHelper.CreateFullTestBlockSet()
+	top := uint32(len(blocks) - 1)
+
+	state.NodeMode = "PRUNED"
+	state.PruneKeepBlocks = 3
+	state.PruneEntries(top)
+
+	old := blocks[1].EBlock.GetEntryHashes()[0]
+	_, jErr := HandleV2Entry(state, &HashRequest{Hash: old.String()})
+	if jErr == nil || jErr.Code != NewEntryPrunedError().Code {
+		t.Errorf("Expected a pruned error, got %v", jErr)
+	}
+	_, jErr = HandleV2RawData(state, &HashRequest{Hash: old.String()})
+	if jErr == nil || jErr.Code != NewEntryPrunedError().Code {
+		t.Errorf("Expected a pruned error from raw-data, got %v", jErr)
+	}
+
+	recent := blocks[top].EBlock.GetEntryHashes()[0]
+	if _, jErr = HandleV2Entry(state, &HashRequest{Hash: recent.String()}); jErr != nil {
+		t.Errorf("%v", jErr)
+	}
+
+	missing := primitives.NewZeroHash()
+	_, jErr = HandleV2Entry(state, &HashRequest{Hash: missing.String()})
+	if jErr == nil || jErr.Code != NewEntryNotFoundError().Code || jErr.Message != NewEntryNotFoundError().Message {
+		t.Errorf("Expected not found, got %v", jErr)
+	}
+}
+
+func TestHandleV2BalancesAtHeight(t *testing.T) {
+	state := testHelper.CreateAndPopulateTestStateAndStartValidator()
+	blocks := testHelper.CreateFullTestBlockSet()
+	top := int64(len(blocks) - 1)
+
+	out := blocks[1].FBlock.GetTransactions()[0].GetOutputs()[0]
+	fct := primitives.ConvertFctAddressToUserStr(out.GetAddress())
+	req := &BalancesAtHeightRequest{Addresses: []string{fct, "FA-not-an-address"}, Height: 0}
+	resp, jErr := HandleV2BalancesAtHeight(state, req)
+	if jErr != nil {
+		t.Fatalf("%v", jErr)
+	}
+	balances := resp.(*BalancesAtHeightResponse).Balances
+	if len(balances) != 2 || balances[0].Address != fct || balances[1].Error == "" {
+		t.Fatalf("Unexpected balances %+v", balances)
+	}
+
+	// The balance only moves at the heights the address has transactions
+	prev := balances[0].Balance
+	for height := int64(1); height <= top; height++ {
+		req.Height = height
+		resp, jErr = HandleV2BalancesAtHeight(state, req)
+		if jErr != nil {
+			t.Fatalf("%v", jErr)
+		}
+		balance := resp.(*BalancesAtHeightResponse).Balances[0].Balance
+		txs, err := state.GetDB().FetchFactoidAddressTransactions(out.GetAddress(), uint32(height), uint32(height), 0)
+		if err != nil {
+			t.Fatalf("%v", err)
+		}
+		if len(txs) == 0 && balance != prev {
+			t.Errorf("Balance changed at height %v without a transaction", height)
+		}
+		prev = balance
+	}
+	if prev <= 0 {
+		t.Errorf("Output address has balance %v at the top", prev)
+	}
+
+	req.Height = top + 1
+	if _, jErr = HandleV2BalancesAtHeight(state, req); jErr == nil {
+		t.Errorf("Got balances above the highest saved block")
+	}
+	req.Addresses = nil
+	req.Height = 0
+	if _, jErr = HandleV2BalancesAtHeight(state, req); jErr == nil {
+		t.Errorf("Got balances without addresses")
+	}
+}
+
+func TestHandleV2RequestHeight(t *testing.T) {
+	state := testHelper.CreateAndPopulateTestState()
+	top := uint32(testHelper.BlockCount - 1)
+
+	j := primitives.NewJSON2Request("heights", 1, nil)
+	resp, jErr := HandleV2Request(state, j)
+	if jErr != nil {
+		t.Fatalf("%v", jErr)
+	}
+	if resp.Height == nil || *resp.Height != top {
+		t.Fatalf("Served at height %v, expected %v", resp.Height, top)
+	}
+	if h := resp.Result.(*HeightsResponse).DirectoryBlockHeight; h != int64(top) {
+		t.Errorf("Heights reports %v, served at %v", h, top)
+	}
+	if !strings.Contains(resp.String(), fmt.Sprintf(`"height":%d`, top)) {
+		t.Errorf("Height missing from %v", resp.String())
+	}
+
+	// Releasing the snapshot leaves the database open
+	if head, err := state.GetDB().FetchDBlockHead(); err != nil || head == nil {
+		t.Errorf("Database unusable after a request: %v", err)
+	}
+}
+
+func TestHandleV2RequestMissingFilter(t *testing.T) {
+	state := testHelper.CreateAndPopulateTestState()
+	keyMR, err := state.GetDB().FetchDBKeyMRByHeight(1)
+	if err != nil || keyMR == nil {
+		t.Fatalf("No block at height 1: %v", err)
+	}
+	// As saved by a version without filters
+	dbo := state.GetDB().(*databaseOverlay.Overlay)
+	if err := dbo.Delete(databaseOverlay.DBLOCK_FILTER, keyMR.Bytes()); err != nil {
+		t.Fatal(err)
+	}
+
+	// Built from the read only snapshot the request is served from
+	j := primitives.NewJSON2Request("dblock-filter", 1, &HeightRequest{Height: 1})
+	resp, jErr := HandleV2Request(state, j)
+	if jErr != nil {
+		t.Fatalf("%v", jErr)
+	}
+	if f := resp.Result.(*DBlockFilterResponse); f.KeyMR != keyMR.String() || f.Filter == "" {
+		t.Errorf("Wrong filter %+v", f)
+	}
+}
+
+func TestHandleV2Batch(t *testing.T) {
+	call := func(body string) *testHelper.TestResponseWriter {
+		context := testHelper.CreateWebContext()
+		context.Request, _ = http.NewRequest("POST", "/v2", bytes.NewBufferString(body))
+		context.Request.RemoteAddr = "10.0.0.1:5555"
+		HandleV2(context)
+		return context.ResponseWriter.(*testHelper.TestResponseWriter)
+	}
+
+	resp := call(` [
+		{"jsonrpc": "2.0", "id": 1, "method": "heights"},
+		{"jsonrpc": "2.0", "id": 2, "method": "no-such-method"},
+		{"jsonrpc": "2.0", "method": "heights"},
+		{"jsonrpc": "1.0", "id": 3, "method": "heights"},
+		{"jsonrpc": "2.0", "id": "four", "method": "properties"}
+	]`)
+	var responses []struct {
+		ID     interface{}            `json:"id"`
+		Error  *primitives.JSONError  `json:"error"`
+		Result map[string]interface{} `json:"result"`
+	}
+	if err := json.Unmarshal([]byte(resp.Body), &responses); err != nil {
+		t.Fatalf("Batch response %s is not an array: %v", resp.Body, err)
+	}
+	if len(responses) != 4 {
+		t.Fatalf("Expected 4 responses, got %s", resp.Body)
+	}
+	if responses[0].ID != float64(1) || responses[0].Error != nil || responses[0].Result["directoryblockheight"] == nil {
+		t.Errorf("Wrong heights response %+v", responses[0])
+	}
+	if responses[1].ID != float64(2) || responses[1].Error == nil || responses[1].Error.Code != -32601 {
+		t.Errorf("Wrong unknown method response %+v", responses[1])
+	}
+	if responses[2].ID != nil || responses[2].Error == nil {
+		t.Errorf("Wrong invalid request response %+v", responses[2])
+	}
+	if responses[3].ID != "four" || responses[3].Error != nil || responses[3].Result["factomdversion"] == nil {
+		t.Errorf("Wrong properties response %+v", responses[3])
+	}
+
+	if resp := call(`[{"jsonrpc": "2.0", "method": "heights"}, {"jsonrpc": "2.0", "method": "no-such-method"}]`); resp.Body != "" {
+		t.Errorf("Batch of notifications gave %s", resp.Body)
+	}
+	if resp := call(`[{"jsonrpc": "2.0", "id": null, "method": "heights"}]`); !strings.Contains(resp.Body, "directoryblockheight") {
+		t.Errorf("Request with a null id gave %s", resp.Body)
+	}
+	if resp := call(`[]`); !strings.Contains(resp.Body, `"error"`) || strings.HasPrefix(resp.Body, "[") {
+		t.Errorf("Empty batch gave %s", resp.Body)
+	}
+	if resp := call(`[{"jsonrpc": "2.0"`); !strings.Contains(resp.Body, "-32700") {
+		t.Errorf("Invalid batch gave %s", resp.Body)
+	}
+
+	SetApiLimits(ApiLimits{MaxBatch: 2})
+	defer SetApiLimits(ApiLimits{})
+	body := `[{"jsonrpc": "2.0", "id": 1, "method": "heights"}, {"jsonrpc": "2.0", "id": 2, "method": "heights"}, {"jsonrpc": "2.0", "id": 3, "method": "heights"}]`
+	if resp := call(body); !strings.Contains(resp.Body, "-32016") {
+		t.Errorf("Batch over the max gave %s", resp.Body)
+	}
+}
+
+func TestHandleV2SimulateTransaction(t *testing.T) {
+	state := testHelper.CreateAndPopulateTestState()
+	rate := state.GetFactoshisPerEC()
+
+	newTx := func(amount uint64) *factoid.Transaction {
+		tx := new(factoid.Transaction)
+		tx.AddInput(testHelper.NewFactoidAddress(0), amount)
+		tx.AddOutput(testHelper.NewFactoidAddress(1), amount)
+		tx.SetTimestamp(primitives.NewTimestampNow())
+		fee, err := tx.CalculateFee(rate)
+		if err != nil {
+			t.Fatal(err)
+		}
+		in, _ := tx.GetInput(0)
+		in.SetAmount(amount + fee)
+		return tx
+	}
+	simulate := func(tx *factoid.Transaction) *SimulateTransactionResponse {
+		data, err := tx.MarshalBinary()
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, jErr := HandleV2SimulateTransaction(state, &TransactionRequest{Transaction: hex.EncodeToString(data)})
+		if jErr != nil {
+			t.Fatalf("%v", jErr)
+		}
+		return resp.(*SimulateTransactionResponse)
+	}
+
+	tx := newTx(1000)
+	testHelper.SignFactoidTransaction(0, tx)
+	fee, _ := tx.CalculateFee(rate)
+	sim := simulate(tx)
+	if sim.Fee != fee || sim.PaidFee != int64(fee) || sim.FactoshisPerEC != rate {
+		t.Errorf("Fee %v paid %v at %v, expected %v at %v", sim.Fee, sim.PaidFee, sim.FactoshisPerEC, fee, rate)
+	}
+	if len(sim.Signatures) != 1 || !sim.Signatures[0].Valid || sim.Signatures[0].RCDType != 1 {
+		t.Errorf("Signatures %+v", sim.Signatures)
+	}
+	balance := state.GetFactoidState().GetFactoidBalance(testHelper.NewFactoidAddress(0).Fixed())
+	if len(sim.Inputs) != 1 || sim.Inputs[0].Balance != balance || sim.Inputs[0].Sufficient != (balance >= int64(1000+fee)) {
+		t.Errorf("Inputs %+v, balance %v", sim.Inputs, balance)
+	}
+	if sim.TxID != tx.GetSigHash().String() {
+		t.Errorf("TxID %v", sim.TxID)
+	}
+
+	// More than the address has
+	tx = newTx(uint64(balance) + 1)
+	testHelper.SignFactoidTransaction(0, tx)
+	sim = simulate(tx)
+	if sim.Valid || sim.Inputs[0].Sufficient || !strings.Contains(sim.Error, "Not enough funds") {
+		t.Errorf("Spent more than the balance: %+v", sim)
+	}
+
+	// Signed by the wrong key
+	tx = newTx(1000)
+	tx.AddAuthorization(testHelper.NewFactoidRCDAddress(0))
+	data, _ := tx.MarshalBinarySig()
+	tx.SetSignatureBlock(0, factoid.NewSingleSignatureBlock(testHelper.NewPrivKey(1), data))
+	sim = simulate(tx)
+	if sim.Valid || sim.Signatures[0].Valid || !strings.Contains(sim.Error, "signatures") {
+		t.Errorf("Bad signature was accepted: %+v", sim)
+	}
+
+	if _, jErr := HandleV2SimulateTransaction(state, &TransactionRequest{Transaction: "00"}); jErr == nil {
+		t.Error("Simulated a transaction that does not decode")
+	}
+}
+
+func TestHandleV2EstimateFee(t *testing.T) {
+	state := testHelper.CreateAndPopulateTestState()
+	rate := state.GetFactoshisPerEC()
+
+	tx := new(factoid.Transaction)
+	tx.AddInput(testHelper.NewFactoidAddress(0), 1000)
+	tx.AddOutput(testHelper.NewFactoidAddress(1), 500)
+	tx.AddECOutput(testHelper.NewECAddress(0), 500)
+	tx.SetTimestamp(primitives.NewTimestampNow())
+	ledger, err := tx.MarshalBinarySig()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	testHelper.SignFactoidTransaction(0, tx)
+	expected, _ := tx.CalculateFee(rate)
+
+	// Without the RCD, which is taken to be an RCD_1
+	resp, jErr := HandleV2EstimateFee(state, &TransactionRequest{Transaction: hex.EncodeToString(ledger)})
+	if jErr != nil {
+		t.Fatalf("%v", jErr)
+	}
+	estimate := resp.(*EstimateFeeResponse)
+	if estimate.Fee != expected || estimate.EntryCredits != expected/rate || estimate.AssumedRCDs != 1 || estimate.Signatures != 1 {
+		t.Errorf("Estimated %+v, the signed transaction needs %v", estimate, expected)
+	}
+
+	// With the RCD
+	rcd, _ := testHelper.NewFactoidRCDAddress(0).MarshalBinary()
+	resp, jErr = HandleV2EstimateFee(state, &TransactionRequest{Transaction: hex.EncodeToString(append(ledger, rcd...))})
+	if jErr != nil {
+		t.Fatalf("%v", jErr)
+	}
+	if estimate = resp.(*EstimateFeeResponse); estimate.Fee != expected || estimate.AssumedRCDs != 0 {
+		t.Errorf("Estimated %+v with the RCD, the signed transaction needs %v", estimate, expected)
+	}
+}
+
+func TestHandleV2EntryCost(t *testing.T) {
+	state := testHelper.CreateEmptyTestState()
+	entry := entryBlock.NewEntry()
+	entry.ExtIDs = []primitives.ByteSlice{{Bytes: []byte("cost")}}
+	entry.Content = primitives.ByteSlice{Bytes: make([]byte, 1500)}
+	data, err := entry.MarshalBinary()
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := &EntryRequest{Entry: hex.EncodeToString(data)}
+
+	resp, jErr := HandleV2EntryCost(state, req)
+	if jErr != nil {
+		t.Fatalf("%v", jErr)
+	}
+	if cost := resp.(*EntryCostResponse); cost.EntryCredits != 2 || cost.Size != len(data) {
+		t.Errorf("Entry of %d bytes costs %+v", len(data), cost)
+	}
+
+	// Without a chain ID the chain cost takes it from the external IDs
+	resp, jErr = HandleV2ChainCost(state, req)
+	if jErr != nil {
+		t.Fatalf("%v", jErr)
+	}
+	chainID := entryBlock.ExternalIDsToChainID(entry.ExternalIDs())
+	if cost := resp.(*EntryCostResponse); cost.EntryCredits != 12 || cost.ChainID != chainID.String() {
+		t.Errorf("Chain costs %+v", cost)
+	}
+	entry.ChainID = primitives.RandomHash()
+	data, _ = entry.MarshalBinary()
+	if _, jErr = HandleV2ChainCost(state, &EntryRequest{Entry: hex.EncodeToString(data)}); jErr == nil {
+		t.Error("Chain ID that is not the hash of the external IDs was accepted")
+	}
+
+	entry.Content = primitives.ByteSlice{Bytes: make([]byte, 10241)}
+	data, _ = entry.MarshalBinary()
+	if _, jErr = HandleV2EntryCost(state, &EntryRequest{Entry: hex.EncodeToString(data)}); jErr == nil {
+		t.Error("Entry over 10KB has a cost")
+	}
+}
+
+func TestHandleV2PendingCommit(t *testing.T) {
+	state := testHelper.CreateAndPopulateTestState()
+	entry := entryBlock.NewEntry()
+	entry.ChainID = primitives.RandomHash()
+	entry.Content = primitives.ByteSlice{Bytes: make([]byte, 1500)}
+	data, _ := entry.MarshalBinary()
+
+	newCommit := func(credits uint8) *entryCreditBlock.CommitEntry {
+		commit := entryCreditBlock.NewCommitEntry()
+		commit.EntryHash = entry.GetHash()
+		commit.Credits = credits
+		commit.MilliTime = new(primitives.ByteSlice6)
+		ts, _ := primitives.NewTimestampNow().MarshalBinary()
+		copy(commit.MilliTime[:], ts)
+		return commit
+	}
+	req := &PendingCommitRequest{Hash: entry.GetHash().String()}
+
+	resp, jErr := HandleV2PendingCommit(state, req)
+	if jErr != nil {
+		t.Fatalf("%v", jErr)
+	}
+	if pending := resp.(*PendingCommitResponse); pending.Pending || pending.RevealPossible {
+		t.Errorf("Found a commit that was never made: %+v", pending)
+	}
+
+	// One entry credit does not pay for 1500 bytes
+	state.PutCommit(entry.GetHash(), &messages.CommitEntryMsg{CommitEntry: newCommit(1)})
+	better, _ := newCommit(2).MarshalBinary()
+	req = &PendingCommitRequest{Entry: hex.EncodeToString(data), Commit: hex.EncodeToString(better)}
+	resp, jErr = HandleV2PendingCommit(state, req)
+	if jErr != nil {
+		t.Fatalf("%v", jErr)
+	}
+	pending := resp.(*PendingCommitResponse)
+	if !pending.Pending || pending.Type != "commit-entry" || pending.Credits != 1 || pending.Required != 2 {
+		t.Errorf("Pending commit %+v", pending)
+	}
+	if pending.RevealPossible || !pending.Supersedes || pending.Expires <= time.Now().UnixNano()/1e6 {
+		t.Errorf("Underpaid commit %+v", pending)
+	}
+
+	state.PutCommit(entry.GetHash(), &messages.CommitEntryMsg{CommitEntry: newCommit(2)})
+	resp, jErr = HandleV2PendingCommit(state, req)
+	if jErr != nil {
+		t.Fatalf("%v", jErr)
+	}
+	if pending = resp.(*PendingCommitResponse); !pending.RevealPossible || pending.Supersedes || pending.Credits != 2 {
+		t.Errorf("Commit that pays for the entry %+v", pending)
+	}
+}